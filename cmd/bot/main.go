@@ -7,23 +7,28 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"spectrum-club-bot/internal/alerts"
+	"spectrum-club-bot/internal/bootstrap"
 	"spectrum-club-bot/internal/bot"
+	"spectrum-club-bot/internal/migrations"
 	"spectrum-club-bot/internal/models/config"
-	"spectrum-club-bot/internal/repository/attendance"
-	"spectrum-club-bot/internal/repository/coach"
-	"spectrum-club-bot/internal/repository/group"
-	"spectrum-club-bot/internal/repository/schedule"
-	"spectrum-club-bot/internal/repository/schedule_template"
-	"spectrum-club-bot/internal/repository/student"
-	"spectrum-club-bot/internal/repository/subscription"
-	"spectrum-club-bot/internal/repository/user"
+	"spectrum-club-bot/internal/notify"
+	"spectrum-club-bot/internal/payments"
+	"spectrum-club-bot/internal/refund"
+	"spectrum-club-bot/internal/reminder"
+	"spectrum-club-bot/internal/renewal"
+	"spectrum-club-bot/internal/repository/memory"
+	"spectrum-club-bot/internal/service"
 	attendance_service "spectrum-club-bot/internal/service/attendance"
 	coach_service "spectrum-club-bot/internal/service/coach"
 	group_serivce "spectrum-club-bot/internal/service/group"
+	payment_service "spectrum-club-bot/internal/service/payment"
+	renewal_service "spectrum-club-bot/internal/service/renewal"
 	schedule_service "spectrum-club-bot/internal/service/schedule"
 	student_service "spectrum-club-bot/internal/service/student"
 	subscription_service "spectrum-club-bot/internal/service/subscription"
 	user_service "spectrum-club-bot/internal/service/user"
+	"spectrum-club-bot/internal/waitlist"
 	"spectrum-club-bot/internal/web"
 	database "spectrum-club-bot/pkg"
 	"syscall"
@@ -33,6 +38,14 @@ import (
 )
 
 func main() {
+	// `bot migrate up|down|status` — только работа со схемой, без запуска бота
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := migrateMain(os.Args[2:]); err != nil {
+			log.Fatalf("❌ Ошибка миграции: %v", err)
+		}
+		return
+	}
+
 	// Загружаем конфигурацию
 	if err := config.Load(); err != nil {
 		log.Fatalf("❌ Ошибка загрузки конфигурации: %v", err)
@@ -41,31 +54,85 @@ func main() {
 	cfg := config.AppConfig
 	log.Printf("🚀 Запуск в окружении: %s", cfg.Environment)
 
-	// Подключаемся к БД
-	db, err := database.NewPostgres()
-	if err != nil {
-		log.Fatalf("❌ Ошибка подключения к БД: %v", err)
+	var repos *bootstrap.Repositories
+	if cfg.DemoMode {
+		// Демо-режим: всё хранится в памяти процесса, БД не нужна
+		store := memory.NewStore()
+		if err := memory.SeedDemoData(store); err != nil {
+			log.Fatalf("❌ Ошибка заполнения демо-данных: %v", err)
+		}
+		repos = bootstrap.NewMemoryRepositories(store, cfg.Consumption)
+		log.Printf("🧪 Демо-режим: данные хранятся в памяти и пропадут после остановки")
+	} else {
+		// Подключаемся к БД
+		db, err := database.NewPostgres()
+		if err != nil {
+			log.Fatalf("❌ Ошибка подключения к БД: %v", err)
+		}
+		defer db.Close()
+
+		if cfg.Database.AutoMigrate {
+			applied, err := migrations.Up(db)
+			if err != nil {
+				log.Fatalf("❌ Ошибка применения миграций: %v", err)
+			}
+			log.Printf("🗂️  Схема БД актуальна (применено миграций: %d)", applied)
+		}
+
+		repos = bootstrap.NewPostgresRepositories(db, cfg.Consumption)
 	}
-	defer db.Close()
-
-	// Инициализация репозиториев
-	userRepo := user.NewUserRepository(db)
-	studentRepo := student.NewStudentRepository(db)
-	coachRepo := coach.NewCoachRepository(db)
-	subscriptionRepo := subscription.NewSubscriptionRepository(db)
-	attendanceRepo := attendance.NewAttendanceRepository(db)
-	scheduleRepo := schedule.NewTrainingScheduleRepository(db)
-	trainingGroupRepo := group.NewTrainingGroupRepository(db)
-	templateScheduleRepos := schedule_template.NewWeekScheduleRepository(db)
+
 	// Инициализация сервисов
-	userService := user_service.NewUserService(userRepo, studentRepo, coachRepo, subscriptionRepo)
-	studentService := student_service.NewStudentService(studentRepo)
-	coachService := coach_service.NewCoachService(coachRepo)
-	subscriptionService := subscription_service.NewSubscriptionService(subscriptionRepo)
-	trainingGroupService := group_serivce.NewTrainingGroupService(trainingGroupRepo)
+	userService := user_service.NewUserService(repos.Users, repos.Students, repos.Coaches, repos.Subscriptions)
+	studentService := student_service.NewStudentService(repos.Students)
+	coachService := coach_service.NewCoachService(repos.Coaches)
+	subscriptionService := subscription_service.NewSubscriptionService(repos.Subscriptions, repos.Plans, repos.Freezes, repos.Ledger, repos.Transactor)
+	trainingGroupService := group_serivce.NewTrainingGroupService(repos.TrainingGroups)
+
+	// Остаток занятий меняется только вместе с журналом; расхождение значит, что его изменили в обход
+	if mismatches, err := subscriptionService.ReconcileLessons(); err != nil {
+		log.Printf("⚠️ Не удалось сверить остатки занятий с журналом: %v", err)
+	} else {
+		for _, m := range mismatches {
+			log.Printf("⚠️ Абонемент %d: остаток %d, по журналу занятий %d", m.SubscriptionID, m.RemainingLessons, m.LedgerBalance)
+		}
+	}
+	// Все сообщения пользователям идут через очередь уведомлений
+	telegramSender, err := notify.NewTelegramSender(cfg.Bot.Token, cfg.Bot.APIURL)
+	if err != nil {
+		log.Fatalf("❌ Ошибка настройки отправки сообщений: %v", err)
+	}
+	dispatcher := notify.NewDispatcher(repos.Outbox, telegramSender)
+	notifier := notify.NewNotifier(repos.Outbox, dispatcher)
 	//new
-	attendanceService := attendance_service.NewAttendanceService(attendanceRepo, scheduleRepo, subscriptionService)
-	scheduleService := schedule_service.NewScheduleService(scheduleRepo, attendanceRepo, templateScheduleRepos, trainingGroupRepo)
+	attendanceService := attendance_service.NewAttendanceService(
+		repos.Attendance,
+		repos.Schedule,
+		repos.Subscriptions,
+		repos.Waitlist,
+		repos.Transactor,
+		waitlist.NewOfferNotifier(notifier),
+		refund.NewNotifier(notifier),
+		cfg.Waitlist.OfferTTL,
+		cfg.Cancel,
+	)
+	// Покупка абонемента в боте: Telegram Payments или локальная заглушка
+	var paymentProvider service.PaymentProvider
+	switch {
+	case cfg.Payments.ProviderToken != "":
+		paymentProvider = payments.NewTelegramProvider(telegramSender, cfg.Payments.ProviderToken)
+	case cfg.Payments.Stub:
+		log.Printf("🧪 Оплата в боте работает через заглушку, деньги не списываются")
+		paymentProvider = payments.NewStubProvider(notifier)
+	}
+	paymentService := payment_service.NewPaymentService(repos.Payments, repos.Plans, repos.Transactor, paymentProvider, cfg.Payments.Currency)
+	renewalService := renewal_service.NewRenewalService(
+		repos.Renewals,
+		subscriptionService,
+		renewal.NewNotifier(notifier, repos.Coaches, repos.Users),
+	)
+	scheduleService := schedule_service.NewScheduleService(repos.Schedule, repos.Attendance, repos.WeekSchedule, repos.TrainingGroups)
+
 	// Создаем веб-хендлер с botToken для проверки Telegram WebApp initData
 	calendarHandler := web.NewHandler(
 		scheduleService,
@@ -82,9 +149,13 @@ func main() {
 		coachService,
 		studentService,
 		subscriptionService,
+		paymentService,
+		renewalService,
 		attendanceService,
 		scheduleService,
 		trainingGroupService,
+		bot.NewPostgresSessionStore(repos.BotSessions),
+		notifier,
 	)
 	if err != nil {
 		log.Fatal("❌ Failed to create bot:", err)
@@ -199,6 +270,11 @@ func main() {
 	mux.HandleFunc("/api/cancel", calendarHandler.CancelRegistration)
 	mux.HandleFunc("/api/mark-attendance", calendarHandler.MarkAttendanceAPI)
 
+	// В режиме вебхука Telegram присылает обновления на этот же HTTP сервер
+	if cfg.Bot.UpdateMode == config.UpdateModeWebhook {
+		mux.Handle(telegramBot.WebhookPath(), telegramBot.WebhookHandler())
+	}
+
 	// Статические файлы Angular (для production)
 	// В development Angular dev server будет на порту 4200
 	angularDir := http.Dir("frontend/dist/spectrum-club-calendar/browser")
@@ -245,7 +321,13 @@ func main() {
 		}
 	}()
 
-	// Запускаем бота в горутине
+	go dispatcher.Run(ctx)
+	go reminder.NewScheduler(repos.Reminders, notifier, cfg.Reminders.Offsets, cfg.Reminders.CheckInterval).Run(ctx)
+	go waitlist.NewExpirer(attendanceService, cfg.Waitlist.CheckInterval).Run(ctx)
+	go alerts.NewScheduler(repos.Subscriptions, repos.Students, repos.Users, repos.Coaches, repos.Alerts, notifier, cfg.Alerts).Run(ctx)
+
+	// Запускаем бота в горутине (polling блокирует, вебхук только регистрируется)
+	log.Printf("📨 Режим получения обновлений: %s", cfg.Bot.UpdateMode)
 	go func() {
 		if err := telegramBot.Start(); err != nil {
 			log.Printf("❌ Ошибка запуска бота: %v", err)
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"spectrum-club-bot/internal/migrations"
+	"spectrum-club-bot/internal/models/config"
+	database "spectrum-club-bot/pkg"
+	"strconv"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// migrateMain читает только настройки БД: токен бота и прочие параметры для миграций не нужны
+func migrateMain(args []string) error {
+	if err := config.LoadDatabase(); err != nil {
+		return fmt.Errorf("ошибка загрузки конфигурации: %w", err)
+	}
+
+	db, err := database.NewPostgres()
+	if err != nil {
+		return fmt.Errorf("ошибка подключения к БД: %w", err)
+	}
+	defer db.Close()
+
+	return runMigrateCommand(db, args)
+}
+
+// runMigrateCommand обрабатывает `bot migrate up|down [N]|status`
+func runMigrateCommand(db *sqlx.DB, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("использование: migrate up|down [N]|status")
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrations.Up(db)
+		if err != nil {
+			return err
+		}
+		log.Printf("✅ Применено миграций: %d", applied)
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n <= 0 {
+				return fmt.Errorf("неверное количество шагов отката: %s", args[1])
+			}
+			steps = n
+		}
+		reverted, err := migrations.Down(db, steps)
+		if err != nil {
+			return err
+		}
+		log.Printf("↩️ Откачено миграций: %d", reverted)
+
+	case "status":
+		statuses, err := migrations.Status(db)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			if s.AppliedAt != nil {
+				fmt.Printf("✅ %04d_%s\t%s\n", s.Version, s.Name, s.AppliedAt.Format("02.01.2006 15:04:05"))
+			} else {
+				fmt.Printf("⏳ %04d_%s\tне применена\n", s.Version, s.Name)
+			}
+		}
+
+	default:
+		return fmt.Errorf("неизвестная команда migrate: %s", args[0])
+	}
+
+	return nil
+}
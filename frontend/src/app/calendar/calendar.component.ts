@@ -440,6 +440,11 @@ export class CalendarComponent implements OnInit {
   isAlreadyAttended(participant: any): boolean {
     return participant.attended === true || participant.status === 'attended';
   }
+
+  // Проверка, отмечен ли участник как не пришедший
+  isNoShow(participant: any): boolean {
+    return participant.status === 'no_show';
+  }
   
   // Переключение выбора конкретного ученика
   toggleStudentSelection(studentId: number) {
@@ -489,12 +494,21 @@ export class CalendarComponent implements OnInit {
       return;
     }
     
-    if (!confirm(`Подтвердить посещаемость для ${this.selectedStudents.size} учеников?`)) {
+    // Не выбранные и ещё не отмеченные ученики не пришли
+    const noShowIds = this.selectedTraining.participants
+      .filter(p => !this.selectedStudents.has(p.student_id) && p.status === 'registered')
+      .map(p => p.student_id);
+
+    let question = `Подтвердить посещаемость для ${this.selectedStudents.size} учеников?`;
+    if (noShowIds.length > 0) {
+      question += `\nНе пришедшими будут отмечены: ${noShowIds.length}`;
+    }
+    if (!confirm(question)) {
       return;
     }
     
     const studentIds = Array.from(this.selectedStudents);
-    this.calendarService.markAttendance(this.selectedTraining.training.id, studentIds).subscribe({
+    this.calendarService.markAttendance(this.selectedTraining.training.id, studentIds, noShowIds).subscribe({
       next: (response: any) => {
         // Проверяем, есть ли ошибки в ответе
         if (response.success === false || (response.failed_count && response.failed_count > 0)) {
@@ -628,7 +642,12 @@ export class CalendarComponent implements OnInit {
     // Обновляем initData перед запросом (на случай, если он появился позже)
     this.calendarService.updateInitData();
 
-    if (!confirm('Вы уверены, что хотите отменить запись?')) return;
+    // Перед подтверждением показываем срок бесплатной отмены
+    let question = 'Вы уверены, что хотите отменить запись?';
+    if (this.selectedTraining.cancel_terms) {
+      question += '\n\n⏰ ' + this.selectedTraining.cancel_terms;
+    }
+    if (!confirm(question)) return;
 
     // Получаем user_id для fallback (если initData нет)
     const userId = this.getUserId();
@@ -657,6 +676,48 @@ export class CalendarComponent implements OnInit {
     });
   }
 
+  joinWaitlist() {
+    if (!this.selectedTraining) return;
+
+    this.calendarService.updateInitData();
+
+    // /api/register сам ставит в лист ожидания, если мест нет
+    const userId = this.getUserId();
+    this.calendarService.registerForTraining(this.selectedTraining.training.id, userId || '').subscribe({
+      next: (message: string) => {
+        alert('⏳ ' + message);
+        this.closeModal();
+        this.reloadCalendar();
+      },
+      error: (err) => {
+        const errorMessage = typeof err.error === 'string' ? err.error : 'Не удалось встать в лист ожидания';
+        alert('❌ Ошибка: ' + errorMessage);
+      }
+    });
+  }
+
+  leaveWaitlist() {
+    if (!this.selectedTraining) return;
+
+    this.calendarService.updateInitData();
+
+    if (!confirm('Выйти из листа ожидания?')) return;
+
+    // Для ученика из листа ожидания /api/cancel означает выход из очереди
+    const userId = this.getUserId();
+    this.calendarService.cancelRegistration(this.selectedTraining.training.id, userId || '').subscribe({
+      next: () => {
+        alert('✅ Вы вышли из листа ожидания');
+        this.closeModal();
+        this.reloadCalendar();
+      },
+      error: (err) => {
+        const errorMessage = typeof err.error === 'string' ? err.error : 'Не удалось выйти из листа ожидания';
+        alert('❌ Ошибка: ' + errorMessage);
+      }
+    });
+  }
+
   reloadCalendar() {
     // user_id больше не передаем, используется initData из заголовков
     const dateStr = this.viewDate.toISOString().split('T')[0];
@@ -87,7 +87,13 @@ export interface TrainingDetails {
   can_register: boolean;
   is_full: boolean;
   is_past: boolean;
+  waitlist_position: number | null; // место в листе ожидания; null — не в очереди
+  waitlist_offered: boolean;
+  can_join_waitlist: boolean;
   current_time: string;
+  cancel_deadline: string; // до этого момента отмена записи бесплатная
+  late_cancel_policy: 'charge' | 'block'; // поздняя отмена списывает занятие или запрещена
+  cancel_terms: string; // условия отмены для ученика
 }
 
 export interface Participant {
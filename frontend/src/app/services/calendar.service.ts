@@ -171,10 +171,12 @@ export class CalendarService {
   }
 
   // Подтвердить посещаемость тренировки (для тренеров)
-  markAttendance(trainingId: number, studentIds: number[]): Observable<any> {
+  // noShowIds — записавшиеся, которые не пришли
+  markAttendance(trainingId: number, studentIds: number[], noShowIds: number[] = []): Observable<any> {
     const body = {
       training_id: trainingId,
-      student_ids: studentIds
+      student_ids: studentIds,
+      no_show_ids: noShowIds
     };
     return this.http.post(`${this.apiUrl}/mark-attendance`, body, {
       headers: this.getHeaders()
@@ -0,0 +1,307 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/models/config"
+	"spectrum-club-bot/internal/notify"
+	"spectrum-club-bot/internal/repository"
+	"strings"
+	"time"
+)
+
+// Scheduler раз в interval проверяет абонементы: предупреждает учеников, что занятия
+// или срок заканчиваются, и раз в неделю присылает тренерам сводку по истекающим абонементам.
+// Отметки о предупреждениях хранятся в БД, поэтому рестарт не приводит к повторным сообщениям.
+type Scheduler struct {
+	subscriptions repository.SubscriptionRepository
+	students      repository.StudentRepository
+	users         repository.UserRepository
+	coaches       repository.CoachRepository
+	alerts        repository.SubscriptionAlertRepository
+	notifier      notify.Notifier
+
+	lowLessons int
+	expiryDays int
+	interval   time.Duration
+}
+
+func NewScheduler(
+	subscriptions repository.SubscriptionRepository,
+	students repository.StudentRepository,
+	users repository.UserRepository,
+	coaches repository.CoachRepository,
+	alerts repository.SubscriptionAlertRepository,
+	notifier notify.Notifier,
+	cfg config.SubscriptionAlertConfig,
+) *Scheduler {
+	interval := cfg.CheckInterval
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+
+	return &Scheduler{
+		subscriptions: subscriptions,
+		students:      students,
+		users:         users,
+		coaches:       coaches,
+		alerts:        alerts,
+		notifier:      notifier,
+		lowLessons:    cfg.LowLessons,
+		expiryDays:    cfg.ExpiryDays,
+		interval:      interval,
+	}
+}
+
+// Run проверяет абонементы каждые interval до отмены ctx
+func (s *Scheduler) Run(ctx context.Context) {
+	log.Printf("🎫 Предупреждения об абонементах: осталось занятий <= %d, до окончания <= %d дн.", s.lowLessons, s.expiryDays)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.check(time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) check(now time.Time) {
+	subscriptions, err := s.subscriptions.GetAll()
+	if err != nil {
+		log.Printf("❌ Абонементы: ошибка получения абонементов: %v", err)
+		return
+	}
+
+	latest := latestByStudent(subscriptions)
+	watched := watchedSubscriptions(subscriptions, latest, now)
+	for _, subscription := range watched {
+		if subscription.EndDate.Before(now) {
+			continue
+		}
+		// Об остатке занятий говорим только по последнему абонементу: старый, даже
+		// с занятиями, уже сменил новый, и "продлите" про него вводило бы в заблуждение
+		if latest[subscription.StudentID] == subscription {
+			s.checkLowLessons(subscription)
+		}
+		s.checkExpiry(subscription, now)
+	}
+
+	s.sendDigest(watched, now)
+}
+
+// watchedSubscriptions абонементы, за которыми следим: последний абонемент каждого ученика
+// и более старые действующие абонементы, на которых ещё остались занятия, — их занятия
+// тоже сгорят, если ученик не успеет их посетить
+func watchedSubscriptions(subscriptions []*models.Subscription, latest map[int64]*models.Subscription, now time.Time) []*models.Subscription {
+	var watched []*models.Subscription
+	for _, subscription := range subscriptions {
+		isLatest := latest[subscription.StudentID] == subscription
+		if isLatest || (subscription.RemainingLessons > 0 && !subscription.EndDate.Before(now)) {
+			watched = append(watched, subscription)
+		}
+	}
+	return watched
+}
+
+// latestByStudent у каждого ученика абонемент с самым поздним сроком
+func latestByStudent(subscriptions []*models.Subscription) map[int64]*models.Subscription {
+	latest := make(map[int64]*models.Subscription)
+	for _, subscription := range subscriptions {
+		current, ok := latest[subscription.StudentID]
+		if !ok || subscription.EndDate.After(current.EndDate) {
+			latest[subscription.StudentID] = subscription
+		}
+	}
+	return latest
+}
+
+func (s *Scheduler) checkLowLessons(subscription *models.Subscription) {
+	if s.lowLessons == 0 {
+		return
+	}
+	if subscription.RemainingLessons > s.lowLessons {
+		// Абонемент пополнили: при следующем снижении предупредим снова
+		s.unmark(subscription.ID, models.SubscriptionAlertLowLessons)
+		return
+	}
+
+	var text strings.Builder
+	if subscription.RemainingLessons == 0 {
+		text.WriteString("🎫 *Занятия на абонементе закончились*\n\n")
+		text.WriteString("Записаться на тренировку не получится, пока абонемент не продлят. Обратитесь к тренеру.")
+	} else {
+		text.WriteString(fmt.Sprintf("🎫 *На абонементе осталось занятий: %d*\n\n", subscription.RemainingLessons))
+		text.WriteString(fmt.Sprintf("Абонемент действует до %s. ", subscription.EndDate.Format("02.01.2006")))
+		text.WriteString("Чтобы не пропускать тренировки, продлите его заранее у тренера.")
+	}
+	s.warn(subscription, models.SubscriptionAlertLowLessons, text.String())
+}
+
+func (s *Scheduler) checkExpiry(subscription *models.Subscription, now time.Time) {
+	if s.expiryDays == 0 {
+		return
+	}
+	daysLeft := daysBetween(now, subscription.EndDate)
+	if daysLeft > s.expiryDays {
+		// Абонемент продлили: перед новым сроком окончания предупредим снова
+		s.unmark(subscription.ID, models.SubscriptionAlertExpiry)
+		return
+	}
+	if subscription.RemainingLessons == 0 {
+		// Занятий не осталось — об этом уже говорит предупреждение об остатке
+		return
+	}
+
+	var text strings.Builder
+	if daysLeft == 0 {
+		text.WriteString("⏳ *Абонемент заканчивается сегодня*\n\n")
+	} else {
+		text.WriteString(fmt.Sprintf("⏳ *Абонемент заканчивается %s*\n\n", subscription.EndDate.Format("02.01.2006")))
+	}
+	text.WriteString(fmt.Sprintf("Осталось занятий: %d. Неиспользованные занятия сгорят — ", subscription.RemainingLessons))
+	text.WriteString("успейте их посетить или продлите абонемент у тренера.")
+	s.warn(subscription, models.SubscriptionAlertExpiry, text.String())
+}
+
+// warn отмечает предупреждение и ставит его в очередь; если поставить не удалось,
+// отметка снимается, и предупреждение уйдёт при следующей проверке
+func (s *Scheduler) warn(subscription *models.Subscription, kind, text string) {
+	marked, err := s.alerts.MarkSent(subscription.ID, kind)
+	if err != nil {
+		log.Printf("❌ Абонементы: ошибка отметки предупреждения %s для абонемента %d: %v", kind, subscription.ID, err)
+		return
+	}
+	if !marked {
+		return
+	}
+
+	telegramID, _, err := s.studentContact(subscription.StudentID)
+	if err == nil {
+		err = s.notifier.Send(notify.Message{
+			ChatID:    telegramID,
+			Text:      text,
+			ParseMode: "Markdown",
+		})
+	}
+	if err != nil {
+		log.Printf("❌ Абонементы: не удалось отправить предупреждение %s по абонементу %d: %v", kind, subscription.ID, err)
+		s.unmark(subscription.ID, kind)
+	}
+}
+
+func (s *Scheduler) unmark(subscriptionID int64, kind string) {
+	if err := s.alerts.Unmark(subscriptionID, kind); err != nil {
+		log.Printf("❌ Абонементы: ошибка снятия отметки %s для абонемента %d: %v", kind, subscriptionID, err)
+	}
+}
+
+// sendDigest раз в неделю присылает тренерам абонементы, срок которых заканчивается на этой неделе
+func (s *Scheduler) sendDigest(subscriptions []*models.Subscription, now time.Time) {
+	weekStart := startOfWeek(now)
+	weekEnd := weekStart.AddDate(0, 0, 6)
+
+	var lapsing []*models.Subscription
+	for _, subscription := range subscriptions {
+		end := dateOnly(subscription.EndDate)
+		if !end.Before(weekStart) && !end.After(weekEnd) {
+			lapsing = append(lapsing, subscription)
+		}
+	}
+	if len(lapsing) == 0 {
+		return
+	}
+	sort.Slice(lapsing, func(i, j int) bool { return lapsing[i].EndDate.Before(lapsing[j].EndDate) })
+
+	recipients, err := s.coachChats()
+	if err != nil {
+		log.Printf("❌ Абонементы: ошибка получения тренеров для сводки: %v", err)
+		return
+	}
+	if len(recipients) == 0 {
+		return
+	}
+
+	marked, err := s.alerts.MarkDigestSent(weekStart)
+	if err != nil {
+		log.Printf("❌ Абонементы: ошибка отметки сводки за неделю %s: %v", weekStart.Format("02.01.2006"), err)
+		return
+	}
+	if !marked {
+		return
+	}
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("📋 Абонементы, которые заканчиваются на этой неделе (%s – %s):\n\n",
+		weekStart.Format("02.01"), weekEnd.Format("02.01")))
+	for i, subscription := range lapsing {
+		name := "Неизвестный"
+		if _, fullName, err := s.studentContact(subscription.StudentID); err == nil {
+			name = fullName
+		}
+		text.WriteString(fmt.Sprintf("%d. %s — до %s, осталось занятий: %d\n",
+			i+1, name, subscription.EndDate.Format("02.01"), subscription.RemainingLessons))
+	}
+
+	for _, chatID := range recipients {
+		if err := s.notifier.Send(notify.Message{ChatID: chatID, Text: text.String()}); err != nil {
+			log.Printf("❌ Абонементы: не удалось отправить сводку тренеру %d: %v", chatID, err)
+		}
+	}
+}
+
+// studentContact telegram_id и имя ученика
+func (s *Scheduler) studentContact(studentID int64) (int64, string, error) {
+	student, err := s.students.GetByID(studentID)
+	if err != nil {
+		return 0, "", fmt.Errorf("ученик %d не найден: %w", studentID, err)
+	}
+	user, err := s.users.GetByID(student.UserID)
+	if err != nil {
+		return 0, "", fmt.Errorf("пользователь %d не найден: %w", student.UserID, err)
+	}
+	return user.TelegramID, strings.TrimSpace(user.FirstName + " " + user.LastName), nil
+}
+
+// coachChats telegram_id всех тренеров
+func (s *Scheduler) coachChats() ([]int64, error) {
+	coaches, err := s.coaches.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var chats []int64
+	for _, coach := range coaches {
+		user, err := s.users.GetByID(coach.UserID)
+		if err != nil {
+			log.Printf("❌ Абонементы: пользователь тренера %d не найден: %v", coach.ID, err)
+			continue
+		}
+		chats = append(chats, user.TelegramID)
+	}
+	return chats, nil
+}
+
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// daysBetween число календарных дней от from до to
+func daysBetween(from, to time.Time) int {
+	return int(dateOnly(to).Sub(dateOnly(from)).Hours()+12) / 24
+}
+
+// startOfWeek понедельник недели, в которую попадает t
+func startOfWeek(t time.Time) time.Time {
+	day := dateOnly(t)
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset)
+}
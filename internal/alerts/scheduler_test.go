@@ -0,0 +1,153 @@
+package alerts
+
+import (
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/models/config"
+	"spectrum-club-bot/internal/notify"
+	"spectrum-club-bot/internal/repository"
+	"spectrum-club-bot/internal/repository/memory"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sentMessages notify.Notifier, запоминающий сообщения вместо отправки
+type sentMessages []notify.Message
+
+func (s *sentMessages) Send(message notify.Message) error {
+	*s = append(*s, message)
+	return nil
+}
+
+// fixture планировщик поверх in-memory хранилища с одним учеником (telegram_id 42)
+type fixture struct {
+	scheduler     *Scheduler
+	subscriptions repository.SubscriptionRepository
+	studentID     int64
+	sent          *sentMessages
+}
+
+func newFixture(t *testing.T, cfg config.SubscriptionAlertConfig) *fixture {
+	t.Helper()
+	store := memory.NewStore()
+	users := memory.NewUserRepository(store)
+	students := memory.NewStudentRepository(store)
+
+	user := &models.User{TelegramID: 42, FirstName: "Анна", LastName: "Тестова", Role: "student"}
+	if err := users.CreateOrUpdate(user); err != nil {
+		t.Fatal(err)
+	}
+	student := &models.Student{UserID: user.ID}
+	if err := students.Create(student); err != nil {
+		t.Fatal(err)
+	}
+
+	f := &fixture{
+		subscriptions: memory.NewSubscriptionRepository(store, models.ConsumptionExpiringFirst),
+		studentID:     student.ID,
+		sent:          &sentMessages{},
+	}
+	f.scheduler = NewScheduler(f.subscriptions, students, users, memory.NewCoachRepository(store),
+		memory.NewSubscriptionAlertRepository(store), f.sent, cfg)
+	return f
+}
+
+func (f *fixture) addSubscription(t *testing.T, remaining int, created, end time.Time) *models.Subscription {
+	t.Helper()
+	subscription := &models.Subscription{
+		StudentID:        f.studentID,
+		StartDate:        created,
+		EndDate:          end,
+		TotalLessons:     8,
+		RemainingLessons: remaining,
+		CreatedAt:        created,
+	}
+	if err := f.subscriptions.Create(subscription); err != nil {
+		t.Fatal(err)
+	}
+	return subscription
+}
+
+func TestLowLessonsThreshold(t *testing.T) {
+	tests := []struct {
+		name      string
+		remaining int
+		want      string // начало предупреждения; пусто — предупреждения нет
+	}{
+		{name: "выше порога", remaining: 3},
+		{name: "на пороге", remaining: 2, want: "🎫 *На абонементе осталось занятий: 2*"},
+		{name: "ниже порога", remaining: 1, want: "🎫 *На абонементе осталось занятий: 1*"},
+		{name: "закончились", remaining: 0, want: "🎫 *Занятия на абонементе закончились*"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t, config.SubscriptionAlertConfig{LowLessons: 2})
+			now := time.Now()
+			f.addSubscription(t, tt.remaining, now, now.AddDate(0, 1, 0))
+
+			f.scheduler.check(now)
+			if tt.want == "" {
+				if len(*f.sent) != 0 {
+					t.Errorf("отправлено %+v, want ничего", *f.sent)
+				}
+				return
+			}
+			if len(*f.sent) != 1 || !strings.HasPrefix((*f.sent)[0].Text, tt.want) {
+				t.Fatalf("отправлено %+v, want %q", *f.sent, tt.want)
+			}
+			if (*f.sent)[0].ChatID != 42 {
+				t.Errorf("ChatID = %d, want 42", (*f.sent)[0].ChatID)
+			}
+		})
+	}
+}
+
+func TestLowLessonsWarnsAgainAfterTopUp(t *testing.T) {
+	f := newFixture(t, config.SubscriptionAlertConfig{LowLessons: 2})
+	now := time.Now()
+	subscription := f.addSubscription(t, 1, now, now.AddDate(0, 1, 0))
+
+	steps := []struct {
+		name      string
+		remaining int
+		wantTotal int
+	}{
+		{name: "мало занятий", remaining: 1, wantTotal: 1},
+		{name: "повторная проверка", remaining: 1, wantTotal: 1},
+		{name: "пополнили", remaining: 10, wantTotal: 1},
+		{name: "снова мало", remaining: 2, wantTotal: 2},
+	}
+	for _, step := range steps {
+		subscription.RemainingLessons = step.remaining
+		if err := f.subscriptions.Update(subscription); err != nil {
+			t.Fatal(err)
+		}
+		f.scheduler.check(now)
+		if len(*f.sent) != step.wantTotal {
+			t.Fatalf("%s: отправлено %d предупреждений, want %d", step.name, len(*f.sent), step.wantTotal)
+		}
+	}
+}
+
+func TestExpiryWarnsAboutOlderPassWithLessons(t *testing.T) {
+	f := newFixture(t, config.SubscriptionAlertConfig{ExpiryDays: 3})
+	now := time.Now()
+	older := f.addSubscription(t, 4, now.AddDate(0, 0, -20), now.AddDate(0, 0, 2))
+	f.addSubscription(t, 0, now.AddDate(0, 0, -30), now.AddDate(0, 0, 1)) // пустой — не о чем предупреждать
+	f.addSubscription(t, 8, now, now.AddDate(0, 2, 0))
+
+	f.scheduler.check(now)
+	if len(*f.sent) != 1 {
+		t.Fatalf("отправлено %d предупреждений, want 1: %+v", len(*f.sent), *f.sent)
+	}
+	text := (*f.sent)[0].Text
+	if !strings.Contains(text, older.EndDate.Format("02.01.2006")) || !strings.Contains(text, "Осталось занятий: 4") {
+		t.Errorf("предупреждение не про старый абонемент: %q", text)
+	}
+
+	f.scheduler.check(now)
+	if len(*f.sent) != 1 {
+		t.Errorf("предупреждение об окончании срока отправлено повторно")
+	}
+}
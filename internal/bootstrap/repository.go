@@ -0,0 +1,102 @@
+package bootstrap
+
+import (
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/repository"
+	"spectrum-club-bot/internal/repository/attendance"
+	"spectrum-club-bot/internal/repository/coach"
+	"spectrum-club-bot/internal/repository/group"
+	"spectrum-club-bot/internal/repository/lesson_ledger"
+	"spectrum-club-bot/internal/repository/memory"
+	"spectrum-club-bot/internal/repository/outbox"
+	"spectrum-club-bot/internal/repository/payment"
+	"spectrum-club-bot/internal/repository/reminder"
+	"spectrum-club-bot/internal/repository/renewal_request"
+	"spectrum-club-bot/internal/repository/schedule"
+	"spectrum-club-bot/internal/repository/schedule_template"
+	"spectrum-club-bot/internal/repository/session"
+	"spectrum-club-bot/internal/repository/student"
+	"spectrum-club-bot/internal/repository/subscription"
+	"spectrum-club-bot/internal/repository/subscription_alert"
+	"spectrum-club-bot/internal/repository/subscription_freeze"
+	"spectrum-club-bot/internal/repository/subscription_plan"
+	"spectrum-club-bot/internal/repository/transaction"
+	"spectrum-club-bot/internal/repository/user"
+	"spectrum-club-bot/internal/repository/waitlist"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Repositories набор репозиториев приложения, независимый от хранилища
+type Repositories struct {
+	Users          repository.UserRepository
+	Students       repository.StudentRepository
+	Coaches        repository.CoachRepository
+	Subscriptions  repository.SubscriptionRepository
+	Plans          repository.SubscriptionPlanRepository
+	Freezes        repository.SubscriptionFreezeRepository
+	Ledger         repository.LessonLedgerRepository
+	Payments       repository.PaymentRepository
+	Attendance     repository.AttendanceRepository
+	Schedule       repository.TrainingScheduleRepository
+	TrainingGroups repository.TrainingGroupRepository
+	WeekSchedule   repository.WeekScheduleRepository
+	BotSessions    repository.BotSessionRepository
+	Outbox         repository.OutboxRepository
+	Reminders      repository.ReminderRepository
+	Alerts         repository.SubscriptionAlertRepository
+	Waitlist       repository.WaitlistRepository
+	Renewals       repository.RenewalRequestRepository
+	Transactor     repository.Transactor
+}
+
+// NewPostgresRepositories репозитории поверх PostgreSQL; order — порядок списания
+// занятий, когда у ученика несколько активных абонементов
+func NewPostgresRepositories(db *sqlx.DB, order models.ConsumptionOrder) *Repositories {
+	return &Repositories{
+		Users:          user.NewUserRepository(db),
+		Students:       student.NewStudentRepository(db),
+		Coaches:        coach.NewCoachRepository(db),
+		Subscriptions:  subscription.NewSubscriptionRepository(db, order),
+		Plans:          subscription_plan.NewSubscriptionPlanRepository(db),
+		Freezes:        subscription_freeze.NewSubscriptionFreezeRepository(db),
+		Ledger:         lesson_ledger.NewLessonLedgerRepository(db),
+		Payments:       payment.NewPaymentRepository(db),
+		Attendance:     attendance.NewAttendanceRepository(db),
+		Schedule:       schedule.NewTrainingScheduleRepository(db),
+		TrainingGroups: group.NewTrainingGroupRepository(db),
+		WeekSchedule:   schedule_template.NewWeekScheduleRepository(db),
+		BotSessions:    session.NewBotSessionRepository(db),
+		Outbox:         outbox.NewOutboxRepository(db),
+		Reminders:      reminder.NewReminderRepository(db),
+		Alerts:         subscription_alert.NewSubscriptionAlertRepository(db),
+		Waitlist:       waitlist.NewWaitlistRepository(db),
+		Renewals:       renewal_request.NewRenewalRequestRepository(db),
+		Transactor:     transaction.NewTransactor(db, order),
+	}
+}
+
+// NewMemoryRepositories репозитории в памяти процесса (демо-режим, локальные прогоны без БД)
+func NewMemoryRepositories(store *memory.Store, order models.ConsumptionOrder) *Repositories {
+	return &Repositories{
+		Users:          memory.NewUserRepository(store),
+		Students:       memory.NewStudentRepository(store),
+		Coaches:        memory.NewCoachRepository(store),
+		Subscriptions:  memory.NewSubscriptionRepository(store, order),
+		Plans:          memory.NewSubscriptionPlanRepository(store),
+		Freezes:        memory.NewSubscriptionFreezeRepository(store),
+		Ledger:         memory.NewLessonLedgerRepository(store),
+		Payments:       memory.NewPaymentRepository(store),
+		Attendance:     memory.NewAttendanceRepository(store),
+		Schedule:       memory.NewTrainingScheduleRepository(store),
+		TrainingGroups: memory.NewTrainingGroupRepository(store),
+		WeekSchedule:   memory.NewWeekScheduleRepository(store),
+		BotSessions:    memory.NewBotSessionRepository(store),
+		Outbox:         memory.NewOutboxRepository(store),
+		Reminders:      memory.NewReminderRepository(store),
+		Alerts:         memory.NewSubscriptionAlertRepository(store),
+		Waitlist:       memory.NewWaitlistRepository(store),
+		Renewals:       memory.NewRenewalRequestRepository(store),
+		Transactor:     memory.NewTransactor(store, order),
+	}
+}
@@ -0,0 +1,113 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"spectrum-club-bot/internal/models"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// noShowCallbackPrefix callback data кнопки «не пришёл»: noshow:<id тренировки>:<id ученика>
+const noShowCallbackPrefix = "noshow:"
+
+func noShowCallbackData(trainingID, studentID int) string {
+	return noShowCallbackPrefix + strconv.Itoa(trainingID) + ":" + strconv.Itoa(studentID)
+}
+
+// parseNoShowCallback разбирает callback data кнопки «не пришёл»
+func parseNoShowCallback(data string) (trainingID, studentID int, ok bool) {
+	rest, found := strings.CutPrefix(data, noShowCallbackPrefix)
+	if !found {
+		return 0, 0, false
+	}
+	trainingStr, studentStr, found := strings.Cut(rest, ":")
+	if !found {
+		return 0, 0, false
+	}
+	trainingID, err := strconv.Atoi(trainingStr)
+	if err != nil {
+		return 0, 0, false
+	}
+	studentID, err = strconv.Atoi(studentStr)
+	if err != nil {
+		return 0, 0, false
+	}
+	return trainingID, studentID, true
+}
+
+// trainingStartTime момент начала тренировки: DATE и TIME из БД хранят время клуба без зоны
+func trainingStartTime(training *models.TrainingSchedule) time.Time {
+	date := training.TrainingDate
+	return time.Date(date.Year(), date.Month(), date.Day(), training.StartTime.Hour(), training.StartTime.Minute(), 0, 0, time.Local)
+}
+
+// showTrainingParticipants список записавшихся со статусами. После начала тренировки
+// у ещё не отмеченных учеников появляется кнопка «не пришёл»
+func (b *Bot) showTrainingParticipants(chatID int64, trainingID int) {
+	training, err := b.ScheduleService.GetTrainingByID(trainingID)
+	if err != nil || training == nil {
+		b.sendError(chatID, "❌ Тренировка не найдена")
+		return
+	}
+	participants, err := b.AttendanceService.GetParticipants(trainingID)
+	if err != nil {
+		b.sendError(chatID, "❌ Ошибка получения участников")
+		return
+	}
+
+	title := fmt.Sprintf("👥 Участники тренировки %s в %s",
+		training.TrainingDate.Format("02.01.2006"), training.StartTime.Format("15:04"))
+	if len(participants) == 0 {
+		b.sendMessage(chatID, title+"\n\nНикто не записан")
+		return
+	}
+
+	started := !training.IsCancelled() && !trainingStartTime(training).After(time.Now())
+
+	var text strings.Builder
+	text.WriteString(title + ":\n\n")
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for i, p := range participants {
+		fmt.Fprintf(&text, "%d. %s — %s\n", i+1, p.StudentName, models.AttendanceStatusName(p.Status))
+		if started && p.Status == models.AttendanceStatusRegistered {
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("🚫 "+p.StudentName+" — не пришёл", noShowCallbackData(trainingID, p.StudentID)),
+			))
+		}
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text.String())
+	if len(rows) > 0 {
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	}
+	b.send(msg)
+}
+
+// handleNoShowCallback тренер отмечает, что записавшийся ученик не пришёл
+func (b *Bot) handleNoShowCallback(query *tgbotapi.CallbackQuery, trainingID, studentID int) string {
+	if query.Message == nil || query.Message.Chat == nil {
+		return "❌ Не удалось выполнить действие"
+	}
+	chatID := query.Message.Chat.ID
+
+	user, err := b.UserService.GetByTelegramID(int64(query.From.ID))
+	if err != nil {
+		return "❌ Сначала зарегистрируйтесь в боте"
+	}
+	if user.Role != "coach" {
+		return "❌ Отмечать посещаемость может только тренер"
+	}
+
+	err = b.AttendanceService.SetAttendanceStatus(trainingID, studentID, int(user.ID), models.AttendanceStatusNoShow, "")
+	if err != nil {
+		log.Printf("Ошибка отметки неявки ученика %d на тренировку %d: %v", studentID, trainingID, err)
+		return "❌ " + err.Error()
+	}
+
+	b.showTrainingParticipants(chatID, trainingID)
+	return "Отмечено: не пришёл"
+}
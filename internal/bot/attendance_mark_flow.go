@@ -0,0 +1,360 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"spectrum-club-bot/internal/models"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// markCallbackPrefix callback data отметки посещаемости: mark:<действие>:<id тренировки>:<id ученика>
+const markCallbackPrefix = "mark:"
+
+const (
+	markActionOpen   = "open"
+	markActionToggle = "toggle"
+	markActionSave   = "save"
+	markActionWalkIn = "walkin"
+	markActionQR     = "qr"
+)
+
+const (
+	markSelectedMark   = "✅ "
+	markUnselectedMark = "⬜ "
+)
+
+func markCallbackData(action string, trainingID, studentID int) string {
+	return markCallbackPrefix + action + ":" + strconv.Itoa(trainingID) + ":" + strconv.Itoa(studentID)
+}
+
+// parseMarkCallback разбирает callback data отметки посещаемости
+func parseMarkCallback(data string) (action string, trainingID, studentID int, ok bool) {
+	rest, found := strings.CutPrefix(data, markCallbackPrefix)
+	if !found {
+		return "", 0, 0, false
+	}
+	parts := strings.Split(rest, ":")
+	if len(parts) != 3 {
+		return "", 0, 0, false
+	}
+	switch parts[0] {
+	case markActionOpen, markActionToggle, markActionSave, markActionWalkIn, markActionQR:
+	default:
+		return "", 0, 0, false
+	}
+	trainingID, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return "", 0, 0, false
+	}
+	studentID, err = strconv.Atoi(parts[2])
+	if err != nil {
+		return "", 0, 0, false
+	}
+	return parts[0], trainingID, studentID, true
+}
+
+// handleMarkAttendance тренер выбирает одну из своих сегодняшних тренировок для отметки посещаемости
+func (b *Bot) handleMarkAttendance(chatID int64, user *models.User) {
+	if user == nil || user.Role != "coach" {
+		b.sendError(chatID, "❌ Отмечать посещаемость может только тренер")
+		return
+	}
+	coach, err := b.CoachService.GetCoachByUserID(user.ID)
+	if err != nil {
+		b.sendError(chatID, "❌ Ошибка получения данных тренера")
+		return
+	}
+
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	end := time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 59, 0, time.Local)
+
+	trainings, err := b.ScheduleService.GetCoachSchedule(coach.ID, start, end)
+	if err != nil {
+		b.sendError(chatID, "❌ Ошибка при получении расписания")
+		return
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, training := range trainings {
+		if training.IsCancelled() {
+			continue
+		}
+		groupName := training.GroupName
+		if groupName == "" {
+			if group, _ := b.TrainingGroupService.GetGroupByID(training.GroupID); group != nil {
+				groupName = group.Name
+			}
+		}
+		label := fmt.Sprintf("%s-%s %s", training.StartTime.Format("15:04"), training.EndTime.Format("15:04"), groupName)
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(strings.TrimSpace(label), markCallbackData(markActionOpen, training.ID, 0)),
+			tgbotapi.NewInlineKeyboardButtonData("📱 QR", markCallbackData(markActionQR, training.ID, 0)),
+		))
+	}
+
+	if len(rows) == 0 {
+		b.sendMessage(chatID, fmt.Sprintf("📭 У вас нет тренировок на %s", now.Format("02.01.2006")))
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("✅ Тренировки на %s\n\nВыберите тренировку для отметки посещаемости или покажите ученикам QR-код для самоотметки:", now.Format("02.01.2006")))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	b.send(msg)
+}
+
+// handleMarkCallback открытие списка, переключение ученика, сохранение отметки,
+// добавление пришедшего без записи и QR-код для самоотметки
+func (b *Bot) handleMarkCallback(query *tgbotapi.CallbackQuery, action string, trainingID, studentID int) string {
+	if query.Message == nil || query.Message.Chat == nil {
+		return "❌ Не удалось выполнить действие"
+	}
+	chatID := query.Message.Chat.ID
+	// Выбор отметок хранится в сессии: другой экземпляр бота мог её изменить
+	b.dropCachedSession(chatID)
+
+	user, err := b.UserService.GetByTelegramID(int64(query.From.ID))
+	if err != nil {
+		return "❌ Сначала зарегистрируйтесь в боте"
+	}
+	if user.Role != "coach" {
+		return "❌ Отмечать посещаемость может только тренер"
+	}
+
+	switch action {
+	case markActionOpen:
+		return b.openAttendanceMarking(chatID, user, trainingID)
+	case markActionToggle:
+		return b.toggleAttendanceMark(query, trainingID, studentID)
+	case markActionSave:
+		return b.saveAttendanceMarking(query, user, trainingID)
+	case markActionWalkIn:
+		return b.startWalkIn(chatID, user, trainingID)
+	case markActionQR:
+		return b.sendCheckinQR(chatID, user, trainingID)
+	}
+	return ""
+}
+
+// openAttendanceMarking присылает участников тренировки кнопками-переключателями;
+// уже отмеченные пришедшими выбраны сразу
+func (b *Bot) openAttendanceMarking(chatID int64, user *models.User, trainingID int) string {
+	training, err := b.markableTraining(user, trainingID)
+	if err != nil {
+		return "❌ " + err.Error()
+	}
+	participants, err := b.AttendanceService.GetParticipants(trainingID)
+	if err != nil {
+		log.Printf("Ошибка получения участников тренировки %d: %v", trainingID, err)
+		return "❌ Ошибка получения участников"
+	}
+
+	selected := b.startAttendanceMarking(chatID, trainingID, participants)
+	b.sendAttendanceMarking(chatID, training, participants, selected)
+	return ""
+}
+
+// sendAttendanceMarking сообщение с кнопками отметки посещаемости
+func (b *Bot) sendAttendanceMarking(chatID int64, training *models.TrainingSchedule, participants []models.AttendanceWithStudent, selected map[int]bool) {
+	text := fmt.Sprintf("✅ Посещаемость тренировки %s в %s\n\n",
+		training.TrainingDate.Format("02.01.2006"), training.StartTime.Format("15:04"))
+	if len(participants) == 0 {
+		text += "На тренировку никто не записан. Пришедших без записи можно добавить кнопкой ниже."
+	} else {
+		text += "Отметьте пришедших и нажмите «Сохранить». Неотмеченные будут записаны как не пришедшие."
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = markAttendanceKeyboard(training.ID, participants, selected)
+	b.send(msg)
+}
+
+// toggleAttendanceMark переключает ученика и перерисовывает кнопки того же сообщения
+func (b *Bot) toggleAttendanceMark(query *tgbotapi.CallbackQuery, trainingID, studentID int) string {
+	participants, err := b.AttendanceService.GetParticipants(trainingID)
+	if err != nil {
+		log.Printf("Ошибка получения участников тренировки %d: %v", trainingID, err)
+		return "❌ Ошибка получения участников"
+	}
+
+	chatID := query.Message.Chat.ID
+	selected := b.attendanceMarkingSelection(chatID, trainingID, participants)
+	selected[studentID] = !selected[studentID]
+	b.storeAttendanceMarking(chatID, trainingID, selected)
+
+	edit := tgbotapi.NewEditMessageReplyMarkup(chatID, query.Message.MessageID,
+		markAttendanceKeyboard(trainingID, participants, selected))
+	if _, err := b.api.Send(edit); err != nil {
+		log.Printf("⚠️ Ошибка обновления кнопок отметки: %v", err)
+	}
+	return ""
+}
+
+// saveAttendanceMarking применяет отметку так же, как веб-интерфейс: выбранные — пришли
+// (занятие списывается, ученик получает уведомление), остальные — не пришли
+func (b *Bot) saveAttendanceMarking(query *tgbotapi.CallbackQuery, user *models.User, trainingID int) string {
+	chatID := query.Message.Chat.ID
+	if _, err := b.markableTraining(user, trainingID); err != nil {
+		return "❌ " + err.Error()
+	}
+	participants, err := b.AttendanceService.GetParticipants(trainingID)
+	if err != nil {
+		log.Printf("Ошибка получения участников тренировки %d: %v", trainingID, err)
+		return "❌ Ошибка получения участников"
+	}
+
+	selected := b.attendanceMarkingSelection(chatID, trainingID, participants)
+	b.finishAttendanceMarking(chatID)
+	markedCount, noShowCount := 0, 0
+	var failures []string
+	for _, p := range participants {
+		var markErr error
+		switch {
+		case selected[p.StudentID]:
+			if p.Status != models.AttendanceStatusAttended {
+				markErr = b.AttendanceService.MarkAttendance(trainingID, p.StudentID, int(user.ID), true, "")
+			}
+			if markErr == nil {
+				markedCount++
+			}
+		default:
+			if p.Status != models.AttendanceStatusNoShow {
+				markErr = b.AttendanceService.SetAttendanceStatus(trainingID, p.StudentID, int(user.ID), models.AttendanceStatusNoShow, "")
+			}
+			if markErr == nil {
+				noShowCount++
+			}
+		}
+		if markErr != nil {
+			log.Printf("Ошибка отметки ученика %d на тренировке %d: %v", p.StudentID, trainingID, markErr)
+			failures = append(failures, fmt.Sprintf("%s: %v", p.StudentName, markErr))
+		}
+	}
+
+	// Кнопки убираем, чтобы отметку не сохранили повторно из старого сообщения
+	edit := tgbotapi.NewEditMessageReplyMarkup(chatID, query.Message.MessageID, tgbotapi.InlineKeyboardMarkup{
+		InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{},
+	})
+	if _, err := b.api.Send(edit); err != nil {
+		log.Printf("⚠️ Ошибка обновления кнопок отметки: %v", err)
+	}
+
+	text := fmt.Sprintf("💾 Посещаемость сохранена\n\n✅ Пришли: %d\n✗ Не пришли: %d", markedCount, noShowCount)
+	if len(failures) > 0 {
+		text += "\n\n❌ Не удалось отметить:\n" + strings.Join(failures, "\n")
+	}
+	b.sendMessage(chatID, text)
+	return "Сохранено"
+}
+
+// markableTraining тренировка тренера, которая уже началась и не отменена
+func (b *Bot) markableTraining(user *models.User, trainingID int) (*models.TrainingSchedule, error) {
+	training, err := b.coachTraining(user, trainingID)
+	if err != nil {
+		return nil, err
+	}
+	if trainingStartTime(training).After(time.Now()) {
+		return nil, fmt.Errorf("тренировка ещё не началась")
+	}
+	return training, nil
+}
+
+// coachTraining неотменённая тренировка, которую ведёт тренер user
+func (b *Bot) coachTraining(user *models.User, trainingID int) (*models.TrainingSchedule, error) {
+	training, err := b.ScheduleService.GetTrainingByID(trainingID)
+	if err != nil || training == nil {
+		return nil, fmt.Errorf("тренировка не найдена")
+	}
+	coach, err := b.CoachService.GetCoachByUserID(user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения данных тренера")
+	}
+	if training.CoachID == nil || *training.CoachID != coach.ID {
+		return nil, fmt.Errorf("отмечать посещаемость может только тренер этой тренировки")
+	}
+	if training.IsCancelled() {
+		return nil, fmt.Errorf("тренировка отменена")
+	}
+	return training, nil
+}
+
+// markAttendanceKeyboard кнопка на каждого участника, добавление пришедшего без записи и сохранение
+func markAttendanceKeyboard(trainingID int, participants []models.AttendanceWithStudent, selected map[int]bool) tgbotapi.InlineKeyboardMarkup {
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(participants)+2)
+	count := 0
+	for _, p := range participants {
+		mark := markUnselectedMark
+		if selected[p.StudentID] {
+			mark = markSelectedMark
+			count++
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(mark+p.StudentName, markCallbackData(markActionToggle, trainingID, p.StudentID)),
+		))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("➕ Пришёл без записи", markCallbackData(markActionWalkIn, trainingID, 0)),
+	))
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("💾 Сохранить (%d)", count), markCallbackData(markActionSave, trainingID, 0)),
+	))
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// attendanceMarkingSelection выбранные ученики из сессии. Если сессия потеряна или
+// относится к другой тренировке, выбор начинается с уже отмеченных пришедшими
+func (b *Bot) attendanceMarkingSelection(chatID int64, trainingID int, participants []models.AttendanceWithStudent) map[int]bool {
+	session := b.getOrCreateSession(chatID)
+	if session.MarkingTrainingID != trainingID {
+		return b.startAttendanceMarking(chatID, trainingID, participants)
+	}
+	selected := make(map[int]bool, len(session.MarkedStudentIDs))
+	for _, id := range session.MarkedStudentIDs {
+		selected[id] = true
+	}
+	return selected
+}
+
+// startAttendanceMarking начинает отметку тренировки с текущих статусов участников
+func (b *Bot) startAttendanceMarking(chatID int64, trainingID int, participants []models.AttendanceWithStudent) map[int]bool {
+	selected := make(map[int]bool, len(participants))
+	for _, p := range participants {
+		if p.Status == models.AttendanceStatusAttended {
+			selected[p.StudentID] = true
+		}
+	}
+	b.storeAttendanceMarking(chatID, trainingID, selected)
+	return selected
+}
+
+// storeAttendanceMarking сохраняет выбор в сессии. Начатый другой сценарий не прерывается:
+// состояние меняется, только если сессия была пустой
+func (b *Bot) storeAttendanceMarking(chatID int64, trainingID int, selected map[int]bool) {
+	session := b.getOrCreateSession(chatID)
+	if session.State == StateDefault {
+		session.State = StateMarkingAttendance
+	}
+	session.MarkingTrainingID = trainingID
+	session.MarkedStudentIDs = session.MarkedStudentIDs[:0]
+	for id, ok := range selected {
+		if ok {
+			session.MarkedStudentIDs = append(session.MarkedStudentIDs, id)
+		}
+	}
+	b.persistSession(chatID)
+}
+
+// finishAttendanceMarking очищает выбор после сохранения
+func (b *Bot) finishAttendanceMarking(chatID int64) {
+	session := b.getOrCreateSession(chatID)
+	if session.State == StateMarkingAttendance {
+		session.State = StateDefault
+	}
+	session.MarkingTrainingID = 0
+	session.MarkedStudentIDs = nil
+	b.persistSession(chatID)
+}
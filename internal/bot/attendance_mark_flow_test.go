@@ -0,0 +1,129 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"spectrum-club-bot/internal/models"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// press нажимает кнопку с data от имени чата и возвращает ответ бота на нажатие
+func (tb *testBot) press(chatID int64, data string) string {
+	tb.t.Helper()
+	answered := len(tb.srv.Requests("answerCallbackQuery"))
+	tb.srv.PushCallback(chatID, tgbotapi.User{ID: int(chatID)}, 1, data)
+
+	answers, err := tb.srv.WaitForRequests("answerCallbackQuery", answered+1, 2*time.Second)
+	if err != nil {
+		tb.t.Fatalf("нажатие %q: %v", data, err)
+	}
+	return answers[answered].Params.Get("text")
+}
+
+// waitForMessage ждёт сообщения бота в чат chatID, начинающегося с prefix: сообщения
+// уходят через очередь уведомлений и приходят не сразу
+func (tb *testBot) waitForMessage(chatID int64, prefix string) string {
+	tb.t.Helper()
+	for n := 1; ; n++ {
+		messages, err := tb.srv.WaitForMessages(n, 2*time.Second)
+		if err != nil {
+			tb.t.Fatalf("сообщение %q: %v", prefix, err)
+		}
+		if message := messages[n-1]; message.ChatID == chatID && strings.HasPrefix(message.Text, prefix) {
+			return message.Text
+		}
+	}
+}
+
+// startedTraining тренировка демо-тренера, начавшаяся час назад, с записанными учениками
+func (tb *testBot) startedTraining(studentIDs ...int) *models.TrainingSchedule {
+	tb.t.Helper()
+	user, err := tb.repos.Users.GetByTelegramID(demoCoachChatID)
+	if err != nil {
+		tb.t.Fatal(err)
+	}
+	coach, err := tb.repos.Coaches.GetByUserID(user.ID)
+	if err != nil {
+		tb.t.Fatal(err)
+	}
+
+	start := time.Now().Add(-time.Hour)
+	training := &models.TrainingSchedule{
+		GroupID:      1,
+		CoachID:      &coach.ID,
+		TrainingDate: start,
+		StartTime:    start,
+		EndTime:      start.Add(90 * time.Minute),
+	}
+	if err := tb.repos.Schedule.CreateTraining(training); err != nil {
+		tb.t.Fatal(err)
+	}
+	for _, studentID := range studentIDs {
+		if err := tb.repos.Attendance.CreateAttendance(&models.Attendance{TrainingID: training.ID, StudentID: studentID}); err != nil {
+			tb.t.Fatal(err)
+		}
+	}
+	return training
+}
+
+func TestSaveAttendanceMarking(t *testing.T) {
+	tb := newTestBot(t)
+
+	// Демо-ученики: Анна, Борис и Вера, у каждой по абонементу на 8 занятий с тем же ID
+	const anna, boris, vera = 1, 2, 3
+	training := tb.startedTraining(anna, boris, vera)
+	// У Веры закончились занятия: отметить её пришедшей не получится
+	if err := tb.repos.Subscriptions.AddRemainingLessons(vera, -8); err != nil {
+		t.Fatal(err)
+	}
+
+	tb.press(demoCoachChatID, markCallbackData(markActionOpen, training.ID, 0))
+	tb.press(demoCoachChatID, markCallbackData(markActionToggle, training.ID, anna))
+	tb.press(demoCoachChatID, markCallbackData(markActionToggle, training.ID, vera))
+	if answer := tb.press(demoCoachChatID, markCallbackData(markActionSave, training.ID, 0)); answer != "Сохранено" {
+		t.Errorf("ответ на сохранение = %q", answer)
+	}
+
+	summary := tb.waitForMessage(demoCoachChatID, "💾")
+	for _, want := range []string{"Пришли: 1", "Не пришли: 1", "Не удалось отметить", "Вера Сидорова"} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("итог отметки %q не содержит %q", summary, want)
+		}
+	}
+
+	tests := []struct {
+		name          string
+		studentID     int
+		wantStatus    string
+		wantRemaining int
+	}{
+		{name: "выбрана", studentID: anna, wantStatus: models.AttendanceStatusAttended, wantRemaining: 7},
+		{name: "не выбран", studentID: boris, wantStatus: models.AttendanceStatusNoShow, wantRemaining: 8},
+		{name: "выбрана, но занятий нет", studentID: vera, wantStatus: models.AttendanceStatusRegistered, wantRemaining: 0},
+	}
+	for _, tt := range tests {
+		record, err := tb.repos.Attendance.GetStudentAttendanceForTraining(tt.studentID, training.ID)
+		if err != nil || record == nil {
+			t.Fatalf("%s: запись = %+v, %v", tt.name, record, err)
+		}
+		if record.Status != tt.wantStatus {
+			t.Errorf("%s: статус = %s, want %s", tt.name, record.Status, tt.wantStatus)
+		}
+		subscription, err := tb.repos.Subscriptions.GetByID(int64(tt.studentID))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if subscription.RemainingLessons != tt.wantRemaining {
+			t.Errorf("%s: остаток = %d, want %d", tt.name, subscription.RemainingLessons, tt.wantRemaining)
+		}
+	}
+
+	// Выбор очищен: повторное открытие начинается с отмеченных пришедшими
+	session := tb.bot.getOrCreateSession(demoCoachChatID)
+	if session.MarkingTrainingID != 0 || len(session.MarkedStudentIDs) != 0 {
+		t.Errorf("сессия после сохранения = %d, %v", session.MarkingTrainingID, session.MarkedStudentIDs)
+	}
+}
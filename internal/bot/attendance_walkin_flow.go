@@ -0,0 +1,181 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/notify"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// startWalkIn тренер добавляет на начавшуюся тренировку ученика, который пришёл без записи
+func (b *Bot) startWalkIn(chatID int64, user *models.User, trainingID int) string {
+	if _, err := b.markableTraining(user, trainingID); err != nil {
+		return "❌ " + err.Error()
+	}
+	candidates, err := b.walkInCandidates(trainingID)
+	if err != nil {
+		log.Printf("Ошибка получения учеников для тренировки %d: %v", trainingID, err)
+		return "❌ Ошибка при получении списка учеников"
+	}
+	if len(candidates) == 0 {
+		return "Все ученики уже записаны на эту тренировку"
+	}
+
+	session := b.getOrCreateSession(chatID)
+	session.State = StateSelectingWalkInStudent
+	session.SelectedTrainingID = trainingID
+	session.StudentsForSelection = candidates
+	b.persistSession(chatID)
+
+	b.showWalkInCandidates(chatID, "🚶 *Кто пришёл без записи?*", candidates)
+	return ""
+}
+
+// walkInCandidates ученики, которых ещё нет среди участников тренировки
+func (b *Bot) walkInCandidates(trainingID int) ([]*models.User, error) {
+	students, err := b.UserService.GetAllStudents()
+	if err != nil {
+		return nil, err
+	}
+	participants, err := b.AttendanceService.GetParticipants(trainingID)
+	if err != nil {
+		return nil, err
+	}
+
+	signedUp := make(map[int64]bool, len(participants))
+	for _, p := range participants {
+		signedUp[p.Student.UserID] = true
+	}
+	candidates := make([]*models.User, 0, len(students))
+	for _, student := range students {
+		if !signedUp[student.ID] {
+			candidates = append(candidates, student)
+		}
+	}
+	return candidates, nil
+}
+
+// showWalkInCandidates нумерованный список учеников; имена и username вводят сами ученики,
+// поэтому они экранируются для Markdown
+func (b *Bot) showWalkInCandidates(chatID int64, title string, students []*models.User) {
+	msgText := title + "\n\n"
+	for i, student := range students {
+		msgText += fmt.Sprintf("%d. %s\n", i+1, notify.EscapeMarkdown(getStudentDisplayName(student)))
+	}
+	msgText += "\nВведите номер ученика, часть имени для поиска или отправьте '❌ Отмена'"
+
+	msg := tgbotapi.NewMessage(chatID, msgText)
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = createCancelKeyboard()
+	b.send(msg)
+}
+
+// handleWalkInStudentSelection номер из списка отмечает ученика, любой другой текст ищет по имени
+func (b *Bot) handleWalkInStudentSelection(chatID int64, user *models.User, messageText string) {
+	session := b.getOrCreateSession(chatID)
+	if session.State != StateSelectingWalkInStudent {
+		return
+	}
+	if user == nil || user.Role != "coach" {
+		b.finishWalkIn(chatID)
+		return
+	}
+	trainingID := session.SelectedTrainingID
+
+	if messageText == "❌ Отмена" {
+		b.finishWalkIn(chatID)
+		msg := tgbotapi.NewMessage(chatID, "❌ Операция отменена")
+		msg.ReplyMarkup = createMainKeyboard(user.Role)
+		b.send(msg)
+		return
+	}
+
+	index, err := strconv.Atoi(strings.TrimSpace(messageText))
+	if err != nil {
+		b.searchWalkInCandidates(chatID, trainingID, messageText)
+		return
+	}
+	if index < 1 || index > len(session.StudentsForSelection) {
+		b.sendError(chatID, "❌ Пожалуйста, введите корректный номер ученика")
+		return
+	}
+
+	selected := session.StudentsForSelection[index-1]
+	student, err := b.StudentService.GetStudentByUserID(selected.ID)
+	if err != nil {
+		b.sendError(chatID, "❌ Ошибка получения данных студента")
+		return
+	}
+	name := getStudentDisplayName(selected)
+
+	// При ошибке остаёмся в выборе: можно выбрать другого ученика или отменить
+	if err := b.AttendanceService.AddWalkIn(trainingID, int(student.ID), int(user.ID), ""); err != nil {
+		log.Printf("Ошибка отметки ученика %d без записи на тренировке %d: %v", student.ID, trainingID, err)
+		b.sendError(chatID, fmt.Sprintf("❌ Не удалось отметить %s: %v", name, err))
+		return
+	}
+
+	b.finishWalkIn(chatID)
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("✅ %s отмечен без записи, занятие списано с абонемента", name))
+	msg.ReplyMarkup = createMainKeyboard(user.Role)
+	b.send(msg)
+
+	// Открытая отметка этой тренировки продолжается с новым участником уже среди пришедших
+	if session.MarkingTrainingID != trainingID {
+		return
+	}
+	training, err := b.ScheduleService.GetTrainingByID(trainingID)
+	if err != nil || training == nil {
+		return
+	}
+	participants, err := b.AttendanceService.GetParticipants(trainingID)
+	if err != nil {
+		log.Printf("Ошибка получения участников тренировки %d: %v", trainingID, err)
+		return
+	}
+	marked := b.attendanceMarkingSelection(chatID, trainingID, participants)
+	marked[int(student.ID)] = true
+	b.storeAttendanceMarking(chatID, trainingID, marked)
+	b.sendAttendanceMarking(chatID, training, participants, marked)
+}
+
+// searchWalkInCandidates ищет учеников по части имени или username
+func (b *Bot) searchWalkInCandidates(chatID int64, trainingID int, query string) {
+	candidates, err := b.walkInCandidates(trainingID)
+	if err != nil {
+		b.sendError(chatID, "❌ Ошибка при получении списка учеников")
+		return
+	}
+
+	query = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(query), "@"))
+	var found []*models.User
+	for _, student := range candidates {
+		name := strings.ToLower(student.FirstName + " " + student.LastName + " " + student.Username)
+		if strings.Contains(name, query) {
+			found = append(found, student)
+		}
+	}
+	if len(found) == 0 {
+		b.sendError(chatID, fmt.Sprintf("🔍 Никого не найдено по запросу «%s». Попробуйте ещё раз или отправьте '❌ Отмена'", query))
+		return
+	}
+
+	session := b.getOrCreateSession(chatID)
+	session.StudentsForSelection = found
+	b.showWalkInCandidates(chatID, "🔍 *Найденные ученики:*", found)
+}
+
+// finishWalkIn возвращает сессию к отметке посещаемости, если она была открыта
+func (b *Bot) finishWalkIn(chatID int64) {
+	session := b.getOrCreateSession(chatID)
+	session.State = StateDefault
+	if session.MarkingTrainingID != 0 {
+		session.State = StateMarkingAttendance
+	}
+	session.SelectedTrainingID = 0
+	session.StudentsForSelection = nil
+}
@@ -0,0 +1,33 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+
+	"spectrum-club-bot/internal/models"
+)
+
+func TestWalkInCandidatesEscapeMarkdown(t *testing.T) {
+	tb := newTestBot(t)
+
+	// Ученик без имени показывается по username, а "_" в нём — разметка Markdown
+	user := &models.User{TelegramID: -5, Username: "gena_k", Role: "student"}
+	if err := tb.repos.Users.CreateOrUpdate(user); err != nil {
+		t.Fatal(err)
+	}
+	if err := tb.repos.Students.Create(&models.Student{UserID: user.ID}); err != nil {
+		t.Fatal(err)
+	}
+	training := tb.startedTraining(1)
+
+	if answer := tb.press(demoCoachChatID, markCallbackData(markActionWalkIn, training.ID, 0)); answer != "" {
+		t.Fatalf("ответ на кнопку = %q", answer)
+	}
+	list := tb.waitForMessage(demoCoachChatID, "🚶")
+	if !strings.Contains(list, `@gena\_k`) {
+		t.Errorf("username в списке не экранирован: %q", list)
+	}
+	if strings.Contains(list, "Анна") {
+		t.Errorf("записавшаяся ученица в списке пришедших без записи: %q", list)
+	}
+}
@@ -3,8 +3,11 @@ package bot
 import (
 	"fmt"
 	"log"
+	"spectrum-club-bot/internal/checkin"
 	"spectrum-club-bot/internal/models/config"
+	"spectrum-club-bot/internal/notify"
 	"spectrum-club-bot/internal/service"
+	"spectrum-club-bot/internal/telegram"
 	"strings"
 	"sync"
 
@@ -17,15 +20,27 @@ type Bot struct {
 	CoachService        service.CoachService
 	StudentService      service.StudentService
 	SubscriptionService service.SubscriptionService
+	PaymentService      service.PaymentService
+	RenewalService      service.RenewalService
 	//
 	AttendanceService    service.AttendanceService
 	ScheduleService      service.TrainingScheduleService
 	TrainingGroupService service.TrainingGroupService
 	////
-	userSessions map[int64]*UserSession // chatID -> session
+	userSessions map[int64]*UserSession // chatID -> session (кэш текущего обновления)
 	mu           sync.RWMutex
+	sessions     SessionStore // постоянное хранилище сессий
+	notifier     notify.Notifier
 
 	webBaseURL string // Добавляем базовый URL для веб-сервера
+
+	updateMode    string // config.UpdateModePolling или config.UpdateModeWebhook
+	webhookURL    string // публичный адрес (BASE_URL) для регистрации вебхука
+	webhookSecret string
+
+	adminIDs []int64 // Telegram ID администраторов (ADMIN_IDS)
+
+	checkin *checkin.Signer // подпись ссылок самоотметки по QR-коду
 }
 
 func NewBot(
@@ -33,9 +48,13 @@ func NewBot(
 	coachService service.CoachService,
 	studentService service.StudentService,
 	subscriptionService service.SubscriptionService,
+	paymentService service.PaymentService,
+	renewalService service.RenewalService,
 	attendanceService service.AttendanceService,
 	scheduleService service.TrainingScheduleService,
 	trainingGroupService service.TrainingGroupService,
+	sessionStore SessionStore,
+	notifier notify.Notifier,
 ) (*Bot, error) {
 	cfg := config.AppConfig.Bot
 
@@ -43,7 +62,7 @@ func NewBot(
 		log.Panic("BOT_TOKEN не установлен в конфигурации")
 	}
 
-	api, err := tgbotapi.NewBotAPI(cfg.Token)
+	api, err := telegram.NewBotAPI(cfg.Token, cfg.APIURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create bot API: %w", err)
 	}
@@ -56,14 +75,14 @@ func NewBot(
 			return nil, fmt.Errorf("Пустая ссылка для webview")
 		}
 		webBaseURL = cfg.BaseURL // Укажите ваш домен
-		
+
 		// ВАЖНО: Для Telegram WebApp нужен HTTPS URL!
 		// Если URL не начинается с http:// или https://, добавляем https://
 		if !strings.HasPrefix(webBaseURL, "http://") && !strings.HasPrefix(webBaseURL, "https://") {
 			webBaseURL = "https://" + webBaseURL
 			log.Printf("⚠️  URL не содержал протокол, добавлен https://")
 		}
-		
+
 		// Проверяем, что используется HTTPS (Telegram WebApp требует HTTPS для передачи initData)
 		if strings.HasPrefix(webBaseURL, "http://") && !strings.Contains(webBaseURL, "localhost") {
 			log.Printf("⚠️  ВНИМАНИЕ: Используется HTTP вместо HTTPS! Telegram WebApp может не передавать initData для HTTP URL.")
@@ -79,16 +98,38 @@ func NewBot(
 		CoachService:         coachService,
 		StudentService:       studentService,
 		userSessions:         make(map[int64]*UserSession),
+		sessions:             sessionStore,
+		notifier:             notifier,
 		SubscriptionService:  subscriptionService,
+		PaymentService:       paymentService,
+		RenewalService:       renewalService,
 		AttendanceService:    attendanceService,
 		ScheduleService:      scheduleService,
 		TrainingGroupService: trainingGroupService,
 		webBaseURL:           webBaseURL,
+		updateMode:           cfg.UpdateMode,
+		webhookURL:           cfg.BaseURL,
+		webhookSecret:        cfg.WebhookSecret,
+		adminIDs:             cfg.AdminIDs,
+		checkin:              checkin.NewSigner(config.AppConfig.Checkin.Secret, config.AppConfig.Checkin.TTL),
 	}, nil
 }
+
+// Start начинает получать обновления. В режиме вебхука регистрирует его в Telegram
+// и возвращается сразу: обновления приходят в WebhookHandler.
 func (b *Bot) Start() error {
 	log.Printf("Авторизован как %s", b.api.Self.UserName)
 
+	if b.updateMode == config.UpdateModeWebhook {
+		return b.registerWebhook()
+	}
+
+	// Пока вебхук зарегистрирован (бот раньше запускался в режиме вебхука),
+	// getUpdates отвечает 409 Conflict, поэтому перед polling снимаем его
+	if _, err := b.api.RemoveWebhook(); err != nil {
+		return fmt.Errorf("ошибка снятия вебхука: %w", err)
+	}
+
 	u := tgbotapi.NewUpdate(0)
 	u.Timeout = 60
 	updates, err := b.api.GetUpdatesChan(u)
@@ -97,12 +138,20 @@ func (b *Bot) Start() error {
 	}
 
 	for update := range updates {
-		if update.Message == nil {
-			continue
-		}
-
-		go b.handleMessage(update.Message)
+		go b.handleUpdate(update)
 	}
 
 	return nil
 }
+
+// handleUpdate общая точка входа для обновлений из polling и вебхука
+func (b *Bot) handleUpdate(update tgbotapi.Update) {
+	switch {
+	case update.Message != nil:
+		b.handleMessage(update.Message)
+	case update.CallbackQuery != nil:
+		b.handleCallback(update.CallbackQuery)
+	case update.PreCheckoutQuery != nil:
+		b.handlePreCheckout(update.PreCheckoutQuery)
+	}
+}
@@ -46,17 +46,67 @@ const (
 	StateSelectingTrainingDateToSignUp
 	StateSelectingTrainingToSignUp
 	StateConfirmingTrainingSignUp
+
+	// Состояния для управления тарифами абонементов
+	StateSelectingPlanToManage
+	StateManagingPlan
+	StateEditingPlanPrice
+	StateEnteringPlanName
+	StateEnteringPlanLessons
+	StateEnteringPlanDuration
+	StateEnteringPlanPrice
+	StateConfirmingPlan
+
+	// Состояния для заморозки абонемента
+	StateSelectingStudentForFreeze
+	StateSelectingSubscriptionForFreeze
+	StateManagingFreeze
+	StateEnteringFreezePeriod
+	StateEnteringFreezeReason
+	StateConfirmingFreeze
+
+	// Состояния для ручной корректировки занятий
+	StateSelectingStudentForAdjustment
+	StateSelectingSubscriptionForAdjustment
+	StateEnteringAdjustmentDelta
+	StateEnteringAdjustmentReason
+
+	// Состояния для продления абонемента
+	StateSelectingStudentForExtension
+	StateSelectingSubscriptionForExtension
+	StateEnteringExtensionDays
+	StateEnteringExtensionLessons
+	StateConfirmingExtension
+
+	// Причина отмены тренировки (перед подтверждением StateConfirmingDeletion)
+	StateEnteringTrainingCancelReason
+
+	// Состояния для семейного абонемента
+	StateSelectingStudentForFamily
+	StateSelectingSubscriptionForFamily
+	StateManagingFamily
+	StateSelectingFamilyMemberToAdd
+	StateSelectingFamilyMemberToRemove
+
+	// Ограничения тарифа: группы, дни и время тренировок
+	StateEditingPlanRestrictions
+
+	// Отметка посещаемости inline-кнопками: состояние только сохраняет выбор
+	// между нажатиями, текстовые сообщения обрабатываются как обычно
+	StateMarkingAttendance
+	// Выбор ученика, пришедшего без записи: номер из списка или часть имени для поиска
+	StateSelectingWalkInStudent
 )
 
 type UserSession struct {
-	State                    BotState
-	SelectedStudentID        int64
-	SelectedSubscriptionType string
-	SelectedGroupID          int
-	SelectedDate             time.Time
-	SelectedStartTime        time.Time
-	SelectedDuration         time.Duration
-	TrainingDescription      string
+	State               BotState
+	SelectedStudentID   int64
+	SelectedPlanID      int64
+	SelectedGroupID     int
+	SelectedDate        time.Time
+	SelectedStartTime   time.Time
+	SelectedDuration    time.Duration
+	TrainingDescription string
 	// Новые поля для удаления абонемента
 	SelectedStudentForDeletion *models.User
 	SelectedSubscriptionID     int64
@@ -79,4 +129,30 @@ type UserSession struct {
 	SelectedStudentForSignUpID  int
 
 	StudentsForSelection []*models.User
+
+	// Поля для тарифов абонементов
+	AvailablePlans []models.SubscriptionPlan
+	PlanDraft      *models.SubscriptionPlan // новый тариф, пока тренер вводит его параметры
+
+	// Поля для заморозки абонемента
+	FreezeStartDate time.Time
+	FreezeEndDate   time.Time
+	FreezeReason    string
+
+	// Поле для корректировки занятий
+	AdjustmentDelta int
+
+	// Поля для продления абонемента
+	ExtensionDays    int
+	ExtensionLessons int
+
+	// Поле для отмены тренировки
+	TrainingCancelReason string
+
+	// Поле для семейного абонемента: владелец (первым) и подключённые ученики
+	FamilyMembers []models.SubscriptionMember
+
+	// Поля для отметки посещаемости: тренировка и выбранные пришедшие ученики
+	MarkingTrainingID int
+	MarkedStudentIDs  []int
 }
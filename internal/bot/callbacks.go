@@ -0,0 +1,193 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/payments"
+	"spectrum-club-bot/internal/reminder"
+	"spectrum-club-bot/internal/renewal"
+	"spectrum-club-bot/internal/service"
+	"spectrum-club-bot/internal/waitlist"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// handleCallback обработка нажатий inline-кнопок
+func (b *Bot) handleCallback(query *tgbotapi.CallbackQuery) {
+	log.Printf("[%s] callback: %s", query.From.UserName, query.Data)
+
+	if trainingID, confirmed, ok := reminder.ParseCancelCallback(query.Data); ok {
+		b.answerCallback(query.ID, b.cancelSignUpFromReminder(query, trainingID, confirmed))
+		return
+	}
+	if action, trainingID, ok := waitlist.ParseCallback(query.Data); ok {
+		b.answerCallback(query.ID, b.handleWaitlistCallback(query, action, trainingID))
+		return
+	}
+	if trainingID, studentID, ok := parseNoShowCallback(query.Data); ok {
+		b.answerCallback(query.ID, b.handleNoShowCallback(query, trainingID, studentID))
+		return
+	}
+	if action, trainingID, studentID, ok := parseMarkCallback(query.Data); ok {
+		b.answerCallback(query.ID, b.handleMarkCallback(query, action, trainingID, studentID))
+		return
+	}
+	if action, id, ok := renewal.ParseCallback(query.Data); ok {
+		b.answerCallback(query.ID, b.handleRenewalCallback(query, action, id))
+		return
+	}
+	if planID, ok := payments.ParseBuyCallback(query.Data); ok {
+		b.answerCallback(query.ID, b.handleBuyCallback(query, planID))
+		return
+	}
+	if payment, ok := payments.ParseStubCallback(query.Data); ok {
+		b.answerCallback(query.ID, b.handleStubPaymentCallback(query, payment))
+		return
+	}
+
+	b.answerCallback(query.ID, "")
+}
+
+// cancelSignUpFromReminder отмена записи кнопкой из напоминания; возвращает текст подсказки.
+// Сначала ученик видит срок бесплатной отмены и подтверждает её второй кнопкой
+func (b *Bot) cancelSignUpFromReminder(query *tgbotapi.CallbackQuery, trainingID int, confirmed bool) string {
+	if query.Message == nil || query.Message.Chat == nil {
+		return "❌ Не удалось отменить запись"
+	}
+	chatID := query.Message.Chat.ID
+
+	user, err := b.UserService.GetByTelegramID(int64(query.From.ID))
+	if err != nil {
+		return "❌ Сначала зарегистрируйтесь в боте"
+	}
+	student, err := b.StudentService.GetStudentByUserID(user.ID)
+	if err != nil {
+		return "❌ Ошибка получения данных студента"
+	}
+
+	attendance, err := b.AttendanceService.GetStudentAttendanceForTraining(int(student.ID), trainingID)
+	if err != nil {
+		log.Printf("Ошибка получения записи на тренировку %d: %v", trainingID, err)
+		return "❌ Не удалось отменить запись"
+	}
+	if attendance == nil || attendance.Status != models.AttendanceStatusRegistered {
+		return "Вы уже не записаны на эту тренировку"
+	}
+
+	training, err := b.ScheduleService.GetTrainingByID(trainingID)
+	if err != nil || training == nil {
+		return "❌ Тренировка не найдена"
+	}
+	start := trainingStartTime(training)
+	if !start.After(time.Now()) {
+		return "❌ Тренировка уже началась, отменить запись нельзя"
+	}
+
+	policy := b.AttendanceService.CancellationPolicy()
+	late := policy.IsLate(start, time.Now())
+	if late && policy.Late == models.LateCancelBlock {
+		b.sendMessage(chatID, "⏰ "+policy.Terms(start, time.Now()))
+		return "❌ Срок отмены истёк"
+	}
+	if !confirmed {
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("Отменить запись на тренировку %s в %s?\n\n⏰ %s",
+			training.TrainingDate.Format("02.01.2006"), training.StartTime.Format("15:04"), policy.Terms(start, time.Now())))
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("✅ Да, отменить запись", reminder.ConfirmCancelCallbackData(trainingID)),
+			),
+		)
+		b.send(msg)
+		return ""
+	}
+
+	if err := b.AttendanceService.CancelSignUp(int(student.ID), trainingID); err != nil {
+		log.Printf("Ошибка отмены записи на тренировку %d: %v", trainingID, err)
+		if errors.Is(err, service.ErrLateCancellation) {
+			return "❌ Срок отмены истёк"
+		}
+		return "❌ Не удалось отменить запись"
+	}
+
+	text := fmt.Sprintf("✅ Запись на тренировку %s в %s отменена",
+		training.TrainingDate.Format("02.01.2006"), training.StartTime.Format("15:04"))
+	if late {
+		text += "\n\n⏰ Отмена поздняя — по правилам клуба занятие списывается с абонемента"
+	}
+	b.sendMessage(chatID, text)
+	return "Запись отменена"
+}
+
+// handleWaitlistCallback кнопки листа ожидания: встать в очередь, принять или отклонить место
+func (b *Bot) handleWaitlistCallback(query *tgbotapi.CallbackQuery, action string, trainingID int) string {
+	if query.Message == nil || query.Message.Chat == nil {
+		return "❌ Не удалось выполнить действие"
+	}
+	chatID := query.Message.Chat.ID
+
+	user, err := b.UserService.GetByTelegramID(int64(query.From.ID))
+	if err != nil {
+		return "❌ Сначала зарегистрируйтесь в боте"
+	}
+	if user.Role != "student" {
+		return "❌ Лист ожидания доступен только ученикам"
+	}
+	student, err := b.StudentService.GetStudentByUserID(user.ID)
+	if err != nil {
+		return "❌ Ошибка получения данных студента"
+	}
+	studentID := int(student.ID)
+
+	training, err := b.ScheduleService.GetTrainingByID(trainingID)
+	if err != nil || training == nil {
+		return "❌ Тренировка не найдена"
+	}
+	when := fmt.Sprintf("%s в %s", training.TrainingDate.Format("02.01.2006"), training.StartTime.Format("15:04"))
+
+	switch action {
+	case waitlist.ActionJoin:
+		entry, err := b.AttendanceService.JoinWaitlist(studentID, trainingID)
+		if err != nil {
+			log.Printf("Ошибка добавления в лист ожидания тренировки %d: %v", trainingID, err)
+			return "❌ " + err.Error()
+		}
+		if entry.Status == models.WaitlistStatusOffered {
+			return "🎟 Место уже предложено вам — подтвердите запись"
+		}
+		b.sendMessage(chatID, fmt.Sprintf("⏳ Вы в листе ожидания на тренировку %s. Место в очереди: %d.\n"+
+			"Когда место освободится, бот пришлёт сообщение.", when, entry.Position))
+		return fmt.Sprintf("Место в очереди: %d", entry.Position)
+
+	case waitlist.ActionAccept:
+		err := b.AttendanceService.SignUpForTraining(studentID, trainingID)
+		if errors.Is(err, service.ErrTrainingFull) {
+			return "😔 Время на подтверждение истекло, место уже занято"
+		}
+		if err != nil {
+			log.Printf("Ошибка записи из листа ожидания на тренировку %d: %v", trainingID, err)
+			return "❌ " + err.Error()
+		}
+		b.sendMessage(chatID, fmt.Sprintf("✅ Вы записаны на тренировку %s", when))
+		return "Вы записаны"
+
+	case waitlist.ActionDecline:
+		if err := b.AttendanceService.LeaveWaitlist(studentID, trainingID); err != nil {
+			return "Вы уже не в листе ожидания"
+		}
+		b.sendMessage(chatID, fmt.Sprintf("👌 Вы вышли из листа ожидания на тренировку %s", when))
+		return "Готово"
+	}
+
+	return ""
+}
+
+// answerCallback убирает "часики" с кнопки; это служебный ответ, а не сообщение
+// пользователю, поэтому он идёт напрямую, минуя очередь уведомлений
+func (b *Bot) answerCallback(queryID, text string) {
+	if _, err := b.api.AnswerCallbackQuery(tgbotapi.NewCallback(queryID, text)); err != nil {
+		log.Printf("⚠️ Ошибка ответа на callback: %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"spectrum-club-bot/internal/checkin"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/notify"
+	"time"
+)
+
+// sendCheckinQR присылает тренеру QR-код со ссылкой самоотметки на сегодняшнюю тренировку
+func (b *Bot) sendCheckinQR(chatID int64, user *models.User, trainingID int) string {
+	training, err := b.coachTraining(user, trainingID)
+	if err != nil {
+		return "❌ " + err.Error()
+	}
+	now := time.Now()
+	start := trainingStartTime(training)
+	if start.Year() != now.Year() || start.YearDay() != now.YearDay() {
+		return "❌ QR-код можно показать только в день тренировки"
+	}
+
+	token, expiresAt := b.checkin.Issue(trainingID, now)
+	png, err := checkin.QRCode(checkin.DeepLink(b.api.Self.UserName, token))
+	if err != nil {
+		log.Printf("Ошибка генерации QR-кода для тренировки %d: %v", trainingID, err)
+		return "❌ Не удалось создать QR-код"
+	}
+
+	err = b.notifier.Send(notify.Message{
+		ChatID: chatID,
+		Text: fmt.Sprintf("📱 Самоотметка на тренировку %s в %s\n\nУченики сканируют код камерой телефона и отмечаются сами. Код действует до %s.",
+			training.TrainingDate.Format("02.01.2006"), training.StartTime.Format("15:04"), expiresAt.Format("15:04")),
+		Photo: png,
+	})
+	if err != nil {
+		log.Printf("❌ Не удалось отправить QR-код в чат %d: %v", chatID, err)
+		return "❌ Не удалось отправить QR-код"
+	}
+	return ""
+}
+
+// handleCheckin ученик открыл ссылку из QR-кода: проверяем подпись и отмечаем его пришедшим.
+// Подтверждение с остатком занятий приходит уведомлением от сервиса посещаемости
+func (b *Bot) handleCheckin(chatID int64, user *models.User, token string) {
+	if user == nil {
+		b.sendMessage(chatID, "❌ Сначала зарегистрируйтесь в боте: /student")
+		return
+	}
+	trainingID, err := b.checkin.Verify(token, time.Now())
+	if err != nil {
+		b.sendError(chatID, "❌ "+err.Error())
+		return
+	}
+	if user.Role != "student" {
+		b.sendError(chatID, "❌ Отметиться по QR-коду может только ученик")
+		return
+	}
+	student, err := b.StudentService.GetStudentByUserID(user.ID)
+	if err != nil {
+		b.sendError(chatID, "❌ Ошибка получения данных студента")
+		return
+	}
+
+	attendance, err := b.AttendanceService.GetStudentAttendanceForTraining(int(student.ID), trainingID)
+	if err != nil {
+		log.Printf("Ошибка получения записи ученика %d на тренировку %d: %v", student.ID, trainingID, err)
+		b.sendError(chatID, "❌ Не удалось отметиться, попробуйте ещё раз")
+		return
+	}
+	switch {
+	case attendance == nil || !attendance.HoldsSpot():
+		b.sendMessage(chatID, "❌ Вы не записаны на эту тренировку. Подойдите к тренеру — он отметит вас без записи")
+		return
+	case attendance.Status == models.AttendanceStatusAttended:
+		b.sendMessage(chatID, "✅ Вы уже отмечены на этой тренировке")
+		return
+	}
+
+	err = b.AttendanceService.MarkAttendance(trainingID, int(student.ID), int(user.ID), true, models.AttendanceSelfCheckInNote)
+	if err != nil {
+		log.Printf("Ошибка самоотметки ученика %d на тренировке %d: %v", student.ID, trainingID, err)
+		b.sendError(chatID, "❌ Не удалось отметиться: "+err.Error())
+		return
+	}
+	log.Printf("📱 Ученик %d отметился на тренировке %d по QR-коду", student.ID, trainingID)
+}
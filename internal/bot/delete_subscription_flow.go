@@ -3,6 +3,7 @@ package bot
 import (
 	"fmt"
 	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/notify"
 	"strconv"
 	"time"
 
@@ -51,7 +52,7 @@ func (b *Bot) showStudentsForDeletion(chatID int64, students []*models.User) {
 	msg.ParseMode = "Markdown"
 	msg.ReplyMarkup = createCancelKeyboard()
 
-	b.api.Send(msg)
+	b.send(msg)
 }
 
 func (b *Bot) handleStudentSelectionForDeletion(chatID int64, messageText string) {
@@ -91,20 +92,21 @@ func (b *Bot) handleStudentSelectionForDeletion(chatID int64, messageText string
 		return
 	}
 
-	if len(subscriptions) == 0 {
+	owned, shared := splitOwned(subscriptions, student.ID)
+	if len(owned) == 0 {
 		msg := tgbotapi.NewMessage(chatID,
-			fmt.Sprintf("❌ У ученика %s %s нет абонементов",
-				selectedStudent.FirstName, selectedStudent.LastName))
-		b.api.Send(msg)
+			fmt.Sprintf("❌ На ученика %s %s не оформлено абонементов\n%s",
+				selectedStudent.FirstName, selectedStudent.LastName, b.formatSharedSubscriptions(shared)))
+		b.send(msg)
 		b.resetSession(chatID)
 		return
 	}
 
-	session.AvailableSubscriptions = subscriptions
-	b.showSubscriptionsListForDeletion(chatID, selectedStudent, subscriptions)
+	session.AvailableSubscriptions = owned
+	b.showSubscriptionsListForDeletion(chatID, selectedStudent, owned, shared)
 }
 
-func (b *Bot) showSubscriptionsListForDeletion(chatID int64, student *models.User, subscriptions []*models.Subscription) {
+func (b *Bot) showSubscriptionsListForDeletion(chatID int64, student *models.User, subscriptions, shared []*models.Subscription) {
 	msgText := fmt.Sprintf("🎫 *Выберите абонемент для удаления у %s %s:*\n\n", student.FirstName, student.LastName)
 
 	for i, subscription := range subscriptions {
@@ -125,12 +127,14 @@ func (b *Bot) showSubscriptionsListForDeletion(chatID int64, student *models.Use
 			subscription.EndDate.Format("02.01.2006"))
 	}
 
+	msgText += notify.EscapeMarkdown(b.formatSharedSubscriptions(shared))
+
 	msgText += "\nВведите номер абонемента или отправьте '❌ Отмена'"
 
 	msg := tgbotapi.NewMessage(chatID, msgText)
 	msg.ParseMode = "Markdown"
 	msg.ReplyMarkup = createCancelKeyboard()
-	b.api.Send(msg)
+	b.send(msg)
 }
 
 func (b *Bot) handleSubscriptionSelectionForDeletion(chatID int64, messageText string) {
@@ -194,7 +198,7 @@ func (b *Bot) showDeletionConfirmation(chatID int64, student *models.User, subsc
 		),
 	)
 	msg.ReplyMarkup = keyboard
-	b.api.Send(msg)
+	b.send(msg)
 }
 
 func (b *Bot) handleSubscriptionDeletionConfirmation(chatID int64, messageText string) {
@@ -0,0 +1,53 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDeleteSubscriptionSkipsSharedPasses(t *testing.T) {
+	tb := newTestBot(t)
+
+	// Борис подключён к семейному абонементу Анны и имеет собственный
+	const annaSubscriptionID, borisStudentID = 1, 2
+	if err := tb.repos.Subscriptions.AddMember(annaSubscriptionID, borisStudentID, nil); err != nil {
+		t.Fatal(err)
+	}
+	own, err := tb.repos.Subscriptions.GetByStudentID(borisStudentID)
+	if err != nil || len(own) != 2 {
+		t.Fatalf("абонементы Бориса = %d, %v, want 2", len(own), err)
+	}
+
+	replies := tb.say(demoCoachChatID, "🗑️ Удалить абонемент", 1)
+	if !strings.Contains(replies[0].Text, "2. Борис Петров") {
+		t.Fatalf("список учеников: %q", replies[0].Text)
+	}
+
+	replies = tb.say(demoCoachChatID, "2", 1)
+	list := replies[0].Text
+	if !strings.Contains(list, "1. ✅ 8/8 занятий") || strings.Contains(list, "2. ") {
+		t.Errorf("к удалению предложены не только собственные абонементы: %q", list)
+	}
+	if !strings.Contains(list, "🔒 Семейные абонементы") || !strings.Contains(list, "оформлен на Анна Иванова") {
+		t.Errorf("семейный абонемент не показан только для просмотра: %q", list)
+	}
+
+	replies = tb.say(demoCoachChatID, "2", 1)
+	if !strings.Contains(replies[0].Text, "корректный номер абонемента") {
+		t.Fatalf("выбор семейного абонемента: %q", replies[0].Text)
+	}
+
+	tb.say(demoCoachChatID, "1", 1)
+	replies = tb.say(demoCoachChatID, "✅ Удалить абонемент", 1)
+	if !strings.Contains(replies[0].Text, "Абонемент успешно удален у ученика Борис Петров") {
+		t.Fatalf("удаление: %q", replies[0].Text)
+	}
+
+	left, err := tb.repos.Subscriptions.GetByStudentID(borisStudentID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(left) != 1 || left[0].ID != annaSubscriptionID {
+		t.Errorf("после удаления у Бориса абонементы %+v, want только семейный %d", left, annaSubscriptionID)
+	}
+}
@@ -3,7 +3,9 @@ package bot
 import (
 	"fmt"
 	"log"
+	"spectrum-club-bot/internal/checkin"
 	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/notify"
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
@@ -20,6 +22,25 @@ func (b *Bot) handleMessage(message *tgbotapi.Message) {
 
 	chatID := message.Chat.ID
 
+	// Оплата счёта приходит отдельным сервисным сообщением и не зависит от состояния сессии
+	if message.SuccessfulPayment != nil {
+		b.handleSuccessfulPayment(chatID, message.SuccessfulPayment)
+		return
+	}
+
+	// Самоотметка по QR-коду: ссылка открывает бота командой /start checkin_<токен>,
+	// и отметка не должна зависеть от незаконченного сценария в сессии
+	if message.IsCommand() && message.Command() == "start" {
+		if token, ok := checkin.ParseStartPayload(message.CommandArguments()); ok {
+			b.handleCheckin(chatID, user, token)
+			return
+		}
+	}
+
+	// Сессия читается из хранилища заново и сохраняется после обработки
+	b.dropCachedSession(chatID)
+	defer b.persistSession(chatID)
+
 	// Проверяем состояние пользователя ПРЕЖДЕ обработки команд
 	session := b.getOrCreateSession(chatID)
 
@@ -96,6 +117,9 @@ func (b *Bot) handleMessage(message *tgbotapi.Message) {
 			b.handleSchedulePeriodInput(chatID, message.Text)
 			return
 
+		case StateEnteringTrainingCancelReason:
+			b.handleTrainingCancelReason(chatID, message.Text)
+			return
 		case StateConfirmingDeletion:
 			b.handleDeletionConfirmation(chatID, message.Text)
 			return
@@ -109,6 +133,68 @@ func (b *Bot) handleMessage(message *tgbotapi.Message) {
 		case StateConfirmingTrainingSignUp:
 			b.handleTrainingSignUpConfirmation(chatID, message.Text)
 			return
+			// Состояния для тарифов абонементов
+		case StateSelectingPlanToManage:
+			b.handlePlanSelection(chatID, message.Text)
+			return
+		case StateManagingPlan:
+			b.handlePlanAction(chatID, message.Text)
+			return
+		case StateEditingPlanPrice:
+			b.handlePlanPriceEdit(chatID, message.Text)
+			return
+		case StateEditingPlanRestrictions:
+			b.handlePlanRestrictionsEdit(chatID, message.Text)
+			return
+		case StateEnteringPlanName, StateEnteringPlanLessons, StateEnteringPlanDuration,
+			StateEnteringPlanPrice, StateConfirmingPlan:
+			b.handlePlanDraftInput(chatID, message.Text)
+			return
+		case StateSelectingStudentForFreeze:
+			b.handleStudentSelectionForFreeze(chatID, message.Text)
+			return
+		case StateSelectingSubscriptionForFreeze:
+			b.handleSubscriptionSelectionForFreeze(chatID, message.Text)
+			return
+		case StateManagingFreeze:
+			b.handleFreezeAction(chatID, message.Text)
+			return
+		case StateEnteringFreezePeriod, StateEnteringFreezeReason, StateConfirmingFreeze:
+			b.handleFreezeInput(chatID, message.Text)
+			return
+		case StateSelectingStudentForAdjustment:
+			b.handleStudentSelectionForAdjustment(chatID, message.Text)
+			return
+		case StateSelectingSubscriptionForAdjustment:
+			b.handleSubscriptionSelectionForAdjustment(chatID, message.Text)
+			return
+		case StateEnteringAdjustmentDelta, StateEnteringAdjustmentReason:
+			b.handleAdjustmentInput(chatID, message.Text)
+			return
+		case StateSelectingStudentForExtension:
+			b.handleStudentSelectionForExtension(chatID, message.Text)
+			return
+		case StateSelectingSubscriptionForExtension:
+			b.handleSubscriptionSelectionForExtension(chatID, message.Text)
+			return
+		case StateEnteringExtensionDays, StateEnteringExtensionLessons, StateConfirmingExtension:
+			b.handleExtensionInput(chatID, message.Text)
+			return
+		case StateSelectingStudentForFamily:
+			b.handleStudentSelectionForFamily(chatID, message.Text)
+			return
+		case StateSelectingSubscriptionForFamily:
+			b.handleSubscriptionSelectionForFamily(chatID, message.Text)
+			return
+		case StateManagingFamily:
+			b.handleFamilyAction(chatID, message.Text)
+			return
+		case StateSelectingFamilyMemberToAdd, StateSelectingFamilyMemberToRemove:
+			b.handleFamilyMemberSelection(chatID, message.Text)
+			return
+		case StateSelectingWalkInStudent:
+			b.handleWalkInStudentSelection(chatID, user, message.Text)
+			return
 		}
 	}
 
@@ -138,6 +224,8 @@ func (b *Bot) handleMessage(message *tgbotapi.Message) {
 			b.handleStartCommand(message.Chat.ID, user)
 		case "schedule":
 			b.handleCalendarCommand(message)
+		case "plans":
+			b.handlePlansCommand(chatID, user)
 		case "coach":
 			//регистрируем как тренера
 			if user == nil {
@@ -173,6 +261,8 @@ func (b *Bot) handleMessage(message *tgbotapi.Message) {
 		b.showAllStudens(message.Chat.ID, user)
 	case "📅 Календарь":
 		b.handleCalendarCommand(message)
+	case "✅ Отметить посещаемость":
+		b.handleMarkAttendance(message.Chat.ID, user)
 	case "📅 Управление расписанием":
 		b.showScheduleManagementMenu(message.Chat.ID, user)
 	case "💳 Управление абонементами":
@@ -200,6 +290,18 @@ func (b *Bot) handleMessage(message *tgbotapi.Message) {
 		b.handleDeleteSubscription(message.Chat.ID, user)
 	case "👥 Список учеников с абонементами":
 		b.showAllStudens(message.Chat.ID, user)
+	case "📋 Тарифы абонементов":
+		b.handlePlansCommand(message.Chat.ID, user)
+	case "❄️ Заморозка абонемента":
+		b.handleFreezeSubscription(message.Chat.ID, user)
+	case "✏️ Корректировка занятий":
+		b.handleAdjustLessons(message.Chat.ID, user)
+	case "🔄 Продлить абонемент":
+		b.handleExtendSubscription(message.Chat.ID, user)
+	case "👨‍👩‍👧 Семейный абонемент":
+		b.handleFamilySubscription(message.Chat.ID, user)
+	case "📨 Запросы на продление":
+		b.handleRenewalRequests(message.Chat.ID, user)
 
 		// Для студентов
 	case "📝 Записаться на тренировку":
@@ -230,7 +332,7 @@ func (b *Bot) showScheduleManagementMenu(chatID int64, user *models.User) {
 	msg := tgbotapi.NewMessage(chatID, "📅 *Управление расписанием*\n\nВыберите действие:")
 	msg.ParseMode = "Markdown"
 	msg.ReplyMarkup = createScheduleManagementKeyboard()
-	b.api.Send(msg)
+	b.send(msg)
 }
 
 func (b *Bot) showSubscriptionManagementMenu(chatID int64, user *models.User) {
@@ -239,10 +341,16 @@ func (b *Bot) showSubscriptionManagementMenu(chatID int64, user *models.User) {
 		return
 	}
 
-	msg := tgbotapi.NewMessage(chatID, "💳 *Управление абонементами учеников*\n\nВыберите действие:")
+	text := "💳 *Управление абонементами учеников*\n\n"
+	if requests, err := b.RenewalService.GetPendingRequests(); err == nil && len(requests) > 0 {
+		text += fmt.Sprintf("📨 Запросов на продление: %d\n\n", len(requests))
+	}
+	text += "Выберите действие:"
+
+	msg := tgbotapi.NewMessage(chatID, text)
 	msg.ParseMode = "Markdown"
 	msg.ReplyMarkup = createSubscriptionManagementKeyboard()
-	b.api.Send(msg)
+	b.send(msg)
 }
 
 // ///
@@ -263,7 +371,7 @@ func (b *Bot) handleStartCommand(chatID int64, user *models.User) {
 	text := "Введите правильную команду"
 	msg := tgbotapi.NewMessage(chatID, text)
 	msg.ReplyMarkup = createMainKeyboard(user.Role)
-	b.api.Send(msg)
+	b.send(msg)
 }
 
 func (b *Bot) handleCoachCommand(chatID int64, user *models.User) {
@@ -272,14 +380,14 @@ func (b *Bot) handleCoachCommand(chatID int64, user *models.User) {
 	if err != nil {
 		log.Printf("Ошибка регистрации тренера: %v", err)
 		msg := tgbotapi.NewMessage(chatID, "❌ Ошибка при регистрации тренера")
-		b.api.Send(msg)
+		b.send(msg)
 		return
 	}
 	user.Role = "coach"
 
 	msg := tgbotapi.NewMessage(chatID, "✅ Теперь вы зарегистрированы как тренер!")
 	msg.ReplyMarkup = createMainKeyboard(user.Role)
-	b.api.Send(msg)
+	b.send(msg)
 }
 
 func (b *Bot) sendWelcomeMessage(chatID int64, user *models.User) {
@@ -301,14 +409,14 @@ func (b *Bot) sendWelcomeMessage(chatID int64, user *models.User) {
 
 	msg := tgbotapi.NewMessage(chatID, text)
 	msg.ReplyMarkup = createMainKeyboard(user.Role)
-	b.api.Send(msg)
+	b.send(msg)
 }
 
 func (b *Bot) showPersonalAccount(chatID int64, user *models.User) {
 	userProfile, _, _, _, err := b.UserService.GetUserProfile(user.TelegramID)
 	if err != nil {
 		msg := tgbotapi.NewMessage(chatID, "❌ Ошибка при загрузке данных")
-		b.api.Send(msg)
+		b.send(msg)
 		return
 	}
 
@@ -317,7 +425,7 @@ func (b *Bot) showPersonalAccount(chatID int64, user *models.User) {
 		coach, err := b.CoachService.GetCoachByUserID(userProfile.ID)
 		if err != nil {
 			msg := tgbotapi.NewMessage(chatID, "❌ Ошибка при загрузке данных тренера")
-			b.api.Send(msg)
+			b.send(msg)
 			return
 		}
 
@@ -343,31 +451,54 @@ func (b *Bot) showPersonalAccount(chatID int64, user *models.User) {
 	msg := tgbotapi.NewMessage(chatID, text)
 	msg.ParseMode = "Markdown"
 	msg.ReplyMarkup = createPersonalAccountKeyboard(userProfile.Role)
-	b.api.Send(msg)
+	b.send(msg)
 }
 
 func (b *Bot) sendMessage(chatID int64, text string) {
 	msg := tgbotapi.NewMessage(chatID, text)
-	b.api.Send(msg)
+	b.send(msg)
+}
+
+// currentUserID users.id автора действия в чате; 0, если пользователь не найден
+func (b *Bot) currentUserID(chatID int64) int64 {
+	user, _, _, _, err := b.UserService.GetUserProfile(chatID)
+	if err != nil || user == nil {
+		return 0
+	}
+	return user.ID
+}
+
+// send отправляет сообщение через очередь уведомлений
+func (b *Bot) send(msg tgbotapi.MessageConfig) {
+	err := b.notifier.Send(notify.Message{
+		ChatID:                msg.ChatID,
+		Text:                  msg.Text,
+		ParseMode:             msg.ParseMode,
+		ReplyMarkup:           msg.ReplyMarkup,
+		DisableWebPagePreview: msg.DisableWebPagePreview,
+	})
+	if err != nil {
+		log.Printf("❌ Не удалось поставить сообщение в очередь для чата %d: %v", msg.ChatID, err)
+	}
 }
 
 func (b *Bot) showAllStudens(chatID int64, user *models.User) {
 	if user.Role != "coach" {
 		msg := tgbotapi.NewMessage(chatID, "❌ Не имеете права, как вообще сюда попали")
-		b.api.Send(msg)
+		b.send(msg)
 		return
 	}
 
 	students, err := b.UserService.GetAllStudents()
 	if err != nil {
 		msg := tgbotapi.NewMessage(chatID, "❌ Ошибка при выполнении запроса")
-		b.api.Send(msg)
+		b.send(msg)
 		return
 	}
 
 	if len(students) == 0 {
 		msg := tgbotapi.NewMessage(chatID, "📝 Список учеников пуст")
-		b.api.Send(msg)
+		b.send(msg)
 		return
 	}
 
@@ -375,7 +506,7 @@ func (b *Bot) showAllStudens(chatID int64, user *models.User) {
 	allSubscriptions, err := b.SubscriptionService.GetAll()
 	if err != nil {
 		msg := tgbotapi.NewMessage(chatID, "❌ Ошибка при получении абонементов")
-		b.api.Send(msg)
+		b.send(msg)
 		return
 	}
 
@@ -457,5 +588,5 @@ func (b *Bot) showAllStudens(chatID int64, user *models.User) {
 
 	msg := tgbotapi.NewMessage(chatID, message)
 	msg.ParseMode = "Markdown"
-	b.api.Send(msg)
+	b.send(msg)
 }
@@ -1,11 +1,8 @@
 package bot
 
 import (
-	"bytes"
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
+	"strings"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
 )
@@ -25,7 +22,7 @@ type InlineKeyboardButtonWebApp struct {
 }
 
 // InlineKeyboardMarkupWebApp представляет клавиатуру с WebApp кнопками
-// Используется для отправки сообщений с WebApp кнопками через прямой API вызов
+// Передается как ReplyMarkup сообщения вместо клавиатур tgbotapi
 type InlineKeyboardMarkupWebApp struct {
 	InlineKeyboard [][]InlineKeyboardButtonWebApp `json:"inline_keyboard"`
 }
@@ -45,33 +42,9 @@ func (b *Bot) handleCalendarCommand(message *tgbotapi.Message) {
 
 	// Создаем WebApp кнопку для передачи initData
 	// ВАЖНО: Для работы WebApp нужен HTTPS URL (не localhost)!
-	// Telegram передает initData только для WebApp кнопок, не для обычных URL кнопок
-	// Библиотека go-telegram-bot-api/v5 не поддерживает web_app поле напрямую,
-	// поэтому используем прямой HTTP вызов к Telegram Bot API
-	webAppMarkup := InlineKeyboardMarkupWebApp{
-		InlineKeyboard: [][]InlineKeyboardButtonWebApp{
-			{
-				{
-					Text: "📅 Открыть календарь",
-					WebApp: &WebAppInfo{
-						URL: url,
-					},
-				},
-			},
-		},
-	}
-
-	// Создаем структуру для отправки сообщения с WebApp кнопкой
-	requestData := map[string]interface{}{
-		"chat_id":      chatID,
-		"text":         "Нажмите кнопку ниже, чтобы открыть календарь тренировок:\n\n<i>Если кнопка не работает, откройте ссылку в браузере:</i>\n<code>" + url + "</code>",
-		"parse_mode":   "HTML",
-		"reply_markup": webAppMarkup,
-	}
-
-	requestJSON, err := json.Marshal(requestData)
-	if err != nil {
-		// Fallback на обычную URL кнопку при ошибке
+	// Telegram передает initData только для WebApp кнопок, не для обычных URL кнопок,
+	// а web_app кнопку с http:// адресом отклоняет — тогда отправляем обычную URL кнопку
+	if !strings.HasPrefix(url, "https://") {
 		keyboard := tgbotapi.NewInlineKeyboardMarkup(
 			tgbotapi.NewInlineKeyboardRow(
 				tgbotapi.NewInlineKeyboardButtonURL(
@@ -82,47 +55,27 @@ func (b *Bot) handleCalendarCommand(message *tgbotapi.Message) {
 		)
 		msg := tgbotapi.NewMessage(chatID, "Нажмите кнопку ниже, чтобы открыть календарь тренировок:")
 		msg.ReplyMarkup = keyboard
-		b.api.Send(msg)
+		b.send(msg)
 		return
 	}
 
-	// Отправляем через прямой HTTP вызов к Telegram Bot API
-	apiURL := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", b.api.Token)
-	resp, err := http.Post(apiURL, "application/json", bytes.NewBuffer(requestJSON))
-	if err != nil {
-		// Fallback на обычную URL кнопку при ошибке сети
-		keyboard := tgbotapi.NewInlineKeyboardMarkup(
-			tgbotapi.NewInlineKeyboardRow(
-				tgbotapi.NewInlineKeyboardButtonURL(
-					"📅 Открыть календарь",
-					url,
-				),
-			),
-		)
-		msg := tgbotapi.NewMessage(chatID, "Нажмите кнопку ниже, чтобы открыть календарь тренировок:")
-		msg.ReplyMarkup = keyboard
-		b.api.Send(msg)
-		return
+	// Библиотека go-telegram-bot-api не поддерживает web_app поле напрямую,
+	// поэтому передаем собственную структуру клавиатуры
+	webAppMarkup := InlineKeyboardMarkupWebApp{
+		InlineKeyboard: [][]InlineKeyboardButtonWebApp{
+			{
+				{
+					Text: "📅 Открыть календарь",
+					WebApp: &WebAppInfo{
+						URL: url,
+					},
+				},
+			},
+		},
 	}
-	defer resp.Body.Close()
 
-	// Проверяем ответ
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		// Логируем ошибку, но не паникуем - используем fallback
-		fmt.Printf("Ошибка отправки WebApp кнопки: %s\n", string(body))
-		// Fallback на обычную URL кнопку
-		keyboard := tgbotapi.NewInlineKeyboardMarkup(
-			tgbotapi.NewInlineKeyboardRow(
-				tgbotapi.NewInlineKeyboardButtonURL(
-					"📅 Открыть календарь",
-					url,
-				),
-			),
-		)
-		msg := tgbotapi.NewMessage(chatID, "Нажмите кнопку ниже, чтобы открыть календарь тренировок:")
-		msg.ReplyMarkup = keyboard
-		b.api.Send(msg)
-		return
-	}
+	msg := tgbotapi.NewMessage(chatID, "Нажмите кнопку ниже, чтобы открыть календарь тренировок:\n\n<i>Если кнопка не работает, откройте ссылку в браузере:</i>\n<code>"+url+"</code>")
+	msg.ParseMode = "HTML"
+	msg.ReplyMarkup = webAppMarkup
+	b.send(msg)
 }
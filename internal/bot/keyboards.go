@@ -20,6 +20,7 @@ func createMainKeyboard(role string) tgbotapi.ReplyKeyboardMarkup {
 			),
 			tgbotapi.NewKeyboardButtonRow(
 				tgbotapi.NewKeyboardButton("📅 Календарь"),
+				tgbotapi.NewKeyboardButton("✅ Отметить посещаемость"),
 			),
 		)
 	}
@@ -118,12 +119,22 @@ func createSubscriptionManagementKeyboard() tgbotapi.ReplyKeyboardMarkup {
 	return tgbotapi.NewReplyKeyboard(
 		tgbotapi.NewKeyboardButtonRow(
 			tgbotapi.NewKeyboardButton("➕ Добавить абонемент"),
+			tgbotapi.NewKeyboardButton("🔄 Продлить абонемент"),
+		),
+		tgbotapi.NewKeyboardButtonRow(
 			tgbotapi.NewKeyboardButton("🗑️ Удалить абонемент"),
+			tgbotapi.NewKeyboardButton("👨‍👩‍👧 Семейный абонемент"),
 		),
 		tgbotapi.NewKeyboardButtonRow(
 			tgbotapi.NewKeyboardButton("👥 Список учеников с абонементами"),
+			tgbotapi.NewKeyboardButton("📋 Тарифы абонементов"),
+		),
+		tgbotapi.NewKeyboardButtonRow(
+			tgbotapi.NewKeyboardButton("❄️ Заморозка абонемента"),
+			tgbotapi.NewKeyboardButton("✏️ Корректировка занятий"),
 		),
 		tgbotapi.NewKeyboardButtonRow(
+			tgbotapi.NewKeyboardButton("📨 Запросы на продление"),
 			tgbotapi.NewKeyboardButton("◀️ Назад в главное меню"),
 		),
 	)
@@ -0,0 +1,165 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/notify"
+	"spectrum-club-bot/internal/payments"
+	"spectrum-club-bot/internal/service"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// offerSubscriptionPurchase предлагает ученику купить абонемент в боте, если оплата настроена
+func (b *Bot) offerSubscriptionPurchase(chatID int64) {
+	if !b.PaymentService.OnlinePaymentsEnabled() {
+		return
+	}
+
+	plans, err := b.SubscriptionService.GetPlans(false)
+	if err != nil {
+		log.Printf("Ошибка получения тарифов: %v", err)
+		return
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, plan := range plans {
+		if plan.Price <= 0 {
+			continue
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(
+				fmt.Sprintf("💳 %s — %d ₽", plan.Name, plan.Price),
+				payments.BuyCallbackData(plan.ID),
+			),
+		))
+	}
+	if len(rows) == 0 {
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID, "💳 Абонемент можно купить прямо в боте — выберите тариф:")
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	b.send(msg)
+}
+
+// handleBuyCallback кнопка тарифа: выставляет ученику счёт; возвращает текст подсказки
+func (b *Bot) handleBuyCallback(query *tgbotapi.CallbackQuery, planID int64) string {
+	if query.Message == nil || query.Message.Chat == nil {
+		return "❌ Не удалось выставить счёт"
+	}
+	chatID := query.Message.Chat.ID
+
+	user, err := b.UserService.GetByTelegramID(int64(query.From.ID))
+	if err != nil {
+		return "❌ Сначала зарегистрируйтесь в боте"
+	}
+	if user.Role != "student" {
+		return "❌ Купить абонемент может только ученик"
+	}
+	student, err := b.StudentService.GetStudentByUserID(user.ID)
+	if err != nil {
+		return "❌ Ошибка получения данных студента"
+	}
+
+	if _, err := b.PaymentService.CreateInvoice(chatID, student.ID, planID); err != nil {
+		log.Printf("Ошибка выставления счёта ученику %d по тарифу %d: %v", student.ID, planID, err)
+		b.sendError(chatID, "❌ Не удалось выставить счёт: "+err.Error())
+		return "❌ Не удалось выставить счёт"
+	}
+	return "Счёт отправлен"
+}
+
+// handleStubPaymentCallback оплата тестового счёта заглушки — то же, что successful_payment.
+// Данные кнопки присылает клиент, поэтому сервис проверяет провайдера и владельца счёта
+func (b *Bot) handleStubPaymentCallback(query *tgbotapi.CallbackQuery, payment payments.StubPayment) string {
+	if query.Message == nil || query.Message.Chat == nil {
+		return "❌ Не удалось провести оплату"
+	}
+	chatID := query.Message.Chat.ID
+
+	user, err := b.UserService.GetByTelegramID(int64(query.From.ID))
+	if err != nil {
+		return "❌ Сначала зарегистрируйтесь в боте"
+	}
+	student, err := b.StudentService.GetStudentByUserID(user.ID)
+	if err != nil || student == nil {
+		return "❌ Ошибка получения данных студента"
+	}
+
+	subscription, _, err := b.PaymentService.CompleteStubPayment(
+		student.ID, payment.Payload, payment.Currency, payment.TotalAmount, payment.ChargeID())
+	if err != nil && !errors.Is(err, service.ErrPaymentAlreadyProcessed) {
+		log.Printf("⚠️ Оплата кнопкой заглушки по счёту %s отклонена: %v", payment.Payload, err)
+		return "❌ Не удалось провести оплату"
+	}
+	b.reportPayment(chatID, payment.Payload, subscription, err)
+	return ""
+}
+
+// handlePreCheckout последняя проверка счёта перед списанием денег; Telegram ждёт ответа 10 секунд
+func (b *Bot) handlePreCheckout(query *tgbotapi.PreCheckoutQuery) {
+	log.Printf("💳 pre_checkout_query %s: %s, %d %s", query.ID, query.InvoicePayload, query.TotalAmount, query.Currency)
+
+	if err := b.PaymentService.AnswerPreCheckout(query.ID, query.InvoicePayload, query.Currency, query.TotalAmount); err != nil {
+		log.Printf("❌ Ошибка ответа на pre_checkout_query %s: %v", query.ID, err)
+	}
+}
+
+func (b *Bot) handleSuccessfulPayment(chatID int64, payment *tgbotapi.SuccessfulPayment) {
+	log.Printf("💳 successful_payment: %s, %d %s, charge %s",
+		payment.InvoicePayload, payment.TotalAmount, payment.Currency, payment.TelegramPaymentChargeID)
+	b.completePayment(chatID, payment)
+}
+
+// completePayment выдаёт абонемент по оплаченному счёту и сообщает об этом ученику
+func (b *Bot) completePayment(chatID int64, payment *tgbotapi.SuccessfulPayment) {
+	subscription, _, err := b.PaymentService.CompleteInvoicePayment(
+		payment.InvoicePayload,
+		payment.Currency,
+		payment.TotalAmount,
+		payment.TelegramPaymentChargeID,
+		payment.ProviderPaymentChargeID,
+	)
+	b.reportPayment(chatID, payment.InvoicePayload, subscription, err)
+}
+
+// reportPayment сообщает ученику итог оплаты счёта payload
+func (b *Bot) reportPayment(chatID int64, payload string, subscription *models.Subscription, err error) {
+	if errors.Is(err, service.ErrPaymentAlreadyProcessed) {
+		b.sendMessage(chatID, "✅ Эта оплата уже учтена, абонемент выдан")
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Ошибка выдачи абонемента по оплате %s: %v", payload, err)
+		b.sendError(chatID, "❌ Оплата получена, но абонемент не удалось выдать автоматически. Покажите это сообщение тренеру.")
+		return
+	}
+
+	b.sendPurchasedSubscription(chatID, subscription)
+}
+
+func (b *Bot) sendPurchasedSubscription(chatID int64, subscription *models.Subscription) {
+	planName := "Абонемент"
+	if subscription.PlanID != nil {
+		if plan, err := b.SubscriptionService.GetPlanByID(*subscription.PlanID); err == nil {
+			planName = plan.Name
+		}
+	}
+
+	msgText := fmt.Sprintf(
+		"🎫 *Оплата прошла, абонемент зачислен!*\n\n"+
+			"📋 *Тип:* %s\n"+
+			"📊 *Количество занятий:* %d\n"+
+			"📅 *Действует до:* %s\n\n"+
+			"Теперь вы можете записываться на тренировки!",
+		notify.EscapeMarkdown(planName),
+		subscription.TotalLessons,
+		subscription.EndDate.Format("02.01.2006"),
+	)
+	msg := tgbotapi.NewMessage(chatID, msgText)
+	msg.ParseMode = "Markdown"
+	b.send(msg)
+}
@@ -0,0 +1,61 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/payments"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+func TestForgedStubPaymentCallbackIssuesNoPass(t *testing.T) {
+	tb := newTestBot(t)
+
+	// Счёт выставлен Анне, но бот работает без заглушки оплаты
+	const annaStudentID = 1
+	planID := int64(1)
+	payment := &models.Payment{
+		StudentID: annaStudentID,
+		PlanID:    &planID,
+		Amount:    9000,
+		Currency:  "RUB",
+		Method:    models.PaymentMethodTelegram,
+		Status:    models.PaymentStatusPending,
+	}
+	if err := tb.repos.Payments.Create(payment); err != nil {
+		t.Fatal(err)
+	}
+
+	data := payments.StubCallbackData(payments.StubPayment{
+		Payload:     payment.InvoicePayload(),
+		Currency:    payment.Currency,
+		TotalAmount: payment.Amount * 100,
+	})
+	tb.srv.PushCallback(demoStudentChatID, tgbotapi.User{ID: int(demoStudentChatID)}, 1, data)
+
+	answers, err := tb.srv.WaitForRequests("answerCallbackQuery", 1, 2*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := answers[0].Params.Get("text"); !strings.Contains(text, "Не удалось провести оплату") {
+		t.Errorf("ответ на кнопку = %q", text)
+	}
+
+	subscriptions, err := tb.repos.Subscriptions.GetByStudentID(annaStudentID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subscriptions) != 1 {
+		t.Errorf("абонементов у Анны = %d, want 1: поддельная оплата выдала абонемент", len(subscriptions))
+	}
+	stored, err := tb.repos.Payments.GetByID(payment.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != models.PaymentStatusPending {
+		t.Errorf("статус платежа = %s, want %s", stored.Status, models.PaymentStatusPending)
+	}
+}
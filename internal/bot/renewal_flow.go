@@ -0,0 +1,156 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/notify"
+	"spectrum-club-bot/internal/renewal"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// offerSubscriptionRenewal под "Мой абонемент": ожидающий решения запрос на продление
+// с кнопкой отзыва или выбор тарифа для нового запроса
+func (b *Bot) offerSubscriptionRenewal(chatID int64, studentID int64) {
+	pending, err := b.RenewalService.GetPendingRequest(studentID)
+	if err != nil {
+		log.Printf("Ошибка получения запроса на продление ученика %d: %v", studentID, err)
+		return
+	}
+	if pending != nil {
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf(
+			"📨 Запрос на абонемент «%s» от %s ждёт решения тренера",
+			pending.PlanName, pending.CreatedAt.Format("02.01.2006")))
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("❌ Отозвать запрос", renewal.CallbackData(renewal.ActionCancel, pending.ID)),
+			),
+		)
+		b.send(msg)
+		return
+	}
+
+	plans, err := b.SubscriptionService.GetPlans(false)
+	if err != nil {
+		log.Printf("Ошибка получения тарифов: %v", err)
+		return
+	}
+	if len(plans) == 0 {
+		return
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, plan := range plans {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🔄 "+plan.Name, renewal.CallbackData(renewal.ActionRequest, plan.ID)),
+		))
+	}
+
+	msg := tgbotapi.NewMessage(chatID, "🔄 Нужен новый абонемент? Выберите тариф — тренер получит запрос на продление:")
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	b.send(msg)
+}
+
+// handleRenewalCallback кнопки продления: запрос и отзыв — ученику, решение — тренеру;
+// возвращает текст подсказки
+func (b *Bot) handleRenewalCallback(query *tgbotapi.CallbackQuery, action string, id int64) string {
+	if query.Message == nil || query.Message.Chat == nil {
+		return "❌ Не удалось выполнить действие"
+	}
+	chatID := query.Message.Chat.ID
+
+	user, err := b.UserService.GetByTelegramID(int64(query.From.ID))
+	if err != nil {
+		return "❌ Сначала зарегистрируйтесь в боте"
+	}
+
+	switch action {
+	case renewal.ActionRequest, renewal.ActionCancel:
+		if user.Role != "student" {
+			return "❌ Запросить продление может только ученик"
+		}
+		student, err := b.StudentService.GetStudentByUserID(user.ID)
+		if err != nil {
+			return "❌ Ошибка получения данных студента"
+		}
+
+		if action == renewal.ActionCancel {
+			if err := b.RenewalService.CancelRenewal(student.ID, id); err != nil {
+				return "❌ " + err.Error()
+			}
+			b.sendMessage(chatID, "✅ Запрос на продление отозван")
+			return "Запрос отозван"
+		}
+
+		request, err := b.RenewalService.RequestRenewal(student.ID, id)
+		if err != nil {
+			log.Printf("Ошибка запроса продления ученика %d по тарифу %d: %v", student.ID, id, err)
+			b.sendError(chatID, "❌ Не удалось отправить запрос: "+err.Error())
+			return "❌ Не удалось отправить запрос"
+		}
+		b.sendMessage(chatID, fmt.Sprintf(
+			"✅ Запрос на абонемент «%s» отправлен тренеру. Сообщим, когда он примет решение.", request.PlanName))
+		return "Запрос отправлен"
+
+	case renewal.ActionApprove, renewal.ActionReject:
+		if user.Role != "coach" {
+			return "❌ Решение по запросу принимает тренер"
+		}
+
+		if action == renewal.ActionReject {
+			request, err := b.RenewalService.RejectRenewal(id, user.ID)
+			if err != nil {
+				return "❌ " + err.Error()
+			}
+			b.sendMessage(chatID, fmt.Sprintf("❌ Запрос отклонён: %s, абонемент «%s»", request.StudentName, request.PlanName))
+			return "Запрос отклонён"
+		}
+
+		request, subscription, err := b.RenewalService.ApproveRenewal(id, user.ID)
+		if err != nil {
+			log.Printf("Ошибка одобрения запроса на продление %d: %v", id, err)
+			return "❌ " + err.Error()
+		}
+		b.sendMessage(chatID, fmt.Sprintf("✅ Запрос одобрен: %s получает абонемент «%s» — %d занятий до %s",
+			request.StudentName, request.PlanName, subscription.TotalLessons, subscription.EndDate.Format("02.01.2006")))
+		return "Абонемент выдан"
+	}
+
+	return ""
+}
+
+// handleRenewalRequests список запросов на продление, ожидающих решения тренера
+func (b *Bot) handleRenewalRequests(chatID int64, user *models.User) {
+	if user.Role != "coach" {
+		b.sendError(chatID, "❌ Эта функция доступна только тренерам")
+		return
+	}
+
+	requests, err := b.RenewalService.GetPendingRequests()
+	if err != nil {
+		log.Printf("Ошибка получения запросов на продление: %v", err)
+		b.sendError(chatID, "❌ Ошибка при получении запросов на продление")
+		return
+	}
+	if len(requests) == 0 {
+		b.sendMessage(chatID, "📨 Запросов на продление нет")
+		return
+	}
+
+	msgText := "📨 *Запросы на продление*\n\n"
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for i, request := range requests {
+		msgText += fmt.Sprintf("%d. %s — %s (%s)\n", i+1,
+			notify.EscapeMarkdown(request.StudentName), notify.EscapeMarkdown(request.PlanName), request.CreatedAt.Format("02.01 15:04"))
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("✅ %d. Одобрить", i+1), renewal.CallbackData(renewal.ActionApprove, request.ID)),
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("❌ %d. Отклонить", i+1), renewal.CallbackData(renewal.ActionReject, request.ID)),
+		))
+	}
+
+	msg := tgbotapi.NewMessage(chatID, msgText)
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	b.send(msg)
+}
@@ -31,7 +31,7 @@ func (b *Bot) handleMySchedule(chatID int64, user *models.User) {
 	)
 
 	msg.ReplyMarkup = keyboard
-	b.api.Send(msg)
+	b.send(msg)
 }
 
 // ///////////////////////////////////////eeeeeeeeeeeeeeeeeeeee
@@ -257,7 +257,7 @@ func (b *Bot) showDateInputForSchedule(chatID int64, text string) {
 	)
 
 	msg.ReplyMarkup = keyboard
-	b.api.Send(msg)
+	b.send(msg)
 }
 
 func (b *Bot) showPeriodInputForSchedule(chatID int64) {
@@ -283,7 +283,7 @@ func (b *Bot) showPeriodInputForSchedule(chatID int64) {
 	)
 
 	msg.ReplyMarkup = keyboard
-	b.api.Send(msg)
+	b.send(msg)
 }
 
 // Вспомогательная функция для дня недели на русском
@@ -310,7 +310,7 @@ func (b *Bot) showScheduleListView(chatID int64, periodDesc string) {
 	if len(trainings) == 0 {
 		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("📭 Нет тренировок за период: %s", periodDesc))
 		msg.ReplyMarkup = createScheduleManagementKeyboard()
-		b.api.Send(msg)
+		b.send(msg)
 		b.resetSession(chatID)
 		return
 	}
@@ -389,7 +389,7 @@ func (b *Bot) showScheduleListView(chatID int64, periodDesc string) {
 	msg := tgbotapi.NewMessage(chatID, message.String())
 	msg.ParseMode = "Markdown"
 	msg.ReplyMarkup = createScheduleManagementKeyboard() // Возвращаемся к меню управления
-	b.api.Send(msg)
+	b.send(msg)
 
 	// Сбрасываем сессию
 	b.resetSession(chatID)
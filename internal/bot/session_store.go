@@ -0,0 +1,90 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/repository"
+	"sync"
+)
+
+// SessionStore хранилище диалоговых сессий.
+// Load возвращает nil без ошибки, если у чата нет сохранённой сессии.
+type SessionStore interface {
+	Load(chatID int64) (*UserSession, error)
+	Save(chatID int64, session *UserSession) error
+	Delete(chatID int64) error
+}
+
+// memorySessionStore хранит сессии в памяти процесса (теряются при рестарте)
+type memorySessionStore struct {
+	mu       sync.RWMutex
+	sessions map[int64]*UserSession
+}
+
+func NewMemorySessionStore() SessionStore {
+	return &memorySessionStore{sessions: make(map[int64]*UserSession)}
+}
+
+func (s *memorySessionStore) Load(chatID int64) (*UserSession, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.sessions[chatID], nil
+}
+
+func (s *memorySessionStore) Save(chatID int64, session *UserSession) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[chatID] = session
+	return nil
+}
+
+func (s *memorySessionStore) Delete(chatID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, chatID)
+	return nil
+}
+
+// postgresSessionStore сериализует UserSession в JSON и хранит в spectrum.bot_sessions,
+// поэтому незавершённые диалоги переживают рестарт и доступны всем репликам
+type postgresSessionStore struct {
+	repo repository.BotSessionRepository
+}
+
+func NewPostgresSessionStore(repo repository.BotSessionRepository) SessionStore {
+	return &postgresSessionStore{repo: repo}
+}
+
+func (s *postgresSessionStore) Load(chatID int64) (*UserSession, error) {
+	stored, err := s.repo.Get(chatID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка загрузки сессии чата %d: %w", chatID, err)
+	}
+	if stored == nil {
+		return nil, nil
+	}
+
+	var session UserSession
+	if err := json.Unmarshal(stored.Data, &session); err != nil {
+		return nil, fmt.Errorf("ошибка разбора сессии чата %d: %w", chatID, err)
+	}
+	return &session, nil
+}
+
+func (s *postgresSessionStore) Save(chatID int64, session *UserSession) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации сессии чата %d: %w", chatID, err)
+	}
+
+	return s.repo.Save(&models.BotSession{
+		ChatID: chatID,
+		State:  int(session.State),
+		Data:   data,
+	})
+}
+
+func (s *postgresSessionStore) Delete(chatID int64) error {
+	return s.repo.Delete(chatID)
+}
@@ -1,11 +1,15 @@
 package bot
 
 import (
+	"errors"
 	"fmt"
+	"spectrum-club-bot/internal/notify"
 	"strconv"
 	"time"
 
 	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/service"
+	"spectrum-club-bot/internal/waitlist"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
 )
@@ -51,7 +55,7 @@ func (b *Bot) handleSignUpForTraining(chatID int64, user *models.User) {
 	)
 
 	msg.ReplyMarkup = keyboard
-	b.api.Send(msg)
+	b.send(msg)
 }
 
 func (b *Bot) handleDateSelectionForTrainingSignUp(chatID int64, messageText string) {
@@ -111,6 +115,7 @@ func (b *Bot) handleDateSelectionForTrainingSignUp(chatID int64, messageText str
 	// Фильтруем тренировки: только те, которые еще не начались
 	var availableTrainings []models.TrainingSchedule
 	nowTime := time.Now()
+	notCovered := 0 // тренировки, на которые абонемент не распространяется
 
 	for _, training := range trainings {
 		// Тренировка должна быть в будущем
@@ -118,6 +123,11 @@ func (b *Bot) handleDateSelectionForTrainingSignUp(chatID int64, messageText str
 			// Проверяем, есть ли у студента активный абонемент
 			activeSub, err := b.SubscriptionService.GetActiveSubscription(int64(session.SelectedStudentForSignUpID))
 			if err == nil && activeSub != nil && activeSub.RemainingLessons > 0 {
+				// Тренировки вне групп, дней и времени абонемента не предлагаем
+				if _, err := b.SubscriptionService.GetActiveSubscriptionForTraining(int64(session.SelectedStudentForSignUpID), training.ID); err != nil {
+					notCovered++
+					continue
+				}
 				// Проверяем, не записан ли уже студент на эту тренировку
 				existing, err := b.AttendanceService.GetStudentAttendanceForTraining(session.SelectedStudentForSignUpID, training.ID)
 				if err == nil && existing == nil {
@@ -128,11 +138,15 @@ func (b *Bot) handleDateSelectionForTrainingSignUp(chatID int64, messageText str
 	}
 
 	if len(availableTrainings) == 0 {
-		msg := tgbotapi.NewMessage(chatID,
-			fmt.Sprintf("📭 Нет доступных тренировок для записи на %s\n\nПроверьте:\n• Есть ли у вас активный абонемент\n• Не закончились ли занятия\n• Возможно, вы уже записаны на все тренировки в этот день",
-				selectedDate.Format("02.01.2006")))
+		msgText := fmt.Sprintf("📭 Нет доступных тренировок для записи на %s\n\nПроверьте:\n• Есть ли у вас активный абонемент\n• Не закончились ли занятия\n• Возможно, вы уже записаны на все тренировки в этот день",
+			selectedDate.Format("02.01.2006"))
+		if notCovered > 0 {
+			msgText += fmt.Sprintf("\n\n🎫 Тренировок, на которые не распространяется ваш абонемент: %d. "+
+				"Условия абонемента — в разделе «Мой абонемент».", notCovered)
+		}
+		msg := tgbotapi.NewMessage(chatID, msgText)
 		msg.ReplyMarkup = createStudentMainKeyboard()
-		b.api.Send(msg)
+		b.send(msg)
 		b.resetSession(chatID)
 		return
 	}
@@ -165,7 +179,7 @@ func (b *Bot) showAvailableTrainingsForSignUp(chatID int64, trainings []models.T
 		}
 
 		// Текущее количество записанных
-		currentCount, _, _, _ := b.AttendanceService.GetTrainingStats(training.ID)
+		_, _, currentCount, _ := b.AttendanceService.GetTrainingStats(training.ID)
 		maxCount := "без ограничений"
 		if training.MaxParticipants != nil {
 			maxCount = fmt.Sprintf("%d/%d", currentCount, *training.MaxParticipants)
@@ -188,7 +202,7 @@ func (b *Bot) showAvailableTrainingsForSignUp(chatID int64, trainings []models.T
 	msg := tgbotapi.NewMessage(chatID, msgText)
 	msg.ParseMode = "Markdown"
 	msg.ReplyMarkup = createCancelKeyboard()
-	b.api.Send(msg)
+	b.send(msg)
 }
 
 func (b *Bot) handleTrainingSelectionForSignUp(chatID int64, messageText string) {
@@ -236,9 +250,12 @@ func (b *Bot) showTrainingSignUpConfirmation(chatID int64, training models.Train
 
 	dayOfWeek := getRussianDayOfWeek(training.TrainingDate.Weekday())
 
-	// Проверяем абонемент
+	// Абонемент, с которого спишется занятие за эту тренировку
 	session := b.getOrCreateSession(chatID)
-	activeSub, _ := b.SubscriptionService.GetActiveSubscription(int64(session.SelectedStudentForSignUpID))
+	activeSub, err := b.SubscriptionService.GetActiveSubscriptionForTraining(int64(session.SelectedStudentForSignUpID), training.ID)
+	if err != nil {
+		activeSub = nil
+	}
 
 	msgText := fmt.Sprintf(
 		"✅ *Подтвердите запись на тренировку:*\n\n"+
@@ -274,7 +291,7 @@ func (b *Bot) showTrainingSignUpConfirmation(chatID int64, training models.Train
 	)
 
 	msg.ReplyMarkup = keyboard
-	b.api.Send(msg)
+	b.send(msg)
 }
 
 func (b *Bot) handleTrainingSignUpConfirmation(chatID int64, messageText string) {
@@ -297,7 +314,11 @@ func (b *Bot) handleTrainingSignUpConfirmation(chatID int64, messageText string)
 func (b *Bot) processTrainingSignUp(chatID int64, session *UserSession) {
 	// Записываем студента на тренировку
 	err := b.AttendanceService.SignUpForTraining(session.SelectedStudentForSignUpID, session.SelectedTrainingForSignUpID)
-	if err != nil {
+	if errors.Is(err, service.ErrTrainingFull) {
+		b.offerWaitlist(chatID, session.SelectedTrainingForSignUpID)
+	} else if errors.Is(err, service.ErrSubscriptionNotCovered) {
+		b.sendError(chatID, "🎫 Не удалось записаться: "+err.Error())
+	} else if err != nil {
 		b.sendError(chatID, "❌ Ошибка при записи: "+err.Error())
 	} else {
 		// Получаем информацию о тренировке для сообщения
@@ -315,28 +336,45 @@ func (b *Bot) processTrainingSignUp(chatID int64, session *UserSession) {
 					"🕐 *Время:* %s-%s\n"+
 					"👥 *Группа:* %s\n"+
 					"📍 *Место:* %s\n\n"+
+					"⏰ %s\n\n"+
 					"Не забудьте прийти за 10 минут до начала!",
 				training.TrainingDate.Format("02.01.2006"),
 				training.StartTime.Format("15:04"),
 				training.EndTime.Format("15:04"),
 				groupName,
 				training.Description,
+				b.AttendanceService.CancellationPolicy().Terms(trainingStartTime(training), time.Now()),
 			)
 
 			msg := tgbotapi.NewMessage(chatID, msgText)
 			msg.ParseMode = "Markdown"
 			msg.ReplyMarkup = createStudentMainKeyboard()
-			b.api.Send(msg)
+			b.send(msg)
 		} else {
 			msg := tgbotapi.NewMessage(chatID, "✅ Вы успешно записаны на тренировку!")
 			msg.ReplyMarkup = createStudentMainKeyboard()
-			b.api.Send(msg)
+			b.send(msg)
 		}
 	}
 
 	b.resetSession(chatID)
 }
 
+// offerWaitlist предлагает встать в лист ожидания, если мест на тренировку не осталось
+func (b *Bot) offerWaitlist(chatID int64, trainingID int) {
+	msg := tgbotapi.NewMessage(chatID, "😔 На эту тренировку не осталось свободных мест.")
+	msg.ReplyMarkup = createStudentMainKeyboard()
+	b.send(msg)
+
+	msg = tgbotapi.NewMessage(chatID, "Можно встать в лист ожидания: если кто-то отменит запись, бот предложит место вам.")
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("⏳ Встать в лист ожидания", waitlist.CallbackData(waitlist.ActionJoin, trainingID)),
+		),
+	)
+	b.send(msg)
+}
+
 func (b *Bot) handleMyRegistrations(chatID int64, user *models.User) {
 	if user.Role != "student" {
 		b.sendError(chatID, "❌ Эта функция доступна только ученикам")
@@ -360,10 +398,16 @@ func (b *Bot) handleMyRegistrations(chatID int64, user *models.User) {
 		return
 	}
 
-	if len(attendances) == 0 {
+	waitlistEntries, err := b.AttendanceService.GetStudentWaitlist(int(student.ID))
+	if err != nil {
+		b.sendError(chatID, "❌ Ошибка при получении листа ожидания")
+		return
+	}
+
+	if len(attendances) == 0 && len(waitlistEntries) == 0 {
 		msg := tgbotapi.NewMessage(chatID, "📭 У вас нет записей на тренировки.")
 		msg.ReplyMarkup = createStudentMainKeyboard()
-		b.api.Send(msg)
+		b.send(msg)
 		return
 	}
 
@@ -399,8 +443,11 @@ func (b *Bot) handleMyRegistrations(chatID int64, user *models.User) {
 
 			dayOfWeek := getRussianDayOfWeek(training.TrainingDate.Weekday())
 			status := "✅ Записан"
-			if attendance.Attended {
+			switch attendance.Status {
+			case models.AttendanceStatusAttended:
 				status = "✅ Посещена"
+			case models.AttendanceStatusCancelled, models.AttendanceStatusLateCancelled:
+				status = "🚫 Запись отменена"
 			}
 
 			message += fmt.Sprintf("%d. *%s, %s*\n   🕐 %s-%s\n   👥 %s\n   📍 %s\n   %s\n\n",
@@ -428,8 +475,13 @@ func (b *Bot) handleMyRegistrations(chatID int64, user *models.User) {
 
 			dayOfWeek := getRussianDayOfWeek(training.TrainingDate.Weekday())
 			status := "❌ Пропущена"
-			if attendance.Attended {
+			switch attendance.Status {
+			case models.AttendanceStatusAttended:
 				status = "✅ Посещена"
+			case models.AttendanceStatusCancelled:
+				status = "🚫 Запись отменена"
+			case models.AttendanceStatusLateCancelled:
+				status = "⚠️ Поздняя отмена"
 			}
 
 			message += fmt.Sprintf("%d. *%s, %s*\n   🕐 %s-%s\n   👥 %s\n   %s\n",
@@ -449,10 +501,40 @@ func (b *Bot) handleMyRegistrations(chatID int64, user *models.User) {
 		}
 	}
 
+	if len(waitlistEntries) > 0 {
+		message += "\n⏳ *Лист ожидания:*\n\n"
+		for i, entry := range waitlistEntries {
+			training, _ := b.ScheduleService.GetTrainingByID(entry.TrainingID)
+			if training == nil {
+				continue
+			}
+			group, _ := b.TrainingGroupService.GetGroupByID(training.GroupID)
+			groupName := "Неизвестная группа"
+			if group != nil {
+				groupName = group.Name
+			}
+
+			status := fmt.Sprintf("🔢 Место в очереди: %d", entry.Position)
+			if entry.Status == models.WaitlistStatusOffered {
+				status = "🎟 Вам предложено место — подтвердите запись в сообщении от бота"
+			}
+
+			message += fmt.Sprintf("%d. *%s, %s*\n   🕐 %s-%s\n   👥 %s\n   %s\n\n",
+				i+1,
+				getRussianDayOfWeek(training.TrainingDate.Weekday()),
+				training.TrainingDate.Format("02.01.2006"),
+				training.StartTime.Format("15:04"),
+				training.EndTime.Format("15:04"),
+				groupName,
+				status,
+			)
+		}
+	}
+
 	msg := tgbotapi.NewMessage(chatID, message)
 	msg.ParseMode = "Markdown"
 	msg.ReplyMarkup = createStudentMainKeyboard()
-	b.api.Send(msg)
+	b.send(msg)
 }
 
 func (b *Bot) handleMySubscription(chatID int64, user *models.User) {
@@ -491,13 +573,27 @@ func (b *Bot) handleMySubscription(chatID int64, user *models.User) {
 	msgText := "🎫 *Мой абонемент*\n\n"
 
 	if len(activeSubscriptions) == 0 && len(expiredSubscriptions) == 0 {
-		msgText += "У вас нет активных абонементов.\n\nОбратитесь к тренеру для приобретения абонемента."
+		msgText += "У вас нет активных абонементов.\n\nВыберите тариф ниже, чтобы запросить абонемент у тренера."
 	} else {
 		if len(activeSubscriptions) > 0 {
 			msgText += "✅ *Активные абонементы:*\n\n"
 			for i, sub := range activeSubscriptions {
 				msgText += fmt.Sprintf("%d. *%d/%d занятий*\n", i+1, sub.RemainingLessons, sub.TotalLessons)
 				msgText += fmt.Sprintf("   📅 Действует до: %s\n", sub.EndDate.Format("02.01.2006"))
+				if !sub.IsEmpty() {
+					msgText += "   🎯 Условия: " + notify.EscapeMarkdown(sub.Describe(b.groupNames())) + "\n"
+				}
+				msgText += b.familyInfo(sub, student.ID)
+				if freeze, err := b.SubscriptionService.GetCurrentFreeze(sub.ID); err == nil && freeze != nil {
+					msgText += fmt.Sprintf("   ❄️ Заморожен: %s – %s\n",
+						freeze.StartDate.Format("02.01.2006"), freeze.EndDate.Format("02.01.2006"))
+				}
+				if history, err := b.SubscriptionService.GetLessonHistory(sub.ID, lessonHistoryLimit); err == nil && len(history) > 0 {
+					msgText += "   📜 История занятий:\n"
+					for _, entry := range history {
+						msgText += "   " + notify.EscapeMarkdown(formatLedgerEntry(entry)) + "\n"
+					}
+				}
 				// msgText += fmt.Sprintf("   🏷️ Тип: %s\n", sub.SubscriptionType)
 				msgText += "\n"
 			}
@@ -522,5 +618,8 @@ func (b *Bot) handleMySubscription(chatID int64, user *models.User) {
 	msg := tgbotapi.NewMessage(chatID, msgText)
 	msg.ParseMode = "Markdown"
 	msg.ReplyMarkup = createStudentMainKeyboard()
-	b.api.Send(msg)
+	b.send(msg)
+
+	b.offerSubscriptionRenewal(chatID, student.ID)
+	b.offerSubscriptionPurchase(chatID)
 }
@@ -2,6 +2,7 @@ package bot
 
 import (
 	"fmt"
+	"log"
 	"spectrum-club-bot/internal/models"
 	"strconv"
 
@@ -16,11 +17,45 @@ func (b *Bot) getOrCreateSession(chatID int64) *UserSession {
 		return session
 	}
 
-	session := &UserSession{State: StateDefault}
+	session, err := b.sessions.Load(chatID)
+	if err != nil {
+		log.Printf("⚠️ Не удалось загрузить сессию чата %d: %v", chatID, err)
+	}
+	if session == nil {
+		session = &UserSession{State: StateDefault}
+	}
 	b.userSessions[chatID] = session
 	return session
 }
 
+// dropCachedSession сбрасывает кэш, чтобы сессия перечиталась из хранилища
+// (её могла изменить другая реплика)
+func (b *Bot) dropCachedSession(chatID int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.userSessions, chatID)
+}
+
+// persistSession сохраняет текущую сессию чата в хранилище
+func (b *Bot) persistSession(chatID int64) {
+	b.mu.RLock()
+	session, exists := b.userSessions[chatID]
+	b.mu.RUnlock()
+	if !exists {
+		return
+	}
+
+	var err error
+	if session.State == StateDefault {
+		err = b.sessions.Delete(chatID)
+	} else {
+		err = b.sessions.Save(chatID, session)
+	}
+	if err != nil {
+		log.Printf("⚠️ Не удалось сохранить сессию чата %d: %v", chatID, err)
+	}
+}
+
 func (b *Bot) handleAddSubscription(chatID int64) {
 	// ... существующий код до показа учеников ...
 
@@ -32,7 +67,7 @@ func (b *Bot) handleAddSubscription(chatID int64) {
 
 	if len(students) == 0 {
 		msg := tgbotapi.NewMessage(chatID, "📭 Список учеников пуст")
-		b.api.Send(msg)
+		b.send(msg)
 		return
 	}
 
@@ -57,7 +92,7 @@ func (b *Bot) showStudentsForSelection(chatID int64, students []*models.User) {
 	msg.ParseMode = "Markdown"
 	msg.ReplyMarkup = createCancelKeyboard()
 
-	b.api.Send(msg)
+	b.send(msg)
 }
 
 // Вспомогательная функция для получения отображаемого имени ученика
@@ -89,14 +124,14 @@ func getStudentDisplayName(student *models.User) string {
 // 	students, err := b.UserService.GetAllStudents()
 // 	if err != nil {
 // 		msg := tgbotapi.NewMessage(chatID, "❌ Ошибка при получении списка учеников")
-// 		b.api.Send(msg)
+// 		b.send(msg)
 // 		b.resetSession(chatID)
 // 		return
 // 	}
 
 // 	if len(students) == 0 {
 // 		msg := tgbotapi.NewMessage(chatID, "📝 Нет доступных учеников")
-// 		b.api.Send(msg)
+// 		b.send(msg)
 // 		b.resetSession(chatID)
 // 		return
 // 	}
@@ -105,7 +140,7 @@ func getStudentDisplayName(student *models.User) string {
 // 	msg := tgbotapi.NewMessage(chatID, "👥 Выберите ученика:")
 // 	keyboard := b.createStudentsKeyboard(students)
 // 	msg.ReplyMarkup = keyboard
-// 	b.api.Send(msg)
+// 	b.send(msg)
 // }
 
 // ///обработка выбора ученика
@@ -138,7 +173,7 @@ func getStudentDisplayName(student *models.User) string {
 
 // 	if selectedStudent == nil {
 // 		msg := tgbotapi.NewMessage(chatID, "❌ Ученик не найден")
-// 		b.api.Send(msg)
+// 		b.send(msg)
 // 		return
 // 	}
 // 	session.SelectedStudentID = selectedStudent.ID
@@ -176,20 +211,32 @@ func (b *Bot) handleStudentSelection(chatID int64, messageText string) {
 }
 
 func (b *Bot) showSubscriptionTypes(chatID int64, student *models.User) {
-	msg := tgbotapi.NewMessage(chatID,
-		fmt.Sprintf("🎫 Выберите тип абонемента для %s %s:",
-			student.FirstName, student.LastName))
+	session := b.getOrCreateSession(chatID)
 
-	keyboard := tgbotapi.NewReplyKeyboard(
-		tgbotapi.NewKeyboardButtonRow(
-			tgbotapi.NewKeyboardButton("⛰️Пробное занятие"),
-			tgbotapi.NewKeyboardButton("💪 Абонеменет на 12 занятий\n(Несгораемый)"),
-			tgbotapi.NewKeyboardButton("⛏️ Абонемент на 16 занятий\n(30дней)"),
-		),
-	)
+	plans, err := b.SubscriptionService.GetPlans(false)
+	if err != nil {
+		b.sendError(chatID, "❌ Ошибка при получении тарифов")
+		b.resetSession(chatID)
+		return
+	}
+	if len(plans) == 0 {
+		b.showMainKeyboardAfterOperation(chatID, "📭 Нет доступных тарифов. Добавьте их в разделе «📋 Тарифы абонементов».")
+		b.resetSession(chatID)
+		return
+	}
+	session.AvailablePlans = plans
 
-	msg.ReplyMarkup = keyboard
-	b.api.Send(msg)
+	msgText := fmt.Sprintf("🎫 Выберите тип абонемента для %s %s:\n\n", student.FirstName, student.LastName)
+	var rows [][]tgbotapi.KeyboardButton
+	for _, plan := range plans {
+		msgText += fmt.Sprintf("• %s — %s\n", plan.Name, plan.Summary())
+		rows = append(rows, tgbotapi.NewKeyboardButtonRow(tgbotapi.NewKeyboardButton(plan.Name)))
+	}
+	rows = append(rows, tgbotapi.NewKeyboardButtonRow(tgbotapi.NewKeyboardButton("❌ Отмена")))
+
+	msg := tgbotapi.NewMessage(chatID, msgText)
+	msg.ReplyMarkup = tgbotapi.NewReplyKeyboard(rows...)
+	b.send(msg)
 }
 
 func (b *Bot) handleSubscriptionTypeSelection(chatID int64, messageText string) {
@@ -203,20 +250,20 @@ func (b *Bot) handleSubscriptionTypeSelection(chatID int64, messageText string)
 		return
 	}
 
-	// Маппинг текста к типам абонементов
-	subscriptionMap := map[string]string{
-		"⛰️Пробное занятие":                         "1",
-		"💪 Абонеменет на 12 занятий\n(Несгораемый)": "12",
-		"⛏️ Абонемент на 16 занятий\n(30дней)":      "16",
+	// Кнопки подписаны названиями тарифов, показанных в showSubscriptionTypes
+	var selected *models.SubscriptionPlan
+	for i := range session.AvailablePlans {
+		if session.AvailablePlans[i].Name == messageText {
+			selected = &session.AvailablePlans[i]
+			break
+		}
 	}
-
-	subscriptionType, exists := subscriptionMap[messageText]
-	if !exists {
+	if selected == nil {
 		b.sendError(chatID, "❌ Неизвестный тип абонемента")
 		return
 	}
 
-	session.SelectedSubscriptionType = subscriptionType
+	session.SelectedPlanID = selected.ID
 	session.State = StateConfirming
 
 	b.showConfirmation(chatID)
@@ -234,25 +281,39 @@ func (b *Bot) showConfirmation(chatID int64) {
 		}
 	}
 
-	subscriptionNames := map[string]string{
-		"1":  "⛰️Пробное занятие",
-		"12": "💪 Абонеменет на 12 занятий\n(Несгораемый)",
-		"16": "⛏️ Абонемент на 16 занятий\n(30дней)",
+	planName := "Абонемент"
+	price := 0
+	if plan, err := b.SubscriptionService.GetPlanByID(session.SelectedPlanID); err == nil {
+		planName = fmt.Sprintf("%s (%s)", plan.Name, plan.Summary())
+		price = plan.Price
 	}
 
-	msg := tgbotapi.NewMessage(chatID,
-		fmt.Sprintf("✅ Подтвердите добавление:\n\n👤 Ученик: %s\n🎫 Абонемент: %s",
-			studentName, subscriptionNames[session.SelectedSubscriptionType]))
-
+	text := fmt.Sprintf("✅ Подтвердите добавление:\n\n👤 Ученик: %s\n🎫 Абонемент: %s", studentName, planName)
 	keyboard := tgbotapi.NewReplyKeyboard(
 		tgbotapi.NewKeyboardButtonRow(
 			tgbotapi.NewKeyboardButton("✅ Подтвердить"),
 			tgbotapi.NewKeyboardButton("❌ Отмена"),
 		),
 	)
+	// Платный тариф: тренер отмечает, как получил оплату, и она попадает в историю платежей
+	if price > 0 {
+		text += "\n\nКак оплачен абонемент?"
+		keyboard = tgbotapi.NewReplyKeyboard(
+			tgbotapi.NewKeyboardButtonRow(
+				tgbotapi.NewKeyboardButton("💵 Оплачено наличными"),
+				tgbotapi.NewKeyboardButton("💳 Оплачено картой"),
+			),
+			tgbotapi.NewKeyboardButtonRow(
+				tgbotapi.NewKeyboardButton("🎁 Без оплаты"),
+				tgbotapi.NewKeyboardButton("❌ Отмена"),
+			),
+		)
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text)
 
 	msg.ReplyMarkup = keyboard
-	b.api.Send(msg)
+	b.send(msg)
 }
 
 func (b *Bot) handleConfirmation(chatID int64, messageText string) {
@@ -262,8 +323,12 @@ func (b *Bot) handleConfirmation(chatID int64, messageText string) {
 	}
 
 	switch messageText {
-	case "✅ Подтвердить":
-		b.addSubscription(chatID, session)
+	case "✅ Подтвердить", "🎁 Без оплаты":
+		b.addSubscription(chatID, session, "")
+	case "💵 Оплачено наличными":
+		b.addSubscription(chatID, session, models.PaymentMethodCash)
+	case "💳 Оплачено картой":
+		b.addSubscription(chatID, session, models.PaymentMethodCard)
 	case "❌ Отмена":
 		b.cancelOperation(chatID, nil)
 	default:
@@ -271,8 +336,9 @@ func (b *Bot) handleConfirmation(chatID int64, messageText string) {
 	}
 }
 
-func (b *Bot) addSubscription(chatID int64, session *UserSession) {
-	// var err error
+// addSubscription выдаёт абонемент по выбранному тарифу; paymentMethod — как тренер получил
+// оплату (models.PaymentMethodCash или models.PaymentMethodCard), пустой — абонемент без оплаты
+func (b *Bot) addSubscription(chatID int64, session *UserSession, paymentMethod string) {
 	studentFromStudents, err := b.StudentService.GetStudentByUserID(session.SelectedStudentID)
 	if err != nil {
 		b.sendError(chatID, "❌ Ошибка при получении данных студента: "+err.Error())
@@ -280,52 +346,50 @@ func (b *Bot) addSubscription(chatID int64, session *UserSession) {
 		return
 	}
 
-	// Получаем информацию о типе абонемента для уведомления
-	subscriptionNames := map[string]string{
-		"1":  "⛰️ Пробное занятие (1 занятие на 30 дней)",
-		"12": "💪 Абонемент на 12 занятий (Несгораемый)",
-		"16": "⛏️ Абонемент на 16 занятий (30 дней)",
-	}
-	subscriptionName := subscriptionNames[session.SelectedSubscriptionType]
-	if subscriptionName == "" {
-		subscriptionName = "Абонемент"
+	plan, err := b.SubscriptionService.GetPlanByID(session.SelectedPlanID)
+	if err != nil {
+		b.sendError(chatID, "❌ Тариф не найден")
+		b.resetSession(chatID)
+		return
 	}
 
-	var totalLessons int
-	switch session.SelectedSubscriptionType {
-	case "1":
-		err = b.SubscriptionService.Create1For30Days(studentFromStudents.ID)
-		totalLessons = 1
-	case "12":
-		err = b.SubscriptionService.Create12Unlimited(studentFromStudents.ID)
-		totalLessons = 12
-	case "16":
-		err = b.SubscriptionService.Create16For30Days(studentFromStudents.ID)
-		totalLessons = 16
+	var subscription *models.Subscription
+	var payment *models.Payment
+	if paymentMethod == "" {
+		subscription, err = b.SubscriptionService.CreateFromPlan(studentFromStudents.ID, plan.ID, b.currentUserID(chatID))
+	} else {
+		subscription, payment, err = b.PaymentService.RecordManualPayment(studentFromStudents.ID, plan.ID, paymentMethod, b.currentUserID(chatID), "")
 	}
-
 	if err != nil {
 		b.sendError(chatID, "❌ Ошибка при добавлении абонемента: "+err.Error())
 		b.resetSession(chatID)
+		return
+	}
+
+	if payment != nil {
+		b.showMainKeyboardAfterOperation(chatID, fmt.Sprintf("✅ Абонемент успешно добавлен!\n💰 Оплата записана: %d ₽ (%s)",
+			payment.Amount, payment.MethodName()))
 	} else {
 		b.showMainKeyboardAfterOperation(chatID, "✅ Абонемент успешно добавлен!")
-		b.resetSession(chatID)
+	}
+	b.resetSession(chatID)
 
-		// Отправляем уведомление студенту
-		studentUser, err := b.UserService.GetByID(session.SelectedStudentID)
-		if err == nil && studentUser != nil {
-			msgText := fmt.Sprintf(
-				"🎫 *Вам зачислен абонемент!*\n\n"+
-					"📋 *Тип:* %s\n"+
-					"📊 *Количество занятий:* %d\n\n"+
-					"Теперь вы можете записываться на тренировки!",
-				subscriptionName,
-				totalLessons,
-			)
-			msg := tgbotapi.NewMessage(studentUser.TelegramID, msgText)
-			msg.ParseMode = "Markdown"
-			b.api.Send(msg)
-		}
+	// Отправляем уведомление студенту
+	studentUser, err := b.UserService.GetByID(session.SelectedStudentID)
+	if err == nil && studentUser != nil {
+		msgText := fmt.Sprintf(
+			"🎫 *Вам зачислен абонемент!*\n\n"+
+				"📋 *Тип:* %s\n"+
+				"📊 *Количество занятий:* %d\n"+
+				"📅 *Действует до:* %s\n\n"+
+				"Теперь вы можете записываться на тренировки!",
+			plan.Name,
+			subscription.TotalLessons,
+			subscription.EndDate.Format("02.01.2006"),
+		)
+		msg := tgbotapi.NewMessage(studentUser.TelegramID, msgText)
+		msg.ParseMode = "Markdown"
+		b.send(msg)
 	}
 }
 
@@ -333,8 +397,12 @@ func (b *Bot) addSubscription(chatID int64, session *UserSession) {
 
 func (b *Bot) resetSession(chatID int64) {
 	b.mu.Lock()
-	defer b.mu.Unlock()
 	delete(b.userSessions, chatID)
+	b.mu.Unlock()
+
+	if err := b.sessions.Delete(chatID); err != nil {
+		log.Printf("⚠️ Не удалось удалить сессию чата %d: %v", chatID, err)
+	}
 }
 
 func (b *Bot) cancelOperation(chatID int64, user *models.User) {
@@ -350,7 +418,7 @@ func (b *Bot) cancelOperation(chatID int64, user *models.User) {
 			msg.ReplyMarkup = tgbotapi.NewRemoveKeyboard(true)
 		}
 	}
-	b.api.Send(msg)
+	b.send(msg)
 	b.resetSession(chatID)
 }
 
@@ -363,10 +431,10 @@ func (b *Bot) showMainKeyboardAfterOperation(chatID int64, message string) {
 	} else {
 		msg.ReplyMarkup = tgbotapi.NewRemoveKeyboard(true)
 	}
-	b.api.Send(msg)
+	b.send(msg)
 }
 
 func (b *Bot) sendError(chatID int64, text string) {
 	msg := tgbotapi.NewMessage(chatID, text)
-	b.api.Send(msg)
+	b.send(msg)
 }
@@ -0,0 +1,214 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"spectrum-club-bot/internal/bootstrap"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/models/config"
+	"spectrum-club-bot/internal/notify"
+	"spectrum-club-bot/internal/refund"
+	"spectrum-club-bot/internal/renewal"
+	"spectrum-club-bot/internal/repository/memory"
+	attendance_service "spectrum-club-bot/internal/service/attendance"
+	coach_service "spectrum-club-bot/internal/service/coach"
+	group_serivce "spectrum-club-bot/internal/service/group"
+	payment_service "spectrum-club-bot/internal/service/payment"
+	renewal_service "spectrum-club-bot/internal/service/renewal"
+	schedule_service "spectrum-club-bot/internal/service/schedule"
+	student_service "spectrum-club-bot/internal/service/student"
+	subscription_service "spectrum-club-bot/internal/service/subscription"
+	user_service "spectrum-club-bot/internal/service/user"
+	"spectrum-club-bot/internal/telegram/telegramtest"
+	"spectrum-club-bot/internal/waitlist"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// Демо-данные: тренер с telegram_id -1, первая ученица в списке — Анна Иванова (-2)
+const (
+	demoCoachChatID   int64 = -1
+	demoStudentChatID int64 = -2
+)
+
+// testBot бот на демо-данных в памяти, который получает обновления из поддельного
+// Telegram через getUpdates (как в режиме polling) и отвечает туда же
+type testBot struct {
+	t     *testing.T
+	srv   *telegramtest.Server
+	repos *bootstrap.Repositories
+	bot   *Bot
+	seen  int
+}
+
+func newTestBot(t *testing.T) *testBot {
+	t.Helper()
+	const token = "123:test"
+	srv := telegramtest.NewServer(token)
+	t.Cleanup(srv.Close)
+
+	config.AppConfig = &config.Config{
+		Environment: "development",
+		Bot:         config.BotConfig{Token: token, APIURL: srv.URL(), UpdateMode: config.UpdateModePolling},
+		Checkin:     config.CheckinConfig{Secret: token, TTL: 10 * time.Minute},
+	}
+
+	store := memory.NewStore()
+	if err := memory.SeedDemoData(store); err != nil {
+		t.Fatal(err)
+	}
+	repos := bootstrap.NewMemoryRepositories(store, models.ConsumptionExpiringFirst)
+
+	sender, err := notify.NewTelegramSender(token, srv.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dispatcher := notify.NewDispatcher(repos.Outbox, sender)
+	notifier := notify.NewNotifier(repos.Outbox, dispatcher)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go dispatcher.Run(ctx)
+
+	subscriptionService := subscription_service.NewSubscriptionService(repos.Subscriptions, repos.Plans, repos.Freezes, repos.Ledger, repos.Transactor)
+	attendanceService := attendance_service.NewAttendanceService(
+		repos.Attendance,
+		repos.Schedule,
+		repos.Subscriptions,
+		repos.Waitlist,
+		repos.Transactor,
+		waitlist.NewOfferNotifier(notifier),
+		refund.NewNotifier(notifier),
+		time.Hour,
+		models.CancellationPolicy{},
+	)
+	b, err := NewBot(
+		user_service.NewUserService(repos.Users, repos.Students, repos.Coaches, repos.Subscriptions),
+		coach_service.NewCoachService(repos.Coaches),
+		student_service.NewStudentService(repos.Students),
+		subscriptionService,
+		payment_service.NewPaymentService(repos.Payments, repos.Plans, repos.Transactor, nil, "RUB"),
+		renewal_service.NewRenewalService(repos.Renewals, subscriptionService, renewal.NewNotifier(notifier, repos.Coaches, repos.Users)),
+		attendanceService,
+		schedule_service.NewScheduleService(repos.Schedule, repos.Attendance, repos.WeekSchedule, repos.TrainingGroups),
+		group_serivce.NewTrainingGroupService(repos.TrainingGroups),
+		NewPostgresSessionStore(repos.BotSessions),
+		notifier,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		if err := b.Start(); err != nil {
+			t.Errorf("Start() = %v", err)
+		}
+	}()
+	t.Cleanup(b.api.StopReceivingUpdates)
+	// Перед polling бот снимает вебхук; дожидаемся этого, чтобы тест не закончился раньше
+	if _, err := srv.WaitForRequests("setWebhook", 1, 2*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	return &testBot{t: t, srv: srv, repos: repos, bot: b}
+}
+
+// say кладёт в очередь getUpdates текст от имени чата и возвращает want новых ответов
+func (tb *testBot) say(chatID int64, text string, want int) []telegramtest.SentMessage {
+	tb.t.Helper()
+	tb.srv.PushMessage(chatID, tgbotapi.User{ID: int(chatID)}, text)
+
+	messages, err := tb.srv.WaitForMessages(tb.seen+want, 2*time.Second)
+	if err != nil {
+		tb.t.Fatalf("ответ на %q: %v", text, err)
+	}
+	replies := messages[tb.seen:]
+	tb.seen = len(messages)
+	return replies
+}
+
+func TestAddSubscriptionConversation(t *testing.T) {
+	tests := []struct {
+		name        string
+		plan        string
+		payment     string
+		wantReply   string
+		wantPayment int
+	}{
+		{
+			name:      "бесплатный тариф",
+			plan:      "⛰️ Пробное занятие",
+			payment:   "✅ Подтвердить",
+			wantReply: "✅ Абонемент успешно добавлен!",
+		},
+		{
+			name:        "оплата наличными",
+			plan:        "💪 12 занятий (несгораемый)",
+			payment:     "💵 Оплачено наличными",
+			wantReply:   "💰 Оплата записана: 9000 ₽",
+			wantPayment: 9000,
+		},
+		{
+			name:      "платный тариф без оплаты",
+			plan:      "⛏️ 16 занятий на 30 дней",
+			payment:   "🎁 Без оплаты",
+			wantReply: "✅ Абонемент успешно добавлен!",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tb := newTestBot(t)
+
+			replies := tb.say(demoCoachChatID, "➕ Добавить абонемент", 1)
+			if !strings.Contains(replies[0].Text, "1. Анна Иванова") {
+				t.Fatalf("список учеников: %q", replies[0].Text)
+			}
+
+			replies = tb.say(demoCoachChatID, "1", 1)
+			if !strings.Contains(replies[0].Text, tt.plan) || !strings.Contains(replies[0].ReplyMarkup, tt.plan) {
+				t.Fatalf("выбор тарифа: %q", replies[0].Text)
+			}
+
+			replies = tb.say(demoCoachChatID, tt.plan, 1)
+			if !strings.Contains(replies[0].Text, "👤 Ученик: Анна Иванова") || !strings.Contains(replies[0].ReplyMarkup, tt.payment) {
+				t.Fatalf("подтверждение: %q, клавиатура %s", replies[0].Text, replies[0].ReplyMarkup)
+			}
+
+			// Тренеру — итог, ученице — уведомление о зачислении
+			replies = tb.say(demoCoachChatID, tt.payment, 2)
+			byChat := make(map[int64]string)
+			for _, r := range replies {
+				byChat[r.ChatID] = r.Text
+			}
+			if !strings.Contains(byChat[demoCoachChatID], tt.wantReply) {
+				t.Errorf("ответ тренеру = %q, want %q", byChat[demoCoachChatID], tt.wantReply)
+			}
+			if !strings.Contains(byChat[demoStudentChatID], "Вам зачислен абонемент") {
+				t.Errorf("уведомление ученице = %q", byChat[demoStudentChatID])
+			}
+
+			history, err := tb.repos.Subscriptions.GetHistoryByStudentID(1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(history) != 2 {
+				t.Fatalf("абонементов у ученицы = %d, want 2", len(history))
+			}
+			payments, err := tb.repos.Payments.GetByStudentID(1, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			switch {
+			case tt.wantPayment == 0 && len(payments) != 0:
+				t.Errorf("записаны платежи %+v, want нет", payments)
+			case tt.wantPayment != 0 && (len(payments) != 1 || payments[0].Amount != tt.wantPayment):
+				t.Errorf("платежи = %+v, want один на %d", payments, tt.wantPayment)
+			}
+			if _, ok := tb.bot.userSessions[demoCoachChatID]; ok {
+				t.Error("сессия тренера не сброшена после выдачи абонемента")
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package bot
+
+import (
+	"fmt"
+	"spectrum-club-bot/internal/models"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// Флоу ручной корректировки занятий (для тренеров): ученик -> абонемент -> +/-N -> причина.
+// Каждая корректировка попадает в журнал занятий, ученик видит её в "🎫 Мой абонемент".
+
+// lessonHistoryLimit сколько последних движений по абонементу показывать в боте
+const lessonHistoryLimit = 10
+
+func (b *Bot) handleAdjustLessons(chatID int64, user *models.User) {
+	if user == nil || user.Role != "coach" {
+		b.sendError(chatID, "❌ Эта функция доступна только тренерам")
+		return
+	}
+
+	students, err := b.UserService.GetAllStudents()
+	if err != nil {
+		b.sendError(chatID, "❌ Ошибка при получении списка учеников")
+		b.resetSession(chatID)
+		return
+	}
+	if len(students) == 0 {
+		b.sendError(chatID, "📝 Нет доступных учеников")
+		b.resetSession(chatID)
+		return
+	}
+
+	session := b.getOrCreateSession(chatID)
+	session.State = StateSelectingStudentForAdjustment
+	session.StudentsForSelection = students
+
+	msgText := "👥 Выберите ученика для корректировки занятий:\n\n"
+	for i, student := range students {
+		msgText += fmt.Sprintf("%d. %s\n", i+1, getStudentDisplayName(student))
+	}
+	msgText += "\nВведите номер ученика или отправьте '❌ Отмена'"
+
+	msg := tgbotapi.NewMessage(chatID, msgText)
+	msg.ReplyMarkup = createCancelKeyboard()
+	b.send(msg)
+}
+
+func (b *Bot) handleStudentSelectionForAdjustment(chatID int64, messageText string) {
+	if messageText == "❌ Отмена" {
+		b.cancelOperation(chatID, nil)
+		return
+	}
+
+	session := b.getOrCreateSession(chatID)
+	index, err := strconv.Atoi(strings.TrimSpace(messageText))
+	if err != nil || index < 1 || index > len(session.StudentsForSelection) {
+		b.sendError(chatID, "❌ Пожалуйста, введите корректный номер ученика")
+		return
+	}
+
+	selectedStudent := session.StudentsForSelection[index-1]
+	student, err := b.StudentService.GetStudentByUserID(selectedStudent.ID)
+	if err != nil || student == nil {
+		b.sendError(chatID, "❌ Ошибка получения данных ученика")
+		return
+	}
+
+	subscriptions, err := b.SubscriptionService.GetSubscriptionsByStudentID(student.ID)
+	if err != nil {
+		b.sendError(chatID, "❌ Ошибка получения абонементов ученика")
+		return
+	}
+	subscriptions, shared := splitOwned(subscriptions, student.ID)
+	if len(subscriptions) == 0 {
+		b.showMainKeyboardAfterOperation(chatID,
+			fmt.Sprintf("❌ На ученика %s не оформлено абонементов\n%s",
+				getStudentDisplayName(selectedStudent), b.formatSharedSubscriptions(shared)))
+		b.resetSession(chatID)
+		return
+	}
+
+	session.SelectedStudentID = selectedStudent.ID
+	session.AvailableSubscriptions = subscriptions
+	session.State = StateSelectingSubscriptionForAdjustment
+
+	now := time.Now()
+	msgText := fmt.Sprintf("🎫 Абонементы ученика %s:\n\n", getStudentDisplayName(selectedStudent))
+	for i, subscription := range subscriptions {
+		status := "✅"
+		if subscription.RemainingLessons <= 0 {
+			status = "❌"
+		} else if now.After(subscription.EndDate) {
+			status = "⏰"
+		}
+		msgText += fmt.Sprintf("%d. %s %d/%d занятий (до %s)\n", i+1, status,
+			subscription.RemainingLessons, subscription.TotalLessons, subscription.EndDate.Format("02.01.2006"))
+	}
+	msgText += b.formatSharedSubscriptions(shared)
+	msgText += "\nВведите номер абонемента:"
+
+	msg := tgbotapi.NewMessage(chatID, msgText)
+	msg.ReplyMarkup = createCancelKeyboard()
+	b.send(msg)
+}
+
+func (b *Bot) handleSubscriptionSelectionForAdjustment(chatID int64, messageText string) {
+	if messageText == "❌ Отмена" {
+		b.cancelOperation(chatID, nil)
+		return
+	}
+
+	session := b.getOrCreateSession(chatID)
+	index, err := strconv.Atoi(strings.TrimSpace(messageText))
+	if err != nil || index < 1 || index > len(session.AvailableSubscriptions) {
+		b.sendError(chatID, "❌ Пожалуйста, введите корректный номер абонемента")
+		return
+	}
+
+	subscription := session.AvailableSubscriptions[index-1]
+	session.SelectedSubscriptionID = subscription.ID
+	session.State = StateEnteringAdjustmentDelta
+
+	msgText := fmt.Sprintf("🎫 Остаток: %d/%d занятий\n", subscription.RemainingLessons, subscription.TotalLessons)
+	if history, err := b.SubscriptionService.GetLessonHistory(subscription.ID, lessonHistoryLimit); err == nil && len(history) > 0 {
+		msgText += "\n📜 Последние движения:\n"
+		for _, entry := range history {
+			msgText += formatLedgerEntry(entry) + "\n"
+		}
+	}
+	msgText += "\nВведите изменение числом: +1 — вернуть занятие, -1 — списать."
+
+	msg := tgbotapi.NewMessage(chatID, msgText)
+	msg.ReplyMarkup = createCancelKeyboard()
+	b.send(msg)
+}
+
+// handleAdjustmentInput ввод изменения и причины корректировки
+func (b *Bot) handleAdjustmentInput(chatID int64, messageText string) {
+	if messageText == "❌ Отмена" {
+		b.cancelOperation(chatID, nil)
+		return
+	}
+
+	session := b.getOrCreateSession(chatID)
+	text := strings.TrimSpace(messageText)
+
+	switch session.State {
+	case StateEnteringAdjustmentDelta:
+		delta, err := strconv.Atoi(strings.TrimPrefix(text, "+"))
+		if err != nil || delta == 0 {
+			b.sendError(chatID, "❌ Введите ненулевое число, например +1 или -2")
+			return
+		}
+		session.AdjustmentDelta = delta
+		session.State = StateEnteringAdjustmentReason
+		b.sendMessage(chatID, "📝 Укажите причину корректировки (её увидит ученик):")
+
+	case StateEnteringAdjustmentReason:
+		if text == "" {
+			b.sendError(chatID, "❌ Причина не может быть пустой")
+			return
+		}
+
+		err := b.SubscriptionService.AdjustLessons(session.SelectedSubscriptionID, session.AdjustmentDelta,
+			b.currentUserID(chatID), text)
+		if err != nil {
+			b.sendError(chatID, "❌ Ошибка корректировки: "+err.Error())
+			b.resetSession(chatID)
+			return
+		}
+
+		b.showMainKeyboardAfterOperation(chatID, "✅ Количество занятий скорректировано")
+		if studentUser, err := b.UserService.GetByID(session.SelectedStudentID); err == nil && studentUser != nil {
+			notice := fmt.Sprintf("✏️ Тренер скорректировал ваш абонемент: %+d зан.\n📝 Причина: %s", session.AdjustmentDelta, text)
+			if subscription, err := b.SubscriptionService.GetSubscriptionByID(session.SelectedSubscriptionID); err == nil {
+				notice += fmt.Sprintf("\n🎫 Осталось занятий: %d", subscription.RemainingLessons)
+			}
+			b.sendMessage(studentUser.TelegramID, notice)
+		}
+		b.resetSession(chatID)
+	}
+}
+
+// formatLedgerEntry строка истории: дата, изменение, причина и подробности
+func formatLedgerEntry(entry models.LessonLedgerEntry) string {
+	line := fmt.Sprintf("%s %+d %s", entry.CreatedAt.Format("02.01.2006"), entry.Delta, entry.ReasonTitle())
+	if entry.Comment != "" {
+		line += ": " + entry.Comment
+	}
+	return line
+}
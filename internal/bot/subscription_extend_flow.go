@@ -0,0 +1,211 @@
+package bot
+
+import (
+	"fmt"
+	"spectrum-club-bot/internal/models"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// Флоу продления абонемента (для тренеров): ученик -> абонемент -> дни -> занятия -> подтверждение
+
+func (b *Bot) handleExtendSubscription(chatID int64, user *models.User) {
+	if user == nil || user.Role != "coach" {
+		b.sendError(chatID, "❌ Эта функция доступна только тренерам")
+		return
+	}
+
+	students, err := b.UserService.GetAllStudents()
+	if err != nil {
+		b.sendError(chatID, "❌ Ошибка при получении списка учеников")
+		b.resetSession(chatID)
+		return
+	}
+	if len(students) == 0 {
+		b.sendError(chatID, "📝 Нет доступных учеников")
+		b.resetSession(chatID)
+		return
+	}
+
+	session := b.getOrCreateSession(chatID)
+	session.State = StateSelectingStudentForExtension
+	session.StudentsForSelection = students
+
+	msgText := "👥 Выберите ученика, чей абонемент нужно продлить:\n\n"
+	for i, student := range students {
+		msgText += fmt.Sprintf("%d. %s\n", i+1, getStudentDisplayName(student))
+	}
+	msgText += "\nВведите номер ученика или отправьте '❌ Отмена'"
+
+	msg := tgbotapi.NewMessage(chatID, msgText)
+	msg.ReplyMarkup = createCancelKeyboard()
+	b.send(msg)
+}
+
+func (b *Bot) handleStudentSelectionForExtension(chatID int64, messageText string) {
+	if messageText == "❌ Отмена" {
+		b.cancelOperation(chatID, nil)
+		return
+	}
+
+	session := b.getOrCreateSession(chatID)
+	index, err := strconv.Atoi(strings.TrimSpace(messageText))
+	if err != nil || index < 1 || index > len(session.StudentsForSelection) {
+		b.sendError(chatID, "❌ Пожалуйста, введите корректный номер ученика")
+		return
+	}
+
+	selectedStudent := session.StudentsForSelection[index-1]
+	student, err := b.StudentService.GetStudentByUserID(selectedStudent.ID)
+	if err != nil || student == nil {
+		b.sendError(chatID, "❌ Ошибка получения данных ученика")
+		return
+	}
+
+	subscriptions, err := b.SubscriptionService.GetSubscriptionHistory(student.ID)
+	if err != nil {
+		b.sendError(chatID, "❌ Ошибка получения абонементов ученика")
+		return
+	}
+	subscriptions, shared := splitOwned(subscriptions, student.ID)
+	if len(subscriptions) == 0 {
+		b.showMainKeyboardAfterOperation(chatID,
+			fmt.Sprintf("❌ На ученика %s не оформлено абонементов\n%s",
+				getStudentDisplayName(selectedStudent), b.formatSharedSubscriptions(shared)))
+		b.resetSession(chatID)
+		return
+	}
+
+	session.SelectedStudentID = selectedStudent.ID
+	session.AvailableSubscriptions = subscriptions
+	session.State = StateSelectingSubscriptionForExtension
+
+	now := time.Now()
+	msgText := fmt.Sprintf("🎫 Абонементы ученика %s:\n\n", getStudentDisplayName(selectedStudent))
+	for i, subscription := range subscriptions {
+		status := "✅"
+		if subscription.RemainingLessons <= 0 {
+			status = "❌"
+		} else if now.After(subscription.EndDate) {
+			status = "⏰"
+		}
+		msgText += fmt.Sprintf("%d. %s %d/%d занятий (до %s)\n", i+1, status,
+			subscription.RemainingLessons, subscription.TotalLessons, subscription.EndDate.Format("02.01.2006"))
+	}
+	msgText += b.formatSharedSubscriptions(shared)
+	msgText += "\nВведите номер абонемента:"
+
+	msg := tgbotapi.NewMessage(chatID, msgText)
+	msg.ReplyMarkup = createCancelKeyboard()
+	b.send(msg)
+}
+
+func (b *Bot) handleSubscriptionSelectionForExtension(chatID int64, messageText string) {
+	if messageText == "❌ Отмена" {
+		b.cancelOperation(chatID, nil)
+		return
+	}
+
+	session := b.getOrCreateSession(chatID)
+	index, err := strconv.Atoi(strings.TrimSpace(messageText))
+	if err != nil || index < 1 || index > len(session.AvailableSubscriptions) {
+		b.sendError(chatID, "❌ Пожалуйста, введите корректный номер абонемента")
+		return
+	}
+
+	subscription := session.AvailableSubscriptions[index-1]
+	session.SelectedSubscriptionID = subscription.ID
+	session.State = StateEnteringExtensionDays
+
+	msgText := fmt.Sprintf("🎫 Абонемент: %d/%d занятий, действует до %s\n\n",
+		subscription.RemainingLessons, subscription.TotalLessons, subscription.EndDate.Format("02.01.2006"))
+	if subscription.EndDate.Before(time.Now()) {
+		msgText += "⏰ Срок истёк — продление отсчитывается от сегодняшнего дня.\n\n"
+	}
+	msgText += "📅 На сколько дней продлить срок? Введите 0, если срок менять не нужно."
+
+	msg := tgbotapi.NewMessage(chatID, msgText)
+	msg.ReplyMarkup = createCancelKeyboard()
+	b.send(msg)
+}
+
+// handleExtensionInput ввод дней и занятий продления, затем подтверждение
+func (b *Bot) handleExtensionInput(chatID int64, messageText string) {
+	if messageText == "❌ Отмена" {
+		b.cancelOperation(chatID, nil)
+		return
+	}
+
+	session := b.getOrCreateSession(chatID)
+	text := strings.TrimSpace(messageText)
+
+	switch session.State {
+	case StateEnteringExtensionDays:
+		days, err := strconv.Atoi(text)
+		if err != nil || days < 0 {
+			b.sendError(chatID, "❌ Введите количество дней числом, например 14 или 0")
+			return
+		}
+		session.ExtensionDays = days
+		session.State = StateEnteringExtensionLessons
+		b.sendMessage(chatID, "📊 Сколько занятий добавить? Введите 0, если занятия добавлять не нужно.")
+
+	case StateEnteringExtensionLessons:
+		lessons, err := strconv.Atoi(text)
+		if err != nil || lessons < 0 {
+			b.sendError(chatID, "❌ Введите количество занятий числом, например 4 или 0")
+			return
+		}
+		if lessons == 0 && session.ExtensionDays == 0 {
+			b.sendError(chatID, "❌ Нужно добавить хотя бы дни или занятия")
+			return
+		}
+		session.ExtensionLessons = lessons
+		session.State = StateConfirmingExtension
+
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf(
+			"🔄 Подтвердите продление абонемента:\n\n📅 Дней: +%d\n📊 Занятий: +%d",
+			session.ExtensionDays, session.ExtensionLessons))
+		msg.ReplyMarkup = tgbotapi.NewReplyKeyboard(
+			tgbotapi.NewKeyboardButtonRow(
+				tgbotapi.NewKeyboardButton("✅ Подтвердить"),
+				tgbotapi.NewKeyboardButton("❌ Отмена"),
+			),
+		)
+		b.send(msg)
+
+	case StateConfirmingExtension:
+		if messageText != "✅ Подтвердить" {
+			b.sendError(chatID, "❌ Неизвестная команда")
+			return
+		}
+
+		subscription, err := b.SubscriptionService.ExtendSubscription(session.SelectedSubscriptionID,
+			session.ExtensionDays, session.ExtensionLessons, b.currentUserID(chatID))
+		if err != nil {
+			b.sendError(chatID, "❌ "+err.Error())
+			b.resetSession(chatID)
+			return
+		}
+
+		b.showMainKeyboardAfterOperation(chatID, fmt.Sprintf("✅ Абонемент продлён: %d/%d занятий, до %s",
+			subscription.RemainingLessons, subscription.TotalLessons, subscription.EndDate.Format("02.01.2006")))
+
+		if studentUser, err := b.UserService.GetByID(session.SelectedStudentID); err == nil && studentUser != nil {
+			notice := "🔄 Ваш абонемент продлён!\n"
+			if session.ExtensionDays > 0 {
+				notice += fmt.Sprintf("\n📅 Срок: +%d дн.", session.ExtensionDays)
+			}
+			if session.ExtensionLessons > 0 {
+				notice += fmt.Sprintf("\n📊 Занятия: +%d", session.ExtensionLessons)
+			}
+			notice += fmt.Sprintf("\n\n🎫 Осталось занятий: %d\n📅 Действует до: %s",
+				subscription.RemainingLessons, subscription.EndDate.Format("02.01.2006"))
+			b.sendMessage(studentUser.TelegramID, notice)
+		}
+		b.resetSession(chatID)
+	}
+}
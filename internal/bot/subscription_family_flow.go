@@ -0,0 +1,354 @@
+package bot
+
+import (
+	"fmt"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/notify"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// Флоу семейного абонемента (для тренеров): владелец -> его абонемент -> подключить или отключить ученика
+
+func (b *Bot) handleFamilySubscription(chatID int64, user *models.User) {
+	if user == nil || user.Role != "coach" {
+		b.sendError(chatID, "❌ Эта функция доступна только тренерам")
+		return
+	}
+
+	students, err := b.UserService.GetAllStudents()
+	if err != nil {
+		b.sendError(chatID, "❌ Ошибка при получении списка учеников")
+		b.resetSession(chatID)
+		return
+	}
+	if len(students) == 0 {
+		b.sendError(chatID, "📝 Нет доступных учеников")
+		b.resetSession(chatID)
+		return
+	}
+
+	session := b.getOrCreateSession(chatID)
+	session.State = StateSelectingStudentForFamily
+	session.StudentsForSelection = students
+
+	msgText := "👨‍👩‍👧 Выберите ученика, на которого оформлен семейный абонемент:\n\n"
+	for i, student := range students {
+		msgText += fmt.Sprintf("%d. %s\n", i+1, getStudentDisplayName(student))
+	}
+	msgText += "\nВведите номер ученика или отправьте '❌ Отмена'"
+
+	msg := tgbotapi.NewMessage(chatID, msgText)
+	msg.ReplyMarkup = createCancelKeyboard()
+	b.send(msg)
+}
+
+func (b *Bot) handleStudentSelectionForFamily(chatID int64, messageText string) {
+	if messageText == "❌ Отмена" {
+		b.cancelOperation(chatID, nil)
+		return
+	}
+
+	session := b.getOrCreateSession(chatID)
+	index, err := strconv.Atoi(strings.TrimSpace(messageText))
+	if err != nil || index < 1 || index > len(session.StudentsForSelection) {
+		b.sendError(chatID, "❌ Пожалуйста, введите корректный номер ученика")
+		return
+	}
+
+	selectedStudent := session.StudentsForSelection[index-1]
+	student, err := b.StudentService.GetStudentByUserID(selectedStudent.ID)
+	if err != nil || student == nil {
+		b.sendError(chatID, "❌ Ошибка получения данных ученика")
+		return
+	}
+
+	subscriptions, err := b.SubscriptionService.GetSubscriptionsByStudentID(student.ID)
+	if err != nil {
+		b.sendError(chatID, "❌ Ошибка получения абонементов ученика")
+		return
+	}
+
+	// Подключать учеников может только владелец: абонементы, к которым подключён сам ученик, не показываем
+	var owned []*models.Subscription
+	now := time.Now()
+	for _, subscription := range subscriptions {
+		if subscription.StudentID == student.ID && subscription.EndDate.After(now) {
+			owned = append(owned, subscription)
+		}
+	}
+	if len(owned) == 0 {
+		b.showMainKeyboardAfterOperation(chatID,
+			fmt.Sprintf("❌ На ученика %s не оформлено действующих абонементов", getStudentDisplayName(selectedStudent)))
+		b.resetSession(chatID)
+		return
+	}
+
+	session.SelectedStudentID = selectedStudent.ID
+	session.AvailableSubscriptions = owned
+	session.State = StateSelectingSubscriptionForFamily
+
+	msgText := fmt.Sprintf("🎫 Абонементы ученика %s:\n\n", getStudentDisplayName(selectedStudent))
+	for i, subscription := range owned {
+		msgText += fmt.Sprintf("%d. %d/%d занятий (до %s)\n", i+1,
+			subscription.RemainingLessons, subscription.TotalLessons, subscription.EndDate.Format("02.01.2006"))
+	}
+	msgText += "\nВведите номер абонемента:"
+
+	msg := tgbotapi.NewMessage(chatID, msgText)
+	msg.ReplyMarkup = createCancelKeyboard()
+	b.send(msg)
+}
+
+func (b *Bot) handleSubscriptionSelectionForFamily(chatID int64, messageText string) {
+	if messageText == "❌ Отмена" {
+		b.cancelOperation(chatID, nil)
+		return
+	}
+
+	session := b.getOrCreateSession(chatID)
+	index, err := strconv.Atoi(strings.TrimSpace(messageText))
+	if err != nil || index < 1 || index > len(session.AvailableSubscriptions) {
+		b.sendError(chatID, "❌ Пожалуйста, введите корректный номер абонемента")
+		return
+	}
+
+	subscription := session.AvailableSubscriptions[index-1]
+	session.SelectedSubscriptionID = subscription.ID
+	b.showFamilyMembers(chatID, subscription)
+}
+
+// showFamilyMembers участники абонемента и действия с ними
+func (b *Bot) showFamilyMembers(chatID int64, subscription *models.Subscription) {
+	session := b.getOrCreateSession(chatID)
+
+	members, err := b.SubscriptionService.GetSubscriptionMembers(subscription.ID)
+	if err != nil {
+		b.sendError(chatID, "❌ Ошибка получения участников абонемента")
+		b.resetSession(chatID)
+		return
+	}
+	session.FamilyMembers = members
+	session.State = StateManagingFamily
+
+	msgText := fmt.Sprintf("🎫 Абонемент: %d/%d занятий, действует до %s\n\n",
+		subscription.RemainingLessons, subscription.TotalLessons, subscription.EndDate.Format("02.01.2006"))
+	msgText += formatFamilyMembers(members)
+
+	rows := [][]tgbotapi.KeyboardButton{
+		tgbotapi.NewKeyboardButtonRow(tgbotapi.NewKeyboardButton("➕ Подключить ученика")),
+	}
+	if len(members) > 1 {
+		rows[0] = append(rows[0], tgbotapi.NewKeyboardButton("➖ Отключить ученика"))
+	}
+	rows = append(rows, tgbotapi.NewKeyboardButtonRow(tgbotapi.NewKeyboardButton("❌ Отмена")))
+
+	msg := tgbotapi.NewMessage(chatID, msgText)
+	msg.ReplyMarkup = tgbotapi.NewReplyKeyboard(rows...)
+	b.send(msg)
+}
+
+// formatFamilyMembers список пользующихся абонементом с числом использованных занятий
+func formatFamilyMembers(members []models.SubscriptionMember) string {
+	var text strings.Builder
+	text.WriteString("👨‍👩‍👧 Пользуются абонементом:\n")
+	for _, member := range members {
+		role := ""
+		if member.IsOwner {
+			role = " (владелец)"
+		}
+		text.WriteString(fmt.Sprintf("• %s%s — использовано занятий: %d\n", member.StudentName, role, member.LessonsUsed))
+	}
+	return text.String()
+}
+
+func (b *Bot) handleFamilyAction(chatID int64, messageText string) {
+	session := b.getOrCreateSession(chatID)
+
+	switch messageText {
+	case "➕ Подключить ученика":
+		students, err := b.UserService.GetAllStudents()
+		if err != nil {
+			b.sendError(chatID, "❌ Ошибка при получении списка учеников")
+			return
+		}
+
+		// Владельца и уже подключённых не предлагаем
+		var candidates []*models.User
+		for _, student := range students {
+			if !b.isFamilyMember(session.FamilyMembers, student.ID) {
+				candidates = append(candidates, student)
+			}
+		}
+		if len(candidates) == 0 {
+			b.sendError(chatID, "📝 Нет учеников, которых можно подключить")
+			return
+		}
+
+		session.StudentsForSelection = candidates
+		session.State = StateSelectingFamilyMemberToAdd
+
+		msgText := "👥 Кого подключить к абонементу? Занятия будут списываться с общего остатка.\n\n"
+		for i, student := range candidates {
+			msgText += fmt.Sprintf("%d. %s\n", i+1, getStudentDisplayName(student))
+		}
+		msgText += "\nВведите номер ученика:"
+
+		msg := tgbotapi.NewMessage(chatID, msgText)
+		msg.ReplyMarkup = createCancelKeyboard()
+		b.send(msg)
+
+	case "➖ Отключить ученика":
+		if len(session.FamilyMembers) < 2 {
+			b.sendError(chatID, "📝 К абонементу никто не подключён")
+			return
+		}
+
+		session.State = StateSelectingFamilyMemberToRemove
+		msgText := "👥 Кого отключить от абонемента? Уже использованные занятия останутся в истории.\n\n"
+		for i, member := range session.FamilyMembers[1:] {
+			msgText += fmt.Sprintf("%d. %s\n", i+1, member.StudentName)
+		}
+		msgText += "\nВведите номер ученика:"
+
+		msg := tgbotapi.NewMessage(chatID, msgText)
+		msg.ReplyMarkup = createCancelKeyboard()
+		b.send(msg)
+
+	case "❌ Отмена":
+		b.cancelOperation(chatID, nil)
+
+	default:
+		b.sendError(chatID, "❌ Неизвестная команда")
+	}
+}
+
+// isFamilyMember пользуется ли абонементом ученик с users.id userID
+func (b *Bot) isFamilyMember(members []models.SubscriptionMember, userID int64) bool {
+	student, err := b.StudentService.GetStudentByUserID(userID)
+	if err != nil || student == nil {
+		return true
+	}
+	for _, member := range members {
+		if member.StudentID == student.ID {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *Bot) handleFamilyMemberSelection(chatID int64, messageText string) {
+	if messageText == "❌ Отмена" {
+		b.cancelOperation(chatID, nil)
+		return
+	}
+
+	session := b.getOrCreateSession(chatID)
+	index, err := strconv.Atoi(strings.TrimSpace(messageText))
+
+	switch session.State {
+	case StateSelectingFamilyMemberToAdd:
+		if err != nil || index < 1 || index > len(session.StudentsForSelection) {
+			b.sendError(chatID, "❌ Пожалуйста, введите корректный номер ученика")
+			return
+		}
+		selected := session.StudentsForSelection[index-1]
+		student, err := b.StudentService.GetStudentByUserID(selected.ID)
+		if err != nil || student == nil {
+			b.sendError(chatID, "❌ Ошибка получения данных ученика")
+			return
+		}
+
+		if err := b.SubscriptionService.AddSubscriptionMember(session.SelectedSubscriptionID, student.ID, b.currentUserID(chatID)); err != nil {
+			b.sendError(chatID, "❌ "+err.Error())
+			b.resetSession(chatID)
+			return
+		}
+		b.showMainKeyboardAfterOperation(chatID,
+			fmt.Sprintf("✅ %s подключен(а) к семейному абонементу", getStudentDisplayName(selected)))
+		b.notifyFamilyMember(selected.TelegramID, session.SelectedSubscriptionID,
+			"👨‍👩‍👧 Вас подключили к семейному абонементу — записывайтесь на тренировки, занятия спишутся с общего остатка.")
+		b.resetSession(chatID)
+
+	case StateSelectingFamilyMemberToRemove:
+		others := session.FamilyMembers[min(1, len(session.FamilyMembers)):]
+		if err != nil || index < 1 || index > len(others) {
+			b.sendError(chatID, "❌ Пожалуйста, введите корректный номер ученика")
+			return
+		}
+		member := others[index-1]
+
+		if err := b.SubscriptionService.RemoveSubscriptionMember(session.SelectedSubscriptionID, member.StudentID); err != nil {
+			b.sendError(chatID, "❌ "+err.Error())
+			b.resetSession(chatID)
+			return
+		}
+		b.showMainKeyboardAfterOperation(chatID, fmt.Sprintf("✅ %s отключен(а) от семейного абонемента", member.StudentName))
+		if _, user, err := b.StudentService.GetStudentWithUser(member.StudentID); err == nil && user != nil {
+			b.notifyFamilyMember(user.TelegramID, session.SelectedSubscriptionID,
+				"👨‍👩‍👧 Вас отключили от семейного абонемента. Для записи на тренировки нужен свой абонемент.")
+		}
+		b.resetSession(chatID)
+	}
+}
+
+func (b *Bot) notifyFamilyMember(telegramID, subscriptionID int64, text string) {
+	if subscription, err := b.SubscriptionService.GetSubscriptionByID(subscriptionID); err == nil {
+		text += fmt.Sprintf("\n\n🎫 Абонемент: %d/%d занятий, действует до %s",
+			subscription.RemainingLessons, subscription.TotalLessons, subscription.EndDate.Format("02.01.2006"))
+	}
+	b.sendMessage(telegramID, text)
+}
+
+// familyInfo строки "Мой абонемент" про семейный абонемент: владельцу — сколько занятий
+// использовал каждый, подключённому ученику — на кого абонемент оформлен
+func (b *Bot) familyInfo(subscription *models.Subscription, studentID int64) string {
+	members, err := b.SubscriptionService.GetSubscriptionMembers(subscription.ID)
+	if err != nil || len(members) < 2 {
+		return ""
+	}
+
+	if subscription.StudentID != studentID {
+		return fmt.Sprintf("   👨‍👩‍👧 Семейный абонемент, оформлен на: %s\n", notify.EscapeMarkdown(members[0].StudentName))
+	}
+
+	text := "   👨‍👩‍👧 Семейный абонемент, использовано занятий:\n"
+	for _, member := range members {
+		text += fmt.Sprintf("   • %s — %d\n", notify.EscapeMarkdown(member.StudentName), member.LessonsUsed)
+	}
+	return text
+}
+
+// splitOwned делит абонементы ученика на оформленные на него и семейные, к которым он только подключён.
+// Удалять и менять абонемент тренер может только из карточки владельца
+func splitOwned(subscriptions []*models.Subscription, studentID int64) (owned, shared []*models.Subscription) {
+	for _, subscription := range subscriptions {
+		if subscription.StudentID == studentID {
+			owned = append(owned, subscription)
+		} else {
+			shared = append(shared, subscription)
+		}
+	}
+	return owned, shared
+}
+
+// formatSharedSubscriptions семейные абонементы других учеников для списка: только просмотр, без номера
+func (b *Bot) formatSharedSubscriptions(shared []*models.Subscription) string {
+	if len(shared) == 0 {
+		return ""
+	}
+
+	text := "\n🔒 Семейные абонементы (изменить можно только у владельца):\n"
+	for _, subscription := range shared {
+		owner := "другого ученика"
+		if members, err := b.SubscriptionService.GetSubscriptionMembers(subscription.ID); err == nil && len(members) > 0 {
+			owner = members[0].StudentName
+		}
+		text += fmt.Sprintf("• %d/%d занятий (до %s), оформлен на %s\n",
+			subscription.RemainingLessons, subscription.TotalLessons, subscription.EndDate.Format("02.01.2006"), owner)
+	}
+	return text
+}
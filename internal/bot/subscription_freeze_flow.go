@@ -0,0 +1,297 @@
+package bot
+
+import (
+	"fmt"
+	"spectrum-club-bot/internal/models"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// Флоу заморозки абонемента (для тренеров): ученик -> абонемент -> заморозить или снять заморозку
+
+func (b *Bot) handleFreezeSubscription(chatID int64, user *models.User) {
+	if user == nil || user.Role != "coach" {
+		b.sendError(chatID, "❌ Эта функция доступна только тренерам")
+		return
+	}
+
+	students, err := b.UserService.GetAllStudents()
+	if err != nil {
+		b.sendError(chatID, "❌ Ошибка при получении списка учеников")
+		b.resetSession(chatID)
+		return
+	}
+	if len(students) == 0 {
+		b.sendError(chatID, "📝 Нет доступных учеников")
+		b.resetSession(chatID)
+		return
+	}
+
+	session := b.getOrCreateSession(chatID)
+	session.State = StateSelectingStudentForFreeze
+	session.StudentsForSelection = students
+
+	msgText := "👥 Выберите ученика, чей абонемент нужно заморозить или разморозить:\n\n"
+	for i, student := range students {
+		msgText += fmt.Sprintf("%d. %s\n", i+1, getStudentDisplayName(student))
+	}
+	msgText += "\nВведите номер ученика или отправьте '❌ Отмена'"
+
+	msg := tgbotapi.NewMessage(chatID, msgText)
+	msg.ReplyMarkup = createCancelKeyboard()
+	b.send(msg)
+}
+
+func (b *Bot) handleStudentSelectionForFreeze(chatID int64, messageText string) {
+	if messageText == "❌ Отмена" {
+		b.cancelOperation(chatID, nil)
+		return
+	}
+
+	session := b.getOrCreateSession(chatID)
+	index, err := strconv.Atoi(strings.TrimSpace(messageText))
+	if err != nil || index < 1 || index > len(session.StudentsForSelection) {
+		b.sendError(chatID, "❌ Пожалуйста, введите корректный номер ученика")
+		return
+	}
+
+	selectedStudent := session.StudentsForSelection[index-1]
+	student, err := b.StudentService.GetStudentByUserID(selectedStudent.ID)
+	if err != nil || student == nil {
+		b.sendError(chatID, "❌ Ошибка получения данных ученика")
+		return
+	}
+
+	subscriptions, err := b.SubscriptionService.GetSubscriptionsByStudentID(student.ID)
+	if err != nil {
+		b.sendError(chatID, "❌ Ошибка получения абонементов ученика")
+		return
+	}
+
+	// Заморозить можно только собственный абонемент, которым ещё можно пользоваться
+	owned, shared := splitOwned(subscriptions, student.ID)
+	var usable []*models.Subscription
+	now := time.Now()
+	for _, subscription := range owned {
+		if subscription.RemainingLessons > 0 && subscription.EndDate.After(now) {
+			usable = append(usable, subscription)
+		}
+	}
+	if len(usable) == 0 {
+		b.showMainKeyboardAfterOperation(chatID,
+			fmt.Sprintf("❌ У ученика %s нет действующих абонементов\n%s",
+				getStudentDisplayName(selectedStudent), b.formatSharedSubscriptions(shared)))
+		b.resetSession(chatID)
+		return
+	}
+
+	session.SelectedStudentID = selectedStudent.ID
+	session.AvailableSubscriptions = usable
+	session.State = StateSelectingSubscriptionForFreeze
+
+	msgText := fmt.Sprintf("🎫 Абонементы ученика %s:\n\n", getStudentDisplayName(selectedStudent))
+	for i, subscription := range usable {
+		msgText += fmt.Sprintf("%d. %d/%d занятий (до %s)%s\n", i+1,
+			subscription.RemainingLessons, subscription.TotalLessons,
+			subscription.EndDate.Format("02.01.2006"), b.freezeMark(subscription.ID))
+	}
+	msgText += b.formatSharedSubscriptions(shared)
+	msgText += "\nВведите номер абонемента:"
+
+	msg := tgbotapi.NewMessage(chatID, msgText)
+	msg.ReplyMarkup = createCancelKeyboard()
+	b.send(msg)
+}
+
+// freezeMark пометка для списка абонементов, если у абонемента есть текущая или будущая заморозка
+func (b *Bot) freezeMark(subscriptionID int64) string {
+	freeze, err := b.SubscriptionService.GetCurrentFreeze(subscriptionID)
+	if err != nil || freeze == nil {
+		return ""
+	}
+	return fmt.Sprintf(" ❄️ заморожен %s–%s",
+		freeze.StartDate.Format("02.01.2006"), freeze.EndDate.Format("02.01.2006"))
+}
+
+func (b *Bot) handleSubscriptionSelectionForFreeze(chatID int64, messageText string) {
+	if messageText == "❌ Отмена" {
+		b.cancelOperation(chatID, nil)
+		return
+	}
+
+	session := b.getOrCreateSession(chatID)
+	index, err := strconv.Atoi(strings.TrimSpace(messageText))
+	if err != nil || index < 1 || index > len(session.AvailableSubscriptions) {
+		b.sendError(chatID, "❌ Пожалуйста, введите корректный номер абонемента")
+		return
+	}
+
+	subscription := session.AvailableSubscriptions[index-1]
+	session.SelectedSubscriptionID = subscription.ID
+
+	freeze, err := b.SubscriptionService.GetCurrentFreeze(subscription.ID)
+	if err != nil {
+		b.sendError(chatID, "❌ Ошибка получения заморозок абонемента")
+		return
+	}
+
+	msgText := fmt.Sprintf("🎫 Абонемент: %d/%d занятий, действует до %s\n\n",
+		subscription.RemainingLessons, subscription.TotalLessons, subscription.EndDate.Format("02.01.2006"))
+	action := "❄️ Заморозить"
+	if freeze != nil {
+		msgText += fmt.Sprintf("❄️ Заморожен с %s по %s\n📝 Причина: %s",
+			freeze.StartDate.Format("02.01.2006"), freeze.EndDate.Format("02.01.2006"), freeze.Reason)
+		action = "🔥 Снять заморозку"
+	} else {
+		msgText += "Заморозки нет."
+	}
+
+	session.State = StateManagingFreeze
+	msg := tgbotapi.NewMessage(chatID, msgText)
+	msg.ReplyMarkup = tgbotapi.NewReplyKeyboard(
+		tgbotapi.NewKeyboardButtonRow(
+			tgbotapi.NewKeyboardButton(action),
+			tgbotapi.NewKeyboardButton("❌ Отмена"),
+		),
+	)
+	b.send(msg)
+}
+
+func (b *Bot) handleFreezeAction(chatID int64, messageText string) {
+	session := b.getOrCreateSession(chatID)
+
+	switch messageText {
+	case "❄️ Заморозить":
+		session.State = StateEnteringFreezePeriod
+		msg := tgbotapi.NewMessage(chatID,
+			"📅 Введите период заморозки в формате ДД.ММ.ГГГГ-ДД.ММ.ГГГГ\n"+
+				"или число дней — тогда заморозка начнётся сегодня.\n\n"+
+				"Срок действия абонемента продлится на длительность заморозки.")
+		msg.ReplyMarkup = createCancelKeyboard()
+		b.send(msg)
+
+	case "🔥 Снять заморозку":
+		if _, err := b.SubscriptionService.UnfreezeSubscription(session.SelectedSubscriptionID); err != nil {
+			b.sendError(chatID, "❌ "+err.Error())
+			b.resetSession(chatID)
+			return
+		}
+		b.showMainKeyboardAfterOperation(chatID, "🔥 Заморозка снята")
+		b.notifyStudentAboutFreeze(session.SelectedStudentID, session.SelectedSubscriptionID,
+			"🔥 Заморозка абонемента снята, им снова можно пользоваться.")
+		b.resetSession(chatID)
+
+	case "❌ Отмена":
+		b.cancelOperation(chatID, nil)
+
+	default:
+		b.sendError(chatID, "❌ Неизвестная команда")
+	}
+}
+
+// handleFreezeInput пошаговый ввод заморозки: период, причина, подтверждение
+func (b *Bot) handleFreezeInput(chatID int64, messageText string) {
+	if messageText == "❌ Отмена" {
+		b.cancelOperation(chatID, nil)
+		return
+	}
+
+	session := b.getOrCreateSession(chatID)
+	text := strings.TrimSpace(messageText)
+
+	switch session.State {
+	case StateEnteringFreezePeriod:
+		start, end, err := parseFreezePeriod(text)
+		if err != nil {
+			b.sendError(chatID, "❌ "+err.Error())
+			return
+		}
+		session.FreezeStartDate = start
+		session.FreezeEndDate = end
+		session.State = StateEnteringFreezeReason
+		b.sendMessage(chatID, "📝 Укажите причину заморозки (например: травма, отпуск):")
+
+	case StateEnteringFreezeReason:
+		if text == "" {
+			b.sendError(chatID, "❌ Причина не может быть пустой")
+			return
+		}
+		session.FreezeReason = text
+		session.State = StateConfirmingFreeze
+
+		days := int(session.FreezeEndDate.Sub(session.FreezeStartDate)/(24*time.Hour)) + 1
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf(
+			"❄️ Подтвердите заморозку:\n\n📅 С %s по %s (дней: %d)\n📝 Причина: %s\n\n"+
+				"Срок действия абонемента продлится на %d дн.",
+			session.FreezeStartDate.Format("02.01.2006"), session.FreezeEndDate.Format("02.01.2006"),
+			days, session.FreezeReason, days))
+		msg.ReplyMarkup = tgbotapi.NewReplyKeyboard(
+			tgbotapi.NewKeyboardButtonRow(
+				tgbotapi.NewKeyboardButton("✅ Подтвердить"),
+				tgbotapi.NewKeyboardButton("❌ Отмена"),
+			),
+		)
+		b.send(msg)
+
+	case StateConfirmingFreeze:
+		if messageText != "✅ Подтвердить" {
+			b.sendError(chatID, "❌ Неизвестная команда")
+			return
+		}
+
+		freeze, err := b.SubscriptionService.FreezeSubscription(session.SelectedSubscriptionID,
+			session.FreezeStartDate, session.FreezeEndDate, session.FreezeReason, b.currentUserID(chatID))
+		if err != nil {
+			b.sendError(chatID, "❌ Ошибка при заморозке абонемента: "+err.Error())
+			b.resetSession(chatID)
+			return
+		}
+
+		b.showMainKeyboardAfterOperation(chatID, "❄️ Абонемент заморожен")
+		b.notifyStudentAboutFreeze(session.SelectedStudentID, session.SelectedSubscriptionID,
+			fmt.Sprintf("❄️ Ваш абонемент заморожен с %s по %s.\n📝 Причина: %s",
+				freeze.StartDate.Format("02.01.2006"), freeze.EndDate.Format("02.01.2006"), freeze.Reason))
+		b.resetSession(chatID)
+	}
+}
+
+// parseFreezePeriod разбирает "ДД.ММ.ГГГГ-ДД.ММ.ГГГГ" или число дней начиная с сегодня
+func parseFreezePeriod(text string) (time.Time, time.Time, error) {
+	if days, err := strconv.Atoi(text); err == nil {
+		if days <= 0 {
+			return time.Time{}, time.Time{}, fmt.Errorf("число дней должно быть больше нуля")
+		}
+		now := time.Now()
+		start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 0, days-1), nil
+	}
+
+	parts := strings.Split(text, "-")
+	if len(parts) != 2 {
+		return time.Time{}, time.Time{}, fmt.Errorf("неверный формат периода. Используйте ДД.ММ.ГГГГ-ДД.ММ.ГГГГ или число дней")
+	}
+	start, err1 := time.Parse("02.01.2006", strings.TrimSpace(parts[0]))
+	end, err2 := time.Parse("02.01.2006", strings.TrimSpace(parts[1]))
+	if err1 != nil || err2 != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("неверный формат даты. Используйте ДД.ММ.ГГГГ")
+	}
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("дата окончания должна быть не раньше даты начала")
+	}
+	return start, end, nil
+}
+
+// notifyStudentAboutFreeze сообщает ученику о заморозке и новом сроке абонемента
+func (b *Bot) notifyStudentAboutFreeze(studentUserID, subscriptionID int64, text string) {
+	studentUser, err := b.UserService.GetByID(studentUserID)
+	if err != nil || studentUser == nil {
+		return
+	}
+	if subscription, err := b.SubscriptionService.GetSubscriptionByID(subscriptionID); err == nil {
+		text += fmt.Sprintf("\n📅 Абонемент действует до %s", subscription.EndDate.Format("02.01.2006"))
+	}
+	b.sendMessage(studentUser.TelegramID, text)
+}
@@ -0,0 +1,418 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"spectrum-club-bot/internal/models"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// Флоу управления тарифами абонементов (для тренеров и администраторов)
+
+// canManagePlans тарифы меняют тренеры и администраторы из ADMIN_IDS
+func (b *Bot) canManagePlans(user *models.User) bool {
+	if user == nil {
+		return false
+	}
+	return user.Role == "coach" || slices.Contains(b.adminIDs, user.TelegramID)
+}
+
+func (b *Bot) handlePlansCommand(chatID int64, user *models.User) {
+	if !b.canManagePlans(user) {
+		b.sendError(chatID, "❌ Эта функция доступна только тренерам и администраторам")
+		return
+	}
+	b.showPlansList(chatID)
+}
+
+func (b *Bot) showPlansList(chatID int64) {
+	plans, err := b.SubscriptionService.GetPlans(true)
+	if err != nil {
+		b.sendError(chatID, "❌ Ошибка при получении тарифов")
+		b.resetSession(chatID)
+		return
+	}
+
+	session := b.getOrCreateSession(chatID)
+	session.State = StateSelectingPlanToManage
+	session.AvailablePlans = plans
+	session.PlanDraft = nil
+
+	msgText := "📋 Тарифы абонементов\n\n"
+	if len(plans) == 0 {
+		msgText += "Тарифов пока нет.\n"
+	}
+	for i, plan := range plans {
+		status := ""
+		if !plan.IsActive {
+			status = " ⏸️ скрыт"
+		}
+		msgText += fmt.Sprintf("%d. %s\n   %s%s\n", i+1, plan.Name, plan.Summary(), status)
+	}
+	msgText += "\nВведите номер тарифа, чтобы изменить его, или добавьте новый."
+
+	msg := tgbotapi.NewMessage(chatID, msgText)
+	msg.ReplyMarkup = tgbotapi.NewReplyKeyboard(
+		tgbotapi.NewKeyboardButtonRow(
+			tgbotapi.NewKeyboardButton("➕ Новый тариф"),
+		),
+		tgbotapi.NewKeyboardButtonRow(
+			tgbotapi.NewKeyboardButton("◀️ Назад к абонементам"),
+		),
+	)
+	b.send(msg)
+}
+
+func (b *Bot) handlePlanSelection(chatID int64, messageText string) {
+	session := b.getOrCreateSession(chatID)
+
+	switch messageText {
+	case "➕ Новый тариф":
+		session.PlanDraft = &models.SubscriptionPlan{IsActive: true}
+		session.State = StateEnteringPlanName
+		msg := tgbotapi.NewMessage(chatID, "✏️ Введите название тарифа (так оно будет подписано на кнопке):")
+		msg.ReplyMarkup = createCancelKeyboard()
+		b.send(msg)
+		return
+	case "◀️ Назад к абонементам":
+		b.resetSession(chatID)
+		user, _, _, _, _ := b.UserService.GetUserProfile(chatID)
+		if user != nil && user.Role == "coach" {
+			b.showSubscriptionManagementMenu(chatID, user)
+		} else {
+			b.sendWelcomeMessage(chatID, user)
+		}
+		return
+	case "❌ Отмена":
+		b.cancelOperation(chatID, nil)
+		return
+	}
+
+	index, err := strconv.Atoi(strings.TrimSpace(messageText))
+	if err != nil || index < 1 || index > len(session.AvailablePlans) {
+		b.sendError(chatID, "❌ Введите корректный номер тарифа")
+		return
+	}
+
+	plan := session.AvailablePlans[index-1]
+	session.SelectedPlanID = plan.ID
+	session.State = StateManagingPlan
+	b.showPlanCard(chatID, plan)
+}
+
+func (b *Bot) showPlanCard(chatID int64, plan models.SubscriptionPlan) {
+	status := "✅ Выдаётся"
+	toggle := "⏸️ Скрыть тариф"
+	if !plan.IsActive {
+		status = "⏸️ Скрыт, при выдаче абонемента не предлагается"
+		toggle = "▶️ Вернуть тариф"
+	}
+
+	msgText := fmt.Sprintf("🎫 %s\n\n📊 Занятий: %d\n📅 Срок: %d дней\n💰 Цена: %d ₽\n🎯 Ограничения: %s\n%s",
+		plan.Name, plan.Lessons, plan.DurationDays, plan.Price, plan.Describe(b.groupNames()), status)
+
+	msg := tgbotapi.NewMessage(chatID, msgText)
+	msg.ReplyMarkup = tgbotapi.NewReplyKeyboard(
+		tgbotapi.NewKeyboardButtonRow(
+			tgbotapi.NewKeyboardButton("💰 Изменить цену"),
+			tgbotapi.NewKeyboardButton(toggle),
+		),
+		tgbotapi.NewKeyboardButtonRow(
+			tgbotapi.NewKeyboardButton("🎯 Ограничения"),
+		),
+		tgbotapi.NewKeyboardButtonRow(
+			tgbotapi.NewKeyboardButton("◀️ Назад к тарифам"),
+		),
+	)
+	b.send(msg)
+}
+
+func (b *Bot) handlePlanAction(chatID int64, messageText string) {
+	session := b.getOrCreateSession(chatID)
+
+	plan, err := b.SubscriptionService.GetPlanByID(session.SelectedPlanID)
+	if err != nil {
+		b.sendError(chatID, "❌ Тариф не найден")
+		b.showPlansList(chatID)
+		return
+	}
+
+	switch messageText {
+	case "💰 Изменить цену":
+		session.State = StateEditingPlanPrice
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("💰 Текущая цена: %d ₽\nВведите новую цену в рублях:", plan.Price))
+		msg.ReplyMarkup = createCancelKeyboard()
+		b.send(msg)
+	case "🎯 Ограничения":
+		session.State = StateEditingPlanRestrictions
+		b.showPlanRestrictionsPrompt(chatID, plan)
+	case "⏸️ Скрыть тариф", "▶️ Вернуть тариф":
+		plan.IsActive = messageText == "▶️ Вернуть тариф"
+		if err := b.SubscriptionService.UpdatePlan(plan); err != nil {
+			b.sendError(chatID, "❌ Ошибка при обновлении тарифа: "+err.Error())
+			return
+		}
+		if plan.IsActive {
+			b.sendMessage(chatID, fmt.Sprintf("▶️ Тариф «%s» снова выдаётся", plan.Name))
+		} else {
+			b.sendMessage(chatID, fmt.Sprintf("⏸️ Тариф «%s» скрыт. Уже выданные абонементы продолжают действовать.", plan.Name))
+		}
+		b.showPlansList(chatID)
+	case "◀️ Назад к тарифам":
+		b.showPlansList(chatID)
+	case "❌ Отмена":
+		b.cancelOperation(chatID, nil)
+	default:
+		b.sendError(chatID, "❌ Неизвестная команда")
+	}
+}
+
+func (b *Bot) handlePlanPriceEdit(chatID int64, messageText string) {
+	if messageText == "❌ Отмена" {
+		b.showPlansList(chatID)
+		return
+	}
+
+	price, err := strconv.Atoi(strings.TrimSpace(messageText))
+	if err != nil || price < 0 {
+		b.sendError(chatID, "❌ Введите цену числом, например 4000")
+		return
+	}
+
+	session := b.getOrCreateSession(chatID)
+	plan, err := b.SubscriptionService.GetPlanByID(session.SelectedPlanID)
+	if err != nil {
+		b.sendError(chatID, "❌ Тариф не найден")
+		b.showPlansList(chatID)
+		return
+	}
+
+	plan.Price = price
+	if err := b.SubscriptionService.UpdatePlan(plan); err != nil {
+		b.sendError(chatID, "❌ Ошибка при обновлении тарифа: "+err.Error())
+		return
+	}
+
+	b.sendMessage(chatID, fmt.Sprintf("✅ Цена тарифа «%s»: %d ₽", plan.Name, plan.Price))
+	b.showPlansList(chatID)
+}
+
+func (b *Bot) showPlanRestrictionsPrompt(chatID int64, plan *models.SubscriptionPlan) {
+	groups, err := b.TrainingGroupService.GetAllGroups()
+	if err != nil {
+		b.sendError(chatID, "❌ Ошибка при получении групп")
+		b.showPlansList(chatID)
+		return
+	}
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("🎯 Ограничения тарифа «%s»\n", plan.Name))
+	text.WriteString("Сейчас: " + plan.Describe(b.groupNames()) + "\n\n")
+	text.WriteString("Отправьте условия, каждое с новой строки (ненужные строки можно пропустить):\n")
+	text.WriteString("группы: 1, 3\nдни: пн, ср, пт\nвремя: 18:00-22:00\n\n")
+	text.WriteString("Время можно ограничить с одной стороны: «18:00-» или «-14:00».\n\n")
+	text.WriteString("Группы:\n")
+	for i, group := range groups {
+		text.WriteString(fmt.Sprintf("%d. %s\n", i+1, group.Name))
+	}
+	text.WriteString("\nЧтобы снять ограничения, отправьте «нет». ")
+	text.WriteString("Уже выданные абонементы сохраняют прежние условия.")
+
+	msg := tgbotapi.NewMessage(chatID, text.String())
+	msg.ReplyMarkup = createCancelKeyboard()
+	b.send(msg)
+}
+
+func (b *Bot) handlePlanRestrictionsEdit(chatID int64, messageText string) {
+	if messageText == "❌ Отмена" {
+		b.showPlansList(chatID)
+		return
+	}
+
+	session := b.getOrCreateSession(chatID)
+	plan, err := b.SubscriptionService.GetPlanByID(session.SelectedPlanID)
+	if err != nil {
+		b.sendError(chatID, "❌ Тариф не найден")
+		b.showPlansList(chatID)
+		return
+	}
+	groups, err := b.TrainingGroupService.GetAllGroups()
+	if err != nil {
+		b.sendError(chatID, "❌ Ошибка при получении групп")
+		return
+	}
+
+	restrictions, err := parseRestrictions(messageText, groups)
+	if err != nil {
+		b.sendError(chatID, "❌ "+err.Error())
+		return
+	}
+
+	plan.SubscriptionRestrictions = restrictions
+	if err := b.SubscriptionService.UpdatePlan(plan); err != nil {
+		b.sendError(chatID, "❌ Ошибка при обновлении тарифа: "+err.Error())
+		return
+	}
+
+	b.sendMessage(chatID, fmt.Sprintf("✅ Ограничения тарифа «%s»: %s", plan.Name, plan.Describe(b.groupNames())))
+	b.showPlansList(chatID)
+}
+
+// parseRestrictions разбирает ограничения в формате "группы: 1, 3", "дни: пн, ср",
+// "время: 18:00-22:00" по строке на условие; группы указываются номерами из списка groups
+func parseRestrictions(text string, groups []models.TrainingGroup) (models.SubscriptionRestrictions, error) {
+	var restrictions models.SubscriptionRestrictions
+	text = strings.TrimSpace(text)
+	if strings.EqualFold(text, "нет") {
+		return restrictions, nil
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return restrictions, fmt.Errorf("не понимаю строку «%s»", line)
+		}
+		value = strings.TrimSpace(value)
+
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "группы":
+			for _, item := range strings.Split(value, ",") {
+				index, err := strconv.Atoi(strings.TrimSpace(item))
+				if err != nil || index < 1 || index > len(groups) {
+					return restrictions, fmt.Errorf("нет группы с номером «%s»", strings.TrimSpace(item))
+				}
+				restrictions.GroupIDs = append(restrictions.GroupIDs, int64(groups[index-1].ID))
+			}
+		case "дни":
+			for _, item := range strings.Split(value, ",") {
+				day := slices.Index(models.WeekdayNames[:], strings.ToLower(strings.TrimSpace(item)))
+				if day < 0 {
+					return restrictions, fmt.Errorf("не понимаю день «%s», используйте пн, вт, ср, чт, пт, сб, вс", strings.TrimSpace(item))
+				}
+				restrictions.Weekdays = append(restrictions.Weekdays, int64(day))
+			}
+		case "время":
+			from, to, _ := strings.Cut(value, "-")
+			var err error
+			if restrictions.TimeFrom, err = parseClock(from); err != nil {
+				return restrictions, err
+			}
+			if restrictions.TimeTo, err = parseClock(to); err != nil {
+				return restrictions, err
+			}
+			if restrictions.TimeFrom == nil && restrictions.TimeTo == nil {
+				return restrictions, errors.New("укажите время в формате 18:00-22:00")
+			}
+		default:
+			return restrictions, fmt.Errorf("не понимаю условие «%s», используйте группы, дни или время", strings.TrimSpace(key))
+		}
+	}
+	return restrictions, nil
+}
+
+// parseClock время "ЧЧ:ММ"; пустая строка — без ограничения
+func parseClock(value string) (*time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	clock, err := time.Parse("15:04", value)
+	if err != nil {
+		return nil, fmt.Errorf("неверное время «%s», используйте формат ЧЧ:ММ", value)
+	}
+	return &clock, nil
+}
+
+// groupNames названия групп по ID для описания ограничений абонемента
+func (b *Bot) groupNames() map[int]string {
+	names := make(map[int]string)
+	groups, err := b.TrainingGroupService.GetAllGroups()
+	if err != nil {
+		return names
+	}
+	for _, group := range groups {
+		names[group.ID] = group.Name
+	}
+	return names
+}
+
+// handlePlanDraftInput пошаговый ввод нового тарифа: название, занятия, срок, цена
+func (b *Bot) handlePlanDraftInput(chatID int64, messageText string) {
+	session := b.getOrCreateSession(chatID)
+	if messageText == "❌ Отмена" || session.PlanDraft == nil {
+		b.showPlansList(chatID)
+		return
+	}
+	draft := session.PlanDraft
+	text := strings.TrimSpace(messageText)
+
+	switch session.State {
+	case StateEnteringPlanName:
+		if text == "" {
+			b.sendError(chatID, "❌ Название не может быть пустым")
+			return
+		}
+		draft.Name = text
+		session.State = StateEnteringPlanLessons
+		b.sendMessage(chatID, "📊 Сколько занятий входит в абонемент?")
+
+	case StateEnteringPlanLessons:
+		lessons, err := strconv.Atoi(text)
+		if err != nil || lessons <= 0 {
+			b.sendError(chatID, "❌ Введите количество занятий числом больше нуля")
+			return
+		}
+		draft.Lessons = lessons
+		session.State = StateEnteringPlanDuration
+		b.sendMessage(chatID, "📅 Сколько дней действует абонемент?")
+
+	case StateEnteringPlanDuration:
+		days, err := strconv.Atoi(text)
+		if err != nil || days <= 0 {
+			b.sendError(chatID, "❌ Введите срок в днях числом больше нуля")
+			return
+		}
+		draft.DurationDays = days
+		session.State = StateEnteringPlanPrice
+		b.sendMessage(chatID, "💰 Цена в рублях:")
+
+	case StateEnteringPlanPrice:
+		price, err := strconv.Atoi(text)
+		if err != nil || price < 0 {
+			b.sendError(chatID, "❌ Введите цену числом, например 4000")
+			return
+		}
+		draft.Price = price
+		session.State = StateConfirmingPlan
+
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("✅ Подтвердите новый тариф:\n\n🎫 %s\n%s", draft.Name, draft.Summary()))
+		msg.ReplyMarkup = tgbotapi.NewReplyKeyboard(
+			tgbotapi.NewKeyboardButtonRow(
+				tgbotapi.NewKeyboardButton("✅ Подтвердить"),
+				tgbotapi.NewKeyboardButton("❌ Отмена"),
+			),
+		)
+		b.send(msg)
+
+	case StateConfirmingPlan:
+		if messageText != "✅ Подтвердить" {
+			b.sendError(chatID, "❌ Неизвестная команда")
+			return
+		}
+		if err := b.SubscriptionService.CreatePlan(draft); err != nil {
+			b.sendError(chatID, "❌ Ошибка при создании тарифа: "+err.Error())
+			return
+		}
+		b.sendMessage(chatID, fmt.Sprintf("✅ Тариф «%s» добавлен", draft.Name))
+		b.showPlansList(chatID)
+	}
+}
@@ -38,7 +38,7 @@ func (b *Bot) handleCreateFromTemplates(chatID int64, user *models.User) {
 		),
 	)
 	msg.ReplyMarkup = keyboard
-	b.api.Send(msg)
+	b.send(msg)
 }
 
 // handleWeeksCountSelection обрабатывает выбор количества недель
@@ -99,7 +99,7 @@ func (b *Bot) handleWeeksCountSelection(chatID int64, messageText string) {
 		),
 	)
 	msg.ReplyMarkup = keyboard
-	b.api.Send(msg)
+	b.send(msg)
 }
 
 // handleWeeklyScheduleConfirmation обрабатывает подтверждение создания расписания
@@ -136,7 +136,7 @@ func (b *Bot) createWeeklySchedule(chatID int64, user *models.User) {
 
 	// Показываем сообщение о начале процесса
 	msg := tgbotapi.NewMessage(chatID, "⏳ Создаю расписание... Это может занять несколько секунд.")
-	b.api.Send(msg)
+	b.send(msg)
 
 	// Используем метод сервиса (нужно будет добавить его в интерфейс)
 	createdCount, err := b.ScheduleService.CreateTrainingsFromTemplates(
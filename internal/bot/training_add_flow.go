@@ -29,7 +29,7 @@ func (b *Bot) handleAddTraining(chatID int64, user *models.User) {
 	msg := tgbotapi.NewMessage(chatID, "👥 Выберите группу для тренировки:")
 	keyboard := b.createGroupsKeyboard(groups)
 	msg.ReplyMarkup = keyboard
-	b.api.Send(msg)
+	b.send(msg)
 }
 
 func (b *Bot) handleGroupSelection(chatID int64, messageText string) {
@@ -84,7 +84,7 @@ func (b *Bot) showDateSelection(chatID int64) {
 	)
 
 	msg.ReplyMarkup = keyboard
-	b.api.Send(msg)
+	b.send(msg)
 }
 
 func (b *Bot) handleDateSelection(chatID int64, messageText string) {
@@ -154,7 +154,7 @@ func (b *Bot) showTimeSelection(chatID int64) {
 	)
 
 	msg.ReplyMarkup = keyboard
-	b.api.Send(msg)
+	b.send(msg)
 }
 
 func (b *Bot) handleTimeSelection(chatID int64, messageText string) {
@@ -215,7 +215,7 @@ func (b *Bot) showDurationSelection(chatID int64) {
 	)
 
 	msg.ReplyMarkup = keyboard
-	b.api.Send(msg)
+	b.send(msg)
 }
 
 func (b *Bot) handleDurationSelection(chatID int64, messageText string) {
@@ -284,7 +284,7 @@ func (b *Bot) showTrainingConfirmation(chatID int64) {
 	)
 
 	msg.ReplyMarkup = keyboard
-	b.api.Send(msg)
+	b.send(msg)
 }
 
 func formatDuration(d time.Duration) string {
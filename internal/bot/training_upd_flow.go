@@ -2,6 +2,7 @@ package bot
 
 import (
 	"fmt"
+	"spectrum-club-bot/internal/notify"
 	"strconv"
 	"strings"
 	"time"
@@ -43,7 +44,7 @@ func (b *Bot) handleEditTraining(chatID int64, user *models.User) {
 	)
 
 	msg.ReplyMarkup = keyboard
-	b.api.Send(msg)
+	b.send(msg)
 }
 
 func (b *Bot) handleDateSelectionForTrainingForEdit(chatID int64, messageText string) {
@@ -114,7 +115,7 @@ func (b *Bot) handleDateSelectionForTrainingForEdit(chatID int64, messageText st
 			fmt.Sprintf("📭 У вас нет тренировок на %s",
 				selectedDate.Format("02.01.2006")))
 		msg.ReplyMarkup = createScheduleManagementKeyboard()
-		b.api.Send(msg)
+		b.send(msg)
 		b.resetSession(chatID)
 		return
 	}
@@ -149,7 +150,7 @@ func (b *Bot) showTrainingsForEdit(chatID int64, trainings []models.TrainingSche
 	msg := tgbotapi.NewMessage(chatID, msgText)
 	msg.ParseMode = "Markdown"
 	msg.ReplyMarkup = createCancelKeyboard()
-	b.api.Send(msg)
+	b.send(msg)
 }
 
 func (b *Bot) handleTrainingSelectionForEdit(chatID int64, messageText string) {
@@ -176,7 +177,7 @@ func (b *Bot) handleTrainingSelectionForEdit(chatID int64, messageText string) {
 	b.showFieldSelectionMenu(chatID, &training)
 }
 
-// Упрощенное меню редактирования - только время и место
+// Упрощенное меню редактирования - время, место, участники и отмена
 func (b *Bot) showFieldSelectionMenu(chatID int64, training *models.TrainingSchedule) {
 	group, _ := b.TrainingGroupService.GetGroupByID(training.GroupID)
 	groupName := "Неизвестная группа"
@@ -210,7 +211,8 @@ func (b *Bot) showFieldSelectionMenu(chatID int64, training *models.TrainingSche
 			tgbotapi.NewKeyboardButton("📍 Изменить место"),
 		),
 		tgbotapi.NewKeyboardButtonRow(
-			tgbotapi.NewKeyboardButton("🗑️ Удалить тренировку"),
+			tgbotapi.NewKeyboardButton("👥 Участники"),
+			tgbotapi.NewKeyboardButton("🚫 Отменить тренировку"),
 		),
 		tgbotapi.NewKeyboardButtonRow(
 			tgbotapi.NewKeyboardButton("❌ Отмена"),
@@ -218,7 +220,7 @@ func (b *Bot) showFieldSelectionMenu(chatID int64, training *models.TrainingSche
 	)
 
 	msg.ReplyMarkup = keyboard
-	b.api.Send(msg)
+	b.send(msg)
 }
 
 func (b *Bot) handleFieldSelectionForEdit(chatID int64, messageText string) {
@@ -234,9 +236,21 @@ func (b *Bot) handleFieldSelectionForEdit(chatID int64, messageText string) {
 	case "📍 Изменить место":
 		session.State = StateEditingPlace
 		b.showPlaceEditMenu(chatID)
-	case "🗑️ Удалить тренировку":
-		session.State = StateConfirmingDeletion
-		b.showDeletionTrainingConfirmation(chatID)
+	case "👥 Участники":
+		b.showTrainingParticipants(chatID, session.SelectedTrainingID)
+	case "🚫 Отменить тренировку":
+		session.State = StateEnteringTrainingCancelReason
+		session.TrainingCancelReason = ""
+		msg := tgbotapi.NewMessage(chatID, "💬 Укажите причину отмены — её увидят записавшиеся ученики:")
+		msg.ReplyMarkup = tgbotapi.NewReplyKeyboard(
+			tgbotapi.NewKeyboardButtonRow(
+				tgbotapi.NewKeyboardButton("⏭️ Без причины"),
+			),
+			tgbotapi.NewKeyboardButtonRow(
+				tgbotapi.NewKeyboardButton("❌ Отмена"),
+			),
+		)
+		b.send(msg)
 	case "❌ Отмена":
 		b.cancelOperation(chatID, nil)
 	default:
@@ -254,7 +268,7 @@ func (b *Bot) showTimeEditMenu(chatID int64) {
 
 	msg.ParseMode = "Markdown"
 	msg.ReplyMarkup = createCancelKeyboard()
-	b.api.Send(msg)
+	b.send(msg)
 }
 
 func (b *Bot) handleTimeEdit(chatID int64, messageText string) {
@@ -355,7 +369,7 @@ func (b *Bot) handleTimeEdit(chatID int64, messageText string) {
 	} else {
 		msg := tgbotapi.NewMessage(chatID, "✅ Время тренировки успешно обновлено!")
 		msg.ReplyMarkup = createScheduleManagementKeyboard()
-		b.api.Send(msg)
+		b.send(msg)
 	}
 
 	b.resetSession(chatID)
@@ -372,7 +386,7 @@ func (b *Bot) showPlaceEditMenu(chatID int64) {
 
 	msg.ParseMode = "Markdown"
 	msg.ReplyMarkup = createCancelKeyboard()
-	b.api.Send(msg)
+	b.send(msg)
 }
 
 func (b *Bot) handlePlaceEdit(chatID int64, messageText string) {
@@ -401,7 +415,7 @@ func (b *Bot) handlePlaceEdit(chatID int64, messageText string) {
 	} else {
 		msg := tgbotapi.NewMessage(chatID, "✅ Место тренировки успешно обновлено!")
 		msg.ReplyMarkup = createScheduleManagementKeyboard()
-		b.api.Send(msg)
+		b.send(msg)
 	}
 
 	b.resetSession(chatID)
@@ -440,13 +454,13 @@ func (b *Bot) showDeletionTrainingConfirmation(chatID int64) {
 	dayOfWeek := getRussianDayOfWeek(training.TrainingDate.Weekday())
 
 	msgText := fmt.Sprintf(
-		"⚠️ *Подтвердите удаление тренировки*\n\n"+
-			"Вы действительно хотите удалить эту тренировку?\n\n"+
+		"⚠️ *Подтвердите отмену тренировки*\n\n"+
+			"Вы действительно хотите отменить эту тренировку?\n\n"+
 			"📅 *%s, %s*\n"+
 			"🕐 *Время:* %s-%s\n"+
 			"👥 *Группа:* %s\n"+
 			"📍 *Место:* %s\n\n"+
-			"*Это действие невозможно отменить!*",
+			"Записавшиеся ученики получат уведомление, списанные занятия вернутся на их абонементы.",
 		dayOfWeek,
 		training.TrainingDate.Format("02.01.2006"),
 		training.StartTime.Format("15:04"),
@@ -454,19 +468,22 @@ func (b *Bot) showDeletionTrainingConfirmation(chatID int64) {
 		groupName,
 		training.Description,
 	)
+	if session.TrainingCancelReason != "" {
+		msgText += "\n💬 *Причина:* " + notify.EscapeMarkdown(session.TrainingCancelReason)
+	}
 
 	msg := tgbotapi.NewMessage(chatID, msgText)
 	msg.ParseMode = "Markdown"
 
 	keyboard := tgbotapi.NewReplyKeyboard(
 		tgbotapi.NewKeyboardButtonRow(
-			tgbotapi.NewKeyboardButton("✅ Да, удалить"),
+			tgbotapi.NewKeyboardButton("✅ Да, отменить"),
 			tgbotapi.NewKeyboardButton("❌ Нет, отменить"),
 		),
 	)
 
 	msg.ReplyMarkup = keyboard
-	b.api.Send(msg)
+	b.send(msg)
 }
 
 func (b *Bot) handleDeletionConfirmation(chatID int64, messageText string) {
@@ -476,8 +493,8 @@ func (b *Bot) handleDeletionConfirmation(chatID int64, messageText string) {
 	}
 
 	switch messageText {
-	case "✅ Да, удалить":
-		b.deleteTraining(chatID, session)
+	case "✅ Да, отменить":
+		b.cancelTraining(chatID, session)
 	case "❌ Нет, отменить":
 		// Возвращаемся к меню редактирования
 		training, err := b.ScheduleService.GetTrainingByID(session.SelectedTrainingID)
@@ -493,7 +510,24 @@ func (b *Bot) handleDeletionConfirmation(chatID int64, messageText string) {
 	}
 }
 
-func (b *Bot) deleteTraining(chatID int64, session *UserSession) {
+func (b *Bot) handleTrainingCancelReason(chatID int64, messageText string) {
+	session := b.getOrCreateSession(chatID)
+
+	switch messageText {
+	case "❌ Отмена":
+		b.cancelOperation(chatID, nil)
+		return
+	case "⏭️ Без причины":
+		session.TrainingCancelReason = ""
+	default:
+		session.TrainingCancelReason = strings.TrimSpace(messageText)
+	}
+
+	session.State = StateConfirmingDeletion
+	b.showDeletionTrainingConfirmation(chatID)
+}
+
+func (b *Bot) cancelTraining(chatID int64, session *UserSession) {
 	// Получаем информацию о тренировке для сообщения
 	training, err := b.ScheduleService.GetTrainingByID(session.SelectedTrainingID)
 	if err != nil {
@@ -507,13 +541,13 @@ func (b *Bot) deleteTraining(chatID int64, session *UserSession) {
 		groupName = group.Name
 	}
 
-	// Удаляем тренировку
-	err = b.ScheduleService.DeleteTraining(session.SelectedTrainingID)
+	// Тренировка остаётся в истории, записи отменяются, занятия возвращаются
+	err = b.AttendanceService.CancelTraining(session.SelectedTrainingID, session.TrainingCancelReason, b.currentUserID(chatID))
 	if err != nil {
-		b.sendError(chatID, "❌ Ошибка при удалении тренировки: "+err.Error())
+		b.sendError(chatID, "❌ Ошибка при отмене тренировки: "+err.Error())
 	} else {
 		msg := tgbotapi.NewMessage(chatID,
-			fmt.Sprintf("✅ Тренировка успешно удалена!\n\n"+
+			fmt.Sprintf("✅ Тренировка отменена, ученики получат уведомление.\n\n"+
 				"📅 *%s, %s*\n"+
 				"🕐 *Время:* %s-%s\n"+
 				"👥 *Группа:* %s",
@@ -524,7 +558,7 @@ func (b *Bot) deleteTraining(chatID int64, session *UserSession) {
 				groupName))
 		msg.ParseMode = "Markdown"
 		msg.ReplyMarkup = createScheduleManagementKeyboard()
-		b.api.Send(msg)
+		b.send(msg)
 	}
 
 	b.resetSession(chatID)
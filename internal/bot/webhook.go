@@ -0,0 +1,76 @@
+package bot
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// webhookSecretHeader заголовок, в котором Telegram передаёт secret_token из setWebhook
+const webhookSecretHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// WebhookPath путь вебхука на HTTP-сервере. Производный от токена, чтобы адрес
+// нельзя было угадать; сам запрос дополнительно проверяется по secret_token.
+func (b *Bot) WebhookPath() string {
+	sum := sha256.Sum256([]byte(b.api.Token))
+	return "/telegram/webhook/" + hex.EncodeToString(sum[:16])
+}
+
+// WebhookHandler принимает обновления от Telegram и передаёт их в общую обработку
+func (b *Bot) WebhookHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		secret := r.Header.Get(webhookSecretHeader)
+		if subtle.ConstantTimeCompare([]byte(secret), []byte(b.webhookSecret)) != 1 {
+			log.Printf("⚠️ Вебхук: запрос с неверным secret_token от %s", r.RemoteAddr)
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		var update tgbotapi.Update
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			log.Printf("⚠️ Вебхук: не удалось разобрать обновление: %v", err)
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+
+		// Отвечаем сразу: Telegram ждёт ответ ограниченное время и при таймауте
+		// повторит доставку, а обработка диалога может ходить в БД и Bot API
+		w.WriteHeader(http.StatusOK)
+		go b.handleUpdate(update)
+	})
+}
+
+// registerWebhook сообщает Telegram адрес вебхука вместе с secret_token.
+// tgbotapi v4 не умеет передавать secret_token, поэтому вызываем метод напрямую.
+func (b *Bot) registerWebhook() error {
+	baseURL := strings.TrimRight(b.webhookURL, "/")
+	if !strings.HasPrefix(baseURL, "http://") && !strings.HasPrefix(baseURL, "https://") {
+		baseURL = "https://" + baseURL
+	}
+	webhookURL := baseURL + b.WebhookPath()
+
+	params := url.Values{}
+	params.Set("url", webhookURL)
+	params.Set("secret_token", b.webhookSecret)
+
+	if _, err := b.api.MakeRequest("setWebhook", params); err != nil {
+		return fmt.Errorf("ошибка регистрации вебхука: %w", err)
+	}
+
+	// Полный путь не логируем: он производный от токена
+	log.Printf("🪝 Вебхук зарегистрирован на %s", baseURL)
+	return nil
+}
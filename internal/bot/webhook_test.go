@@ -0,0 +1,75 @@
+package bot
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWebhookHandler(t *testing.T) {
+	const secret = "webhook-secret"
+	update := `{"update_id":1,"message":{"message_id":1,"from":{"id":-1},"chat":{"id":-1,"type":"private"},"text":"➕ Добавить абонемент"}}`
+
+	tests := []struct {
+		name       string
+		method     string
+		secret     string
+		body       string
+		wantStatus int
+		wantReply  bool
+	}{
+		{name: "не POST", method: http.MethodGet, secret: secret, wantStatus: http.StatusMethodNotAllowed},
+		{name: "без secret_token", method: http.MethodPost, body: update, wantStatus: http.StatusForbidden},
+		{name: "неверный secret_token", method: http.MethodPost, secret: "wrong", body: update, wantStatus: http.StatusForbidden},
+		{name: "неразборчивое тело", method: http.MethodPost, secret: secret, body: "{", wantStatus: http.StatusBadRequest},
+		{name: "обновление", method: http.MethodPost, secret: secret, body: update, wantStatus: http.StatusOK, wantReply: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tb := newTestBot(t)
+			tb.bot.webhookSecret = secret
+
+			req := httptest.NewRequest(tt.method, tb.bot.WebhookPath(), strings.NewReader(tt.body))
+			if tt.secret != "" {
+				req.Header.Set(webhookSecretHeader, tt.secret)
+			}
+			rec := httptest.NewRecorder()
+			tb.bot.WebhookHandler().ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("статус = %d, want %d", rec.Code, tt.wantStatus)
+			}
+
+			if !tt.wantReply {
+				// Отклонённое обновление не должно дойти до обработки
+				time.Sleep(50 * time.Millisecond)
+				if sent := tb.srv.SentMessages(); len(sent) != 0 {
+					t.Errorf("бот ответил на отклонённый запрос: %+v", sent)
+				}
+				return
+			}
+			replies, err := tb.srv.WaitForMessages(1, 2*time.Second)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if replies[0].ChatID != demoCoachChatID || !strings.Contains(replies[0].Text, "1. Анна Иванова") {
+				t.Errorf("ответ = %+v", replies[0])
+			}
+		})
+	}
+}
+
+func TestStartPollingRemovesWebhook(t *testing.T) {
+	tb := newTestBot(t)
+
+	// Вебхук снимается до первого getUpdates: бот, получивший обновление, его уже снял
+	tb.say(demoCoachChatID, "➕ Добавить абонемент", 1)
+
+	calls := tb.srv.Requests("setWebhook")
+	if len(calls) != 1 || calls[0].Params.Get("url") != "" {
+		t.Errorf("setWebhook = %+v, want один вызов без url", calls)
+	}
+}
@@ -0,0 +1,101 @@
+package checkin
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// payloadPrefix параметр /start самоотметки: checkin_<токен>
+const payloadPrefix = "checkin_"
+
+// Токен — id тренировки и срок действия (по 4 байта) плюс усечённая HMAC-подпись.
+// В base64url это 32 символа: вместе с префиксом укладывается в лимит Telegram
+// на параметр start (64 символа из A-Z, a-z, 0-9, _ и -)
+const (
+	claimsSize    = 8
+	signatureSize = 16
+	qrImageSize   = 512
+)
+
+var (
+	ErrInvalidToken = errors.New("QR-код недействителен")
+	ErrExpiredToken = errors.New("QR-код устарел, попросите тренера показать новый")
+)
+
+// Signer выпускает и проверяет подписанные токены самоотметки
+type Signer struct {
+	key []byte
+	ttl time.Duration
+}
+
+// NewSigner ключ подписи выводится из секрета, чтобы токен бота не использовался напрямую
+func NewSigner(secret string, ttl time.Duration) *Signer {
+	mac := hmac.New(sha256.New, []byte("checkin"))
+	mac.Write([]byte(secret))
+	return &Signer{key: mac.Sum(nil), ttl: ttl}
+}
+
+// Issue токен самоотметки на тренировку, действующий ttl с момента now
+func (s *Signer) Issue(trainingID int, now time.Time) (token string, expiresAt time.Time) {
+	expiresAt = now.Add(s.ttl).Truncate(time.Second)
+
+	claims := make([]byte, claimsSize, claimsSize+signatureSize)
+	binary.BigEndian.PutUint32(claims[:4], uint32(trainingID))
+	binary.BigEndian.PutUint32(claims[4:], uint32(expiresAt.Unix()))
+	return base64.RawURLEncoding.EncodeToString(append(claims, s.sign(claims)...)), expiresAt
+}
+
+// Verify проверяет подпись и срок действия токена и возвращает id тренировки
+func (s *Signer) Verify(token string, now time.Time) (int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(raw) != claimsSize+signatureSize {
+		return 0, ErrInvalidToken
+	}
+	claims, signature := raw[:claimsSize], raw[claimsSize:]
+	if !hmac.Equal(signature, s.sign(claims)) {
+		return 0, ErrInvalidToken
+	}
+
+	expiresAt := time.Unix(int64(binary.BigEndian.Uint32(claims[4:])), 0)
+	if now.After(expiresAt) {
+		return 0, ErrExpiredToken
+	}
+	return int(binary.BigEndian.Uint32(claims[:4])), nil
+}
+
+func (s *Signer) sign(claims []byte) []byte {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write(claims)
+	return mac.Sum(nil)[:signatureSize]
+}
+
+// DeepLink ссылка, открывающая бота с командой /start checkin_<токен>
+func DeepLink(botUserName, token string) string {
+	return fmt.Sprintf("https://t.me/%s?start=%s%s", botUserName, payloadPrefix, token)
+}
+
+// ParseStartPayload достаёт токен из аргументов команды /start
+func ParseStartPayload(args string) (token string, ok bool) {
+	token, found := strings.CutPrefix(strings.TrimSpace(args), payloadPrefix)
+	if !found || token == "" {
+		return "", false
+	}
+	return token, true
+}
+
+// QRCode PNG с QR-кодом ссылки
+func QRCode(link string) ([]byte, error) {
+	png, err := qrcode.Encode(link, qrcode.Medium, qrImageSize)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка генерации QR-кода: %w", err)
+	}
+	return png, nil
+}
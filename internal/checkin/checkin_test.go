@@ -0,0 +1,85 @@
+package checkin
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestIssueVerify(t *testing.T) {
+	now := time.Date(2030, 3, 10, 19, 0, 0, 0, time.UTC)
+	signer := NewSigner("секрет", 10*time.Minute)
+	token, expiresAt := signer.Issue(42, now)
+
+	if want := now.Add(10 * time.Minute); !expiresAt.Equal(want) {
+		t.Fatalf("expiresAt = %v, want %v", expiresAt, want)
+	}
+	if len(payloadPrefix+token) > 64 {
+		t.Fatalf("параметр start длиной %d не влезает в лимит Telegram", len(payloadPrefix+token))
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := append([]byte(nil), raw...)
+	tampered[len(tampered)-1] ^= 0xff
+	forged := append([]byte(nil), raw...)
+	forged[3]++
+
+	tests := []struct {
+		name   string
+		signer *Signer
+		token  string
+		now    time.Time
+		wantID int
+		want   error
+	}{
+		{name: "действующий", signer: signer, token: token, now: now, wantID: 42},
+		{name: "ровно в момент истечения", signer: signer, token: token, now: expiresAt, wantID: 42},
+		{name: "просрочен", signer: signer, token: token, now: expiresAt.Add(time.Second), want: ErrExpiredToken},
+		{name: "подпись изменена", signer: signer, token: base64.RawURLEncoding.EncodeToString(tampered), now: now, want: ErrInvalidToken},
+		{name: "подменена тренировка", signer: signer, token: base64.RawURLEncoding.EncodeToString(forged), now: now, want: ErrInvalidToken},
+		{name: "чужой секрет", signer: NewSigner("другой", 10*time.Minute), token: token, now: now, want: ErrInvalidToken},
+		{name: "короткий", signer: signer, token: base64.RawURLEncoding.EncodeToString(raw[:len(raw)-1]), now: now, want: ErrInvalidToken},
+		{name: "длинный", signer: signer, token: base64.RawURLEncoding.EncodeToString(append(raw, 0)), now: now, want: ErrInvalidToken},
+		{name: "не base64", signer: signer, token: "!!!", now: now, want: ErrInvalidToken},
+		{name: "пустой", signer: signer, token: "", now: now, want: ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := tt.signer.Verify(tt.token, tt.now)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.want)
+			}
+			if id != tt.wantID {
+				t.Errorf("Verify() = %d, want %d", id, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestParseStartPayload(t *testing.T) {
+	tests := []struct {
+		args      string
+		wantToken string
+		wantOK    bool
+	}{
+		{args: "checkin_abc", wantToken: "abc", wantOK: true},
+		{args: "  checkin_abc \n", wantToken: "abc", wantOK: true},
+		{args: ""},
+		{args: "checkin_"},
+		{args: "  checkin_  "},
+		{args: "abc"},
+		{args: "pay_abc"},
+		{args: "CHECKIN_abc"},
+		{args: "xcheckin_abc"},
+	}
+	for _, tt := range tests {
+		token, ok := ParseStartPayload(tt.args)
+		if token != tt.wantToken || ok != tt.wantOK {
+			t.Errorf("ParseStartPayload(%q) = %q, %v, want %q, %v", tt.args, token, ok, tt.wantToken, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package migrations
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+//go:embed sql/*.sql
+var files embed.FS
+
+// lockID ключ advisory lock, чтобы две реплики не накатывали миграции одновременно
+const lockID = 7_301_245_001
+
+// Migration одна версия схемы: пара файлов NNNN_name.up.sql / NNNN_name.down.sql
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus состояние миграции в конкретной базе
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Load читает встроенные миграции и сортирует их по версии
+func Load() ([]Migration, error) {
+	entries, err := fs.ReadDir(files, "sql")
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения каталога миграций: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		fileName := entry.Name()
+
+		var direction string
+		switch {
+		case strings.HasSuffix(fileName, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(fileName, ".down.sql"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("неизвестный файл миграции: %s", fileName)
+		}
+
+		base := strings.TrimSuffix(fileName, "."+direction+".sql")
+		versionStr, name, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("неверное имя файла миграции: %s", fileName)
+		}
+		version, err := strconv.ParseInt(versionStr, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("неверная версия миграции %s: %w", fileName, err)
+		}
+
+		body, err := files.ReadFile(path.Join("sql", fileName))
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения миграции %s: %w", fileName, err)
+		}
+
+		m, exists := byVersion[version]
+		if !exists {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("версия %d используется двумя миграциями: %s и %s", version, m.Name, name)
+		}
+
+		if direction == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	result := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("у миграции %04d_%s нет up-файла", m.Version, m.Name)
+		}
+		result = append(result, *m)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Version < result[j].Version })
+
+	return result, nil
+}
+
+// Up применяет все ещё не применённые миграции и возвращает их количество
+func Up(db *sqlx.DB) (int, error) {
+	all, err := Load()
+	if err != nil {
+		return 0, err
+	}
+	if err := ensureVersionTable(db); err != nil {
+		return 0, err
+	}
+
+	applied := 0
+	for _, m := range all {
+		done, err := apply(db, m)
+		if err != nil {
+			return applied, err
+		}
+		if done {
+			applied++
+		}
+	}
+
+	return applied, nil
+}
+
+// Down откатывает последние steps применённых миграций
+func Down(db *sqlx.DB, steps int) (int, error) {
+	if steps <= 0 {
+		return 0, nil
+	}
+
+	all, err := Load()
+	if err != nil {
+		return 0, err
+	}
+	if err := ensureVersionTable(db); err != nil {
+		return 0, err
+	}
+
+	byVersion := make(map[int64]Migration, len(all))
+	for _, m := range all {
+		byVersion[m.Version] = m
+	}
+
+	reverted := 0
+	for reverted < steps {
+		done, err := revertLatest(db, byVersion)
+		if err != nil {
+			return reverted, err
+		}
+		if !done {
+			break
+		}
+		reverted++
+	}
+
+	return reverted, nil
+}
+
+// Status возвращает список всех миграций с отметкой о применении
+func Status(db *sqlx.DB) ([]MigrationStatus, error) {
+	all, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureVersionTable(db); err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		Version   int64     `db:"version"`
+		AppliedAt time.Time `db:"applied_at"`
+	}
+	if err := db.Select(&rows, `SELECT version, applied_at FROM spectrum.schema_migrations`); err != nil {
+		return nil, fmt.Errorf("ошибка чтения версий схемы: %w", err)
+	}
+
+	appliedAt := make(map[int64]time.Time, len(rows))
+	for _, row := range rows {
+		appliedAt[row.Version] = row.AppliedAt
+	}
+
+	result := make([]MigrationStatus, 0, len(all))
+	for _, m := range all {
+		status := MigrationStatus{Migration: m}
+		if t, ok := appliedAt[m.Version]; ok {
+			status.AppliedAt = &t
+		}
+		result = append(result, status)
+	}
+
+	return result, nil
+}
+
+func ensureVersionTable(db *sqlx.DB) error {
+	_, err := db.Exec(`
+		CREATE SCHEMA IF NOT EXISTS spectrum;
+		CREATE TABLE IF NOT EXISTS spectrum.schema_migrations (
+			version    BIGINT PRIMARY KEY,
+			name       VARCHAR(255) NOT NULL,
+			applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("ошибка создания таблицы версий схемы: %w", err)
+	}
+	return nil
+}
+
+// apply применяет одну миграцию в отдельной транзакции.
+// Под блокировкой повторно проверяем версию: её могла применить соседняя реплика.
+func apply(db *sqlx.DB, m Migration) (bool, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock($1)`, lockID); err != nil {
+		return false, fmt.Errorf("ошибка блокировки миграций: %w", err)
+	}
+
+	var exists bool
+	if err := tx.Get(&exists, `SELECT EXISTS(SELECT 1 FROM spectrum.schema_migrations WHERE version = $1)`, m.Version); err != nil {
+		return false, err
+	}
+	if exists {
+		return false, nil
+	}
+
+	if _, err := tx.Exec(m.Up); err != nil {
+		return false, fmt.Errorf("ошибка применения миграции %04d_%s: %w", m.Version, m.Name, err)
+	}
+	if _, err := tx.Exec(`INSERT INTO spectrum.schema_migrations (version, name) VALUES ($1, $2)`, m.Version, m.Name); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+func revertLatest(db *sqlx.DB, byVersion map[int64]Migration) (bool, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock($1)`, lockID); err != nil {
+		return false, fmt.Errorf("ошибка блокировки миграций: %w", err)
+	}
+
+	var version int64
+	err = tx.Get(&version, `SELECT version FROM spectrum.schema_migrations ORDER BY version DESC LIMIT 1`)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	m, ok := byVersion[version]
+	if !ok {
+		return false, fmt.Errorf("применённая миграция %d отсутствует в сборке", version)
+	}
+	if m.Down == "" {
+		return false, fmt.Errorf("миграция %04d_%s не поддерживает откат", m.Version, m.Name)
+	}
+
+	if _, err := tx.Exec(m.Down); err != nil {
+		return false, fmt.Errorf("ошибка отката миграции %04d_%s: %w", m.Version, m.Name, err)
+	}
+	if _, err := tx.Exec(`DELETE FROM spectrum.schema_migrations WHERE version = $1`, m.Version); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS spectrum.week_schedule_templates;
+DROP TABLE IF EXISTS spectrum.attendance;
+DROP TABLE IF EXISTS spectrum.training_schedule;
+DROP TABLE IF EXISTS spectrum.training_groups;
+DROP TABLE IF EXISTS spectrum.subscriptions;
+DROP TABLE IF EXISTS spectrum.coaches;
+DROP TABLE IF EXISTS spectrum.students;
+DROP TABLE IF EXISTS spectrum.users;
@@ -0,0 +1,114 @@
+-- Базовая схема. Все операторы идемпотентны, чтобы миграция применялась
+-- как к пустой базе, так и к уже существующей продовой схеме.
+
+CREATE TABLE IF NOT EXISTS spectrum.users (
+    id            BIGSERIAL PRIMARY KEY,
+    telegram_id   BIGINT       NOT NULL,
+    first_name    VARCHAR(255) NOT NULL DEFAULT '',
+    last_name     VARCHAR(255) NOT NULL DEFAULT '',
+    username      VARCHAR(255) NOT NULL DEFAULT '',
+    role          VARCHAR(32)  NOT NULL DEFAULT 'student',
+    registered_at TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at    TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_telegram_id_uidx ON spectrum.users (telegram_id);
+CREATE INDEX IF NOT EXISTS users_role_idx ON spectrum.users (role);
+
+CREATE TABLE IF NOT EXISTS spectrum.students (
+    id             BIGSERIAL PRIMARY KEY,
+    user_id        BIGINT       NOT NULL REFERENCES spectrum.users (id) ON DELETE CASCADE,
+    athletic_title VARCHAR(255) NOT NULL DEFAULT '',
+    created_at     TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at     TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS students_user_id_uidx ON spectrum.students (user_id);
+
+CREATE TABLE IF NOT EXISTS spectrum.coaches (
+    id          BIGSERIAL PRIMARY KEY,
+    user_id     BIGINT       NOT NULL REFERENCES spectrum.users (id) ON DELETE CASCADE,
+    specialty   VARCHAR(255) NOT NULL DEFAULT '',
+    experience  VARCHAR(255) NOT NULL DEFAULT '',
+    description TEXT         NOT NULL DEFAULT '',
+    created_at  TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at  TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS coaches_user_id_uidx ON spectrum.coaches (user_id);
+
+CREATE TABLE IF NOT EXISTS spectrum.subscriptions (
+    id                BIGSERIAL PRIMARY KEY,
+    student_id        BIGINT    NOT NULL REFERENCES spectrum.students (id) ON DELETE CASCADE,
+    start_date        TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    end_date          TIMESTAMP,
+    total_lessons     INT       NOT NULL CHECK (total_lessons >= 0),
+    remaining_lessons INT       NOT NULL CHECK (remaining_lessons >= 0),
+    created_at        TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Колонка days_left была в первоначальном DDL, но код её никогда не использовал,
+-- а SELECT * в репозитории ломается на лишних колонках.
+ALTER TABLE spectrum.subscriptions DROP COLUMN IF EXISTS days_left;
+
+CREATE INDEX IF NOT EXISTS subscriptions_student_id_idx ON spectrum.subscriptions (student_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS spectrum.training_groups (
+    id          SERIAL PRIMARY KEY,
+    name        VARCHAR(255) NOT NULL,
+    code        VARCHAR(64)  NOT NULL,
+    age_min     INT          NOT NULL DEFAULT 0,
+    age_max     INT,
+    description TEXT         NOT NULL DEFAULT '',
+    created_at  TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS training_groups_code_uidx ON spectrum.training_groups (code);
+
+CREATE TABLE IF NOT EXISTS spectrum.training_schedule (
+    id               SERIAL PRIMARY KEY,
+    group_id         INT       NOT NULL REFERENCES spectrum.training_groups (id),
+    coach_id         BIGINT    REFERENCES spectrum.coaches (id) ON DELETE SET NULL,
+    training_date    DATE      NOT NULL,
+    start_time       TIME      NOT NULL,
+    end_time         TIME      NOT NULL,
+    description      TEXT      NOT NULL DEFAULT '',
+    max_participants INT       CHECK (max_participants IS NULL OR max_participants > 0),
+    created_by       BIGINT    REFERENCES spectrum.users (id) ON DELETE SET NULL,
+    created_at       TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at       TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS training_schedule_date_idx ON spectrum.training_schedule (training_date, start_time);
+CREATE INDEX IF NOT EXISTS training_schedule_coach_date_idx ON spectrum.training_schedule (coach_id, training_date);
+CREATE INDEX IF NOT EXISTS training_schedule_group_date_idx ON spectrum.training_schedule (group_id, training_date);
+
+CREATE TABLE IF NOT EXISTS spectrum.attendance (
+    id          SERIAL PRIMARY KEY,
+    training_id INT         NOT NULL REFERENCES spectrum.training_schedule (id) ON DELETE CASCADE,
+    student_id  BIGINT      NOT NULL REFERENCES spectrum.students (id) ON DELETE CASCADE,
+    status      VARCHAR(32) NOT NULL DEFAULT 'registered',
+    attended    BOOLEAN     NOT NULL DEFAULT FALSE,
+    notes       TEXT        NOT NULL DEFAULT '',
+    recorded_by BIGINT      REFERENCES spectrum.users (id) ON DELETE SET NULL,
+    recorded_at TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at  TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at  TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS attendance_training_student_uidx ON spectrum.attendance (training_id, student_id);
+CREATE INDEX IF NOT EXISTS attendance_student_status_idx ON spectrum.attendance (student_id, status);
+
+CREATE TABLE IF NOT EXISTS spectrum.week_schedule_templates (
+    id          SERIAL PRIMARY KEY,
+    group_id    INT       NOT NULL REFERENCES spectrum.training_groups (id) ON DELETE CASCADE,
+    day_of_week INT       NOT NULL CHECK (day_of_week BETWEEN 1 AND 7),
+    start_time  TIME      NOT NULL,
+    end_time    TIME      NOT NULL,
+    description TEXT      NOT NULL DEFAULT '',
+    is_active   BOOLEAN   NOT NULL DEFAULT TRUE,
+    created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS week_schedule_templates_active_idx ON spectrum.week_schedule_templates (is_active, day_of_week);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS spectrum.bot_sessions (
+    chat_id    BIGINT PRIMARY KEY,
+    state      INT       NOT NULL DEFAULT 0,
+    data       JSONB     NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS bot_sessions_updated_at_idx ON spectrum.bot_sessions (updated_at);
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS spectrum.notification_outbox (
+    id              BIGSERIAL PRIMARY KEY,
+    chat_id         BIGINT      NOT NULL,
+    method          VARCHAR(64) NOT NULL,
+    payload         JSONB       NOT NULL,
+    status          VARCHAR(16) NOT NULL DEFAULT 'pending',
+    attempts        INT         NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_error      TEXT        NOT NULL DEFAULT '',
+    created_at      TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    sent_at         TIMESTAMP
+);
+
+-- Выборка готовых к отправке и проверка "нет более раннего сообщения в этот чат"
+CREATE INDEX IF NOT EXISTS notification_outbox_pending_idx
+    ON spectrum.notification_outbox (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS notification_outbox_pending_chat_idx
+    ON spectrum.notification_outbox (chat_id, id) WHERE status = 'pending';
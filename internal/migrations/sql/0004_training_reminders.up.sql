@@ -0,0 +1,7 @@
+-- Отправленные напоминания: по одному на запись и смещение, чтобы не слать повторно после рестарта
+CREATE TABLE IF NOT EXISTS spectrum.training_reminders (
+    attendance_id  INT       NOT NULL REFERENCES spectrum.attendance (id) ON DELETE CASCADE,
+    offset_minutes INT       NOT NULL,
+    sent_at        TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (attendance_id, offset_minutes)
+);
@@ -0,0 +1,18 @@
+-- Лист ожидания на заполненные тренировки
+CREATE TABLE IF NOT EXISTS spectrum.training_waitlist (
+    id               SERIAL PRIMARY KEY,
+    training_id      INT         NOT NULL REFERENCES spectrum.training_schedule (id) ON DELETE CASCADE,
+    student_id       BIGINT      NOT NULL REFERENCES spectrum.students (id) ON DELETE CASCADE,
+    status           VARCHAR(16) NOT NULL DEFAULT 'waiting',
+    offer_expires_at TIMESTAMP,
+    created_at       TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at       TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- В очереди тренировки ученик может стоять только один раз; история (accepted, expired, ...) не мешает встать снова
+CREATE UNIQUE INDEX IF NOT EXISTS training_waitlist_active_uidx
+    ON spectrum.training_waitlist (training_id, student_id) WHERE status IN ('waiting', 'offered');
+CREATE INDEX IF NOT EXISTS training_waitlist_queue_idx
+    ON spectrum.training_waitlist (training_id, id) WHERE status = 'waiting';
+CREATE INDEX IF NOT EXISTS training_waitlist_offers_idx
+    ON spectrum.training_waitlist (offer_expires_at) WHERE status = 'offered';
@@ -0,0 +1,2 @@
+ALTER TABLE spectrum.subscriptions DROP COLUMN IF EXISTS plan_id;
+DROP TABLE IF EXISTS spectrum.subscription_plans;
@@ -0,0 +1,24 @@
+-- Тарифы абонементов: раньше были зашиты в код (Create12Unlimited и т.п.)
+CREATE TABLE IF NOT EXISTS spectrum.subscription_plans (
+    id            BIGSERIAL PRIMARY KEY,
+    name          VARCHAR(255) NOT NULL,
+    lessons       INT          NOT NULL CHECK (lessons > 0),
+    duration_days INT          NOT NULL CHECK (duration_days > 0),
+    price         INT          NOT NULL DEFAULT 0 CHECK (price >= 0),
+    is_active     BOOLEAN      NOT NULL DEFAULT TRUE,
+    created_at    TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at    TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS subscription_plans_name_uidx ON spectrum.subscription_plans (name);
+
+-- Прежние зашитые тарифы; цену тренеры выставят в боте
+INSERT INTO spectrum.subscription_plans (name, lessons, duration_days)
+VALUES
+    ('⛰️ Пробное занятие', 1, 30),
+    ('💪 12 занятий (несгораемый)', 12, 730),
+    ('⛏️ 16 занятий на 30 дней', 16, 30)
+ON CONFLICT (name) DO NOTHING;
+
+ALTER TABLE spectrum.subscriptions
+    ADD COLUMN IF NOT EXISTS plan_id BIGINT REFERENCES spectrum.subscription_plans (id) ON DELETE SET NULL;
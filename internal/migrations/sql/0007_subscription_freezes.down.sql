@@ -0,0 +1 @@
+DROP TABLE IF EXISTS spectrum.subscription_freezes;
@@ -0,0 +1,15 @@
+-- Заморозки абонементов (травма, отпуск): пока заморозка действует, абонемент
+-- не считается активным, а end_date абонемента сдвигается на её длительность
+CREATE TABLE IF NOT EXISTS spectrum.subscription_freezes (
+    id              BIGSERIAL PRIMARY KEY,
+    subscription_id BIGINT    NOT NULL REFERENCES spectrum.subscriptions (id) ON DELETE CASCADE,
+    reason          TEXT      NOT NULL DEFAULT '',
+    start_date      DATE      NOT NULL,
+    end_date        DATE      NOT NULL,
+    created_by      BIGINT    REFERENCES spectrum.users (id) ON DELETE SET NULL,
+    created_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (end_date >= start_date)
+);
+
+CREATE INDEX IF NOT EXISTS subscription_freezes_subscription_idx
+    ON spectrum.subscription_freezes (subscription_id, start_date);
@@ -0,0 +1,28 @@
+-- Журнал занятий: каждое начисление и списание по абонементу отдельной строкой.
+-- remaining_lessons абонемента меняется только вместе с записью в журнале.
+CREATE TABLE IF NOT EXISTS spectrum.lesson_ledger (
+    id              BIGSERIAL PRIMARY KEY,
+    subscription_id BIGINT      NOT NULL REFERENCES spectrum.subscriptions (id) ON DELETE CASCADE,
+    delta           INT         NOT NULL CHECK (delta <> 0),
+    reason          VARCHAR(32) NOT NULL CHECK (reason IN ('purchase', 'attended', 'refund', 'adjustment')),
+    attendance_id   INT         REFERENCES spectrum.attendance (id) ON DELETE SET NULL,
+    training_id     INT         REFERENCES spectrum.training_schedule (id) ON DELETE SET NULL,
+    actor_id        BIGINT      REFERENCES spectrum.users (id) ON DELETE SET NULL,
+    comment         TEXT        NOT NULL DEFAULT '',
+    created_at      TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS lesson_ledger_subscription_idx ON spectrum.lesson_ledger (subscription_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS lesson_ledger_attendance_idx ON spectrum.lesson_ledger (attendance_id);
+
+-- Уже выданные абонементы: начисление при покупке и одна корректировка
+-- на занятия, списанные до появления журнала (какие тренировки их заняли, уже не восстановить)
+INSERT INTO spectrum.lesson_ledger (subscription_id, delta, reason, comment, created_at)
+SELECT id, total_lessons, 'purchase', '', created_at
+FROM spectrum.subscriptions
+WHERE total_lessons > 0;
+
+INSERT INTO spectrum.lesson_ledger (subscription_id, delta, reason, comment)
+SELECT id, remaining_lessons - total_lessons, 'adjustment', 'Занятия, списанные до ведения журнала'
+FROM spectrum.subscriptions
+WHERE remaining_lessons <> total_lessons;
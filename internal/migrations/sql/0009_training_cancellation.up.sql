@@ -0,0 +1,5 @@
+-- Мягкая отмена тренировок: строка остаётся в расписании вместе с записями
+-- и журналом занятий, списанные за неё занятия возвращаются на абонементы
+ALTER TABLE spectrum.training_schedule
+    ADD COLUMN IF NOT EXISTS cancelled_at  TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS cancel_reason TEXT NOT NULL DEFAULT '';
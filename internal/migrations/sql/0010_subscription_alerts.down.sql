@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS spectrum.subscription_digests;
+DROP TABLE IF EXISTS spectrum.subscription_alerts;
@@ -0,0 +1,15 @@
+-- Отправленные предупреждения об абонементах: по одному на абонемент и вид.
+-- Отметка снимается, когда условие перестаёт выполняться (абонемент продлили),
+-- и предупреждение может прийти снова
+CREATE TABLE IF NOT EXISTS spectrum.subscription_alerts (
+    subscription_id BIGINT      NOT NULL REFERENCES spectrum.subscriptions (id) ON DELETE CASCADE,
+    kind            VARCHAR(32) NOT NULL,
+    sent_at         TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (subscription_id, kind)
+);
+
+-- Еженедельные сводки для тренеров: по одной на неделю (week_start — понедельник)
+CREATE TABLE IF NOT EXISTS spectrum.subscription_digests (
+    week_start DATE      PRIMARY KEY,
+    sent_at    TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,23 @@
+-- Оплаты абонементов: ручные записи тренеров (наличные, карта) и счета Telegram Payments.
+-- Счёт создаётся в статусе pending; после оплаты ему проставляется выданный абонемент
+CREATE TABLE IF NOT EXISTS spectrum.payments (
+    id                 BIGSERIAL PRIMARY KEY,
+    student_id         BIGINT      NOT NULL REFERENCES spectrum.students (id) ON DELETE CASCADE,
+    plan_id            BIGINT      REFERENCES spectrum.subscription_plans (id) ON DELETE SET NULL,
+    subscription_id    BIGINT      REFERENCES spectrum.subscriptions (id) ON DELETE SET NULL,
+    amount             INT         NOT NULL CHECK (amount >= 0),
+    currency           VARCHAR(3)  NOT NULL DEFAULT 'RUB',
+    method             VARCHAR(16) NOT NULL CHECK (method IN ('cash', 'card', 'telegram')),
+    status             VARCHAR(16) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'paid', 'cancelled')),
+    telegram_charge_id TEXT        NOT NULL DEFAULT '',
+    provider_charge_id TEXT        NOT NULL DEFAULT '',
+    recorded_by        BIGINT      REFERENCES spectrum.users (id) ON DELETE SET NULL,
+    comment            TEXT        NOT NULL DEFAULT '',
+    created_at         TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    paid_at            TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS payments_student_idx ON spectrum.payments (student_id, created_at DESC);
+-- Повторное уведомление Telegram об одной и той же оплате не должно выдать второй абонемент
+CREATE UNIQUE INDEX IF NOT EXISTS payments_telegram_charge_idx ON spectrum.payments (telegram_charge_id)
+    WHERE telegram_charge_id <> '';
@@ -0,0 +1,11 @@
+-- Семейные абонементы: кроме владельца (subscriptions.student_id) абонементом
+-- пользуются подключённые ученики, занятия списываются с общего остатка
+CREATE TABLE IF NOT EXISTS spectrum.subscription_members (
+    subscription_id BIGINT    NOT NULL REFERENCES spectrum.subscriptions (id) ON DELETE CASCADE,
+    student_id      BIGINT    NOT NULL REFERENCES spectrum.students (id) ON DELETE CASCADE,
+    added_by        BIGINT    REFERENCES spectrum.users (id) ON DELETE SET NULL,
+    added_at        TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (subscription_id, student_id)
+);
+
+CREATE INDEX IF NOT EXISTS subscription_members_student_idx ON spectrum.subscription_members (student_id);
@@ -0,0 +1,11 @@
+ALTER TABLE spectrum.subscriptions
+    DROP COLUMN IF EXISTS time_to,
+    DROP COLUMN IF EXISTS time_from,
+    DROP COLUMN IF EXISTS allowed_weekdays,
+    DROP COLUMN IF EXISTS allowed_group_ids;
+
+ALTER TABLE spectrum.subscription_plans
+    DROP COLUMN IF EXISTS time_to,
+    DROP COLUMN IF EXISTS time_from,
+    DROP COLUMN IF EXISTS allowed_weekdays,
+    DROP COLUMN IF EXISTS allowed_group_ids;
@@ -0,0 +1,13 @@
+-- Ограничения абонемента: группы, дни недели (0 — воскресенье) и время тренировок.
+-- Пустое поле ничего не ограничивает. Тариф задаёт ограничения, абонемент получает их копию при выдаче
+ALTER TABLE spectrum.subscription_plans
+    ADD COLUMN IF NOT EXISTS allowed_group_ids INTEGER[],
+    ADD COLUMN IF NOT EXISTS allowed_weekdays  INTEGER[],
+    ADD COLUMN IF NOT EXISTS time_from         TIME,
+    ADD COLUMN IF NOT EXISTS time_to           TIME;
+
+ALTER TABLE spectrum.subscriptions
+    ADD COLUMN IF NOT EXISTS allowed_group_ids INTEGER[],
+    ADD COLUMN IF NOT EXISTS allowed_weekdays  INTEGER[],
+    ADD COLUMN IF NOT EXISTS time_from         TIME,
+    ADD COLUMN IF NOT EXISTS time_to           TIME;
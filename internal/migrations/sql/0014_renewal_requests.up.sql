@@ -0,0 +1,17 @@
+-- Запросы учеников на продление абонемента: ученик выбирает тариф, тренер одобряет
+-- (выдаётся абонемент) или отклоняет запрос
+CREATE TABLE IF NOT EXISTS spectrum.renewal_requests (
+    id              BIGSERIAL PRIMARY KEY,
+    student_id      BIGINT      NOT NULL REFERENCES spectrum.students (id) ON DELETE CASCADE,
+    plan_id         BIGINT      NOT NULL REFERENCES spectrum.subscription_plans (id) ON DELETE CASCADE,
+    status          VARCHAR(16) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'approved', 'rejected', 'cancelled')),
+    subscription_id BIGINT      REFERENCES spectrum.subscriptions (id) ON DELETE SET NULL,
+    decided_by      BIGINT      REFERENCES spectrum.users (id) ON DELETE SET NULL,
+    created_at      TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    decided_at      TIMESTAMP
+);
+
+-- У ученика не больше одного запроса, ожидающего решения
+CREATE UNIQUE INDEX IF NOT EXISTS renewal_requests_pending_uidx ON spectrum.renewal_requests (student_id)
+    WHERE status = 'pending';
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS spectrum.attendance_status_log;
+
+ALTER TABLE spectrum.attendance DROP COLUMN IF EXISTS attended;
+ALTER TABLE spectrum.attendance ADD COLUMN attended BOOLEAN NOT NULL DEFAULT FALSE;
+UPDATE spectrum.attendance SET attended = TRUE WHERE status = 'attended';
+
+ALTER TABLE spectrum.attendance DROP CONSTRAINT IF EXISTS attendance_status_check;
+UPDATE spectrum.attendance SET status = 'registered' WHERE status = 'no_show';
+UPDATE spectrum.attendance SET status = 'cancelled' WHERE status = 'late_cancelled';
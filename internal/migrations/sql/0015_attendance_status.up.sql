@@ -0,0 +1,35 @@
+-- Статус записи на тренировку — единственный источник правды:
+-- registered -> attended / no_show / cancelled / late_cancelled.
+-- attended становится вычисляемой колонкой, каждый переход попадает в историю
+
+-- Приводим старые строки к статусу: отметка посещения важнее статуса
+UPDATE spectrum.attendance SET status = 'attended' WHERE attended AND status <> 'cancelled';
+UPDATE spectrum.attendance SET status = 'registered'
+WHERE status NOT IN ('registered', 'attended', 'cancelled')
+   OR (status = 'attended' AND NOT attended);
+
+ALTER TABLE spectrum.attendance
+    ADD CONSTRAINT attendance_status_check
+        CHECK (status IN ('registered', 'attended', 'no_show', 'cancelled', 'late_cancelled'));
+
+ALTER TABLE spectrum.attendance DROP COLUMN attended;
+ALTER TABLE spectrum.attendance
+    ADD COLUMN attended BOOLEAN GENERATED ALWAYS AS (status = 'attended') STORED;
+
+-- История статусов записи; from_status NULL — запись создана.
+-- actor_id NULL — действие самого ученика или системы
+CREATE TABLE IF NOT EXISTS spectrum.attendance_status_log (
+    id            BIGSERIAL PRIMARY KEY,
+    attendance_id INT         NOT NULL REFERENCES spectrum.attendance (id) ON DELETE CASCADE,
+    from_status   VARCHAR(32),
+    to_status     VARCHAR(32) NOT NULL,
+    actor_id      BIGINT      REFERENCES spectrum.users (id) ON DELETE SET NULL,
+    created_at    TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS attendance_status_log_attendance_idx ON spectrum.attendance_status_log (attendance_id, id);
+
+-- Для существующих записей история начинается с текущего статуса
+INSERT INTO spectrum.attendance_status_log (attendance_id, from_status, to_status, actor_id, created_at)
+SELECT id, NULL, status, recorded_by, updated_at
+FROM spectrum.attendance;
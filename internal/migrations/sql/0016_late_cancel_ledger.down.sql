@@ -0,0 +1,5 @@
+ALTER TABLE spectrum.lesson_ledger DROP CONSTRAINT IF EXISTS lesson_ledger_reason_check;
+UPDATE spectrum.lesson_ledger SET reason = 'adjustment' WHERE reason = 'late_cancel';
+ALTER TABLE spectrum.lesson_ledger
+    ADD CONSTRAINT lesson_ledger_reason_check
+        CHECK (reason IN ('purchase', 'attended', 'refund', 'adjustment'));
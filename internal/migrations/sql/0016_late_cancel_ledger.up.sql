@@ -0,0 +1,5 @@
+-- Поздняя отмена записи может списывать занятие — отдельная причина в журнале
+ALTER TABLE spectrum.lesson_ledger DROP CONSTRAINT IF EXISTS lesson_ledger_reason_check;
+ALTER TABLE spectrum.lesson_ledger
+    ADD CONSTRAINT lesson_ledger_reason_check
+        CHECK (reason IN ('purchase', 'attended', 'late_cancel', 'refund', 'adjustment'));
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// Статусы записи на тренировку
+const (
+	AttendanceStatusRegistered    = "registered"     // записан
+	AttendanceStatusAttended      = "attended"       // пришёл, занятие списано с абонемента
+	AttendanceStatusNoShow        = "no_show"        // был записан, но не пришёл
+	AttendanceStatusCancelled     = "cancelled"      // запись отменена заранее или тренировка отменена
+	AttendanceStatusLateCancelled = "late_cancelled" // запись отменена, когда было уже поздно
+)
+
+// AttendanceWalkInNote пометка записи ученика, пришедшего без записи: тренер добавил его
+// после начала тренировки в обход лимита мест
+const AttendanceWalkInNote = "Пришёл без записи"
+
+// AttendanceSelfCheckInNote пометка записи, которую ученик отметил сам по QR-коду тренера
+const AttendanceSelfCheckInNote = "Самоотметка по QR-коду"
+
+// attendanceTransitions допустимые переходы статуса записи. Кроме основного пути
+// registered -> итоговый статус тренер может исправить отметку (attended <-> no_show,
+// вернуть в registered), отмена тренировки переводит в cancelled любую действующую запись,
+// а после обычной отмены можно записаться снова
+var attendanceTransitions = map[string][]string{
+	AttendanceStatusRegistered: {AttendanceStatusAttended, AttendanceStatusNoShow, AttendanceStatusCancelled, AttendanceStatusLateCancelled},
+	AttendanceStatusAttended:   {AttendanceStatusNoShow, AttendanceStatusRegistered, AttendanceStatusCancelled},
+	AttendanceStatusNoShow:     {AttendanceStatusAttended, AttendanceStatusRegistered, AttendanceStatusCancelled},
+	AttendanceStatusCancelled:  {AttendanceStatusRegistered},
+}
+
+// CanTransitionAttendance можно ли перевести запись из статуса from в to
+func CanTransitionAttendance(from, to string) bool {
+	return slices.Contains(attendanceTransitions[from], to)
+}
+
+// AttendanceStatusName статус записи для сообщений
+func AttendanceStatusName(status string) string {
+	switch status {
+	case AttendanceStatusRegistered:
+		return "записан"
+	case AttendanceStatusAttended:
+		return "пришёл"
+	case AttendanceStatusNoShow:
+		return "не пришёл"
+	case AttendanceStatusCancelled:
+		return "запись отменена"
+	case AttendanceStatusLateCancelled:
+		return "поздняя отмена"
+	default:
+		return status
+	}
+}
+
+// HoldsSpot запись занимает место на тренировке (не отменена)
+func (a Attendance) HoldsSpot() bool {
+	return a.Status != AttendanceStatusCancelled && a.Status != AttendanceStatusLateCancelled
+}
+
+// AttendanceTransition переход статуса записи из истории
+type AttendanceTransition struct {
+	ID           int64     `db:"id" json:"id"`
+	AttendanceID int       `db:"attendance_id" json:"attendance_id"`
+	FromStatus   *string   `db:"from_status" json:"from_status"` // nil — запись создана
+	ToStatus     string    `db:"to_status" json:"to_status"`
+	ActorID      *int64    `db:"actor_id" json:"actor_id"` // users.id; nil — сам ученик или система
+	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+}
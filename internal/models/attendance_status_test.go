@@ -0,0 +1,37 @@
+package models
+
+import "testing"
+
+func TestCanTransitionAttendance(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{from: AttendanceStatusRegistered, to: AttendanceStatusAttended, want: true},
+		{from: AttendanceStatusRegistered, to: AttendanceStatusNoShow, want: true},
+		{from: AttendanceStatusRegistered, to: AttendanceStatusCancelled, want: true},
+		{from: AttendanceStatusRegistered, to: AttendanceStatusLateCancelled, want: true},
+		{from: AttendanceStatusAttended, to: AttendanceStatusNoShow, want: true},
+		{from: AttendanceStatusAttended, to: AttendanceStatusRegistered, want: true},
+		{from: AttendanceStatusAttended, to: AttendanceStatusCancelled, want: true},
+		{from: AttendanceStatusNoShow, to: AttendanceStatusAttended, want: true},
+		{from: AttendanceStatusNoShow, to: AttendanceStatusRegistered, want: true},
+		{from: AttendanceStatusNoShow, to: AttendanceStatusCancelled, want: true},
+		{from: AttendanceStatusCancelled, to: AttendanceStatusRegistered, want: true},
+
+		{from: AttendanceStatusRegistered, to: AttendanceStatusRegistered},
+		{from: AttendanceStatusAttended, to: AttendanceStatusLateCancelled},
+		{from: AttendanceStatusNoShow, to: AttendanceStatusLateCancelled},
+		{from: AttendanceStatusCancelled, to: AttendanceStatusAttended},
+		{from: AttendanceStatusCancelled, to: AttendanceStatusNoShow},
+		{from: AttendanceStatusLateCancelled, to: AttendanceStatusRegistered},
+		{from: AttendanceStatusLateCancelled, to: AttendanceStatusAttended},
+		{from: AttendanceStatusLateCancelled, to: AttendanceStatusCancelled},
+		{from: "unknown", to: AttendanceStatusRegistered},
+	}
+	for _, tt := range tests {
+		if got := CanTransitionAttendance(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransitionAttendance(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// BotSession сохранённое состояние диалога бота с конкретным чатом
+type BotSession struct {
+	ChatID    int64     `db:"chat_id" json:"chat_id"`
+	State     int       `db:"state" json:"state"`
+	Data      []byte    `db:"data" json:"data"` // сериализованная bot.UserSession (JSON)
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// LateCancelPolicy что происходит, когда ученик отменяет запись после срока
+type LateCancelPolicy string
+
+const (
+	LateCancelCharge LateCancelPolicy = "charge" // отмена проходит, но занятие списывается с абонемента
+	LateCancelBlock  LateCancelPolicy = "block"  // после срока отменить запись нельзя
+)
+
+// CancellationPolicy правила отмены записи учеником: отмена позже чем за Deadline
+// до начала тренировки считается поздней
+type CancellationPolicy struct {
+	Deadline time.Duration
+	Late     LateCancelPolicy
+}
+
+// DeadlineFor последний момент бесплатной отмены записи на тренировку, начинающуюся в start
+func (p CancellationPolicy) DeadlineFor(start time.Time) time.Time {
+	return start.Add(-p.Deadline)
+}
+
+// IsLate будет ли отмена в момент now поздней
+func (p CancellationPolicy) IsLate(start, now time.Time) bool {
+	return !now.Before(p.DeadlineFor(start))
+}
+
+// Terms условия отмены, которые ученик видит перед подтверждением
+func (p CancellationPolicy) Terms(start, now time.Time) string {
+	deadline := p.DeadlineFor(start).Format("02.01.2006 15:04")
+	switch {
+	case !p.IsLate(start, now) && p.Late == LateCancelBlock:
+		return fmt.Sprintf("Отменить запись можно до %s, позже отмена невозможна.", deadline)
+	case !p.IsLate(start, now):
+		return fmt.Sprintf("Бесплатно отменить запись можно до %s, позже занятие спишется с абонемента.", deadline)
+	case p.Late == LateCancelBlock:
+		return fmt.Sprintf("Срок отмены истёк %s — отменить запись уже нельзя.", deadline)
+	default:
+		return fmt.Sprintf("Срок бесплатной отмены истёк %s — при отмене занятие спишется с абонемента.", deadline)
+	}
+}
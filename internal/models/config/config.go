@@ -1,19 +1,81 @@
 package config
 
+import (
+	"spectrum-club-bot/internal/models"
+	"time"
+)
+
 // AppConfig глобальная конфигурация приложения
 var AppConfig *Config
 
 // Config основной конфиг
 type Config struct {
 	Environment string
+	DemoMode    bool // работа без БД на in-memory репозиториях
 	Bot         BotConfig
 	Database    DatabaseConfig
+	Reminders   ReminderConfig
+	Waitlist    WaitlistConfig
+	Alerts      SubscriptionAlertConfig
+	Consumption models.ConsumptionOrder // с какого из активных абонементов ученика списывать занятия
+	Cancel      models.CancellationPolicy
+	Checkin     CheckinConfig
+	Payments    PaymentConfig
 	HTTPPort    string `mapstructure:"HTTP_PORT" default:"8080"`
 }
 
+// Способы получения обновлений от Telegram
+const (
+	UpdateModePolling = "polling" // long polling через getUpdates, удобно локально
+	UpdateModeWebhook = "webhook" // Telegram сам присылает обновления на BASE_URL
+)
+
+// ReminderConfig напоминания ученикам о тренировках
+type ReminderConfig struct {
+	Offsets       []time.Duration // за сколько до начала напоминать; пусто — напоминания выключены
+	CheckInterval time.Duration
+}
+
+// WaitlistConfig лист ожидания на заполненные тренировки
+type WaitlistConfig struct {
+	OfferTTL      time.Duration // сколько освободившееся место держится за учеником из очереди
+	CheckInterval time.Duration // как часто закрывать просроченные предложения
+}
+
+// SubscriptionAlertConfig предупреждения ученикам о заканчивающемся абонементе
+// и еженедельная сводка для тренеров
+type SubscriptionAlertConfig struct {
+	LowLessons    int // предупреждать, когда занятий осталось столько или меньше; 0 — не предупреждать
+	ExpiryDays    int // предупреждать за столько дней до окончания срока; 0 — не предупреждать
+	CheckInterval time.Duration
+}
+
+// CheckinConfig самоотметка учеников по QR-коду, который показывает тренер
+type CheckinConfig struct {
+	Secret string        // ключ подписи ссылок; по умолчанию токен бота
+	TTL    time.Duration // сколько действует показанный QR-код
+}
+
+// PaymentConfig оплата абонементов учениками прямо в боте (Telegram Payments).
+// Без токена провайдера и без заглушки ученики покупают абонементы только у тренера
+type PaymentConfig struct {
+	ProviderToken string // токен платёжного провайдера из BotFather
+	Currency      string // трёхбуквенный код валюты ISO 4217
+	Stub          bool   // локальная заглушка вместо Telegram Payments: оплата подтверждается кнопкой
+}
+
+// OnlineEnabled можно ли ученику купить абонемент в боте
+func (c PaymentConfig) OnlineEnabled() bool {
+	return c.ProviderToken != "" || c.Stub
+}
+
 type BotConfig struct {
 	Token    string
 	Debug    bool
 	BaseURL  string
+	APIURL   string  // адрес Telegram Bot API (по умолчанию api.telegram.org)
 	AdminIDs []int64 // ID администраторов для уведомлений
+
+	UpdateMode    string // UpdateModePolling или UpdateModeWebhook
+	WebhookSecret string // secret_token, который Telegram передаёт в X-Telegram-Bot-Api-Secret-Token
 }
@@ -2,8 +2,11 @@ package config
 
 import (
 	"fmt"
+	"log"
+	"spectrum-club-bot/internal/models"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // DatabaseConfig конфигурация БД
@@ -14,6 +17,8 @@ type DatabaseConfig struct {
 	Password string
 	Name     string
 	SSLMode  string
+	// AutoMigrate применять миграции схемы при старте
+	AutoMigrate bool
 }
 
 // Load загружает конфигурацию
@@ -23,25 +28,78 @@ func Load() error {
 	AppConfig = &Config{
 		HTTPPort:    getEnv("HTTP_PORT", "8080"),
 		Environment: env,
+		DemoMode:    getEnvAsBool("DEMO_MODE", false),
 		Bot: BotConfig{
 			Token:    getEnv("BOT_TOKEN", ""),
 			Debug:    getEnvAsBool("BOT_DEBUG", env != "production"),
 			AdminIDs: parseAdminIDs(getEnv("ADMIN_IDS", "")),
 			BaseURL:  getEnv("BASE_URL", ""),
+			APIURL:   getEnv("TELEGRAM_API_URL", "https://api.telegram.org"),
+
+			UpdateMode:    getEnv("BOT_UPDATE_MODE", UpdateModePolling),
+			WebhookSecret: getEnv("WEBHOOK_SECRET", ""),
+		},
+		Reminders: ReminderConfig{
+			Offsets:       getEnvAsDurations("REMINDER_OFFSETS", []time.Duration{24 * time.Hour, 2 * time.Hour}),
+			CheckInterval: getEnvAsDuration("REMINDER_CHECK_INTERVAL", time.Minute),
+		},
+		Waitlist: WaitlistConfig{
+			OfferTTL:      getEnvAsDuration("WAITLIST_OFFER_TTL", 2*time.Hour),
+			CheckInterval: getEnvAsDuration("WAITLIST_CHECK_INTERVAL", time.Minute),
+		},
+		Alerts: SubscriptionAlertConfig{
+			LowLessons:    getEnvAsInt("SUBSCRIPTION_LOW_LESSONS", 2),
+			ExpiryDays:    getEnvAsInt("SUBSCRIPTION_EXPIRY_DAYS", 3),
+			CheckInterval: getEnvAsDuration("SUBSCRIPTION_ALERT_INTERVAL", 24*time.Hour),
 		},
-		Database: DatabaseConfig{
-			Host:     getEnv("DB_HOST", "localhost"),
-			Port:     getEnvAsInt("DB_PORT", 5432),
-			Username: getEnv("DB_USER", ""),
-			Password: getEnv("DB_PASSWORD", ""),
-			Name:     getEnv("DB_NAME", "spectrum-db"),
-			SSLMode:  getSSLMode(env),
+		Consumption: models.ConsumptionOrder(getEnv("SUBSCRIPTION_CONSUMPTION_ORDER", string(models.ConsumptionExpiringFirst))),
+		Cancel: models.CancellationPolicy{
+			Deadline: getEnvAsDuration("CANCEL_DEADLINE", 12*time.Hour),
+			Late:     models.LateCancelPolicy(getEnv("LATE_CANCEL_POLICY", string(models.LateCancelCharge))),
 		},
+		Checkin: CheckinConfig{
+			Secret: checkinSecret(),
+			TTL:    getEnvAsDuration("CHECKIN_TTL", 10*time.Minute),
+		},
+		Payments: PaymentConfig{
+			ProviderToken: getEnv("PAYMENTS_PROVIDER_TOKEN", ""),
+			Currency:      strings.ToUpper(getEnv("PAYMENTS_CURRENCY", "RUB")),
+			Stub:          getEnvAsBool("PAYMENTS_STUB", getEnvAsBool("DEMO_MODE", false)),
+		},
+		Database: loadDatabase(env),
 	}
 
 	return validate()
 }
 
+// LoadDatabase загружает только настройки БД — для команд, которым не нужен бот (migrate)
+func LoadDatabase() error {
+	env := getEnv("ENVIRONMENT", "development")
+
+	AppConfig = &Config{
+		Environment: env,
+		Database:    loadDatabase(env),
+	}
+
+	if errors := validateDatabase(); len(errors) > 0 {
+		return fmt.Errorf("config validation failed: %s", strings.Join(errors, ", "))
+	}
+	return nil
+}
+
+func loadDatabase(env string) DatabaseConfig {
+	return DatabaseConfig{
+		Host:     getEnv("DB_HOST", "localhost"),
+		Port:     getEnvAsInt("DB_PORT", 5432),
+		Username: getEnv("DB_USER", ""),
+		Password: getEnv("DB_PASSWORD", ""),
+		Name:     getEnv("DB_NAME", "spectrum-db"),
+		SSLMode:  getSSLMode(env),
+
+		AutoMigrate: getEnvAsBool("DB_AUTO_MIGRATE", true),
+	}
+}
+
 // validate проверяет обязательные параметры
 func validate() error {
 	var errors []string
@@ -50,14 +108,53 @@ func validate() error {
 		errors = append(errors, "BOT_TOKEN is required")
 	}
 
-	if AppConfig.Database.Username == "" {
-		errors = append(errors, "DB_USER is required")
+	switch AppConfig.Bot.UpdateMode {
+	case UpdateModePolling:
+	case UpdateModeWebhook:
+		if AppConfig.Bot.BaseURL == "" {
+			errors = append(errors, "BASE_URL is required in webhook mode")
+		}
+		if AppConfig.Bot.WebhookSecret == "" {
+			errors = append(errors, "WEBHOOK_SECRET is required in webhook mode")
+		}
+	default:
+		errors = append(errors, fmt.Sprintf("BOT_UPDATE_MODE must be %q or %q", UpdateModePolling, UpdateModeWebhook))
 	}
 
-	if AppConfig.Database.Password == "" && AppConfig.Environment == "production" {
-		errors = append(errors, "DB_PASSWORD is required in production")
+	if AppConfig.Waitlist.OfferTTL <= 0 {
+		errors = append(errors, "WAITLIST_OFFER_TTL must be positive")
 	}
 
+	switch AppConfig.Consumption {
+	case models.ConsumptionExpiringFirst, models.ConsumptionOldestFirst, models.ConsumptionNewestFirst:
+	default:
+		errors = append(errors, fmt.Sprintf("SUBSCRIPTION_CONSUMPTION_ORDER must be %q, %q or %q",
+			models.ConsumptionExpiringFirst, models.ConsumptionOldestFirst, models.ConsumptionNewestFirst))
+	}
+
+	if AppConfig.Cancel.Deadline < 0 {
+		errors = append(errors, "CANCEL_DEADLINE must not be negative")
+	}
+	switch AppConfig.Cancel.Late {
+	case models.LateCancelCharge, models.LateCancelBlock:
+	default:
+		errors = append(errors, fmt.Sprintf("LATE_CANCEL_POLICY must be %q or %q", models.LateCancelCharge, models.LateCancelBlock))
+	}
+
+	if AppConfig.Checkin.TTL <= 0 {
+		errors = append(errors, "CHECKIN_TTL must be positive")
+	}
+
+	if len(AppConfig.Payments.Currency) != 3 {
+		errors = append(errors, "PAYMENTS_CURRENCY must be a three-letter ISO 4217 code")
+	}
+
+	if AppConfig.Alerts.LowLessons < 0 || AppConfig.Alerts.ExpiryDays < 0 {
+		errors = append(errors, "SUBSCRIPTION_LOW_LESSONS and SUBSCRIPTION_EXPIRY_DAYS must not be negative")
+	}
+
+	errors = append(errors, validateDatabase()...)
+
 	if len(errors) > 0 {
 		return fmt.Errorf("config validation failed: %s", strings.Join(errors, ", "))
 	}
@@ -65,6 +162,21 @@ func validate() error {
 	return nil
 }
 
+// validateDatabase проверяет параметры подключения к БД
+func validateDatabase() []string {
+	var errors []string
+
+	if AppConfig.Database.Username == "" && !AppConfig.DemoMode {
+		errors = append(errors, "DB_USER is required")
+	}
+
+	if AppConfig.Database.Password == "" && AppConfig.Environment == "production" {
+		errors = append(errors, "DB_PASSWORD is required in production")
+	}
+
+	return errors
+}
+
 // getSSLMode возвращает режим SSL в зависимости от окружения
 func getSSLMode(env string) string {
 	if env == "production" {
@@ -87,3 +199,12 @@ func parseAdminIDs(ids string) []int64 {
 	}
 	return result
 }
+
+// checkinSecret секрет подписи QR-кодов; без CHECKIN_SECRET подписываем производным от токена бота
+func checkinSecret() string {
+	if secret := getEnv("CHECKIN_SECRET", ""); secret != "" {
+		return secret
+	}
+	log.Printf("⚠️ CHECKIN_SECRET не задан, ключ подписи QR-кодов выводится из BOT_TOKEN: задайте отдельный секрет")
+	return getEnv("BOT_TOKEN", "")
+}
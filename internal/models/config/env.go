@@ -3,6 +3,8 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // getEnv получает переменную окружения или значение по умолчанию
@@ -30,3 +32,29 @@ func getEnvAsBool(key string, defaultValue bool) bool {
 	}
 	return defaultValue
 }
+
+// getEnvAsDuration получает переменную окружения как time.Duration ("90s", "5m")
+func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+	strValue := getEnv(key, "")
+	if value, err := time.ParseDuration(strValue); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvAsDurations получает список длительностей через запятую ("24h,2h").
+// Пустое значение переменной даёт пустой список.
+func getEnvAsDurations(key string, defaultValue []time.Duration) []time.Duration {
+	strValue, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+
+	var result []time.Duration
+	for _, part := range strings.Split(strValue, ",") {
+		if value, err := time.ParseDuration(strings.TrimSpace(part)); err == nil && value > 0 {
+			result = append(result, value)
+		}
+	}
+	return result
+}
@@ -28,22 +28,31 @@ type TrainingSchedule struct {
 	CreatedAt       time.Time `json:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at"`
 
+	// Отмена: тренировка остаётся в расписании для истории, но на неё нельзя записаться
+	CancelledAt  *time.Time `json:"cancelled_at,omitempty"`
+	CancelReason string     `json:"cancel_reason,omitempty"`
+
 	// Joined fields
 	GroupName string `json:"group_name,omitempty"`
 	CoachName string `json:"coach_name,omitempty"`
 }
 
+// IsCancelled тренировка отменена
+func (t TrainingSchedule) IsCancelled() bool {
+	return t.CancelledAt != nil
+}
+
 type Attendance struct {
 	ID         int       `json:"id"`
 	TrainingID int       `json:"training_id"`
 	StudentID  int       `json:"student_id"`
-	Attended   bool      `json:"attended"`
+	Attended   bool      `json:"attended"` // Status == attended; в БД вычисляется из статуса
 	Notes      string    `json:"notes"`
 	RecordedBy *int      `json:"recorded_by"`
 	RecordedAt time.Time `json:"recorded_at"`
 	CreatedAt  time.Time `json:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at"`
-	Status     string    `json:"status"` // registered, cancelled, attended
+	Status     string    `json:"status"` // AttendanceStatus*; меняется только допустимыми переходами
 
 	// Joined fields
 	StudentName string `json:"student_name,omitempty"`
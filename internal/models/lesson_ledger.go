@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// Причины движения занятий по абонементу
+const (
+	LedgerReasonPurchase   = "purchase"    // абонемент выдан
+	LedgerReasonAttended   = "attended"    // занятие списано за посещение
+	LedgerReasonLateCancel = "late_cancel" // занятие списано за позднюю отмену записи
+	LedgerReasonRefund     = "refund"      // списание отменено
+	LedgerReasonAdjustment = "adjustment"  // ручная корректировка тренером
+)
+
+// LessonLedgerEntry запись журнала занятий: журнал только дополняется,
+// сумма Delta по абонементу равна его RemainingLessons
+type LessonLedgerEntry struct {
+	ID             int64     `db:"id" json:"id"`
+	SubscriptionID int64     `db:"subscription_id" json:"subscription_id"`
+	Delta          int       `db:"delta" json:"delta"`
+	Reason         string    `db:"reason" json:"reason"`
+	AttendanceID   *int      `db:"attendance_id" json:"attendance_id,omitempty"`
+	TrainingID     *int      `db:"training_id" json:"training_id,omitempty"`
+	ActorID        *int64    `db:"actor_id" json:"actor_id,omitempty"` // users.id того, кто изменил баланс
+	Comment        string    `db:"comment" json:"comment"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+}
+
+// ReasonTitle причина по-русски для истории абонемента
+func (e LessonLedgerEntry) ReasonTitle() string {
+	switch e.Reason {
+	case LedgerReasonPurchase:
+		return "Покупка абонемента"
+	case LedgerReasonAttended:
+		return "Посещение"
+	case LedgerReasonLateCancel:
+		return "Поздняя отмена"
+	case LedgerReasonRefund:
+		return "Возврат занятия"
+	case LedgerReasonAdjustment:
+		return "Корректировка"
+	default:
+		return e.Reason
+	}
+}
+
+// LessonBalanceMismatch абонемент, у которого остаток занятий расходится с журналом
+type LessonBalanceMismatch struct {
+	SubscriptionID   int64 `db:"subscription_id"`
+	RemainingLessons int   `db:"remaining_lessons"`
+	LedgerBalance    int   `db:"ledger_balance"`
+}
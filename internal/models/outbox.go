@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Статусы исходящих сообщений
+const (
+	OutboxStatusPending = "pending" // ждёт отправки (в том числе повторной)
+	OutboxStatusSent    = "sent"
+	OutboxStatusFailed  = "failed" // попытки исчерпаны или ошибка не лечится повтором
+)
+
+// OutboxMessage исходящее сообщение в очереди отправки
+type OutboxMessage struct {
+	ID            int64      `db:"id" json:"id"`
+	ChatID        int64      `db:"chat_id" json:"chat_id"`
+	Method        string     `db:"method" json:"method"`   // метод Bot API, например sendMessage
+	Payload       []byte     `db:"payload" json:"payload"` // параметры метода (JSON)
+	Status        string     `db:"status" json:"status"`
+	Attempts      int        `db:"attempts" json:"attempts"`
+	NextAttemptAt time.Time  `db:"next_attempt_at" json:"next_attempt_at"`
+	LastError     string     `db:"last_error" json:"last_error"`
+	CreatedAt     time.Time  `db:"created_at" json:"created_at"`
+	SentAt        *time.Time `db:"sent_at" json:"sent_at"`
+}
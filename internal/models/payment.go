@@ -0,0 +1,81 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Способы оплаты абонемента
+const (
+	PaymentMethodCash     = "cash"     // наличными тренеру
+	PaymentMethodCard     = "card"     // картой или переводом тренеру
+	PaymentMethodTelegram = "telegram" // учеником через Telegram Payments
+)
+
+// Статусы платежа
+const (
+	PaymentStatusPending   = "pending"   // счёт выставлен, оплаты ещё не было
+	PaymentStatusPaid      = "paid"      // оплачен, абонемент выдан
+	PaymentStatusCancelled = "cancelled" // счёт отменён
+)
+
+// invoicePayloadPrefix payload счёта Telegram: "payment:<id>"
+const invoicePayloadPrefix = "payment:"
+
+// Payment оплата абонемента: ручная запись тренера или счёт, выставленный ученику в Telegram
+type Payment struct {
+	ID               int64      `db:"id" json:"id"`
+	StudentID        int64      `db:"student_id" json:"student_id"`
+	PlanID           *int64     `db:"plan_id" json:"plan_id"`
+	SubscriptionID   *int64     `db:"subscription_id" json:"subscription_id"` // абонемент, выданный после оплаты
+	Amount           int        `db:"amount" json:"amount"`                   // в рублях
+	Currency         string     `db:"currency" json:"currency"`
+	Method           string     `db:"method" json:"method"`
+	Status           string     `db:"status" json:"status"`
+	TelegramChargeID string     `db:"telegram_charge_id" json:"telegram_charge_id,omitempty"`
+	ProviderChargeID string     `db:"provider_charge_id" json:"provider_charge_id,omitempty"`
+	RecordedBy       *int64     `db:"recorded_by" json:"recorded_by"` // users.id тренера, принявшего оплату
+	Comment          string     `db:"comment" json:"comment"`
+	CreatedAt        time.Time  `db:"created_at" json:"created_at"`
+	PaidAt           *time.Time `db:"paid_at" json:"paid_at"`
+}
+
+// InvoicePayload payload счёта, по которому платёж находится после оплаты
+func (p Payment) InvoicePayload() string {
+	return invoicePayloadPrefix + strconv.FormatInt(p.ID, 10)
+}
+
+// ParseInvoicePayload ID платежа из payload счёта
+func ParseInvoicePayload(payload string) (int64, error) {
+	id, err := strconv.ParseInt(strings.TrimPrefix(payload, invoicePayloadPrefix), 10, 64)
+	if err != nil || !strings.HasPrefix(payload, invoicePayloadPrefix) {
+		return 0, fmt.Errorf("неизвестный счёт: %q", payload)
+	}
+	return id, nil
+}
+
+// MethodName способ оплаты для сообщений
+func (p Payment) MethodName() string {
+	switch p.Method {
+	case PaymentMethodCash:
+		return "наличные"
+	case PaymentMethodCard:
+		return "карта"
+	case PaymentMethodTelegram:
+		return "онлайн в Telegram"
+	default:
+		return p.Method
+	}
+}
+
+// Invoice счёт на оплату абонемента, который провайдер показывает ученику
+type Invoice struct {
+	ChatID      int64
+	Payload     string // Payment.InvoicePayload()
+	Title       string
+	Description string
+	Currency    string
+	Amount      int // в рублях
+}
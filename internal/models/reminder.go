@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// UpcomingRegistration актуальная запись ученика на тренировку с данными для напоминания
+type UpcomingRegistration struct {
+	AttendanceID int       `db:"attendance_id"`
+	TrainingID   int       `db:"training_id"`
+	StudentID    int       `db:"student_id"`
+	TelegramID   int64     `db:"telegram_id"`
+	TrainingDate time.Time `db:"training_date"`
+	StartTime    time.Time `db:"start_time"`
+	EndTime      time.Time `db:"end_time"`
+	Description  string    `db:"description"`
+	GroupName    string    `db:"group_name"`
+	CoachName    string    `db:"coach_name"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Статусы запроса на продление абонемента
+const (
+	RenewalStatusPending   = "pending"   // ждёт решения тренера
+	RenewalStatusApproved  = "approved"  // тренер одобрил, абонемент выдан
+	RenewalStatusRejected  = "rejected"  // тренер отклонил
+	RenewalStatusCancelled = "cancelled" // ученик отозвал запрос
+)
+
+// RenewalRequest запрос ученика на новый абонемент по выбранному тарифу
+type RenewalRequest struct {
+	ID             int64      `db:"id" json:"id"`
+	StudentID      int64      `db:"student_id" json:"student_id"`
+	PlanID         int64      `db:"plan_id" json:"plan_id"`
+	Status         string     `db:"status" json:"status"`
+	SubscriptionID *int64     `db:"subscription_id" json:"subscription_id"` // абонемент, выданный после одобрения
+	DecidedBy      *int64     `db:"decided_by" json:"decided_by"`           // users.id тренера, рассмотревшего запрос
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+	DecidedAt      *time.Time `db:"decided_at" json:"decided_at"`
+
+	// Поля из JOIN для сообщений ученику и тренерам
+	StudentName string `db:"student_name" json:"student_name"`
+	TelegramID  int64  `db:"telegram_id" json:"telegram_id"` // чат ученика
+	PlanName    string `db:"plan_name" json:"plan_name"`
+}
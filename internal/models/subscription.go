@@ -10,15 +10,37 @@ type Subscription struct {
 	TotalLessons     int       `db:"total_lessons" json:"total_lessons"`
 	RemainingLessons int       `db:"remaining_lessons" json:"remaining_lessons"`
 	CreatedAt        time.Time `db:"created_at" json:"created_at"`
+	PlanID           *int64    `db:"plan_id" json:"plan_id"` // тариф, по которому выдан абонемент
+
+	// Ограничения копируются из тарифа при выдаче: изменение тарифа не меняет выданные абонементы
+	SubscriptionRestrictions
 }
 
-// CREATE TABLE spectrum.subscriptions (
-//     id SERIAL PRIMARY KEY,
-//     student_id BIGINT REFERENCES students(id) ON DELETE CASCADE,
-//     start_date TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-//     end_date TIMESTAMP,
-//     total_lessons INT NOT NULL,
-//     remaining_lessons INT NOT NULL,
-//     days_left INT,
-//     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-// );
+// ConsumptionOrder с какого из нескольких активных абонементов ученика списываются занятия.
+// Собственный абонемент ученика всегда идёт раньше семейного, порядок задаёт выбор среди равных
+type ConsumptionOrder string
+
+const (
+	ConsumptionExpiringFirst ConsumptionOrder = "expiring_first" // сначала тот, что раньше заканчивается
+	ConsumptionOldestFirst   ConsumptionOrder = "oldest_first"   // сначала выданный раньше
+	ConsumptionNewestFirst   ConsumptionOrder = "newest_first"   // сначала выданный последним
+)
+
+// Before списывается ли a раньше b (без учёта владельца абонемента)
+func (o ConsumptionOrder) Before(a, b Subscription) bool {
+	switch o {
+	case ConsumptionNewestFirst:
+		return a.CreatedAt.After(b.CreatedAt)
+	case ConsumptionOldestFirst:
+		return a.CreatedAt.Before(b.CreatedAt)
+	default:
+		// Нулевая дата соответствует end_date IS NULL: бессрочный абонемент — последним
+		if !a.EndDate.Equal(b.EndDate) {
+			if a.EndDate.IsZero() || b.EndDate.IsZero() {
+				return b.EndDate.IsZero()
+			}
+			return a.EndDate.Before(b.EndDate)
+		}
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+}
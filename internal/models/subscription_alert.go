@@ -0,0 +1,7 @@
+package models
+
+// Виды предупреждений ученику об абонементе
+const (
+	SubscriptionAlertLowLessons = "low_lessons" // осталось мало занятий
+	SubscriptionAlertExpiry     = "expiry"      // скоро закончится срок
+)
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// SubscriptionFreeze заморозка абонемента: в период [StartDate, EndDate] включительно
+// абонемент не считается активным, а его срок продлён на длительность заморозки
+type SubscriptionFreeze struct {
+	ID             int64     `db:"id" json:"id"`
+	SubscriptionID int64     `db:"subscription_id" json:"subscription_id"`
+	Reason         string    `db:"reason" json:"reason"`
+	StartDate      time.Time `db:"start_date" json:"start_date"`
+	EndDate        time.Time `db:"end_date" json:"end_date"`
+	CreatedBy      *int64    `db:"created_by" json:"created_by"` // users.id тренера
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+}
+
+// Days длительность заморозки в днях (обе даты включительно)
+func (f SubscriptionFreeze) Days() int {
+	return int(f.EndDate.Sub(f.StartDate)/(24*time.Hour)) + 1
+}
+
+// Covers попадает ли календарный день day в период заморозки
+func (f SubscriptionFreeze) Covers(day time.Time) bool {
+	d := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	return !d.Before(f.StartDate) && !d.After(f.EndDate)
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// SubscriptionMember ученик, который пользуется абонементом. Владелец — ученик, на которого
+// абонемент оформлен (его семья и оплачивает абонемент); остальные подключены к общему остатку занятий
+type SubscriptionMember struct {
+	SubscriptionID int64     `db:"subscription_id" json:"subscription_id"`
+	StudentID      int64     `db:"student_id" json:"student_id"`
+	StudentName    string    `db:"student_name" json:"student_name"`
+	IsOwner        bool      `db:"is_owner" json:"is_owner"`
+	LessonsUsed    int       `db:"lessons_used" json:"lessons_used"` // списано за посещения ученика за вычетом возвратов
+	AddedAt        time.Time `db:"added_at" json:"added_at"`
+}
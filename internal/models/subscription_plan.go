@@ -0,0 +1,40 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// SubscriptionPlan тариф абонемента, который тренер может выдать ученику
+type SubscriptionPlan struct {
+	ID           int64     `db:"id" json:"id"`
+	Name         string    `db:"name" json:"name"`
+	Lessons      int       `db:"lessons" json:"lessons"`
+	DurationDays int       `db:"duration_days" json:"duration_days"` // срок действия абонемента с момента выдачи
+	Price        int       `db:"price" json:"price"`                 // в рублях
+	IsActive     bool      `db:"is_active" json:"is_active"`         // неактивный тариф не предлагается при выдаче
+	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time `db:"updated_at" json:"updated_at"`
+
+	SubscriptionRestrictions // на какие тренировки действуют абонементы по тарифу
+}
+
+// Summary краткое описание условий: "занятий: 16, срок: 30 дн., 4000 ₽"
+func (p SubscriptionPlan) Summary() string {
+	return fmt.Sprintf("занятий: %d, срок: %d дн., %d ₽", p.Lessons, p.DurationDays, p.Price)
+}
+
+// NewSubscription абонемент по тарифу, выданный ученику в момент now
+func (p SubscriptionPlan) NewSubscription(studentID int64, now time.Time) *Subscription {
+	return &Subscription{
+		StudentID:        studentID,
+		StartDate:        now,
+		EndDate:          now.AddDate(0, 0, p.DurationDays),
+		TotalLessons:     p.Lessons,
+		RemainingLessons: p.Lessons,
+		CreatedAt:        now,
+		PlanID:           &p.ID,
+
+		SubscriptionRestrictions: p.SubscriptionRestrictions,
+	}
+}
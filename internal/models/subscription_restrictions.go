@@ -0,0 +1,101 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// SubscriptionRestrictions на какие тренировки распространяется абонемент. Пустое поле
+// ничего не ограничивает: абонемент без ограничений подходит для любой тренировки
+type SubscriptionRestrictions struct {
+	GroupIDs pq.Int64Array `db:"allowed_group_ids" json:"allowed_group_ids,omitempty"`
+	Weekdays pq.Int64Array `db:"allowed_weekdays" json:"allowed_weekdays,omitempty"` // 0 — воскресенье, как в time.Weekday
+	TimeFrom *time.Time    `db:"time_from" json:"time_from,omitempty"`               // тренировка начинается не раньше
+	TimeTo   *time.Time    `db:"time_to" json:"time_to,omitempty"`                   // и заканчивается не позже
+}
+
+// WeekdayNames краткие названия дней недели в порядке time.Weekday
+var WeekdayNames = [7]string{"вс", "пн", "вт", "ср", "чт", "пт", "сб"}
+
+func (r SubscriptionRestrictions) IsEmpty() bool {
+	return len(r.GroupIDs) == 0 && len(r.Weekdays) == 0 && r.TimeFrom == nil && r.TimeTo == nil
+}
+
+// CheckTraining возвращает причину, по которой абонемент не подходит для тренировки
+// ("в него не входит группа «Взрослые»"), или nil, если подходит
+func (r SubscriptionRestrictions) CheckTraining(training TrainingSchedule) error {
+	if len(r.GroupIDs) > 0 && !slices.Contains(r.GroupIDs, int64(training.GroupID)) {
+		group := training.GroupName
+		if group == "" {
+			group = fmt.Sprintf("№%d", training.GroupID)
+		}
+		return fmt.Errorf("в него не входит группа «%s»", group)
+	}
+	if len(r.Weekdays) > 0 && !slices.Contains(r.Weekdays, int64(training.TrainingDate.Weekday())) {
+		return fmt.Errorf("он действует только в дни: %s", r.weekdays())
+	}
+	if (r.TimeFrom != nil && minuteOfDay(training.StartTime) < minuteOfDay(*r.TimeFrom)) ||
+		(r.TimeTo != nil && minuteOfDay(training.EndTime) > minuteOfDay(*r.TimeTo)) {
+		return fmt.Errorf("он действует только на тренировки %s", r.window())
+	}
+	return nil
+}
+
+// Describe ограничения одной строкой: "группы: Дети; дни: пн, ср; время: с 18:00 до 22:00".
+// groupNames — названия групп по ID; группа без названия выводится номером
+func (r SubscriptionRestrictions) Describe(groupNames map[int]string) string {
+	if r.IsEmpty() {
+		return "без ограничений"
+	}
+
+	var parts []string
+	if len(r.GroupIDs) > 0 {
+		names := make([]string, 0, len(r.GroupIDs))
+		for _, id := range r.GroupIDs {
+			name, ok := groupNames[int(id)]
+			if !ok {
+				name = fmt.Sprintf("№%d", id)
+			}
+			names = append(names, name)
+		}
+		parts = append(parts, "группы: "+strings.Join(names, ", "))
+	}
+	if len(r.Weekdays) > 0 {
+		parts = append(parts, "дни: "+r.weekdays())
+	}
+	if r.TimeFrom != nil || r.TimeTo != nil {
+		parts = append(parts, "время: "+r.window())
+	}
+	return strings.Join(parts, "; ")
+}
+
+// weekdays разрешённые дни, начиная с понедельника
+func (r SubscriptionRestrictions) weekdays() string {
+	var names []string
+	for i := 1; i <= 7; i++ {
+		day := i % 7
+		if slices.Contains(r.Weekdays, int64(day)) {
+			names = append(names, WeekdayNames[day])
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+func (r SubscriptionRestrictions) window() string {
+	switch {
+	case r.TimeFrom != nil && r.TimeTo != nil:
+		return fmt.Sprintf("с %s до %s", r.TimeFrom.Format("15:04"), r.TimeTo.Format("15:04"))
+	case r.TimeFrom != nil:
+		return "с " + r.TimeFrom.Format("15:04")
+	default:
+		return "до " + r.TimeTo.Format("15:04")
+	}
+}
+
+func minuteOfDay(t time.Time) int {
+	return t.Hour()*60 + t.Minute()
+}
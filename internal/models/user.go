@@ -12,3 +12,11 @@ type User struct {
 	RegisteredAt time.Time `db:"registered_at" json:"registered_at"`
 	UpdatedAt    time.Time `db:"updated_at" json:"updated_at"`
 }
+
+// OptionalUserID users.id автора действия; 0 означает "без автора" (NULL в actor_id, recorded_by и т.п.)
+func OptionalUserID(userID int64) *int64 {
+	if userID == 0 {
+		return nil
+	}
+	return &userID
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Статусы записи в листе ожидания
+const (
+	WaitlistStatusWaiting   = "waiting"   // стоит в очереди
+	WaitlistStatusOffered   = "offered"   // ему предложено освободившееся место, ждём ответа
+	WaitlistStatusAccepted  = "accepted"  // записался на тренировку
+	WaitlistStatusDeclined  = "declined"  // отказался от предложенного места
+	WaitlistStatusExpired   = "expired"   // не ответил на предложение вовремя
+	WaitlistStatusLeft      = "left"      // сам вышел из очереди
+	WaitlistStatusCancelled = "cancelled" // тренировка отменена
+)
+
+// WaitlistEntry запись ученика в листе ожидания тренировки
+type WaitlistEntry struct {
+	ID             int        `db:"id" json:"id"`
+	TrainingID     int        `db:"training_id" json:"training_id"`
+	StudentID      int        `db:"student_id" json:"student_id"`
+	Status         string     `db:"status" json:"status"`
+	OfferExpiresAt *time.Time `db:"offer_expires_at" json:"offer_expires_at"` // до какого момента держится предложенное место
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time  `db:"updated_at" json:"updated_at"`
+
+	// Поля из JOIN
+	TelegramID int64 `db:"telegram_id" json:"-"`
+	Position   int   `db:"position" json:"position"` // место среди ожидающих (с 1); 0, если место уже предложено
+}
+
+// IsActive запись ещё в очереди или держит предложенное место
+func (e WaitlistEntry) IsActive() bool {
+	return e.Status == WaitlistStatusWaiting || e.Status == WaitlistStatusOffered
+}
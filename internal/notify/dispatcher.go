@@ -0,0 +1,157 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"log"
+	"spectrum-club-bot/internal/repository"
+	"time"
+)
+
+const (
+	batchSize    = 20
+	pollInterval = 5 * time.Second
+	// claimLease сколько сообщение считается взятым в работу; если реплика упала
+	// посреди отправки, по истечении lease сообщение снова попадёт в выборку
+	claimLease  = time.Minute
+	maxAttempts = 8
+	baseBackoff = 2 * time.Second
+	maxBackoff  = 10 * time.Minute
+)
+
+// Dispatcher забирает сообщения из outbox и отправляет их через Sender.
+// Временные ошибки повторяются с экспоненциальной задержкой, на 429 соблюдается
+// retry_after, постоянные ошибки (400/403) и исчерпанные попытки помечают сообщение failed.
+type Dispatcher struct {
+	repo   repository.OutboxRepository
+	sender Sender
+	wake   chan struct{}
+}
+
+func NewDispatcher(repo repository.OutboxRepository, sender Sender) *Dispatcher {
+	return &Dispatcher{
+		repo:   repo,
+		sender: sender,
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// Wake просит dispatcher проверить очередь, не дожидаясь таймера
+func (d *Dispatcher) Wake() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run обрабатывает очередь до отмены ctx
+func (d *Dispatcher) Run(ctx context.Context) {
+	log.Printf("📬 Очередь уведомлений запущена")
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Printf("📭 Очередь уведомлений остановлена")
+			return
+		case <-d.wake:
+		case <-timer.C:
+		}
+
+		pause := d.drain(ctx)
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		if pause > 0 {
+			// Telegram ограничил частоту: до истечения retry_after не шлём ничего,
+			// сигналы Wake при этом копятся в буфере канала
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(pause):
+			}
+			timer.Reset(0)
+			continue
+		}
+		timer.Reset(pollInterval)
+	}
+}
+
+// drain отправляет всё, что готово к отправке. Возвращает паузу, если Telegram вернул 429.
+func (d *Dispatcher) drain(ctx context.Context) time.Duration {
+	for ctx.Err() == nil {
+		messages, err := d.repo.ClaimDue(batchSize, claimLease)
+		if err != nil {
+			log.Printf("❌ Очередь уведомлений: ошибка выборки: %v", err)
+			return 0
+		}
+		if len(messages) == 0 {
+			return 0
+		}
+
+		for i, message := range messages {
+			err := d.sender.Send(message.Method, message.Payload)
+			if err == nil {
+				if err := d.repo.MarkSent(message.ID); err != nil {
+					log.Printf("❌ Очередь уведомлений: сообщение %d отправлено, но не отмечено: %v", message.ID, err)
+				}
+				continue
+			}
+
+			var apiErr *APIError
+			if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+				retryAfter := time.Duration(apiErr.RetryAfter) * time.Second
+				log.Printf("⏳ Очередь уведомлений: Telegram просит подождать %s", retryAfter)
+				// Это сообщение и остальные взятые откладываем на тот же срок
+				for _, rest := range messages[i:] {
+					d.reschedule(rest.ID, retryAfter, err)
+				}
+				return retryAfter
+			}
+
+			d.fail(message.ID, message.ChatID, message.Attempts, err)
+		}
+	}
+	return 0
+}
+
+func (d *Dispatcher) fail(id, chatID int64, attempts int, sendErr error) {
+	var apiErr *APIError
+	permanent := errors.As(sendErr, &apiErr) && apiErr.Permanent()
+
+	if permanent || attempts >= maxAttempts {
+		log.Printf("❌ Очередь уведомлений: сообщение %d в чат %d не доставлено (попыток: %d): %v", id, chatID, attempts, sendErr)
+		if err := d.repo.MarkFailed(id, sendErr.Error()); err != nil {
+			log.Printf("❌ Очередь уведомлений: ошибка пометки сообщения %d: %v", id, err)
+		}
+		return
+	}
+
+	delay := backoff(attempts)
+	log.Printf("⚠️ Очередь уведомлений: сообщение %d в чат %d, попытка %d не удалась, повтор через %s: %v", id, chatID, attempts, delay, sendErr)
+	d.reschedule(id, delay, sendErr)
+}
+
+func (d *Dispatcher) reschedule(id int64, delay time.Duration, sendErr error) {
+	if err := d.repo.Reschedule(id, delay, sendErr.Error()); err != nil {
+		log.Printf("❌ Очередь уведомлений: ошибка переноса сообщения %d: %v", id, err)
+	}
+}
+
+// backoff задержка перед попыткой attempts+1: 2s, 4s, 8s ... но не больше maxBackoff
+func backoff(attempts int) time.Duration {
+	delay := baseBackoff
+	for i := 1; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxBackoff {
+		delay = maxBackoff
+	}
+	return delay
+}
@@ -0,0 +1,257 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/telegram/telegramtest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeOutbox очередь, которая отдаёт заранее подложенные сообщения один раз
+// и запоминает, чем закончилась отправка каждого
+type fakeOutbox struct {
+	mu       sync.Mutex
+	pending  []models.OutboxMessage
+	sent     []int64
+	failed   map[int64]string
+	delays   map[int64]time.Duration
+	enqueued []models.OutboxMessage
+}
+
+func newFakeOutbox(messages ...models.OutboxMessage) *fakeOutbox {
+	return &fakeOutbox{pending: messages, failed: make(map[int64]string), delays: make(map[int64]time.Duration)}
+}
+
+func (o *fakeOutbox) Enqueue(message *models.OutboxMessage) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	message.ID = int64(len(o.enqueued) + 1)
+	message.Attempts = 0
+	o.enqueued = append(o.enqueued, *message)
+	o.pending = append(o.pending, *message)
+	return nil
+}
+
+func (o *fakeOutbox) ClaimDue(limit int, lease time.Duration) ([]models.OutboxMessage, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	claimed := o.pending[:min(limit, len(o.pending))]
+	o.pending = o.pending[len(claimed):]
+	for i := range claimed {
+		claimed[i].Attempts++
+	}
+	return claimed, nil
+}
+
+func (o *fakeOutbox) MarkSent(id int64) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.sent = append(o.sent, id)
+	return nil
+}
+
+func (o *fakeOutbox) Reschedule(id int64, delay time.Duration, lastError string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.delays[id] = delay
+	return nil
+}
+
+func (o *fakeOutbox) MarkFailed(id int64, lastError string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.failed[id] = lastError
+	return nil
+}
+
+func outboxMessage(id int64, attempts int) models.OutboxMessage {
+	return models.OutboxMessage{
+		ID:       id,
+		ChatID:   100 + id,
+		Method:   "sendMessage",
+		Payload:  []byte(fmt.Sprintf(`{"chat_id":%d,"text":"сообщение %d"}`, 100+id, id)),
+		Attempts: attempts,
+	}
+}
+
+func newTestDispatcher(t *testing.T, outbox *fakeOutbox) (*Dispatcher, *telegramtest.Server) {
+	t.Helper()
+	const token = "123:test"
+	srv := telegramtest.NewServer(token)
+	t.Cleanup(srv.Close)
+
+	sender, err := NewTelegramSender(token, srv.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewDispatcher(outbox, sender), srv
+}
+
+func TestDispatcherDrain(t *testing.T) {
+	type failure struct {
+		code       int
+		retryAfter int
+	}
+	tests := []struct {
+		name          string
+		attempts      int // неудачных попыток до этой
+		failures      []failure
+		wantPause     time.Duration
+		wantSent      []int64
+		wantFailed    []int64
+		wantDelays    map[int64]time.Duration
+		wantDelivered int // сообщений, дошедших до Telegram
+	}{
+		{
+			name:          "доставлено",
+			wantSent:      []int64{1, 2},
+			wantDelivered: 2,
+		},
+		{
+			name:          "временная ошибка откладывается с backoff",
+			failures:      []failure{{code: http.StatusInternalServerError}},
+			wantSent:      []int64{2},
+			wantDelays:    map[int64]time.Duration{1: baseBackoff},
+			wantDelivered: 1,
+		},
+		{
+			name:          "повторная временная ошибка удваивает задержку",
+			attempts:      1,
+			failures:      []failure{{code: http.StatusBadGateway}},
+			wantSent:      []int64{2},
+			wantDelays:    map[int64]time.Duration{1: 2 * baseBackoff},
+			wantDelivered: 1,
+		},
+		{
+			name:          "попытки исчерпаны",
+			attempts:      maxAttempts - 1,
+			failures:      []failure{{code: http.StatusInternalServerError}},
+			wantSent:      []int64{2},
+			wantFailed:    []int64{1},
+			wantDelivered: 1,
+		},
+		{
+			name:          "400 постоянная ошибка",
+			failures:      []failure{{code: http.StatusBadRequest}},
+			wantSent:      []int64{2},
+			wantFailed:    []int64{1},
+			wantDelivered: 1,
+		},
+		{
+			name:          "403 бот заблокирован",
+			failures:      []failure{{code: http.StatusForbidden}},
+			wantSent:      []int64{2},
+			wantFailed:    []int64{1},
+			wantDelivered: 1,
+		},
+		{
+			name:       "429 откладывает все взятые сообщения на retry_after",
+			failures:   []failure{{code: http.StatusTooManyRequests, retryAfter: 7}},
+			wantPause:  7 * time.Second,
+			wantDelays: map[int64]time.Duration{1: 7 * time.Second, 2: 7 * time.Second},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outbox := newFakeOutbox(outboxMessage(1, tt.attempts), outboxMessage(2, 0))
+			dispatcher, srv := newTestDispatcher(t, outbox)
+			for _, f := range tt.failures {
+				srv.FailNext("sendMessage", f.code, "ошибка", f.retryAfter)
+			}
+
+			pause := dispatcher.drain(context.Background())
+			if pause != tt.wantPause {
+				t.Errorf("пауза = %s, want %s", pause, tt.wantPause)
+			}
+			if fmt.Sprint(outbox.sent) != fmt.Sprint(tt.wantSent) {
+				t.Errorf("доставлены %v, want %v", outbox.sent, tt.wantSent)
+			}
+			var failed []int64
+			for id := range outbox.failed {
+				failed = append(failed, id)
+			}
+			if fmt.Sprint(failed) != fmt.Sprint(tt.wantFailed) {
+				t.Errorf("не доставлены %v, want %v", failed, tt.wantFailed)
+			}
+			if len(outbox.delays) != len(tt.wantDelays) {
+				t.Errorf("отложены %v, want %v", outbox.delays, tt.wantDelays)
+			}
+			for id, want := range tt.wantDelays {
+				if outbox.delays[id] != want {
+					t.Errorf("сообщение %d отложено на %s, want %s", id, outbox.delays[id], want)
+				}
+			}
+			if n := len(srv.SentMessages()); n != tt.wantDelivered {
+				t.Errorf("Telegram получил %d сообщений, want %d", n, tt.wantDelivered)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: 2 * time.Second},
+		{attempts: 2, want: 4 * time.Second},
+		{attempts: 3, want: 8 * time.Second},
+		{attempts: 20, want: maxBackoff},
+	}
+	for _, tt := range tests {
+		if got := backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestNotifierDeliversThroughDispatcher(t *testing.T) {
+	outbox := newFakeOutbox()
+	dispatcher, srv := newTestDispatcher(t, outbox)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go dispatcher.Run(ctx)
+
+	notifier := NewNotifier(outbox, dispatcher)
+	if err := notifier.Send(Message{ChatID: 42, Text: "*Привет*", ParseMode: "Markdown"}); err != nil {
+		t.Fatal(err)
+	}
+
+	messages, err := srv.WaitForMessages(1, 2*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := messages[0]; got.ChatID != 42 || got.Text != "*Привет*" || got.ParseMode != "Markdown" {
+		t.Errorf("отправлено %+v", got)
+	}
+}
+
+func TestNotifierSendsPhotoThroughQueue(t *testing.T) {
+	outbox := newFakeOutbox()
+	dispatcher, srv := newTestDispatcher(t, outbox)
+
+	notifier := NewNotifier(outbox, nil)
+	if err := notifier.Send(Message{ChatID: 42, Text: "QR-код", Photo: []byte("\x89PNG")}); err != nil {
+		t.Fatal(err)
+	}
+	if method := outbox.enqueued[0].Method; method != "sendPhoto" {
+		t.Fatalf("метод в очереди = %q, want sendPhoto", method)
+	}
+
+	dispatcher.drain(context.Background())
+	if len(outbox.sent) != 1 {
+		t.Fatalf("доставлено %v, ошибки %v", outbox.sent, outbox.failed)
+	}
+	calls := srv.Requests("sendPhoto")
+	if len(calls) != 1 || calls[0].Params.Get("chat_id") != "42" || calls[0].Params.Get("caption") != "QR-код" {
+		t.Errorf("sendPhoto = %+v", calls)
+	}
+	if upload := calls[0].Params.Get(photoUploadField); upload != "" {
+		t.Errorf("картинка ушла полем формы, а не файлом: %q", upload)
+	}
+}
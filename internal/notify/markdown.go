@@ -0,0 +1,13 @@
+package notify
+
+import "strings"
+
+// markdownEscaper экранирует спецсимволы Markdown (parse_mode=Markdown)
+var markdownEscaper = strings.NewReplacer("_", "\\_", "*", "\\*", "`", "\\`", "[", "\\[")
+
+// EscapeMarkdown экранирует разметку в тексте, который вводят пользователи
+// (названия групп, имена, описания, причины). Неэкранированный символ ломает
+// разбор сообщения, и Telegram отвечает 400 — сообщение не доставляется.
+func EscapeMarkdown(text string) string {
+	return markdownEscaper.Replace(text)
+}
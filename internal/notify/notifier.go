@@ -0,0 +1,82 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/repository"
+)
+
+// Message сообщение пользователю. ReplyMarkup сериализуется в JSON как есть,
+// поэтому подходят и клавиатуры tgbotapi, и собственные структуры (web_app кнопки).
+// Если задан Photo, сообщение уходит через sendPhoto, а Text становится подписью.
+type Message struct {
+	ChatID                int64       `json:"chat_id"`
+	Text                  string      `json:"text"`
+	ParseMode             string      `json:"parse_mode,omitempty"`
+	ReplyMarkup           interface{} `json:"reply_markup,omitempty"`
+	DisableWebPagePreview bool        `json:"disable_web_page_preview,omitempty"`
+	Photo                 []byte      `json:"-"` // картинка для загрузки (PNG, JPEG)
+}
+
+// photoPayload параметры sendPhoto в очереди: файл хранится в payload
+// (base64 в JSON) и выгружается отправителем как multipart
+type photoPayload struct {
+	ChatID      int64       `json:"chat_id"`
+	Caption     string      `json:"caption,omitempty"`
+	ParseMode   string      `json:"parse_mode,omitempty"`
+	ReplyMarkup interface{} `json:"reply_markup,omitempty"`
+	Photo       []byte      `json:"photo_upload"`
+}
+
+// Notifier единая точка отправки сообщений пользователям.
+// Send ставит сообщение в постоянную очередь; ошибка означает, что сообщение не сохранено.
+type Notifier interface {
+	Send(message Message) error
+}
+
+type outboxNotifier struct {
+	repo       repository.OutboxRepository
+	dispatcher *Dispatcher
+}
+
+// NewNotifier сохраняет сообщения в outbox и будит dispatcher (может быть nil,
+// тогда сообщения заберёт dispatcher другой реплики по таймеру)
+func NewNotifier(repo repository.OutboxRepository, dispatcher *Dispatcher) Notifier {
+	return &outboxNotifier{repo: repo, dispatcher: dispatcher}
+}
+
+func (n *outboxNotifier) Send(message Message) error {
+	method := "sendMessage"
+	var payload []byte
+	var err error
+	if len(message.Photo) > 0 {
+		method = "sendPhoto"
+		payload, err = json.Marshal(photoPayload{
+			ChatID:      message.ChatID,
+			Caption:     message.Text,
+			ParseMode:   message.ParseMode,
+			ReplyMarkup: message.ReplyMarkup,
+			Photo:       message.Photo,
+		})
+	} else {
+		payload, err = json.Marshal(message)
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации сообщения для чата %d: %w", message.ChatID, err)
+	}
+
+	err = n.repo.Enqueue(&models.OutboxMessage{
+		ChatID:  message.ChatID,
+		Method:  method,
+		Payload: payload,
+	})
+	if err != nil {
+		return fmt.Errorf("ошибка постановки сообщения в очередь для чата %d: %w", message.ChatID, err)
+	}
+
+	if n.dispatcher != nil {
+		n.dispatcher.Wake()
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"spectrum-club-bot/internal/telegram"
+	"strconv"
+)
+
+// Sender выполняет один вызов Bot API
+type Sender interface {
+	Send(method string, payload []byte) error
+}
+
+// APIError ошибка, которую вернул Telegram
+type APIError struct {
+	Code        int
+	Description string
+	RetryAfter  int // секунды, для 429 Too Many Requests
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("telegram: %d %s", e.Code, e.Description)
+}
+
+// Permanent повтор не поможет: бот заблокирован, чат не найден, неверный запрос
+func (e *APIError) Permanent() bool {
+	return e.Code == http.StatusBadRequest || e.Code == http.StatusForbidden
+}
+
+// photoUploadField поле payload с картинкой для sendPhoto (см. photoPayload)
+const photoUploadField = "photo_upload"
+
+type telegramSender struct {
+	token  string
+	apiURL string
+	client *http.Client
+}
+
+// NewTelegramSender отправляет вызовы в Bot API по адресу apiURL (config.Bot.APIURL)
+func NewTelegramSender(token, apiURL string) (Sender, error) {
+	client, err := telegram.NewHTTPClient(apiURL)
+	if err != nil {
+		return nil, err
+	}
+	return &telegramSender{token: token, apiURL: apiURL, client: client}, nil
+}
+
+func (s *telegramSender) Send(method string, payload []byte) error {
+	var fields map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber() // chat_id не должен проходить через float64
+	if err := decoder.Decode(&fields); err != nil {
+		return fmt.Errorf("повреждённые параметры сообщения: %w", err)
+	}
+
+	// Картинка из очереди уходит файлом в multipart, остальные параметры — полями формы
+	var photo []byte
+	if encoded, ok := fields[photoUploadField].(string); ok {
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return fmt.Errorf("повреждённая картинка сообщения: %w", err)
+		}
+		photo = decoded
+		delete(fields, photoUploadField)
+	}
+
+	// Параметры уходят формой, как в tgbotapi: вложенные объекты — JSON-строкой
+	params := url.Values{}
+	for key, value := range fields {
+		switch v := value.(type) {
+		case string:
+			params.Set(key, v)
+		case json.Number:
+			params.Set(key, v.String())
+		case bool:
+			params.Set(key, strconv.FormatBool(v))
+		case nil:
+		default:
+			encoded, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			params.Set(key, string(encoded))
+		}
+	}
+
+	var resp *http.Response
+	var err error
+	if photo != nil {
+		resp, err = s.postPhoto(method, params, photo)
+	} else {
+		resp, err = s.client.PostForm(telegram.MethodURL(s.apiURL, s.token, method), params)
+	}
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var apiResp struct {
+		Ok          bool   `json:"ok"`
+		ErrorCode   int    `json:"error_code"`
+		Description string `json:"description"`
+		Parameters  *struct {
+			RetryAfter int `json:"retry_after"`
+		} `json:"parameters"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return fmt.Errorf("telegram: неожиданный ответ %s: %w", resp.Status, err)
+	}
+	if apiResp.Ok {
+		return nil
+	}
+
+	apiErr := &APIError{Code: apiResp.ErrorCode, Description: apiResp.Description}
+	if apiErr.Code == 0 {
+		apiErr.Code = resp.StatusCode
+	}
+	if apiResp.Parameters != nil {
+		apiErr.RetryAfter = apiResp.Parameters.RetryAfter
+	}
+	return apiErr
+}
+
+// postPhoto отправляет sendPhoto с файлом в поле photo
+func (s *telegramSender) postPhoto(method string, params url.Values, photo []byte) (*http.Response, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for key := range params {
+		if err := form.WriteField(key, params.Get(key)); err != nil {
+			return nil, err
+		}
+	}
+	part, err := form.CreateFormFile("photo", "photo.png")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := part.Write(photo); err != nil {
+		return nil, err
+	}
+	if err := form.Close(); err != nil {
+		return nil, err
+	}
+
+	return s.client.Post(telegram.MethodURL(s.apiURL, s.token, method), form.FormDataContentType(), &body)
+}
@@ -0,0 +1,64 @@
+package payments
+
+import (
+	"strconv"
+	"strings"
+)
+
+// buyCallbackPrefix callback data кнопки покупки абонемента: buy:<id тарифа>
+const buyCallbackPrefix = "buy:"
+
+// stubCallbackPrefix callback data кнопки оплаты тестового счёта: paystub:<сумма в копейках>:<валюта>:<payload>
+const stubCallbackPrefix = "paystub:"
+
+// BuyCallbackData callback data кнопки покупки абонемента по тарифу
+func BuyCallbackData(planID int64) string {
+	return buyCallbackPrefix + strconv.FormatInt(planID, 10)
+}
+
+// ParseBuyCallback разбирает callback data кнопки покупки абонемента
+func ParseBuyCallback(data string) (planID int64, ok bool) {
+	idStr, found := strings.CutPrefix(data, buyCallbackPrefix)
+	if !found {
+		return 0, false
+	}
+	planID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return planID, true
+}
+
+// StubPayment оплата тестового счёта: те же поля, что Telegram присылает в successful_payment
+type StubPayment struct {
+	Payload     string
+	Currency    string
+	TotalAmount int // в копейках
+}
+
+// ChargeID идентификатор оплаты, который заглушка подставляет вместо telegram_payment_charge_id
+func (p StubPayment) ChargeID() string {
+	return "stub-" + p.Payload
+}
+
+// StubCallbackData callback data кнопки оплаты тестового счёта
+func StubCallbackData(payment StubPayment) string {
+	return stubCallbackPrefix + strconv.Itoa(payment.TotalAmount) + ":" + payment.Currency + ":" + payment.Payload
+}
+
+// ParseStubCallback разбирает callback data кнопки оплаты тестового счёта
+func ParseStubCallback(data string) (StubPayment, bool) {
+	rest, found := strings.CutPrefix(data, stubCallbackPrefix)
+	if !found {
+		return StubPayment{}, false
+	}
+	parts := strings.SplitN(rest, ":", 3)
+	if len(parts) != 3 {
+		return StubPayment{}, false
+	}
+	amount, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return StubPayment{}, false
+	}
+	return StubPayment{Payload: parts[2], Currency: parts[1], TotalAmount: amount}, true
+}
@@ -0,0 +1,57 @@
+package payments
+
+import (
+	"fmt"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/notify"
+	"spectrum-club-bot/internal/service"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// stubProvider заглушка для локального запуска и демо-режима: вместо счёта Telegram
+// присылает сообщение с кнопкой, нажатие которой бот обрабатывает как successful_payment
+type stubProvider struct {
+	notifier notify.Notifier
+}
+
+func NewStubProvider(notifier notify.Notifier) service.PaymentProvider {
+	return &stubProvider{notifier: notifier}
+}
+
+func (p *stubProvider) SendInvoice(invoice models.Invoice) error {
+	var text strings.Builder
+	text.WriteString("🧪 Тестовый счёт\n\n")
+	text.WriteString(fmt.Sprintf("%s\n%s\n\n", invoice.Title, invoice.Description))
+	text.WriteString(fmt.Sprintf("К оплате: %d %s. Деньги не списываются.", invoice.Amount, invoice.Currency))
+
+	payment := StubPayment{
+		Payload:     invoice.Payload,
+		Currency:    invoice.Currency,
+		TotalAmount: invoice.Amount * 100,
+	}
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(
+				fmt.Sprintf("💳 Оплатить %d %s", invoice.Amount, invoice.Currency),
+				StubCallbackData(payment),
+			),
+		),
+	)
+
+	return p.notifier.Send(notify.Message{
+		ChatID:      invoice.ChatID,
+		Text:        text.String(),
+		ReplyMarkup: keyboard,
+	})
+}
+
+// AnswerPreCheckout заглушка не присылает pre_checkout_query: оплата подтверждается кнопкой
+func (p *stubProvider) AnswerPreCheckout(queryID, errorMessage string) error {
+	return nil
+}
+
+func (p *stubProvider) HandlesCallbacks() bool {
+	return true
+}
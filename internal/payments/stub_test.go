@@ -0,0 +1,64 @@
+package payments
+
+import (
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/notify"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// sentMessages notify.Notifier, запоминающий сообщения вместо отправки
+type sentMessages []notify.Message
+
+func (s *sentMessages) Send(message notify.Message) error {
+	*s = append(*s, message)
+	return nil
+}
+
+func TestStubInvoiceButtonPaysInvoice(t *testing.T) {
+	var sent sentMessages
+	provider := NewStubProvider(&sent)
+	if !provider.HandlesCallbacks() {
+		t.Fatal("заглушка должна подтверждать оплату кнопкой")
+	}
+
+	invoice := models.Invoice{ChatID: -2, Payload: "payment:5", Title: "Абонемент", Currency: "RUB", Amount: 4000}
+	if err := provider.SendInvoice(invoice); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 1 || sent[0].ChatID != invoice.ChatID {
+		t.Fatalf("сообщения = %+v", sent)
+	}
+
+	keyboard, ok := sent[0].ReplyMarkup.(tgbotapi.InlineKeyboardMarkup)
+	if !ok || len(keyboard.InlineKeyboard) != 1 || keyboard.InlineKeyboard[0][0].CallbackData == nil {
+		t.Fatalf("клавиатура = %+v", sent[0].ReplyMarkup)
+	}
+	payment, ok := ParseStubCallback(*keyboard.InlineKeyboard[0][0].CallbackData)
+	if !ok {
+		t.Fatalf("callback data %q не разбирается", *keyboard.InlineKeyboard[0][0].CallbackData)
+	}
+	// Сумма в копейках, как в successful_payment
+	want := StubPayment{Payload: invoice.Payload, Currency: "RUB", TotalAmount: 400000}
+	if payment != want {
+		t.Errorf("оплата = %+v, want %+v", payment, want)
+	}
+}
+
+func TestParseStubCallback(t *testing.T) {
+	tests := []struct {
+		data   string
+		wantOK bool
+	}{
+		{data: "paystub:400000:RUB:payment:5", wantOK: true},
+		{data: "paystub:400000:RUB"},
+		{data: "paystub:много:RUB:payment:5"},
+		{data: "buy:1"},
+	}
+	for _, tt := range tests {
+		if _, ok := ParseStubCallback(tt.data); ok != tt.wantOK {
+			t.Errorf("ParseStubCallback(%q) ok = %v, want %v", tt.data, ok, tt.wantOK)
+		}
+	}
+}
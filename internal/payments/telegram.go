@@ -0,0 +1,73 @@
+package payments
+
+import (
+	"encoding/json"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/notify"
+	"spectrum-club-bot/internal/service"
+)
+
+type labeledPrice struct {
+	Label  string `json:"label"`
+	Amount int    `json:"amount"`
+}
+
+type invoiceRequest struct {
+	ChatID        int64          `json:"chat_id"`
+	Title         string         `json:"title"`
+	Description   string         `json:"description"`
+	Payload       string         `json:"payload"`
+	ProviderToken string         `json:"provider_token"`
+	Currency      string         `json:"currency"`
+	Prices        []labeledPrice `json:"prices"`
+}
+
+type preCheckoutAnswer struct {
+	QueryID      string `json:"pre_checkout_query_id"`
+	Ok           bool   `json:"ok"`
+	ErrorMessage string `json:"error_message,omitempty"`
+}
+
+// telegramProvider счета через Telegram Payments. Вызовы идут напрямую, а не через
+// очередь уведомлений: ученик ждёт счёт, а на pre_checkout_query нужно ответить за 10 секунд
+type telegramProvider struct {
+	sender        notify.Sender
+	providerToken string
+}
+
+func NewTelegramProvider(sender notify.Sender, providerToken string) service.PaymentProvider {
+	return &telegramProvider{sender: sender, providerToken: providerToken}
+}
+
+func (p *telegramProvider) SendInvoice(invoice models.Invoice) error {
+	return p.call("sendInvoice", invoiceRequest{
+		ChatID:        invoice.ChatID,
+		Title:         invoice.Title,
+		Description:   invoice.Description,
+		Payload:       invoice.Payload,
+		ProviderToken: p.providerToken,
+		Currency:      invoice.Currency,
+		Prices:        []labeledPrice{{Label: invoice.Title, Amount: invoice.Amount * 100}},
+	})
+}
+
+func (p *telegramProvider) AnswerPreCheckout(queryID, errorMessage string) error {
+	return p.call("answerPreCheckoutQuery", preCheckoutAnswer{
+		QueryID:      queryID,
+		Ok:           errorMessage == "",
+		ErrorMessage: errorMessage,
+	})
+}
+
+// HandlesCallbacks оплату подтверждает только successful_payment от Telegram
+func (p *telegramProvider) HandlesCallbacks() bool {
+	return false
+}
+
+func (p *telegramProvider) call(method string, request interface{}) error {
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	return p.sender.Send(method, payload)
+}
@@ -0,0 +1,67 @@
+package refund
+
+import (
+	"fmt"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/notify"
+	"spectrum-club-bot/internal/service"
+	"strings"
+)
+
+// notifier сообщает ученикам об отмене тренировок, отметке посещений и возврате занятий через очередь уведомлений
+type notifier struct {
+	notifier notify.Notifier
+}
+
+func NewNotifier(n notify.Notifier) service.TrainingNotifier {
+	return &notifier{notifier: n}
+}
+
+func (n *notifier) TrainingCancelled(telegramID int64, training *models.TrainingSchedule, refunded int) error {
+	var text strings.Builder
+	text.WriteString("🚫 *Тренировка отменена*\n\n")
+	writeTraining(&text, training)
+	if training.CancelReason != "" {
+		text.WriteString(fmt.Sprintf("💬 *Причина:* %s\n", notify.EscapeMarkdown(training.CancelReason)))
+	}
+	if refunded > 0 {
+		text.WriteString(fmt.Sprintf("\n↩️ На абонемент возвращено занятий: %d", refunded))
+	}
+
+	return n.send(telegramID, text.String())
+}
+
+func (n *notifier) AttendanceReverted(telegramID int64, training *models.TrainingSchedule, refunded int) error {
+	var text strings.Builder
+	text.WriteString("↩️ *Отметка о посещении снята*\n\n")
+	writeTraining(&text, training)
+	text.WriteString(fmt.Sprintf("\nНа абонемент возвращено занятий: %d", refunded))
+
+	return n.send(telegramID, text.String())
+}
+
+func (n *notifier) AttendanceMarked(telegramID int64, training *models.TrainingSchedule, remaining int) error {
+	var text strings.Builder
+	text.WriteString("✅ *Посещаемость отмечена!*\n\n")
+	writeTraining(&text, training)
+	text.WriteString(fmt.Sprintf("\n🎫 *Осталось занятий:* %d", remaining))
+
+	return n.send(telegramID, text.String())
+}
+
+func (n *notifier) send(telegramID int64, text string) error {
+	return n.notifier.Send(notify.Message{
+		ChatID:    telegramID,
+		Text:      text,
+		ParseMode: "Markdown",
+	})
+}
+
+func writeTraining(text *strings.Builder, training *models.TrainingSchedule) {
+	text.WriteString(fmt.Sprintf("📅 *Дата:* %s\n", training.TrainingDate.Format("02.01.2006")))
+	text.WriteString(fmt.Sprintf("🕐 *Время:* %s - %s\n",
+		training.StartTime.Format("15:04"), training.EndTime.Format("15:04")))
+	if training.GroupName != "" {
+		text.WriteString(fmt.Sprintf("👥 *Группа:* %s\n", notify.EscapeMarkdown(training.GroupName)))
+	}
+}
@@ -0,0 +1,188 @@
+package reminder
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/notify"
+	"spectrum-club-bot/internal/repository"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// Callback data кнопок отмены записи из напоминания: первая кнопка показывает условия отмены,
+// вторая подтверждает её
+const (
+	cancelCallbackPrefix        = "cancel_signup:"
+	confirmCancelCallbackPrefix = "cancel_signup_ok:"
+)
+
+// CancelCallbackData callback data кнопки "Отменить запись" для тренировки
+func CancelCallbackData(trainingID int) string {
+	return cancelCallbackPrefix + strconv.Itoa(trainingID)
+}
+
+// ConfirmCancelCallbackData callback data кнопки подтверждения отмены записи
+func ConfirmCancelCallbackData(trainingID int) string {
+	return confirmCancelCallbackPrefix + strconv.Itoa(trainingID)
+}
+
+// ParseCancelCallback разбирает callback data кнопок отмены записи;
+// confirmed — ученик уже видел условия и подтвердил отмену
+func ParseCancelCallback(data string) (trainingID int, confirmed, ok bool) {
+	idStr, found := strings.CutPrefix(data, cancelCallbackPrefix)
+	if !found {
+		idStr, confirmed = strings.CutPrefix(data, confirmCancelCallbackPrefix)
+		if !confirmed {
+			return 0, false, false
+		}
+	}
+	trainingID, err := strconv.Atoi(idStr)
+	if err != nil {
+		return 0, false, false
+	}
+	return trainingID, confirmed, true
+}
+
+// Scheduler периодически напоминает ученикам о тренировках, на которые они записаны.
+// Каждое напоминание отмечается в БД до постановки в очередь, поэтому после рестарта
+// (и при нескольких репликах) оно не уходит повторно.
+type Scheduler struct {
+	repo     repository.ReminderRepository
+	notifier notify.Notifier
+	offsets  []time.Duration // по возрастанию
+	interval time.Duration
+}
+
+func NewScheduler(repo repository.ReminderRepository, notifier notify.Notifier, offsets []time.Duration, interval time.Duration) *Scheduler {
+	sorted := append([]time.Duration(nil), offsets...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	return &Scheduler{
+		repo:     repo,
+		notifier: notifier,
+		offsets:  sorted,
+		interval: interval,
+	}
+}
+
+// Run проверяет напоминания каждые interval до отмены ctx
+func (s *Scheduler) Run(ctx context.Context) {
+	if len(s.offsets) == 0 {
+		log.Printf("⏰ Напоминания о тренировках выключены")
+		return
+	}
+	log.Printf("⏰ Напоминания о тренировках: за %v до начала", s.offsets)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.check(time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) check(now time.Time) {
+	maxOffset := s.offsets[len(s.offsets)-1]
+	registrations, err := s.repo.GetUpcomingRegistrations(now, now.Add(maxOffset))
+	if err != nil {
+		log.Printf("❌ Напоминания: ошибка получения записей: %v", err)
+		return
+	}
+
+	for _, registration := range registrations {
+		start := trainingStart(registration)
+		if !start.After(now) {
+			continue
+		}
+
+		// Берём наименьшее смещение, окно которого уже открылось: если ученик
+		// записался за час до начала, он получит одно напоминание, а не все сразу
+		offset, ok := s.dueOffset(start.Sub(now))
+		if !ok {
+			continue
+		}
+		s.remind(registration, start, offset)
+	}
+}
+
+func (s *Scheduler) dueOffset(untilStart time.Duration) (time.Duration, bool) {
+	for _, offset := range s.offsets {
+		if untilStart <= offset {
+			return offset, true
+		}
+	}
+	return 0, false
+}
+
+func (s *Scheduler) remind(registration models.UpcomingRegistration, start time.Time, offset time.Duration) {
+	offsetMinutes := int(offset / time.Minute)
+
+	marked, err := s.repo.MarkSent(registration.AttendanceID, offsetMinutes)
+	if err != nil {
+		log.Printf("❌ Напоминания: ошибка отметки для записи %d: %v", registration.AttendanceID, err)
+		return
+	}
+	if !marked {
+		return
+	}
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("❌ Отменить запись", CancelCallbackData(registration.TrainingID)),
+		),
+	)
+
+	err = s.notifier.Send(notify.Message{
+		ChatID:      registration.TelegramID,
+		Text:        reminderText(registration, start),
+		ParseMode:   "Markdown",
+		ReplyMarkup: keyboard,
+	})
+	if err != nil {
+		log.Printf("❌ Напоминания: не удалось поставить напоминание в очередь (запись %d): %v", registration.AttendanceID, err)
+		if err := s.repo.Unmark(registration.AttendanceID, offsetMinutes); err != nil {
+			log.Printf("❌ Напоминания: ошибка снятия отметки для записи %d: %v", registration.AttendanceID, err)
+		}
+	}
+}
+
+func reminderText(registration models.UpcomingRegistration, start time.Time) string {
+	var text strings.Builder
+	text.WriteString("⏰ *Напоминание о тренировке*\n\n")
+	text.WriteString(fmt.Sprintf("📅 *Дата:* %s\n", start.Format("02.01.2006")))
+	text.WriteString(fmt.Sprintf("🕐 *Время:* %s - %s\n",
+		registration.StartTime.Format("15:04"), registration.EndTime.Format("15:04")))
+	if registration.GroupName != "" {
+		text.WriteString(fmt.Sprintf("👥 *Группа:* %s\n", notify.EscapeMarkdown(registration.GroupName)))
+	}
+	if registration.CoachName != "" {
+		text.WriteString(fmt.Sprintf("👨‍🏫 *Тренер:* %s\n", notify.EscapeMarkdown(registration.CoachName)))
+	}
+	if registration.Description != "" {
+		text.WriteString(fmt.Sprintf("📝 %s\n", notify.EscapeMarkdown(registration.Description)))
+	}
+	text.WriteString("\nЕсли не сможете прийти, отмените запись, чтобы освободить место.")
+	return text.String()
+}
+
+// trainingStart момент начала тренировки: DATE и TIME из БД хранят время клуба без зоны
+func trainingStart(registration models.UpcomingRegistration) time.Time {
+	date := registration.TrainingDate
+	clock := registration.StartTime
+	return time.Date(date.Year(), date.Month(), date.Day(), clock.Hour(), clock.Minute(), 0, 0, time.Local)
+}
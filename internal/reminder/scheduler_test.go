@@ -0,0 +1,99 @@
+package reminder
+
+import (
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/notify"
+	"spectrum-club-bot/internal/repository/memory"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sentMessages notify.Notifier, запоминающий сообщения вместо отправки
+type sentMessages []notify.Message
+
+func (s *sentMessages) Send(message notify.Message) error {
+	*s = append(*s, message)
+	return nil
+}
+
+func TestDueOffset(t *testing.T) {
+	s := NewScheduler(nil, nil, []time.Duration{24 * time.Hour, time.Hour}, time.Minute)
+
+	tests := []struct {
+		untilStart time.Duration
+		want       time.Duration
+		wantOK     bool
+	}{
+		{untilStart: 48 * time.Hour},
+		{untilStart: 24*time.Hour + time.Minute},
+		{untilStart: 24 * time.Hour, want: 24 * time.Hour, wantOK: true},
+		{untilStart: 3 * time.Hour, want: 24 * time.Hour, wantOK: true},
+		{untilStart: time.Hour, want: time.Hour, wantOK: true},
+		{untilStart: time.Minute, want: time.Hour, wantOK: true},
+	}
+	for _, tt := range tests {
+		got, ok := s.dueOffset(tt.untilStart)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("dueOffset(%v) = %v, %v, want %v, %v", tt.untilStart, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestCheckSendsEachReminderOnce(t *testing.T) {
+	store := memory.NewStore()
+	group := store.AddGroup(models.TrainingGroup{Name: "Дети_5-7", Code: "kids"})
+
+	start := time.Date(2030, 3, 10, 19, 0, 0, 0, time.Local)
+	training := models.TrainingSchedule{
+		GroupID:      group.ID,
+		TrainingDate: start,
+		StartTime:    time.Date(0, 1, 1, 19, 0, 0, 0, time.UTC),
+		EndTime:      time.Date(0, 1, 1, 20, 0, 0, 0, time.UTC),
+		Description:  "Взять *скакалку*",
+	}
+	if err := memory.NewTrainingScheduleRepository(store).CreateTraining(&training); err != nil {
+		t.Fatal(err)
+	}
+	user := &models.User{TelegramID: 42, FirstName: "Анна", LastName: "Тестова", Role: "student"}
+	if err := memory.NewUserRepository(store).CreateOrUpdate(user); err != nil {
+		t.Fatal(err)
+	}
+	student := &models.Student{UserID: user.ID}
+	if err := memory.NewStudentRepository(store).Create(student); err != nil {
+		t.Fatal(err)
+	}
+	if err := memory.NewAttendanceRepository(store).CreateAttendance(&models.Attendance{TrainingID: training.ID, StudentID: int(student.ID)}); err != nil {
+		t.Fatal(err)
+	}
+
+	var sent sentMessages
+	s := NewScheduler(memory.NewReminderRepository(store), &sent, []time.Duration{time.Hour, 24 * time.Hour}, time.Minute)
+
+	steps := []struct {
+		name      string
+		now       time.Time
+		wantTotal int
+	}{
+		{name: "за два дня", now: start.Add(-48 * time.Hour), wantTotal: 0},
+		{name: "окно за сутки", now: start.Add(-2 * time.Hour), wantTotal: 1},
+		{name: "повторная проверка", now: start.Add(-90 * time.Minute), wantTotal: 1},
+		{name: "окно за час", now: start.Add(-30 * time.Minute), wantTotal: 2},
+		{name: "повторная проверка часового окна", now: start.Add(-10 * time.Minute), wantTotal: 2},
+		{name: "тренировка началась", now: start.Add(time.Minute), wantTotal: 2},
+	}
+	for _, step := range steps {
+		s.check(step.now)
+		if len(sent) != step.wantTotal {
+			t.Fatalf("%s: отправлено %d напоминаний, want %d", step.name, len(sent), step.wantTotal)
+		}
+	}
+
+	text := sent[0].Text
+	if !strings.Contains(text, `Дети\_5-7`) || !strings.Contains(text, `Взять \*скакалку\*`) {
+		t.Errorf("название группы и описание не экранированы: %q", text)
+	}
+	if sent[0].ChatID != user.TelegramID {
+		t.Errorf("ChatID = %d, want %d", sent[0].ChatID, user.TelegramID)
+	}
+}
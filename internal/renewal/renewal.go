@@ -0,0 +1,125 @@
+package renewal
+
+import (
+	"fmt"
+	"log"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/notify"
+	"spectrum-club-bot/internal/repository"
+	"spectrum-club-bot/internal/service"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// Действия inline-кнопок запросов на продление
+const (
+	ActionRequest = "request" // ученик выбрал тариф; id — тариф
+	ActionCancel  = "cancel"  // ученик отзывает запрос; id — запрос
+	ActionApprove = "approve" // тренер одобряет запрос; id — запрос
+	ActionReject  = "reject"  // тренер отклоняет запрос; id — запрос
+)
+
+// callbackPrefix callback data кнопок продления: renewal:<действие>:<id>
+const callbackPrefix = "renewal:"
+
+// CallbackData callback data кнопки продления
+func CallbackData(action string, id int64) string {
+	return callbackPrefix + action + ":" + strconv.FormatInt(id, 10)
+}
+
+// ParseCallback разбирает callback data кнопки продления
+func ParseCallback(data string) (action string, id int64, ok bool) {
+	rest, found := strings.CutPrefix(data, callbackPrefix)
+	if !found {
+		return "", 0, false
+	}
+	action, idStr, found := strings.Cut(rest, ":")
+	if !found {
+		return "", 0, false
+	}
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return action, id, true
+}
+
+// DecisionKeyboard кнопки тренера под запросом на продление
+func DecisionKeyboard(requestID int64) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ Одобрить", CallbackData(ActionApprove, requestID)),
+			tgbotapi.NewInlineKeyboardButtonData("❌ Отклонить", CallbackData(ActionReject, requestID)),
+		),
+	)
+}
+
+// requestNotifier отправляет запросы тренерам и решения ученикам через очередь уведомлений
+type requestNotifier struct {
+	notifier notify.Notifier
+	coaches  repository.CoachRepository
+	users    repository.UserRepository
+}
+
+func NewNotifier(notifier notify.Notifier, coaches repository.CoachRepository, users repository.UserRepository) service.RenewalNotifier {
+	return &requestNotifier{notifier: notifier, coaches: coaches, users: users}
+}
+
+// RenewalRequested отправляет запрос всем тренерам; кнопки сработают только у первого,
+// кто примет решение, остальным бот ответит, что запрос уже рассмотрен
+func (n *requestNotifier) RenewalRequested(request models.RenewalRequest, plan *models.SubscriptionPlan) error {
+	coaches, err := n.coaches.GetAll()
+	if err != nil {
+		return fmt.Errorf("ошибка получения тренеров: %w", err)
+	}
+
+	text := fmt.Sprintf("📨 *Запрос на продление абонемента*\n\n👤 %s\n📋 %s (%s)",
+		notify.EscapeMarkdown(request.StudentName), notify.EscapeMarkdown(plan.Name), plan.Summary())
+
+	var firstErr error
+	for _, coach := range coaches {
+		user, err := n.users.GetByID(coach.UserID)
+		if err != nil {
+			log.Printf("❌ Продление: пользователь тренера %d не найден: %v", coach.ID, err)
+			continue
+		}
+		err = n.notifier.Send(notify.Message{
+			ChatID:      user.TelegramID,
+			Text:        text,
+			ParseMode:   "Markdown",
+			ReplyMarkup: DecisionKeyboard(request.ID),
+		})
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (n *requestNotifier) RenewalApproved(request models.RenewalRequest, subscription *models.Subscription) error {
+	text := fmt.Sprintf("🎫 *Тренер одобрил продление абонемента!*\n\n"+
+		"📋 *Тип:* %s\n"+
+		"📊 *Количество занятий:* %d\n"+
+		"📅 *Действует до:* %s",
+		notify.EscapeMarkdown(request.PlanName),
+		subscription.TotalLessons,
+		subscription.EndDate.Format("02.01.2006"),
+	)
+
+	return n.notifier.Send(notify.Message{
+		ChatID:    request.TelegramID,
+		Text:      text,
+		ParseMode: "Markdown",
+	})
+}
+
+func (n *requestNotifier) RenewalRejected(request models.RenewalRequest) error {
+	text := fmt.Sprintf("😔 Тренер отклонил запрос на абонемент «%s». Уточните детали у тренера.", request.PlanName)
+
+	return n.notifier.Send(notify.Message{
+		ChatID: request.TelegramID,
+		Text:   text,
+	})
+}
@@ -6,39 +6,41 @@ import (
 	"spectrum-club-bot/internal/models"
 	"spectrum-club-bot/internal/repository"
 	"time"
-
-	"github.com/jmoiron/sqlx"
 )
 
 type attendanceRepository struct {
-	db *sqlx.DB
+	db repository.DBTX
 }
 
-func NewAttendanceRepository(db *sqlx.DB) repository.AttendanceRepository {
+func NewAttendanceRepository(db repository.DBTX) repository.AttendanceRepository {
 	return &attendanceRepository{db: db}
 }
 
 func (r *attendanceRepository) CreateAttendance(attendance *models.Attendance) error {
 	query := `
-		INSERT INTO spectrum.attendance 
-		(training_id, student_id, attended, notes, recorded_by)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id, recorded_at
+		WITH created AS (
+			INSERT INTO spectrum.attendance (training_id, student_id, notes, recorded_by)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id, status, recorded_by, recorded_at
+		), logged AS (
+			INSERT INTO spectrum.attendance_status_log (attendance_id, to_status, actor_id)
+			SELECT id, status, recorded_by FROM created
+		)
+		SELECT id, status, recorded_at FROM created
 	`
 	return r.db.QueryRow(
 		query,
 		attendance.TrainingID,
 		attendance.StudentID,
-		attendance.Attended,
 		attendance.Notes,
 		attendance.RecordedBy,
-	).Scan(&attendance.ID, &attendance.RecordedAt)
+	).Scan(&attendance.ID, &attendance.Status, &attendance.RecordedAt)
 }
 
 func (r *attendanceRepository) GetAttendanceByID(id int) (*models.Attendance, error) {
 	query := `
 		SELECT 
-			a.id, a.training_id, a.student_id, a.attended, a.notes, 
+			a.id, a.training_id, a.student_id, a.status, a.attended, a.notes, 
 			a.recorded_by, a.recorded_at,
 			u.first_name || ' ' || u.last_name as student_name
 		FROM spectrum.attendance a
@@ -50,7 +52,7 @@ func (r *attendanceRepository) GetAttendanceByID(id int) (*models.Attendance, er
 	attendance := &models.Attendance{}
 	err := r.db.QueryRow(query, id).Scan(
 		&attendance.ID, &attendance.TrainingID, &attendance.StudentID,
-		&attendance.Attended, &attendance.Notes, &attendance.RecordedBy,
+		&attendance.Status, &attendance.Attended, &attendance.Notes, &attendance.RecordedBy,
 		&attendance.RecordedAt, &attendance.StudentName,
 	)
 	if err != nil {
@@ -65,7 +67,7 @@ func (r *attendanceRepository) GetAttendanceByID(id int) (*models.Attendance, er
 func (r *attendanceRepository) GetAttendanceByTraining(trainingID int) ([]models.Attendance, error) {
 	query := `
 		SELECT 
-			a.id, a.training_id, a.student_id, a.attended, a.notes, 
+			a.id, a.training_id, a.student_id, a.status, a.attended, a.notes, 
 			a.recorded_by, a.recorded_at,
 			u.first_name || ' ' || u.last_name as student_name
 		FROM spectrum.attendance a
@@ -86,7 +88,7 @@ func (r *attendanceRepository) GetAttendanceByTraining(trainingID int) ([]models
 		var attendance models.Attendance
 		err := rows.Scan(
 			&attendance.ID, &attendance.TrainingID, &attendance.StudentID,
-			&attendance.Attended, &attendance.Notes, &attendance.RecordedBy,
+			&attendance.Status, &attendance.Attended, &attendance.Notes, &attendance.RecordedBy,
 			&attendance.RecordedAt, &attendance.StudentName,
 		)
 		if err != nil {
@@ -101,7 +103,7 @@ func (r *attendanceRepository) GetAttendanceByTraining(trainingID int) ([]models
 func (r *attendanceRepository) GetAttendanceByStudent(studentID int, start, end time.Time) ([]models.Attendance, error) {
 	query := `
 		SELECT 
-			a.id, a.training_id, a.student_id, a.attended, a.notes, 
+			a.id, a.training_id, a.student_id, a.status, a.attended, a.notes, 
 			a.recorded_by, a.recorded_at,
 			u.first_name || ' ' || u.last_name as student_name,
 			ts.training_date, ts.start_time, ts.end_time,
@@ -130,7 +132,7 @@ func (r *attendanceRepository) GetAttendanceByStudent(studentID int, start, end
 
 		err := rows.Scan(
 			&attendance.ID, &attendance.TrainingID, &attendance.StudentID,
-			&attendance.Attended, &attendance.Notes, &attendance.RecordedBy,
+			&attendance.Status, &attendance.Attended, &attendance.Notes, &attendance.RecordedBy,
 			&attendance.RecordedAt, &attendance.StudentName,
 			&trainingDate, &startTime, &endTime, &groupName,
 		)
@@ -190,115 +192,56 @@ func (r *attendanceRepository) GetStudentAttendanceForTraining(studentID, traini
 	return attendance, nil
 }
 
-func (r *attendanceRepository) UpdateAttendance(attendance *models.Attendance) error {
-	// Проверка, что ID установлен
-	if attendance.ID == 0 {
-		return fmt.Errorf("attendance ID не установлен (ID=0)")
-	}
-
-	// Логирование для отладки
-	fmt.Printf("[UpdateAttendance] Обновление записи: id=%d, attended=%v, notes=%s, recorded_by=%v\n",
-		attendance.ID, attendance.Attended, attendance.Notes, attendance.RecordedBy)
-
-	// Сначала проверим текущее значение в БД
-	var currentAttended sql.NullBool
-	checkQuery := `SELECT attended FROM spectrum.attendance WHERE id = $1`
-	err := r.db.QueryRow(checkQuery, attendance.ID).Scan(&currentAttended)
-	if err != nil {
-		fmt.Printf("[UpdateAttendance] Ошибка проверки текущего значения: %v\n", err)
-	} else {
-		fmt.Printf("[UpdateAttendance] Текущее значение в БД: attended=%v (valid=%v)\n",
-			currentAttended.Bool, currentAttended.Valid)
-	}
-
-	// Используем Exec с проверкой количества обновленных строк
+func (r *attendanceRepository) ChangeStatus(id int, from, to string, actorID *int64, notes string) (bool, error) {
+	// Отметку посещения (attended/no_show) запоминаем как recorded_by/recorded_at
 	query := `
-		UPDATE spectrum.attendance 
-		SET 
-			attended = $1::boolean,
-			status = CASE 
-				WHEN $1 = true THEN 'attended'
-				ELSE status
-			END,
-			notes = COALESCE(NULLIF($2, ''), notes),
-			recorded_by = COALESCE($3, recorded_by),
-			recorded_at = COALESCE($4, recorded_at),
-			updated_at = CURRENT_TIMESTAMP
-		WHERE id = $5
+		WITH changed AS (
+			UPDATE spectrum.attendance
+			SET
+				status = $3,
+				notes = COALESCE(NULLIF($5, ''), notes),
+				recorded_by = CASE WHEN $3 IN ('attended', 'no_show') THEN COALESCE($4, recorded_by) ELSE recorded_by END,
+				recorded_at = CASE WHEN $3 IN ('attended', 'no_show') THEN CURRENT_TIMESTAMP ELSE recorded_at END,
+				updated_at = CURRENT_TIMESTAMP
+			WHERE id = $1 AND status = $2
+			RETURNING id
+		)
+		INSERT INTO spectrum.attendance_status_log (attendance_id, from_status, to_status, actor_id)
+		SELECT id, $2, $3, $4 FROM changed
 	`
-
-	result, err := r.db.Exec(
-		query,
-		attendance.Attended,
-		attendance.Notes,
-		attendance.RecordedBy,
-		attendance.RecordedAt,
-		attendance.ID,
-	)
-
+	result, err := r.db.Exec(query, id, from, to, actorID, notes)
 	if err != nil {
-		return fmt.Errorf("ошибка выполнения UPDATE запроса: %w", err)
+		return false, err
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("ошибка получения количества обновленных строк: %w", err)
-	}
-
-	if rowsAffected == 0 {
-		return fmt.Errorf("не найдена запись с id=%d для обновления", attendance.ID)
-	}
-
-	fmt.Printf("[UpdateAttendance] Обновлено строк: %d для id=%d\n", rowsAffected, attendance.ID)
-
-	// Проверяем, что значение действительно обновилось, делая SELECT
-	verifyQuery := `SELECT attended, recorded_at, updated_at FROM spectrum.attendance WHERE id = $1`
-	var returnedAttendedNull sql.NullBool
-	err = r.db.QueryRow(verifyQuery, attendance.ID).Scan(
-		&returnedAttendedNull,
-		&attendance.RecordedAt,
-		&attendance.UpdatedAt,
-	)
-
-	if err != nil {
-		return fmt.Errorf("ошибка проверки обновленного значения: %w", err)
-	}
-
-	// Преобразуем sql.NullBool в bool для сравнения
-	returnedAttended := false
-	if returnedAttendedNull.Valid {
-		returnedAttended = returnedAttendedNull.Bool
-	} else {
-		// Если значение NULL, это ошибка - мы только что обновили его
-		return fmt.Errorf("значение attended NULL после обновления для id=%d", attendance.ID)
-	}
-
-	// Проверка, что значение действительно обновилось
-	if returnedAttended != attendance.Attended {
-		return fmt.Errorf("значение attended не обновилось: ожидалось %v, получено %v (valid=%v)",
-			attendance.Attended, returnedAttended, returnedAttendedNull.Valid)
+		return false, err
 	}
-
-	fmt.Printf("[UpdateAttendance] Успешно обновлено: id=%d, attended в БД=%v (ожидалось %v, valid=%v)\n",
-		attendance.ID, returnedAttended, attendance.Attended, returnedAttendedNull.Valid)
-
-	return nil
+	return rowsAffected > 0, nil
 }
 
-func (r *attendanceRepository) DeleteAttendance(id int) error {
-	query := `DELETE FROM spectrum.attendance WHERE id = $1`
-	_, err := r.db.Exec(query, id)
-	return err
+func (r *attendanceRepository) GetStatusHistory(id int) ([]models.AttendanceTransition, error) {
+	query := `
+		SELECT id, attendance_id, from_status, to_status, actor_id, created_at
+		FROM spectrum.attendance_status_log
+		WHERE attendance_id = $1
+		ORDER BY id
+	`
+
+	var history []models.AttendanceTransition
+	err := r.db.Select(&history, query, id)
+	return history, err
 }
 
 func (r *attendanceRepository) GetTrainingAttendanceStats(trainingID int) (present, absent, total int, err error) {
 	query := `
 		SELECT 
 			COUNT(*) as total,
-			COUNT(CASE WHEN attended = true THEN 1 END) as present,
-			COUNT(CASE WHEN attended = false THEN 1 END) as absent
+			COUNT(CASE WHEN status = 'attended' THEN 1 END) as present,
+			COUNT(CASE WHEN status = 'no_show' THEN 1 END) as absent
 		FROM spectrum.attendance 
-		WHERE training_id = $1
+		WHERE training_id = $1 AND status NOT IN ('cancelled', 'late_cancelled')
 	`
 
 	err = r.db.QueryRow(query, trainingID).Scan(&total, &present, &absent)
@@ -318,11 +261,13 @@ func (r *attendanceRepository) GetParticipants(trainingID int) ([]models.Attenda
             a.notes,
             a.created_at,
             a.updated_at,
-            COALESCE(u.first_name || ' ' || u.last_name, 'Неизвестный') as student_name
+            COALESCE(u.first_name || ' ' || u.last_name, 'Неизвестный') as student_name,
+            COALESCE(s.user_id, 0) as user_id,
+            COALESCE(u.telegram_id, 0) as telegram_id
         FROM spectrum.attendance a
         LEFT JOIN spectrum.students s ON a.student_id = s.id
         LEFT JOIN spectrum.users u ON s.user_id = u.id
-        WHERE a.training_id = $1
+        WHERE a.training_id = $1 AND a.status NOT IN ('cancelled', 'late_cancelled')
         ORDER BY a.created_at ASC
     `
 
@@ -345,7 +290,11 @@ func (r *attendanceRepository) GetParticipants(trainingID int) ([]models.Attenda
 			&participant.CreatedAt,
 			&participant.UpdatedAt,
 			&participant.StudentName,
+			&participant.Student.UserID,
+			&participant.Student.User.TelegramID,
 		)
+		participant.Student.ID = int64(participant.StudentID)
+		participant.Student.StudentName = participant.StudentName
 		if err != nil {
 			return nil, err
 		}
@@ -404,10 +353,16 @@ func (r *attendanceRepository) GetStudentSchedule(studentID int, start, end time
 
 func (r *attendanceRepository) CreateAttendanceRecord(attendance models.Attendance) error {
 	query := `
-        INSERT INTO spectrum.attendance 
-        (training_id, student_id, status, attended, notes, recorded_by, recorded_at)
-        VALUES ($1, $2, $3, $4, $5, $6, $7)
-        RETURNING id, created_at, updated_at
+        WITH created AS (
+            INSERT INTO spectrum.attendance 
+            (training_id, student_id, status, notes, recorded_by, recorded_at)
+            VALUES ($1, $2, $3, $4, $5, $6)
+            RETURNING id, status, recorded_by, created_at, updated_at
+        ), logged AS (
+            INSERT INTO spectrum.attendance_status_log (attendance_id, to_status, actor_id)
+            SELECT id, status, recorded_by FROM created
+        )
+        SELECT id, created_at, updated_at FROM created
     `
 
 	return r.db.QueryRow(
@@ -415,20 +370,29 @@ func (r *attendanceRepository) CreateAttendanceRecord(attendance models.Attendan
 		attendance.TrainingID,
 		attendance.StudentID,
 		attendance.Status,
-		attendance.Attended,
 		attendance.Notes,
 		attendance.RecordedBy,
 		attendance.RecordedAt,
 	).Scan(&attendance.ID, &attendance.CreatedAt, &attendance.UpdatedAt)
 }
 
-func (r *attendanceRepository) CancelAttendance(trainingID, studentID int) error {
+func (r *attendanceRepository) CancelByTraining(trainingID int, actorID *int64) error {
+	// Прежний статус берём из подзапроса: RETURNING видит только новые значения
 	query := `
-        UPDATE spectrum.attendance 
-        SET status = 'cancelled', updated_at = NOW()
-        WHERE training_id = $1 AND student_id = $2 AND status = 'registered'
+        WITH changed AS (
+            UPDATE spectrum.attendance a
+            SET status = 'cancelled', updated_at = NOW()
+            FROM (
+                SELECT id, status FROM spectrum.attendance
+                WHERE training_id = $1 AND status NOT IN ('cancelled', 'late_cancelled')
+            ) old
+            WHERE a.id = old.id
+            RETURNING a.id, old.status
+        )
+        INSERT INTO spectrum.attendance_status_log (attendance_id, from_status, to_status, actor_id)
+        SELECT id, status, 'cancelled', $2 FROM changed
     `
 
-	_, err := r.db.Exec(query, trainingID, studentID)
+	_, err := r.db.Exec(query, trainingID, actorID)
 	return err
 }
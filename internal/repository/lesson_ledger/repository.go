@@ -0,0 +1,73 @@
+package lesson_ledger
+
+import (
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/repository"
+)
+
+type lessonLedgerRepository struct {
+	db repository.DBTX
+}
+
+func NewLessonLedgerRepository(db repository.DBTX) repository.LessonLedgerRepository {
+	return &lessonLedgerRepository{db: db}
+}
+
+func (r *lessonLedgerRepository) Add(entry *models.LessonLedgerEntry) error {
+	query := `
+		INSERT INTO spectrum.lesson_ledger
+		(subscription_id, delta, reason, attendance_id, training_id, actor_id, comment)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`
+	return r.db.QueryRow(
+		query,
+		entry.SubscriptionID,
+		entry.Delta,
+		entry.Reason,
+		entry.AttendanceID,
+		entry.TrainingID,
+		entry.ActorID,
+		entry.Comment,
+	).Scan(&entry.ID, &entry.CreatedAt)
+}
+
+func (r *lessonLedgerRepository) GetBySubscriptionID(subscriptionID int64, limit int) ([]models.LessonLedgerEntry, error) {
+	query := `
+		SELECT * FROM spectrum.lesson_ledger
+		WHERE subscription_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT NULLIF($2, 0)
+	`
+
+	var entries []models.LessonLedgerEntry
+	err := r.db.Select(&entries, query, subscriptionID, max(limit, 0))
+	return entries, err
+}
+
+func (r *lessonLedgerRepository) GetByAttendanceID(attendanceID int) ([]models.LessonLedgerEntry, error) {
+	query := `
+		SELECT * FROM spectrum.lesson_ledger
+		WHERE attendance_id = $1
+		ORDER BY id
+	`
+
+	var entries []models.LessonLedgerEntry
+	err := r.db.Select(&entries, query, attendanceID)
+	return entries, err
+}
+
+func (r *lessonLedgerRepository) GetMismatches() ([]models.LessonBalanceMismatch, error) {
+	query := `
+		SELECT s.id AS subscription_id, s.remaining_lessons, COALESCE(SUM(l.delta), 0) AS ledger_balance
+		FROM spectrum.subscriptions s
+		LEFT JOIN spectrum.lesson_ledger l ON l.subscription_id = s.id
+		GROUP BY s.id, s.remaining_lessons
+		HAVING s.remaining_lessons <> COALESCE(SUM(l.delta), 0)
+		ORDER BY s.id
+	`
+
+	var mismatches []models.LessonBalanceMismatch
+	err := r.db.Select(&mismatches, query)
+	return mismatches, err
+}
@@ -0,0 +1,334 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/repository"
+	"time"
+)
+
+type attendanceRepository struct {
+	store *Store
+}
+
+func NewAttendanceRepository(store *Store) repository.AttendanceRepository {
+	return &attendanceRepository{store: store}
+}
+
+func (r *attendanceRepository) CreateAttendance(attendance *models.Attendance) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	now := time.Now()
+	stored := *attendance
+	stored.Status = models.AttendanceStatusRegistered // DEFAULT колонки: статус в INSERT не передаётся
+	stored.RecordedAt = now
+	stored.CreatedAt = now
+	stored.UpdatedAt = now
+	if err := r.insertLocked(&stored); err != nil {
+		return err
+	}
+
+	attendance.ID = stored.ID
+	attendance.Status = stored.Status
+	attendance.RecordedAt = stored.RecordedAt
+	return nil
+}
+
+func (r *attendanceRepository) CreateAttendanceRecord(attendance models.Attendance) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	now := time.Now()
+	if attendance.Status == "" {
+		attendance.Status = models.AttendanceStatusRegistered
+	}
+	attendance.CreatedAt = now
+	attendance.UpdatedAt = now
+	return r.insertLocked(&attendance)
+}
+
+// insertLocked проверяет внешние ключи и уникальность (training_id, student_id)
+func (r *attendanceRepository) insertLocked(attendance *models.Attendance) error {
+	if _, ok := r.store.trainings[attendance.TrainingID]; !ok {
+		return fmt.Errorf("тренировка %d не существует", attendance.TrainingID)
+	}
+	if _, ok := r.store.students[int64(attendance.StudentID)]; !ok {
+		return fmt.Errorf("ученик %d не существует", attendance.StudentID)
+	}
+	for _, a := range r.store.attendance {
+		if a.TrainingID == attendance.TrainingID && a.StudentID == attendance.StudentID {
+			return fmt.Errorf(`duplicate key value violates unique constraint "attendance_training_student_uidx"`)
+		}
+	}
+
+	attendance.ID = int(r.store.nextID("attendance"))
+	attendance.StudentName = ""
+	attendance.Attended = attendance.Status == models.AttendanceStatusAttended // GENERATED-колонка
+	r.store.attendance[attendance.ID] = *attendance
+	var actorID *int64
+	if attendance.RecordedBy != nil {
+		id := int64(*attendance.RecordedBy)
+		actorID = &id
+	}
+	r.store.logAttendanceLocked(attendance.ID, nil, attendance.Status, actorID)
+	return nil
+}
+
+// GetAttendanceByID запись с именем ученика (INNER JOIN students/users)
+func (r *attendanceRepository) GetAttendanceByID(id int) (*models.Attendance, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	a, ok := r.store.attendance[id]
+	if !ok {
+		return nil, nil
+	}
+	name, ok := r.store.studentName(int64(a.StudentID))
+	if !ok {
+		return nil, nil
+	}
+	a.StudentName = name
+	return &a, nil
+}
+
+func (r *attendanceRepository) GetAttendanceByTraining(trainingID int) ([]models.Attendance, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var attendances []models.Attendance
+	for _, a := range r.store.attendance {
+		if a.TrainingID != trainingID {
+			continue
+		}
+		name, ok := r.store.studentName(int64(a.StudentID))
+		if !ok {
+			continue
+		}
+		a.StudentName = name
+		attendances = append(attendances, a)
+	}
+	sort.Slice(attendances, func(i, j int) bool { return attendances[i].StudentName < attendances[j].StudentName })
+	return attendances, nil
+}
+
+func (r *attendanceRepository) GetAttendanceByStudent(studentID int, start, end time.Time) ([]models.Attendance, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	type row struct {
+		attendance models.Attendance
+		training   models.TrainingSchedule
+	}
+
+	var rows []row
+	for _, a := range r.store.attendance {
+		if a.StudentID != studentID {
+			continue
+		}
+		training, ok := r.store.trainings[a.TrainingID]
+		if !ok || !betweenDates(training.TrainingDate, start, end) {
+			continue
+		}
+		if _, ok := r.store.groups[training.GroupID]; !ok {
+			continue
+		}
+		name, ok := r.store.studentName(int64(a.StudentID))
+		if !ok {
+			continue
+		}
+		a.StudentName = name
+		rows = append(rows, row{attendance: a, training: training})
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		if !rows[i].training.TrainingDate.Equal(rows[j].training.TrainingDate) {
+			return rows[i].training.TrainingDate.After(rows[j].training.TrainingDate)
+		}
+		return rows[i].training.StartTime.After(rows[j].training.StartTime)
+	})
+
+	var attendances []models.Attendance
+	for _, row := range rows {
+		attendances = append(attendances, row.attendance)
+	}
+	return attendances, nil
+}
+
+func (r *attendanceRepository) GetStudentAttendanceForTraining(studentID, trainingID int) (*models.Attendance, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, a := range r.store.attendance {
+		if a.StudentID == studentID && a.TrainingID == trainingID {
+			a.StudentName = ""
+			return &a, nil
+		}
+	}
+	return nil, nil
+}
+
+// ChangeStatus переводит запись из from в to (аналог UPDATE ... WHERE status = from)
+// и пишет переход в историю
+func (r *attendanceRepository) ChangeStatus(id int, from, to string, actorID *int64, notes string) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	a, ok := r.store.attendance[id]
+	if !ok || a.Status != from {
+		return false, nil
+	}
+
+	now := time.Now()
+	a.Status = to
+	a.Attended = to == models.AttendanceStatusAttended
+	if notes != "" {
+		a.Notes = notes
+	}
+	if to == models.AttendanceStatusAttended || to == models.AttendanceStatusNoShow {
+		if actorID != nil {
+			recordedBy := int(*actorID)
+			a.RecordedBy = &recordedBy
+		}
+		a.RecordedAt = now
+	}
+	a.UpdatedAt = now
+	r.store.attendance[id] = a
+	r.store.logAttendanceLocked(id, &from, to, actorID)
+	return true, nil
+}
+
+func (r *attendanceRepository) GetStatusHistory(id int) ([]models.AttendanceTransition, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var history []models.AttendanceTransition
+	for _, transition := range r.store.attendanceLog {
+		if transition.AttendanceID == id {
+			history = append(history, transition)
+		}
+	}
+	sort.Slice(history, func(i, j int) bool { return history[i].ID < history[j].ID })
+	return history, nil
+}
+
+// GetTrainingAttendanceStats present — пришли, absent — не пришли, total — все, кто занимает место
+func (r *attendanceRepository) GetTrainingAttendanceStats(trainingID int) (present, absent, total int, err error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, a := range r.store.attendance {
+		if a.TrainingID != trainingID || !a.HoldsSpot() {
+			continue
+		}
+		total++
+		switch a.Status {
+		case models.AttendanceStatusAttended:
+			present++
+		case models.AttendanceStatusNoShow:
+			absent++
+		}
+	}
+	return present, absent, total, nil
+}
+
+func (r *attendanceRepository) CancelByTraining(trainingID int, actorID *int64) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for id, a := range r.store.attendance {
+		if a.TrainingID == trainingID && a.HoldsSpot() {
+			from := a.Status
+			a.Status = models.AttendanceStatusCancelled
+			a.Attended = false
+			a.UpdatedAt = time.Now()
+			r.store.attendance[id] = a
+			r.store.logAttendanceLocked(id, &from, a.Status, actorID)
+		}
+	}
+	return nil
+}
+
+// GetParticipants записи, занимающие место, в порядке записи (LEFT JOIN: неизвестные ученики тоже попадают)
+func (r *attendanceRepository) GetParticipants(trainingID int) ([]models.AttendanceWithStudent, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var participants []models.AttendanceWithStudent
+	for _, a := range r.store.attendance {
+		if a.TrainingID != trainingID || !a.HoldsSpot() {
+			continue
+		}
+		name, ok := r.store.studentName(int64(a.StudentID))
+		if !ok {
+			name = "Неизвестный"
+		}
+
+		var participant models.AttendanceWithStudent
+		participant.ID = a.ID
+		participant.TrainingID = a.TrainingID
+		participant.StudentID = a.StudentID
+		participant.Status = a.Status
+		participant.Attended = a.Attended
+		participant.Notes = a.Notes
+		participant.CreatedAt = a.CreatedAt
+		participant.UpdatedAt = a.UpdatedAt
+		participant.StudentName = name
+		participant.Student.ID = int64(a.StudentID)
+		participant.Student.StudentName = name
+		if student, ok := r.store.students[int64(a.StudentID)]; ok {
+			participant.Student.UserID = student.UserID
+			if user, ok := r.store.users[student.UserID]; ok {
+				participant.Student.User.TelegramID = user.TelegramID
+			}
+		}
+		participants = append(participants, participant)
+	}
+
+	sort.Slice(participants, func(i, j int) bool {
+		if !participants[i].CreatedAt.Equal(participants[j].CreatedAt) {
+			return participants[i].CreatedAt.Before(participants[j].CreatedAt)
+		}
+		return participants[i].ID < participants[j].ID
+	})
+	return participants, nil
+}
+
+// GetStudentSchedule активные записи ученика; границы сравниваются как timestamp, как в Postgres
+func (r *attendanceRepository) GetStudentSchedule(studentID int, start, end time.Time) ([]models.AttendanceWithTraining, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var schedule []models.AttendanceWithTraining
+	for _, a := range r.store.attendance {
+		if a.StudentID != studentID || a.Status != models.AttendanceStatusRegistered {
+			continue
+		}
+		training, ok := r.store.trainings[a.TrainingID]
+		if !ok {
+			continue
+		}
+
+		day := time.Date(training.TrainingDate.Year(), training.TrainingDate.Month(), training.TrainingDate.Day(), 0, 0, 0, 0, time.Local)
+		if day.Before(start) || day.After(end) {
+			continue
+		}
+
+		item := models.AttendanceWithTraining{Attendance: a}
+		item.Training = training
+		if group, ok := r.store.groups[training.GroupID]; ok {
+			item.Training.GroupName = group.Name
+		}
+		schedule = append(schedule, item)
+	}
+
+	sort.Slice(schedule, func(i, j int) bool {
+		a, b := schedule[i].Training, schedule[j].Training
+		if !a.TrainingDate.Equal(b.TrainingDate) {
+			return a.TrainingDate.Before(b.TrainingDate)
+		}
+		return a.StartTime.Before(b.StartTime)
+	})
+	return schedule, nil
+}
@@ -0,0 +1,80 @@
+package memory
+
+import (
+	"database/sql"
+	"sort"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/repository"
+)
+
+type coachRepository struct {
+	store *Store
+}
+
+func NewCoachRepository(store *Store) repository.CoachRepository {
+	return &coachRepository{store: store}
+}
+
+func (r *coachRepository) Create(coach *models.Coach) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	coach.ID = r.store.nextID("coaches")
+	r.store.coaches[coach.ID] = *coach
+	return nil
+}
+
+func (r *coachRepository) GetByUserID(userID int64) (*models.Coach, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, coach := range r.store.coaches {
+		if coach.UserID == userID {
+			return &coach, nil
+		}
+	}
+	return &models.Coach{}, sql.ErrNoRows
+}
+
+func (r *coachRepository) GetByID(id int64) (*models.Coach, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	coach, ok := r.store.coaches[id]
+	if !ok {
+		return &models.Coach{}, sql.ErrNoRows
+	}
+	return &coach, nil
+}
+
+func (r *coachRepository) GetByCoachID(coachID int64) (*models.Coach, error) {
+	return r.GetByID(coachID)
+}
+
+func (r *coachRepository) GetAll() ([]*models.Coach, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var coaches []*models.Coach
+	for _, coach := range r.store.coaches {
+		coaches = append(coaches, &coach)
+	}
+	sort.Slice(coaches, func(i, j int) bool { return coaches[i].CreatedAt.After(coaches[j].CreatedAt) })
+	return coaches, nil
+}
+
+func (r *coachRepository) Update(coach *models.Coach) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing, ok := r.store.coaches[coach.ID]
+	if !ok {
+		return nil
+	}
+	existing.Specialty = coach.Specialty
+	existing.Experience = coach.Experience
+	existing.Description = coach.Description
+	existing.UpdatedAt = coach.UpdatedAt
+	r.store.coaches[coach.ID] = existing
+	return nil
+}
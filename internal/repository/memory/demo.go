@@ -0,0 +1,133 @@
+package memory
+
+import (
+	"fmt"
+	"spectrum-club-bot/internal/models"
+	"time"
+)
+
+// SeedDemoData наполняет хранилище данными для демо-режима: группы, шаблоны недели,
+// демо-тренер с тренировками на ближайшую неделю и несколько учеников с абонементами
+func SeedDemoData(store *Store) error {
+	now := time.Now()
+
+	kidsMax, teensMax := 10, 15
+	kids := store.AddGroup(models.TrainingGroup{Name: "Дети 6-10", Code: "kids", AgeMin: 6, AgeMax: &kidsMax, Description: "Детская группа"})
+	teens := store.AddGroup(models.TrainingGroup{Name: "Подростки 11-15", Code: "teens", AgeMin: 11, AgeMax: &teensMax, Description: "Подростковая группа"})
+	adults := store.AddGroup(models.TrainingGroup{Name: "Взрослые", Code: "adults", AgeMin: 16, Description: "Вечерняя взрослая группа"})
+
+	templateRepo := NewWeekScheduleRepository(store)
+	templates := []models.WeekScheduleTemplate{
+		{GroupID: kids.ID, DayOfWeek: 1, StartTime: "16:00", EndTime: "17:30", Description: "Боулдеринг", IsActive: true},
+		{GroupID: kids.ID, DayOfWeek: 3, StartTime: "16:00", EndTime: "17:30", Description: "Боулдеринг", IsActive: true},
+		{GroupID: teens.ID, DayOfWeek: 2, StartTime: "17:00", EndTime: "18:30", Description: "Трудность", IsActive: true},
+		{GroupID: teens.ID, DayOfWeek: 4, StartTime: "17:00", EndTime: "18:30", Description: "Трудность", IsActive: true},
+		{GroupID: adults.ID, DayOfWeek: 2, StartTime: "19:30", EndTime: "21:00", Description: "Вечерняя тренировка", IsActive: true},
+		{GroupID: adults.ID, DayOfWeek: 5, StartTime: "19:30", EndTime: "21:00", Description: "Вечерняя тренировка", IsActive: true},
+	}
+	for i := range templates {
+		if err := templateRepo.Create(&templates[i]); err != nil {
+			return fmt.Errorf("ошибка создания демо-шаблона: %w", err)
+		}
+	}
+
+	// Демо-пользователи не связаны с реальными аккаунтами Telegram,
+	// поэтому получают отрицательные telegram_id
+	userRepo := NewUserRepository(store)
+	coachUser := &models.User{TelegramID: -1, FirstName: "Демо", LastName: "Тренер", Username: "demo_coach", Role: "coach", RegisteredAt: now}
+	if err := userRepo.CreateOrUpdate(coachUser); err != nil {
+		return err
+	}
+	coach := &models.Coach{UserID: coachUser.ID, Specialty: "Скалолазание", CreatedAt: now, UpdatedAt: now}
+	if err := NewCoachRepository(store).Create(coach); err != nil {
+		return err
+	}
+
+	// Тренировки по шаблонам на ближайшие 7 дней
+	maxParticipants := 8
+	scheduleRepo := NewTrainingScheduleRepository(store)
+	for day := 0; day < 7; day++ {
+		date := now.AddDate(0, 0, day)
+		weekday := int(date.Weekday())
+		if weekday == 0 {
+			weekday = 7
+		}
+
+		for _, t := range templates {
+			if t.DayOfWeek != weekday {
+				continue
+			}
+			start, _ := time.Parse("15:04", t.StartTime)
+			end, _ := time.Parse("15:04", t.EndTime)
+			trainingStart := time.Date(date.Year(), date.Month(), date.Day(), start.Hour(), start.Minute(), 0, 0, time.Local)
+			trainingEnd := time.Date(date.Year(), date.Month(), date.Day(), end.Hour(), end.Minute(), 0, 0, time.Local)
+
+			training := &models.TrainingSchedule{
+				GroupID:         t.GroupID,
+				CoachID:         &coach.ID,
+				TrainingDate:    trainingStart,
+				StartTime:       trainingStart,
+				EndTime:         trainingEnd,
+				Description:     t.Description,
+				MaxParticipants: &maxParticipants,
+				CreatedBy:       &coachUser.ID,
+			}
+			if err := scheduleRepo.CreateTraining(training); err != nil {
+				return fmt.Errorf("ошибка создания демо-тренировки: %w", err)
+			}
+		}
+	}
+
+	// Те же тарифы, что заводит миграция 0006
+	planRepo := NewSubscriptionPlanRepository(store)
+	demoPlans := []models.SubscriptionPlan{
+		{Name: "⛰️ Пробное занятие", Lessons: 1, DurationDays: 30, Price: 0, IsActive: true},
+		{Name: "💪 12 занятий (несгораемый)", Lessons: 12, DurationDays: 730, Price: 9000, IsActive: true},
+		{Name: "⛏️ 16 занятий на 30 дней", Lessons: 16, DurationDays: 30, Price: 8000, IsActive: true},
+	}
+	for i := range demoPlans {
+		if err := planRepo.Create(&demoPlans[i]); err != nil {
+			return fmt.Errorf("ошибка создания демо-тарифа: %w", err)
+		}
+	}
+
+	studentRepo := NewStudentRepository(store)
+	subscriptionRepo := NewSubscriptionRepository(store, models.ConsumptionExpiringFirst)
+	ledgerRepo := NewLessonLedgerRepository(store)
+	demoStudents := []struct{ first, last string }{
+		{"Анна", "Иванова"},
+		{"Борис", "Петров"},
+		{"Вера", "Сидорова"},
+	}
+	for i, s := range demoStudents {
+		user := &models.User{TelegramID: int64(-2 - i), FirstName: s.first, LastName: s.last, Role: "student", RegisteredAt: now}
+		if err := userRepo.CreateOrUpdate(user); err != nil {
+			return err
+		}
+		student := &models.Student{UserID: user.ID, CreatedAt: now, UpdatedAt: now}
+		if err := studentRepo.Create(student); err != nil {
+			return err
+		}
+		subscription := &models.Subscription{
+			StudentID:        student.ID,
+			StartDate:        now,
+			EndDate:          now.AddDate(0, 0, 30),
+			TotalLessons:     8,
+			RemainingLessons: 8,
+			CreatedAt:        now,
+		}
+		if err := subscriptionRepo.Create(subscription); err != nil {
+			return err
+		}
+		purchase := &models.LessonLedgerEntry{
+			SubscriptionID: subscription.ID,
+			Delta:          subscription.RemainingLessons,
+			Reason:         models.LedgerReasonPurchase,
+		}
+		if err := ledgerRepo.Add(purchase); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
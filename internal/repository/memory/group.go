@@ -0,0 +1,55 @@
+package memory
+
+import (
+	"sort"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/repository"
+)
+
+type trainingGroupRepository struct {
+	store *Store
+}
+
+func NewTrainingGroupRepository(store *Store) repository.TrainingGroupRepository {
+	return &trainingGroupRepository{store: store}
+}
+
+func (r *trainingGroupRepository) GetAllGroups() ([]models.TrainingGroup, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var groups []models.TrainingGroup
+	for _, group := range r.store.groups {
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].AgeMin != groups[j].AgeMin {
+			return groups[i].AgeMin < groups[j].AgeMin
+		}
+		return groups[i].Name < groups[j].Name
+	})
+	return groups, nil
+}
+
+func (r *trainingGroupRepository) GetGroupByID(id int) (*models.TrainingGroup, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	group, ok := r.store.groups[id]
+	if !ok {
+		return nil, nil // группа не найдена
+	}
+	return &group, nil
+}
+
+func (r *trainingGroupRepository) GetGroupByCode(code string) (*models.TrainingGroup, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, group := range r.store.groups {
+		if group.Code == code {
+			return &group, nil
+		}
+	}
+	return nil, nil // группа не найдена
+}
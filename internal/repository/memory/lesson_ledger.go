@@ -0,0 +1,97 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/repository"
+	"time"
+)
+
+type lessonLedgerRepository struct {
+	store *Store
+}
+
+func NewLessonLedgerRepository(store *Store) repository.LessonLedgerRepository {
+	return &lessonLedgerRepository{store: store}
+}
+
+func (r *lessonLedgerRepository) Add(entry *models.LessonLedgerEntry) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.subscriptions[entry.SubscriptionID]; !ok {
+		return fmt.Errorf("абонемент с ID %d не найден", entry.SubscriptionID)
+	}
+	if entry.Delta == 0 {
+		return fmt.Errorf("запись журнала не меняет баланс")
+	}
+
+	entry.ID = r.store.nextID("lesson_ledger")
+	entry.CreatedAt = time.Now()
+	r.store.ledger[entry.ID] = *entry
+	return nil
+}
+
+func (r *lessonLedgerRepository) GetBySubscriptionID(subscriptionID int64, limit int) ([]models.LessonLedgerEntry, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var entries []models.LessonLedgerEntry
+	for _, entry := range r.store.ledger {
+		if entry.SubscriptionID == subscriptionID {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].CreatedAt.Equal(entries[j].CreatedAt) {
+			return entries[i].CreatedAt.After(entries[j].CreatedAt)
+		}
+		return entries[i].ID > entries[j].ID
+	})
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
+
+func (r *lessonLedgerRepository) GetByAttendanceID(attendanceID int) ([]models.LessonLedgerEntry, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var entries []models.LessonLedgerEntry
+	for _, entry := range r.store.ledger {
+		if entry.AttendanceID != nil && *entry.AttendanceID == attendanceID {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ID < entries[j].ID
+	})
+	return entries, nil
+}
+
+func (r *lessonLedgerRepository) GetMismatches() ([]models.LessonBalanceMismatch, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	balances := make(map[int64]int)
+	for _, entry := range r.store.ledger {
+		balances[entry.SubscriptionID] += entry.Delta
+	}
+
+	var mismatches []models.LessonBalanceMismatch
+	for _, subscription := range r.store.subscriptions {
+		if balance := balances[subscription.ID]; balance != subscription.RemainingLessons {
+			mismatches = append(mismatches, models.LessonBalanceMismatch{
+				SubscriptionID:   subscription.ID,
+				RemainingLessons: subscription.RemainingLessons,
+				LedgerBalance:    balance,
+			})
+		}
+	}
+	sort.Slice(mismatches, func(i, j int) bool {
+		return mismatches[i].SubscriptionID < mismatches[j].SubscriptionID
+	})
+	return mismatches, nil
+}
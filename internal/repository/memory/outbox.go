@@ -0,0 +1,115 @@
+package memory
+
+import (
+	"sort"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/repository"
+	"time"
+)
+
+type outboxRepository struct {
+	store *Store
+}
+
+func NewOutboxRepository(store *Store) repository.OutboxRepository {
+	return &outboxRepository{store: store}
+}
+
+func (r *outboxRepository) Enqueue(message *models.OutboxMessage) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	now := time.Now()
+	message.ID = r.store.nextID("notification_outbox")
+	message.Status = models.OutboxStatusPending
+	message.Attempts = 0
+	message.NextAttemptAt = now
+	message.CreatedAt = now
+
+	stored := *message
+	stored.Payload = append([]byte(nil), message.Payload...)
+	r.store.outbox[stored.ID] = stored
+	return nil
+}
+
+func (r *outboxRepository) ClaimDue(limit int, lease time.Duration) ([]models.OutboxMessage, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var pending []models.OutboxMessage
+	for _, message := range r.store.outbox {
+		if message.Status == models.OutboxStatusPending {
+			pending = append(pending, message)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].ID < pending[j].ID })
+
+	now := time.Now()
+	seenChats := make(map[int64]bool)
+	var claimed []models.OutboxMessage
+	for _, message := range pending {
+		if len(claimed) >= limit {
+			break
+		}
+		// Только самое раннее ожидающее сообщение чата, даже если оно ещё не готово
+		if seenChats[message.ChatID] {
+			continue
+		}
+		seenChats[message.ChatID] = true
+		if message.NextAttemptAt.After(now) {
+			continue
+		}
+
+		message.Attempts++
+		message.NextAttemptAt = now.Add(lease)
+		r.store.outbox[message.ID] = message
+
+		message.Payload = append([]byte(nil), message.Payload...)
+		claimed = append(claimed, message)
+	}
+	return claimed, nil
+}
+
+func (r *outboxRepository) MarkSent(id int64) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	message, ok := r.store.outbox[id]
+	if !ok {
+		return nil
+	}
+	sentAt := time.Now()
+	message.Status = models.OutboxStatusSent
+	message.SentAt = &sentAt
+	message.LastError = ""
+	r.store.outbox[id] = message
+	return nil
+}
+
+func (r *outboxRepository) Reschedule(id int64, delay time.Duration, lastError string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	message, ok := r.store.outbox[id]
+	if !ok {
+		return nil
+	}
+	message.NextAttemptAt = time.Now().Add(delay)
+	message.LastError = lastError
+	r.store.outbox[id] = message
+	return nil
+}
+
+func (r *outboxRepository) MarkFailed(id int64, lastError string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	message, ok := r.store.outbox[id]
+	if !ok {
+		return nil
+	}
+	message.Status = models.OutboxStatusFailed
+	message.LastError = lastError
+	r.store.outbox[id] = message
+	return nil
+}
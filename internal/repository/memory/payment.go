@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/repository"
+	"time"
+)
+
+type paymentRepository struct {
+	store *Store
+}
+
+func NewPaymentRepository(store *Store) repository.PaymentRepository {
+	return &paymentRepository{store: store}
+}
+
+func (r *paymentRepository) Create(payment *models.Payment) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.students[payment.StudentID]; !ok {
+		return fmt.Errorf("ученик с ID %d не найден", payment.StudentID)
+	}
+	if payment.Amount < 0 {
+		return fmt.Errorf("сумма платежа не может быть отрицательной")
+	}
+	if payment.Currency == "" {
+		payment.Currency = "RUB"
+	}
+	if payment.Status == "" {
+		payment.Status = models.PaymentStatusPending
+	}
+
+	payment.ID = r.store.nextID("payments")
+	payment.CreatedAt = time.Now()
+	r.store.payments[payment.ID] = *payment
+	return nil
+}
+
+func (r *paymentRepository) GetByID(id int64) (*models.Payment, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	payment, ok := r.store.payments[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &payment, nil
+}
+
+func (r *paymentRepository) MarkPaid(id int64, subscriptionID int64, telegramChargeID, providerChargeID string) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	payment, ok := r.store.payments[id]
+	if !ok || payment.Status != models.PaymentStatusPending {
+		return false, nil
+	}
+	if telegramChargeID != "" {
+		for _, other := range r.store.payments {
+			if other.TelegramChargeID == telegramChargeID {
+				return false, fmt.Errorf("оплата %s уже учтена в платеже %d", telegramChargeID, other.ID)
+			}
+		}
+	}
+
+	now := time.Now()
+	payment.Status = models.PaymentStatusPaid
+	payment.SubscriptionID = &subscriptionID
+	payment.TelegramChargeID = telegramChargeID
+	payment.ProviderChargeID = providerChargeID
+	payment.PaidAt = &now
+	r.store.payments[id] = payment
+	return true, nil
+}
+
+func (r *paymentRepository) Cancel(id int64) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	payment, ok := r.store.payments[id]
+	if !ok || payment.Status != models.PaymentStatusPending {
+		return false, nil
+	}
+	payment.Status = models.PaymentStatusCancelled
+	r.store.payments[id] = payment
+	return true, nil
+}
+
+func (r *paymentRepository) GetByStudentID(studentID int64, limit int) ([]models.Payment, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var payments []models.Payment
+	for _, payment := range r.store.payments {
+		if payment.StudentID == studentID {
+			payments = append(payments, payment)
+		}
+	}
+	sort.Slice(payments, func(i, j int) bool {
+		if !payments[i].CreatedAt.Equal(payments[j].CreatedAt) {
+			return payments[i].CreatedAt.After(payments[j].CreatedAt)
+		}
+		return payments[i].ID > payments[j].ID
+	})
+	if limit > 0 && len(payments) > limit {
+		payments = payments[:limit]
+	}
+	return payments, nil
+}
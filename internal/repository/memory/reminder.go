@@ -0,0 +1,90 @@
+package memory
+
+import (
+	"sort"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/repository"
+	"time"
+)
+
+type reminderKey struct {
+	attendanceID  int
+	offsetMinutes int
+}
+
+type reminderRepository struct {
+	store *Store
+}
+
+func NewReminderRepository(store *Store) repository.ReminderRepository {
+	return &reminderRepository{store: store}
+}
+
+func (r *reminderRepository) GetUpcomingRegistrations(start, end time.Time) ([]models.UpcomingRegistration, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var registrations []models.UpcomingRegistration
+	for _, a := range r.store.attendance {
+		if a.Status != models.AttendanceStatusRegistered {
+			continue
+		}
+		training, ok := r.store.trainings[a.TrainingID]
+		if !ok || training.CancelledAt != nil || !betweenDates(training.TrainingDate, start, end) {
+			continue
+		}
+		student, ok := r.store.students[int64(a.StudentID)]
+		if !ok {
+			continue
+		}
+		user, ok := r.store.users[student.UserID]
+		if !ok {
+			continue
+		}
+
+		training = r.store.withJoins(training)
+		registrations = append(registrations, models.UpcomingRegistration{
+			AttendanceID: a.ID,
+			TrainingID:   a.TrainingID,
+			StudentID:    a.StudentID,
+			TelegramID:   user.TelegramID,
+			TrainingDate: training.TrainingDate,
+			StartTime:    training.StartTime,
+			EndTime:      training.EndTime,
+			Description:  training.Description,
+			GroupName:    training.GroupName,
+			CoachName:    training.CoachName,
+		})
+	}
+
+	sort.Slice(registrations, func(i, j int) bool {
+		if !registrations[i].TrainingDate.Equal(registrations[j].TrainingDate) {
+			return registrations[i].TrainingDate.Before(registrations[j].TrainingDate)
+		}
+		if !registrations[i].StartTime.Equal(registrations[j].StartTime) {
+			return registrations[i].StartTime.Before(registrations[j].StartTime)
+		}
+		return registrations[i].AttendanceID < registrations[j].AttendanceID
+	})
+	return registrations, nil
+}
+
+func (r *reminderRepository) MarkSent(attendanceID int, offsetMinutes int) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	key := reminderKey{attendanceID: attendanceID, offsetMinutes: offsetMinutes}
+	if _, exists := r.store.reminders[key]; exists {
+		return false, nil
+	}
+	r.store.reminders[key] = time.Now()
+	return true, nil
+}
+
+func (r *reminderRepository) Unmark(attendanceID int, offsetMinutes int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	delete(r.store.reminders, reminderKey{attendanceID: attendanceID, offsetMinutes: offsetMinutes})
+	return nil
+}
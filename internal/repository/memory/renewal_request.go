@@ -0,0 +1,162 @@
+package memory
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/repository"
+	"time"
+)
+
+type renewalRequestRepository struct {
+	store *Store
+}
+
+func NewRenewalRequestRepository(store *Store) repository.RenewalRequestRepository {
+	return &renewalRequestRepository{store: store}
+}
+
+func (r *renewalRequestRepository) Create(request *models.RenewalRequest) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.students[request.StudentID]; !ok {
+		return fmt.Errorf("ученик с ID %d не найден", request.StudentID)
+	}
+	if _, ok := r.store.plans[request.PlanID]; !ok {
+		return fmt.Errorf("тариф с ID %d не найден", request.PlanID)
+	}
+	for _, existing := range r.store.renewalRequests {
+		if existing.StudentID == request.StudentID && existing.Status == models.RenewalStatusPending {
+			return fmt.Errorf(`duplicate key value violates unique constraint "renewal_requests_pending_uidx"`)
+		}
+	}
+
+	request.ID = r.store.nextID("renewal_requests")
+	request.Status = models.RenewalStatusPending
+	request.SubscriptionID = nil
+	request.DecidedBy = nil
+	request.DecidedAt = nil
+	request.CreatedAt = time.Now()
+
+	stored := *request
+	stored.StudentName, stored.TelegramID, stored.PlanName = "", 0, ""
+	r.store.renewalRequests[stored.ID] = stored
+	return nil
+}
+
+func (r *renewalRequestRepository) GetByID(id int64) (*models.RenewalRequest, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	stored, ok := r.store.renewalRequests[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	request, ok := r.withJoins(stored)
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &request, nil
+}
+
+func (r *renewalRequestRepository) GetPending() ([]models.RenewalRequest, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var requests []models.RenewalRequest
+	for _, stored := range r.store.renewalRequests {
+		if stored.Status != models.RenewalStatusPending {
+			continue
+		}
+		if request, ok := r.withJoins(stored); ok {
+			requests = append(requests, request)
+		}
+	}
+	sort.Slice(requests, func(i, j int) bool { return requests[i].ID < requests[j].ID })
+	return requests, nil
+}
+
+func (r *renewalRequestRepository) GetPendingByStudent(studentID int64) (*models.RenewalRequest, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, stored := range r.store.renewalRequests {
+		if stored.StudentID != studentID || stored.Status != models.RenewalStatusPending {
+			continue
+		}
+		if request, ok := r.withJoins(stored); ok {
+			return &request, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *renewalRequestRepository) Decide(id int64, status string, decidedBy *int64) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	request, ok := r.store.renewalRequests[id]
+	if !ok || request.Status != models.RenewalStatusPending {
+		return false, nil
+	}
+
+	now := time.Now()
+	request.Status = status
+	request.DecidedBy = decidedBy
+	request.DecidedAt = &now
+	r.store.renewalRequests[id] = request
+	return true, nil
+}
+
+func (r *renewalRequestRepository) Reopen(id int64) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	request, ok := r.store.renewalRequests[id]
+	if !ok || request.Status != models.RenewalStatusApproved || request.SubscriptionID != nil {
+		return nil
+	}
+
+	request.Status = models.RenewalStatusPending
+	request.DecidedBy = nil
+	request.DecidedAt = nil
+	r.store.renewalRequests[id] = request
+	return nil
+}
+
+func (r *renewalRequestRepository) SetSubscription(id int64, subscriptionID int64) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	request, ok := r.store.renewalRequests[id]
+	if !ok {
+		return nil
+	}
+	request.SubscriptionID = &subscriptionID
+	r.store.renewalRequests[id] = request
+	return nil
+}
+
+// withJoins заполняет имя и telegram_id ученика и название тарифа, как JOIN в Postgres;
+// false, если ученика или тарифа уже нет; вызывать под store.mu
+func (r *renewalRequestRepository) withJoins(request models.RenewalRequest) (models.RenewalRequest, bool) {
+	student, ok := r.store.students[request.StudentID]
+	if !ok {
+		return request, false
+	}
+	user, ok := r.store.users[student.UserID]
+	if !ok {
+		return request, false
+	}
+	plan, ok := r.store.plans[request.PlanID]
+	if !ok {
+		return request, false
+	}
+
+	request.StudentName = user.FirstName + " " + user.LastName
+	request.TelegramID = user.TelegramID
+	request.PlanName = plan.Name
+	return request, true
+}
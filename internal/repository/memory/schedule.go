@@ -0,0 +1,293 @@
+package memory
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/repository"
+	"time"
+)
+
+type trainingScheduleRepository struct {
+	store *Store
+}
+
+func NewTrainingScheduleRepository(store *Store) repository.TrainingScheduleRepository {
+	return &trainingScheduleRepository{store: store}
+}
+
+func (r *trainingScheduleRepository) CreateTraining(training *models.TrainingSchedule) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.groups[training.GroupID]; !ok {
+		return fmt.Errorf("группа %d не существует", training.GroupID)
+	}
+
+	now := time.Now()
+	training.ID = int(r.store.nextID("training_schedule"))
+	training.CreatedAt = now
+	training.UpdatedAt = now
+
+	stored := *training
+	stored.TrainingDate = dateOnly(training.TrainingDate)
+	stored.StartTime = clockOnly(training.StartTime)
+	stored.EndTime = clockOnly(training.EndTime)
+	stored.GroupName, stored.CoachName = "", ""
+	r.store.trainings[stored.ID] = stored
+	return nil
+}
+
+func (r *trainingScheduleRepository) GetTrainingByID(id int) (*models.TrainingSchedule, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	training, ok := r.store.trainings[id]
+	if !ok {
+		return nil, nil
+	}
+	training = r.store.withJoins(training)
+	return &training, nil
+}
+
+func (r *trainingScheduleRepository) GetTrainingsByDate(date time.Time) ([]models.TrainingSchedule, error) {
+	return r.filter(func(t models.TrainingSchedule) bool {
+		return t.CancelledAt == nil && t.TrainingDate.Equal(dateOnly(date))
+	}), nil
+}
+
+func (r *trainingScheduleRepository) GetTrainingsByDateRange(start, end time.Time) ([]models.TrainingSchedule, error) {
+	return r.filter(func(t models.TrainingSchedule) bool {
+		return t.CancelledAt == nil && betweenDates(t.TrainingDate, start, end)
+	}), nil
+}
+
+func (r *trainingScheduleRepository) GetTrainingsByGroup(groupID int, start, end time.Time) ([]models.TrainingSchedule, error) {
+	return r.filter(func(t models.TrainingSchedule) bool {
+		return t.CancelledAt == nil && t.GroupID == groupID && betweenDates(t.TrainingDate, start, end)
+	}), nil
+}
+
+func (r *trainingScheduleRepository) GetTrainingsByCoach(coachID int64, start, end time.Time) ([]models.TrainingSchedule, error) {
+	return r.filter(func(t models.TrainingSchedule) bool {
+		return t.CancelledAt == nil && t.CoachID != nil && *t.CoachID == coachID && betweenDates(t.TrainingDate, start, end)
+	}), nil
+}
+
+// GetAvailableTrainingsForStudent будущие тренировки со свободными местами, на которые ученик ещё не записан.
+// Если у ученика есть активный абонемент, остаются только тренировки, которые допускает хотя бы один из них
+func (r *trainingScheduleRepository) GetAvailableTrainingsForStudent(studentID int, start, end time.Time) ([]models.TrainingSchedule, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	now := time.Now()
+	today := dateOnly(now)
+	hasPass := len(r.store.activeSubscriptionsLocked(int64(studentID), now, nil)) > 0
+	var trainings []models.TrainingSchedule
+	for _, t := range r.store.trainings {
+		if t.CancelledAt != nil || !betweenDates(t.TrainingDate, start, end) || t.TrainingDate.Before(today) {
+			continue
+		}
+		if t.MaxParticipants != nil && *t.MaxParticipants <= r.store.participantsCount(t.ID) {
+			continue
+		}
+
+		registered := false
+		for _, a := range r.store.attendance {
+			if a.TrainingID == t.ID && a.StudentID == studentID && a.Status != models.AttendanceStatusCancelled {
+				registered = true
+				break
+			}
+		}
+		if registered {
+			continue
+		}
+		if hasPass && len(r.store.activeSubscriptionsLocked(int64(studentID), now, &t)) == 0 {
+			continue
+		}
+
+		trainings = append(trainings, r.store.withJoins(t))
+	}
+	sortTrainings(trainings)
+	return trainings, nil
+}
+
+func (r *trainingScheduleRepository) UpdateTraining(training *models.TrainingSchedule) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing, ok := r.store.trainings[training.ID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+
+	existing.GroupID = training.GroupID
+	existing.CoachID = training.CoachID
+	existing.TrainingDate = dateOnly(training.TrainingDate)
+	existing.StartTime = clockOnly(training.StartTime)
+	existing.EndTime = clockOnly(training.EndTime)
+	existing.Description = training.Description
+	existing.MaxParticipants = training.MaxParticipants
+	existing.UpdatedAt = time.Now()
+	r.store.trainings[training.ID] = existing
+
+	training.UpdatedAt = existing.UpdatedAt
+	return nil
+}
+
+func (r *trainingScheduleRepository) UpdateTrainingPartial(id int, updates map[string]interface{}) error {
+	if len(updates) == 0 {
+		return errors.New("нет полей для обновления")
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	training, ok := r.store.trainings[id]
+	if !ok {
+		return nil
+	}
+
+	for field, value := range updates {
+		switch field {
+		case "group_id":
+			groupID, ok := value.(int)
+			if !ok {
+				return fmt.Errorf("неверный тип group_id: %T", value)
+			}
+			training.GroupID = groupID
+		case "coach_id":
+			switch v := value.(type) {
+			case int64:
+				training.CoachID = &v
+			case *int64:
+				training.CoachID = v
+			case nil:
+				training.CoachID = nil
+			default:
+				return fmt.Errorf("неверный тип coach_id: %T", value)
+			}
+		case "training_date":
+			date, ok := value.(time.Time)
+			if !ok {
+				parsed, err := time.Parse("2006-01-02", fmt.Sprint(value))
+				if err != nil {
+					return err
+				}
+				date = parsed
+			}
+			training.TrainingDate = dateOnly(date)
+		case "start_time":
+			clock, err := parseClock(value)
+			if err != nil {
+				return err
+			}
+			training.StartTime = clock
+		case "end_time":
+			clock, err := parseClock(value)
+			if err != nil {
+				return err
+			}
+			training.EndTime = clock
+		case "description":
+			training.Description = fmt.Sprint(value)
+		case "max_participants":
+			switch v := value.(type) {
+			case int:
+				training.MaxParticipants = &v
+			case *int:
+				training.MaxParticipants = v
+			case nil:
+				training.MaxParticipants = nil
+			default:
+				return fmt.Errorf("неверный тип max_participants: %T", value)
+			}
+		default:
+			// Пропускаем неразрешенные поля, как белый список в Postgres-репозитории
+		}
+	}
+
+	training.UpdatedAt = time.Now()
+	r.store.trainings[id] = training
+	return nil
+}
+
+// CancelTraining помечает тренировку отменённой. false, если её нет или она уже отменена
+func (r *trainingScheduleRepository) CancelTraining(id int, reason string) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	training, ok := r.store.trainings[id]
+	if !ok || training.CancelledAt != nil {
+		return false, nil
+	}
+
+	now := time.Now()
+	training.CancelledAt = &now
+	training.CancelReason = reason
+	training.UpdatedAt = now
+	r.store.trainings[id] = training
+	return true, nil
+}
+
+func (r *trainingScheduleRepository) IsCoachAvailable(coachID int64, date time.Time, startTime, endTime time.Time) (bool, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	start, end := clockOnly(startTime), clockOnly(endTime)
+	for _, t := range r.store.trainings {
+		if t.CancelledAt != nil || t.CoachID == nil || *t.CoachID != coachID || !t.TrainingDate.Equal(dateOnly(date)) {
+			continue
+		}
+
+		overlaps := (!t.StartTime.After(start) && t.EndTime.After(start)) ||
+			(t.StartTime.Before(end) && !t.EndTime.Before(end)) ||
+			(!t.StartTime.Before(start) && !t.EndTime.After(end))
+		if overlaps {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (r *trainingScheduleRepository) GetTrainingParticipantsCount(trainingID int) (int, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return r.store.participantsCount(trainingID), nil
+}
+
+func (r *trainingScheduleRepository) Exists(groupID int, startTime time.Time) (bool, error) {
+	trainings := r.filter(func(t models.TrainingSchedule) bool {
+		return t.GroupID == groupID &&
+			t.TrainingDate.Equal(dateOnly(startTime)) &&
+			t.StartTime.Equal(clockOnly(startTime))
+	})
+	return len(trainings) > 0, nil
+}
+
+func (r *trainingScheduleRepository) ExistsForCoach(groupID int, coachID int64, startTime time.Time) (bool, error) {
+	trainings := r.filter(func(t models.TrainingSchedule) bool {
+		return t.GroupID == groupID &&
+			t.CoachID != nil && *t.CoachID == coachID &&
+			t.TrainingDate.Equal(dateOnly(startTime)) &&
+			t.StartTime.Equal(clockOnly(startTime))
+	})
+	return len(trainings) > 0, nil
+}
+
+// filter выбирает тренировки с JOIN-полями, отсортированные по дате и времени начала
+func (r *trainingScheduleRepository) filter(match func(t models.TrainingSchedule) bool) []models.TrainingSchedule {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var trainings []models.TrainingSchedule
+	for _, t := range r.store.trainings {
+		if match(t) {
+			trainings = append(trainings, r.store.withJoins(t))
+		}
+	}
+	sortTrainings(trainings)
+	return trainings
+}
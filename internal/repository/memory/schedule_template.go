@@ -0,0 +1,153 @@
+package memory
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/repository"
+	"time"
+)
+
+type weekScheduleRepository struct {
+	store *Store
+}
+
+func NewWeekScheduleRepository(store *Store) repository.WeekScheduleRepository {
+	return &weekScheduleRepository{store: store}
+}
+
+func (r *weekScheduleRepository) GetAllActive() ([]models.WeekScheduleTemplate, error) {
+	return r.filter(func(t models.WeekScheduleTemplate) bool { return t.IsActive }), nil
+}
+
+func (r *weekScheduleRepository) GetByGroupID(groupID int) ([]models.WeekScheduleTemplate, error) {
+	return r.filter(func(t models.WeekScheduleTemplate) bool { return t.IsActive && t.GroupID == groupID }), nil
+}
+
+func (r *weekScheduleRepository) GetByID(id int) (*models.WeekScheduleTemplate, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	t, ok := r.store.templates[id]
+	if !ok {
+		return nil, errors.New("шаблон не найден")
+	}
+	return &t, nil
+}
+
+func (r *weekScheduleRepository) Create(template *models.WeekScheduleTemplate) error {
+	if template.DayOfWeek < 1 || template.DayOfWeek > 7 {
+		return fmt.Errorf("неверный день недели: %d", template.DayOfWeek)
+	}
+	startTime, err := normalizeClockString(template.StartTime)
+	if err != nil {
+		return err
+	}
+	endTime, err := normalizeClockString(template.EndTime)
+	if err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	now := time.Now()
+	template.ID = int(r.store.nextID("week_schedule_templates"))
+	template.CreatedAt = now
+	template.UpdatedAt = now
+
+	stored := *template
+	stored.StartTime = startTime
+	stored.EndTime = endTime
+	r.store.templates[stored.ID] = stored
+	return nil
+}
+
+func (r *weekScheduleRepository) UpdatePartial(id int, updates map[string]interface{}) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	t, ok := r.store.templates[id]
+	if !ok {
+		return nil
+	}
+
+	if groupID, ok := updates["group_id"].(int); ok {
+		t.GroupID = groupID
+	}
+	if dayOfWeek, ok := updates["day_of_week"].(int); ok {
+		t.DayOfWeek = dayOfWeek
+	}
+	if startTime, ok := updates["start_time"]; ok {
+		clock, err := parseClock(startTime)
+		if err != nil {
+			return err
+		}
+		t.StartTime = clock.Format("15:04:05")
+	}
+	if endTime, ok := updates["end_time"]; ok {
+		clock, err := parseClock(endTime)
+		if err != nil {
+			return err
+		}
+		t.EndTime = clock.Format("15:04:05")
+	}
+	// description = COALESCE(NULLIF($n, ''), description)
+	if description, ok := updates["description"].(string); ok && description != "" {
+		t.Description = description
+	}
+	if isActive, ok := updates["is_active"].(bool); ok {
+		t.IsActive = isActive
+	}
+
+	t.UpdatedAt = time.Now()
+	r.store.templates[id] = t
+	return nil
+}
+
+func (r *weekScheduleRepository) Delete(id int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	delete(r.store.templates, id)
+	return nil
+}
+
+func (r *weekScheduleRepository) Activate(id int) error {
+	return r.setActive(id, true)
+}
+
+func (r *weekScheduleRepository) Deactivate(id int) error {
+	return r.setActive(id, false)
+}
+
+func (r *weekScheduleRepository) setActive(id int, active bool) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if t, ok := r.store.templates[id]; ok {
+		t.IsActive = active
+		r.store.templates[id] = t
+	}
+	return nil
+}
+
+func (r *weekScheduleRepository) filter(match func(t models.WeekScheduleTemplate) bool) []models.WeekScheduleTemplate {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var templates []models.WeekScheduleTemplate
+	for _, t := range r.store.templates {
+		if match(t) {
+			templates = append(templates, t)
+		}
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		if templates[i].DayOfWeek != templates[j].DayOfWeek {
+			return templates[i].DayOfWeek < templates[j].DayOfWeek
+		}
+		return templates[i].StartTime < templates[j].StartTime
+	})
+	return templates
+}
@@ -0,0 +1,62 @@
+package memory
+
+import (
+	"spectrum-club-bot/internal/models"
+	"testing"
+)
+
+func TestParticipantsCountIgnoresCancelled(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []string
+		want     int
+	}{
+		{name: "никто не записан", want: 0},
+		{name: "записан", statuses: []string{models.AttendanceStatusRegistered}, want: 1},
+		{name: "пришёл и не пришёл занимают место", statuses: []string{models.AttendanceStatusAttended, models.AttendanceStatusNoShow}, want: 2},
+		{name: "отменённые не считаются", statuses: []string{models.AttendanceStatusCancelled, models.AttendanceStatusLateCancelled}, want: 0},
+		{name: "смешанные", statuses: []string{models.AttendanceStatusRegistered, models.AttendanceStatusCancelled}, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			attendance := NewAttendanceRepository(f.store)
+			for i, status := range tt.statuses {
+				record := &models.Attendance{TrainingID: f.training.ID, StudentID: int(f.students[i])}
+				if err := attendance.CreateAttendance(record); err != nil {
+					t.Fatal(err)
+				}
+				if status == models.AttendanceStatusRegistered {
+					continue
+				}
+				if ok, err := attendance.ChangeStatus(record.ID, models.AttendanceStatusRegistered, status, nil, ""); err != nil || !ok {
+					t.Fatalf("ChangeStatus(%s) = %v, %v", status, ok, err)
+				}
+			}
+
+			got, err := NewTrainingScheduleRepository(f.store).GetTrainingParticipantsCount(f.training.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("GetTrainingParticipantsCount() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateAttendanceUnique(t *testing.T) {
+	f := newFixture(t)
+	attendance := NewAttendanceRepository(f.store)
+	record := models.Attendance{TrainingID: f.training.ID, StudentID: int(f.students[0])}
+	if err := attendance.CreateAttendance(&record); err != nil {
+		t.Fatal(err)
+	}
+	if err := attendance.CreateAttendance(&models.Attendance{TrainingID: f.training.ID, StudentID: int(f.students[0])}); err == nil {
+		t.Error("повторная запись на ту же тренировку не вернула ошибку")
+	}
+	if err := attendance.CreateAttendance(&models.Attendance{TrainingID: f.training.ID + 100, StudentID: int(f.students[0])}); err == nil {
+		t.Error("запись на несуществующую тренировку не вернула ошибку")
+	}
+}
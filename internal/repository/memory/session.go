@@ -0,0 +1,46 @@
+package memory
+
+import (
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/repository"
+	"time"
+)
+
+type botSessionRepository struct {
+	store *Store
+}
+
+func NewBotSessionRepository(store *Store) repository.BotSessionRepository {
+	return &botSessionRepository{store: store}
+}
+
+func (r *botSessionRepository) Get(chatID int64) (*models.BotSession, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	session, ok := r.store.botSessions[chatID]
+	if !ok {
+		return nil, nil
+	}
+	session.Data = append([]byte(nil), session.Data...)
+	return &session, nil
+}
+
+func (r *botSessionRepository) Save(session *models.BotSession) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	session.UpdatedAt = time.Now()
+	stored := *session
+	stored.Data = append([]byte(nil), session.Data...)
+	r.store.botSessions[session.ChatID] = stored
+	return nil
+}
+
+func (r *botSessionRepository) Delete(chatID int64) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	delete(r.store.botSessions, chatID)
+	return nil
+}
@@ -0,0 +1,218 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"spectrum-club-bot/internal/models"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Store общее состояние всех in-memory репозиториев.
+// Репозитории пакета работают поверх одного Store, поэтому JOIN-поля
+// (имена учеников, групп, тренеров) и подсчёт участников ведут себя как в Postgres.
+type Store struct {
+	mu    sync.RWMutex
+	txMu  sync.Mutex  // сериализует транзакции (см. NewTransactor)
+	seqMu *sync.Mutex // последовательности общие для Store и копий транзакций
+
+	users         map[int64]models.User
+	students      map[int64]models.Student
+	coaches       map[int64]models.Coach
+	subscriptions map[int64]models.Subscription
+	plans         map[int64]models.SubscriptionPlan
+	freezes       map[int64]models.SubscriptionFreeze
+	ledger        map[int64]models.LessonLedgerEntry
+	payments      map[int64]models.Payment
+	groups        map[int]models.TrainingGroup
+	trainings     map[int]models.TrainingSchedule
+	templates     map[int]models.WeekScheduleTemplate
+	attendance    map[int]models.Attendance
+	botSessions   map[int64]models.BotSession
+	outbox        map[int64]models.OutboxMessage
+	reminders     map[reminderKey]time.Time
+	waitlist      map[int]models.WaitlistEntry
+
+	subscriptionAlerts  map[subscriptionAlertKey]time.Time
+	subscriptionMembers map[subscriptionMemberKey]subscriptionMember
+	subscriptionDigests map[time.Time]time.Time
+	renewalRequests     map[int64]models.RenewalRequest
+	attendanceLog       map[int64]models.AttendanceTransition
+
+	sequences map[string]int64
+}
+
+func NewStore() *Store {
+	return &Store{
+		users:         make(map[int64]models.User),
+		students:      make(map[int64]models.Student),
+		coaches:       make(map[int64]models.Coach),
+		subscriptions: make(map[int64]models.Subscription),
+		plans:         make(map[int64]models.SubscriptionPlan),
+		freezes:       make(map[int64]models.SubscriptionFreeze),
+		ledger:        make(map[int64]models.LessonLedgerEntry),
+		payments:      make(map[int64]models.Payment),
+		groups:        make(map[int]models.TrainingGroup),
+		trainings:     make(map[int]models.TrainingSchedule),
+		templates:     make(map[int]models.WeekScheduleTemplate),
+		attendance:    make(map[int]models.Attendance),
+		botSessions:   make(map[int64]models.BotSession),
+		outbox:        make(map[int64]models.OutboxMessage),
+		reminders:     make(map[reminderKey]time.Time),
+		waitlist:      make(map[int]models.WaitlistEntry),
+
+		subscriptionAlerts:  make(map[subscriptionAlertKey]time.Time),
+		subscriptionMembers: make(map[subscriptionMemberKey]subscriptionMember),
+		subscriptionDigests: make(map[time.Time]time.Time),
+		renewalRequests:     make(map[int64]models.RenewalRequest),
+		attendanceLog:       make(map[int64]models.AttendanceTransition),
+
+		sequences: make(map[string]int64),
+		seqMu:     &sync.Mutex{},
+	}
+}
+
+// AddGroup добавляет группу тренировок (в Postgres группы заводятся миграциями/вручную,
+// поэтому в интерфейсе репозитория нет метода создания)
+func (s *Store) AddGroup(group models.TrainingGroup) models.TrainingGroup {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	group.ID = int(s.nextID("training_groups"))
+	if group.CreatedAt.IsZero() {
+		group.CreatedAt = time.Now()
+	}
+	s.groups[group.ID] = group
+	return group
+}
+
+// nextID аналог SERIAL; вызывать под s.mu. Как и последовательности Postgres,
+// не откатывается вместе с транзакцией
+func (s *Store) nextID(table string) int64 {
+	s.seqMu.Lock()
+	defer s.seqMu.Unlock()
+
+	s.sequences[table]++
+	return s.sequences[table]
+}
+
+// fullName аналог u.first_name || ' ' || u.last_name; вызывать под s.mu
+func (s *Store) fullName(userID int64) (string, bool) {
+	user, ok := s.users[userID]
+	if !ok {
+		return "", false
+	}
+	return user.FirstName + " " + user.LastName, true
+}
+
+// studentName имя ученика через students -> users; вызывать под s.mu
+func (s *Store) studentName(studentID int64) (string, bool) {
+	student, ok := s.students[studentID]
+	if !ok {
+		return "", false
+	}
+	return s.fullName(student.UserID)
+}
+
+// withJoins заполняет group_name и coach_name как LEFT JOIN в репозитории расписания
+func (s *Store) withJoins(training models.TrainingSchedule) models.TrainingSchedule {
+	if group, ok := s.groups[training.GroupID]; ok {
+		training.GroupName = group.Name
+	}
+	if training.CoachID != nil {
+		if coach, ok := s.coaches[*training.CoachID]; ok {
+			training.CoachName, _ = s.fullName(coach.UserID)
+		}
+	}
+	return training
+}
+
+// participantsCount аналог SELECT COUNT(*) FROM attendance WHERE training_id = $1
+// AND status NOT IN ('cancelled', 'late_cancelled')
+func (s *Store) participantsCount(trainingID int) int {
+	count := 0
+	for _, a := range s.attendance {
+		if a.TrainingID == trainingID && a.HoldsSpot() {
+			count++
+		}
+	}
+	return count
+}
+
+// logAttendanceLocked добавляет переход в историю статусов; вызывать под s.mu
+func (s *Store) logAttendanceLocked(attendanceID int, from *string, to string, actorID *int64) {
+	id := s.nextID("attendance_status_log")
+	s.attendanceLog[id] = models.AttendanceTransition{
+		ID:           id,
+		AttendanceID: attendanceID,
+		FromStatus:   from,
+		ToStatus:     to,
+		ActorID:      actorID,
+		CreatedAt:    time.Now(),
+	}
+}
+
+// frozenLocked заморожен ли абонемент в день day; вызывать под s.mu
+func (s *Store) frozenLocked(subscriptionID int64, day time.Time) bool {
+	for _, freeze := range s.freezes {
+		if freeze.SubscriptionID == subscriptionID && betweenDates(day, freeze.StartDate, freeze.EndDate) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortTrainings(trainings []models.TrainingSchedule) {
+	sort.Slice(trainings, func(i, j int) bool {
+		if !trainings[i].TrainingDate.Equal(trainings[j].TrainingDate) {
+			return trainings[i].TrainingDate.Before(trainings[j].TrainingDate)
+		}
+		if !trainings[i].StartTime.Equal(trainings[j].StartTime) {
+			return trainings[i].StartTime.Before(trainings[j].StartTime)
+		}
+		return trainings[i].ID < trainings[j].ID
+	})
+}
+
+// dateOnly приводит время к значению колонки DATE (так его отдаёт lib/pq)
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// clockOnly приводит время к значению колонки TIME (так его отдаёт lib/pq)
+func clockOnly(t time.Time) time.Time {
+	return time.Date(0, 1, 1, t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+}
+
+// betweenDates аналог training_date BETWEEN 'YYYY-MM-DD' AND 'YYYY-MM-DD'
+func betweenDates(date, start, end time.Time) bool {
+	d := dateOnly(date)
+	return !d.Before(dateOnly(start)) && !d.After(dateOnly(end))
+}
+
+// parseClock разбирает значение для колонки TIME: time.Time или строку ЧЧ:ММ[:СС]
+func parseClock(value interface{}) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return clockOnly(v), nil
+	case string:
+		for _, layout := range []string{"15:04:05", "15:04"} {
+			if t, err := time.Parse(layout, strings.TrimSpace(v)); err == nil {
+				return clockOnly(t), nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("invalid input syntax for type time: %q", v)
+	default:
+		return time.Time{}, fmt.Errorf("invalid input syntax for type time: %v", value)
+	}
+}
+
+// normalizeClockString приводит "15:30" к виду, в котором Postgres возвращает TIME ("15:30:00")
+func normalizeClockString(value string) (string, error) {
+	t, err := parseClock(value)
+	if err != nil {
+		return "", err
+	}
+	return t.Format("15:04:05"), nil
+}
@@ -0,0 +1,63 @@
+package memory
+
+import (
+	"spectrum-club-bot/internal/models"
+	"testing"
+	"time"
+)
+
+// fixture хранилище с группой, тренировкой через два дня и двумя учениками
+type fixture struct {
+	store    *Store
+	group    models.TrainingGroup
+	training models.TrainingSchedule
+	students []int64
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	store := NewStore()
+	group := store.AddGroup(models.TrainingGroup{Name: "Взрослые", Code: "adults"})
+
+	day := time.Now().AddDate(0, 0, 2)
+	training := models.TrainingSchedule{
+		GroupID:      group.ID,
+		TrainingDate: day,
+		StartTime:    time.Date(0, 1, 1, 19, 0, 0, 0, time.UTC),
+		EndTime:      time.Date(0, 1, 1, 20, 30, 0, 0, time.UTC),
+	}
+	if err := NewTrainingScheduleRepository(store).CreateTraining(&training); err != nil {
+		t.Fatal(err)
+	}
+
+	f := &fixture{store: store, group: group, training: training}
+	for i, name := range []string{"Анна", "Борис"} {
+		user := &models.User{TelegramID: int64(-10 - i), FirstName: name, LastName: "Тестова", Role: "student"}
+		if err := NewUserRepository(store).CreateOrUpdate(user); err != nil {
+			t.Fatal(err)
+		}
+		student := &models.Student{UserID: user.ID}
+		if err := NewStudentRepository(store).Create(student); err != nil {
+			t.Fatal(err)
+		}
+		f.students = append(f.students, student.ID)
+	}
+	return f
+}
+
+// addSubscription абонемент ученика со сроком до end (нулевой — бессрочный)
+func (f *fixture) addSubscription(t *testing.T, studentID int64, remaining int, created, end time.Time) models.Subscription {
+	t.Helper()
+	subscription := &models.Subscription{
+		StudentID:        studentID,
+		StartDate:        created,
+		EndDate:          end,
+		TotalLessons:     8,
+		RemainingLessons: remaining,
+		CreatedAt:        created,
+	}
+	if err := NewSubscriptionRepository(f.store, models.ConsumptionExpiringFirst).Create(subscription); err != nil {
+		t.Fatal(err)
+	}
+	return *subscription
+}
@@ -0,0 +1,61 @@
+package memory
+
+import (
+	"database/sql"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/repository"
+)
+
+type studentRepository struct {
+	store *Store
+}
+
+func NewStudentRepository(store *Store) repository.StudentRepository {
+	return &studentRepository{store: store}
+}
+
+func (r *studentRepository) Create(student *models.Student) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	student.ID = r.store.nextID("students")
+	r.store.students[student.ID] = *student
+	return nil
+}
+
+func (r *studentRepository) GetByUserID(userID int64) (*models.Student, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, student := range r.store.students {
+		if student.UserID == userID {
+			return &student, nil
+		}
+	}
+	return &models.Student{}, sql.ErrNoRows
+}
+
+func (r *studentRepository) GetByID(id int64) (*models.Student, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	student, ok := r.store.students[id]
+	if !ok {
+		return &models.Student{}, sql.ErrNoRows
+	}
+	return &student, nil
+}
+
+func (r *studentRepository) Update(student *models.Student) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing, ok := r.store.students[student.ID]
+	if !ok {
+		return nil
+	}
+	existing.AtleticTitle = student.AtleticTitle
+	existing.UpdatedAt = student.UpdatedAt
+	r.store.students[student.ID] = existing
+	return nil
+}
@@ -0,0 +1,372 @@
+package memory
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/repository"
+	"strings"
+	"time"
+)
+
+type subscriptionRepository struct {
+	store *Store
+	order models.ConsumptionOrder
+}
+
+func NewSubscriptionRepository(store *Store, order models.ConsumptionOrder) repository.SubscriptionRepository {
+	return &subscriptionRepository{store: store, order: order}
+}
+
+func (r *subscriptionRepository) Create(subscription *models.Subscription) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	subscription.ID = r.store.nextID("subscriptions")
+	r.store.subscriptions[subscription.ID] = *subscription
+	return nil
+}
+
+func (r *subscriptionRepository) GetByID(id int64) (*models.Subscription, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	subscription, ok := r.store.subscriptions[id]
+	if !ok {
+		return &models.Subscription{}, sql.ErrNoRows
+	}
+	return &subscription, nil
+}
+
+// GetByIDForUpdate в памяти строки не блокируются: транзакции Store и так выполняются по одной
+func (r *subscriptionRepository) GetByIDForUpdate(id int64) (*models.Subscription, error) {
+	return r.GetByID(id)
+}
+
+// GetActiveByStudentID незамороженный абонемент с остатком занятий и не истёкшим сроком,
+// с которого будет списано следующее занятие
+func (r *subscriptionRepository) GetActiveByStudentID(studentID int64) (*models.Subscription, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	active := r.pickLocked(studentID, nil)
+	if active == nil {
+		return &models.Subscription{}, sql.ErrNoRows
+	}
+	return active, nil
+}
+
+func (r *subscriptionRepository) GetActiveForTraining(studentID int64, trainingID int) (*models.Subscription, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	active := r.pickForTrainingLocked(studentID, trainingID)
+	if active == nil {
+		return &models.Subscription{}, sql.ErrNoRows
+	}
+	return active, nil
+}
+
+// GetActiveForTrainingForUpdate в памяти строки не блокируются: транзакции Store
+// и так выполняются по одной (см. NewTransactor)
+func (r *subscriptionRepository) GetActiveForTrainingForUpdate(studentID int64, trainingID int) (*models.Subscription, error) {
+	return r.GetActiveForTraining(studentID, trainingID)
+}
+
+// pickForTrainingLocked активный абонемент, допускающий тренировку; nil, если тренировки нет
+func (r *subscriptionRepository) pickForTrainingLocked(studentID int64, trainingID int) *models.Subscription {
+	training, ok := r.store.trainings[trainingID]
+	if !ok {
+		return nil
+	}
+	return r.pickLocked(studentID, &training)
+}
+
+// pickLocked сначала собственный абонемент ученика, затем семейный, среди равных — по порядку списания
+func (r *subscriptionRepository) pickLocked(studentID int64, training *models.TrainingSchedule) *models.Subscription {
+	var active *models.Subscription
+	for _, subscription := range r.store.activeSubscriptionsLocked(studentID, time.Now(), training) {
+		if active == nil || r.preferred(subscription, *active, studentID) {
+			active = &subscription
+		}
+	}
+	return active
+}
+
+// activeSubscriptionsLocked абонементы ученика с остатком занятий, не истёкшие и не замороженные.
+// Если training не nil, остаются только абонементы, которые её допускают
+func (s *Store) activeSubscriptionsLocked(studentID int64, now time.Time, training *models.TrainingSchedule) []models.Subscription {
+	var active []models.Subscription
+	for _, subscription := range s.subscriptions {
+		if !s.heldByLocked(subscription, studentID) || subscription.RemainingLessons <= 0 {
+			continue
+		}
+		// Нулевая дата соответствует end_date IS NULL
+		if !subscription.EndDate.IsZero() && !subscription.EndDate.After(now) {
+			continue
+		}
+		if s.frozenLocked(subscription.ID, now) {
+			continue
+		}
+		if training != nil && subscription.CheckTraining(*training) != nil {
+			continue
+		}
+		active = append(active, subscription)
+	}
+	return active
+}
+
+func (r *subscriptionRepository) GetHistoryByStudentID(studentID int64) ([]*models.Subscription, error) {
+	return r.GetByStudentID(studentID)
+}
+
+func (r *subscriptionRepository) Update(subscription *models.Subscription) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing, ok := r.store.subscriptions[subscription.ID]
+	if !ok {
+		return nil
+	}
+	existing.RemainingLessons = subscription.RemainingLessons
+	existing.EndDate = subscription.EndDate
+	r.store.subscriptions[subscription.ID] = existing
+	return nil
+}
+
+func (r *subscriptionRepository) DecrementRemainingLessons(studentID int64, trainingID int) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	active := r.pickForTrainingLocked(studentID, trainingID)
+	if active == nil {
+		return 0, fmt.Errorf("нет активного абонемента для тренировки %d у ученика с ID %d", trainingID, studentID)
+	}
+
+	active.RemainingLessons--
+	r.store.subscriptions[active.ID] = *active
+	return active.ID, nil
+}
+
+func (r *subscriptionRepository) Extend(id int64, days int, lessons int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	subscription, ok := r.store.subscriptions[id]
+	if !ok {
+		return fmt.Errorf("абонемент с ID %d не найден", id)
+	}
+	if days > 0 {
+		// GREATEST игнорирует NULL: абонемент без срока продлевается от текущего момента
+		if now := time.Now(); subscription.EndDate.Before(now) {
+			subscription.EndDate = now
+		}
+		subscription.EndDate = subscription.EndDate.AddDate(0, 0, days)
+	}
+	subscription.TotalLessons += lessons
+	subscription.RemainingLessons += lessons
+	r.store.subscriptions[id] = subscription
+	return nil
+}
+
+func (r *subscriptionRepository) AddRemainingLessons(id int64, delta int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	subscription, ok := r.store.subscriptions[id]
+	if !ok || subscription.RemainingLessons+delta < 0 {
+		return fmt.Errorf("абонемент с ID %d не найден или в нём недостаточно занятий", id)
+	}
+	subscription.RemainingLessons += delta
+	r.store.subscriptions[id] = subscription
+	return nil
+}
+
+func (r *subscriptionRepository) GetAll() ([]*models.Subscription, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var subscriptions []*models.Subscription
+	for _, subscription := range r.store.subscriptions {
+		subscriptions = append(subscriptions, &subscription)
+	}
+	sort.Slice(subscriptions, func(i, j int) bool {
+		return subscriptions[i].StartDate.Before(subscriptions[j].StartDate)
+	})
+	return subscriptions, nil
+}
+
+func (r *subscriptionRepository) GetByStudentID(studentID int64) ([]*models.Subscription, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var subscriptions []*models.Subscription
+	for _, subscription := range r.store.subscriptions {
+		if r.store.heldByLocked(subscription, studentID) {
+			subscriptions = append(subscriptions, &subscription)
+		}
+	}
+	sort.Slice(subscriptions, func(i, j int) bool {
+		return subscriptions[i].CreatedAt.After(subscriptions[j].CreatedAt)
+	})
+	return subscriptions, nil
+}
+
+func (r *subscriptionRepository) Delete(id int64) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.subscriptions[id]; !ok {
+		return fmt.Errorf("абонемент с ID %d не найден", id)
+	}
+	delete(r.store.subscriptions, id)
+	for freezeID, freeze := range r.store.freezes {
+		if freeze.SubscriptionID == id {
+			delete(r.store.freezes, freezeID)
+		}
+	}
+	for entryID, entry := range r.store.ledger {
+		if entry.SubscriptionID == id {
+			delete(r.store.ledger, entryID)
+		}
+	}
+	for key := range r.store.subscriptionAlerts {
+		if key.subscriptionID == id {
+			delete(r.store.subscriptionAlerts, key)
+		}
+	}
+	for key := range r.store.subscriptionMembers {
+		if key.subscriptionID == id {
+			delete(r.store.subscriptionMembers, key)
+		}
+	}
+	for paymentID, payment := range r.store.payments {
+		if payment.SubscriptionID != nil && *payment.SubscriptionID == id {
+			payment.SubscriptionID = nil
+			r.store.payments[paymentID] = payment
+		}
+	}
+	return nil
+}
+
+func (r *subscriptionRepository) ExtendEndDate(id int64, days int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	subscription, ok := r.store.subscriptions[id]
+	if !ok {
+		return fmt.Errorf("абонемент с ID %d не найден", id)
+	}
+	// end_date IS NULL + interval остаётся NULL
+	if !subscription.EndDate.IsZero() {
+		subscription.EndDate = subscription.EndDate.AddDate(0, 0, days)
+	}
+	r.store.subscriptions[id] = subscription
+	return nil
+}
+
+// subscriptionMemberKey аналог первичного ключа (subscription_id, student_id)
+type subscriptionMemberKey struct {
+	subscriptionID int64
+	studentID      int64
+}
+
+type subscriptionMember struct {
+	addedBy *int64
+	addedAt time.Time
+}
+
+// heldByLocked пользуется ли ученик абонементом: он владелец или подключён к нему; вызывать под s.mu
+func (s *Store) heldByLocked(subscription models.Subscription, studentID int64) bool {
+	if subscription.StudentID == studentID {
+		return true
+	}
+	_, ok := s.subscriptionMembers[subscriptionMemberKey{subscriptionID: subscription.ID, studentID: studentID}]
+	return ok
+}
+
+// preferred списывается ли занятие ученика studentID с абонемента a раньше, чем с b
+func (r *subscriptionRepository) preferred(a, b models.Subscription, studentID int64) bool {
+	if ownA, ownB := a.StudentID == studentID, b.StudentID == studentID; ownA != ownB {
+		return ownA
+	}
+	return r.order.Before(a, b)
+}
+
+func (r *subscriptionRepository) AddMember(subscriptionID, studentID int64, addedBy *int64) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.subscriptions[subscriptionID]; !ok {
+		return fmt.Errorf("абонемент с ID %d не найден", subscriptionID)
+	}
+	if _, ok := r.store.students[studentID]; !ok {
+		return fmt.Errorf("ученик с ID %d не найден", studentID)
+	}
+	key := subscriptionMemberKey{subscriptionID: subscriptionID, studentID: studentID}
+	if _, ok := r.store.subscriptionMembers[key]; ok {
+		return fmt.Errorf("ученик %d уже подключён к абонементу %d", studentID, subscriptionID)
+	}
+
+	r.store.subscriptionMembers[key] = subscriptionMember{addedBy: addedBy, addedAt: time.Now()}
+	return nil
+}
+
+func (r *subscriptionRepository) RemoveMember(subscriptionID, studentID int64) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	key := subscriptionMemberKey{subscriptionID: subscriptionID, studentID: studentID}
+	if _, ok := r.store.subscriptionMembers[key]; !ok {
+		return false, nil
+	}
+	delete(r.store.subscriptionMembers, key)
+	return true, nil
+}
+
+func (r *subscriptionRepository) GetMembers(subscriptionID int64) ([]models.SubscriptionMember, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	subscription, ok := r.store.subscriptions[subscriptionID]
+	if !ok {
+		return nil, nil
+	}
+
+	members := []models.SubscriptionMember{{
+		SubscriptionID: subscriptionID,
+		StudentID:      subscription.StudentID,
+		IsOwner:        true,
+		AddedAt:        subscription.CreatedAt,
+	}}
+	for key, member := range r.store.subscriptionMembers {
+		if key.subscriptionID == subscriptionID {
+			members = append(members, models.SubscriptionMember{
+				SubscriptionID: subscriptionID,
+				StudentID:      key.studentID,
+				AddedAt:        member.addedAt,
+			})
+		}
+	}
+	sort.SliceStable(members[1:], func(i, j int) bool {
+		return members[i+1].AddedAt.Before(members[j+1].AddedAt)
+	})
+
+	// Списания и возвраты за посещения ссылаются на запись на тренировку, а через неё — на ученика
+	used := make(map[int64]int)
+	for _, entry := range r.store.ledger {
+		if entry.SubscriptionID != subscriptionID || entry.AttendanceID == nil {
+			continue
+		}
+		if attendance, ok := r.store.attendance[*entry.AttendanceID]; ok {
+			used[int64(attendance.StudentID)] -= entry.Delta
+		}
+	}
+	for i := range members {
+		name, _ := r.store.studentName(members[i].StudentID)
+		members[i].StudentName = strings.TrimSpace(name)
+		members[i].LessonsUsed = used[members[i].StudentID]
+	}
+	return members, nil
+}
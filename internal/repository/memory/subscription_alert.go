@@ -0,0 +1,52 @@
+package memory
+
+import (
+	"spectrum-club-bot/internal/repository"
+	"time"
+)
+
+// subscriptionAlertKey аналог первичного ключа (subscription_id, kind)
+type subscriptionAlertKey struct {
+	subscriptionID int64
+	kind           string
+}
+
+type subscriptionAlertRepository struct {
+	store *Store
+}
+
+func NewSubscriptionAlertRepository(store *Store) repository.SubscriptionAlertRepository {
+	return &subscriptionAlertRepository{store: store}
+}
+
+func (r *subscriptionAlertRepository) MarkSent(subscriptionID int64, kind string) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	key := subscriptionAlertKey{subscriptionID: subscriptionID, kind: kind}
+	if _, exists := r.store.subscriptionAlerts[key]; exists {
+		return false, nil
+	}
+	r.store.subscriptionAlerts[key] = time.Now()
+	return true, nil
+}
+
+func (r *subscriptionAlertRepository) Unmark(subscriptionID int64, kind string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	delete(r.store.subscriptionAlerts, subscriptionAlertKey{subscriptionID: subscriptionID, kind: kind})
+	return nil
+}
+
+func (r *subscriptionAlertRepository) MarkDigestSent(weekStart time.Time) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	key := dateOnly(weekStart)
+	if _, exists := r.store.subscriptionDigests[key]; exists {
+		return false, nil
+	}
+	r.store.subscriptionDigests[key] = time.Now()
+	return true, nil
+}
@@ -0,0 +1,79 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/repository"
+	"time"
+)
+
+type subscriptionFreezeRepository struct {
+	store *Store
+}
+
+func NewSubscriptionFreezeRepository(store *Store) repository.SubscriptionFreezeRepository {
+	return &subscriptionFreezeRepository{store: store}
+}
+
+func (r *subscriptionFreezeRepository) Create(freeze *models.SubscriptionFreeze) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.subscriptions[freeze.SubscriptionID]; !ok {
+		return fmt.Errorf("абонемент с ID %d не найден", freeze.SubscriptionID)
+	}
+	if freeze.EndDate.Before(freeze.StartDate) {
+		return fmt.Errorf("дата окончания заморозки раньше даты начала")
+	}
+
+	freeze.ID = r.store.nextID("subscription_freezes")
+	freeze.StartDate = dateOnly(freeze.StartDate)
+	freeze.EndDate = dateOnly(freeze.EndDate)
+	freeze.CreatedAt = time.Now()
+	r.store.freezes[freeze.ID] = *freeze
+	return nil
+}
+
+func (r *subscriptionFreezeRepository) GetBySubscriptionID(subscriptionID int64) ([]models.SubscriptionFreeze, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var freezes []models.SubscriptionFreeze
+	for _, freeze := range r.store.freezes {
+		if freeze.SubscriptionID == subscriptionID {
+			freezes = append(freezes, freeze)
+		}
+	}
+	sort.Slice(freezes, func(i, j int) bool {
+		return freezes[i].StartDate.Before(freezes[j].StartDate)
+	})
+	return freezes, nil
+}
+
+func (r *subscriptionFreezeRepository) UpdateEndDate(id int64, endDate time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	freeze, ok := r.store.freezes[id]
+	if !ok {
+		return fmt.Errorf("заморозка с ID %d не найдена", id)
+	}
+	if dateOnly(endDate).Before(freeze.StartDate) {
+		return fmt.Errorf("дата окончания заморозки раньше даты начала")
+	}
+	freeze.EndDate = dateOnly(endDate)
+	r.store.freezes[id] = freeze
+	return nil
+}
+
+func (r *subscriptionFreezeRepository) Delete(id int64) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.freezes[id]; !ok {
+		return fmt.Errorf("заморозка с ID %d не найдена", id)
+	}
+	delete(r.store.freezes, id)
+	return nil
+}
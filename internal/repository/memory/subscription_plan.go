@@ -0,0 +1,88 @@
+package memory
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/repository"
+	"time"
+)
+
+type subscriptionPlanRepository struct {
+	store *Store
+}
+
+func NewSubscriptionPlanRepository(store *Store) repository.SubscriptionPlanRepository {
+	return &subscriptionPlanRepository{store: store}
+}
+
+func (r *subscriptionPlanRepository) Create(plan *models.SubscriptionPlan) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if err := r.checkNameLocked(plan); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	plan.ID = r.store.nextID("subscription_plans")
+	plan.CreatedAt = now
+	plan.UpdatedAt = now
+	r.store.plans[plan.ID] = *plan
+	return nil
+}
+
+func (r *subscriptionPlanRepository) Update(plan *models.SubscriptionPlan) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.plans[plan.ID]
+	if !ok {
+		return fmt.Errorf("тариф с ID %d не найден", plan.ID)
+	}
+	if err := r.checkNameLocked(plan); err != nil {
+		return err
+	}
+
+	plan.CreatedAt = stored.CreatedAt
+	plan.UpdatedAt = time.Now()
+	r.store.plans[plan.ID] = *plan
+	return nil
+}
+
+func (r *subscriptionPlanRepository) GetByID(id int64) (*models.SubscriptionPlan, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	plan, ok := r.store.plans[id]
+	if !ok {
+		return &models.SubscriptionPlan{}, sql.ErrNoRows
+	}
+	return &plan, nil
+}
+
+func (r *subscriptionPlanRepository) GetAll(activeOnly bool) ([]models.SubscriptionPlan, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var plans []models.SubscriptionPlan
+	for _, plan := range r.store.plans {
+		if activeOnly && !plan.IsActive {
+			continue
+		}
+		plans = append(plans, plan)
+	}
+	sort.Slice(plans, func(i, j int) bool { return plans[i].Name < plans[j].Name })
+	return plans, nil
+}
+
+// checkNameLocked аналог уникального индекса subscription_plans_name_uidx
+func (r *subscriptionPlanRepository) checkNameLocked(plan *models.SubscriptionPlan) error {
+	for _, p := range r.store.plans {
+		if p.ID != plan.ID && p.Name == plan.Name {
+			return fmt.Errorf(`duplicate key value violates unique constraint "subscription_plans_name_uidx"`)
+		}
+	}
+	return nil
+}
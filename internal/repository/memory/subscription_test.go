@@ -0,0 +1,185 @@
+package memory
+
+import (
+	"database/sql"
+	"errors"
+	"spectrum-club-bot/internal/models"
+	"testing"
+	"time"
+)
+
+func TestGetActiveForTraining(t *testing.T) {
+	now := time.Now()
+	month := now.AddDate(0, 1, 0)
+	week := now.AddDate(0, 0, 7)
+
+	type sub struct {
+		remaining int
+		created   time.Time
+		end       time.Time
+		frozen    bool
+		otherOnly bool // разрешена только другая группа
+	}
+	tests := []struct {
+		name  string
+		order models.ConsumptionOrder
+		subs  []sub
+		want  int // индекс ожидаемого абонемента, -1 — активного нет
+	}{
+		{name: "нет абонементов", want: -1},
+		{name: "занятия закончились", subs: []sub{{remaining: 0, created: now, end: month}}, want: -1},
+		{name: "срок истёк", subs: []sub{{remaining: 5, created: now.AddDate(0, -2, 0), end: now.AddDate(0, 0, -1)}}, want: -1},
+		{name: "заморожен", subs: []sub{{remaining: 5, created: now, end: month, frozen: true}}, want: -1},
+		{name: "не допускает группу", subs: []sub{{remaining: 5, created: now, end: month, otherOnly: true}}, want: -1},
+		{name: "бессрочный", subs: []sub{{remaining: 5, created: now}}, want: 0},
+		{
+			name:  "сначала заканчивающийся",
+			order: models.ConsumptionExpiringFirst,
+			subs:  []sub{{remaining: 5, created: now.AddDate(0, 0, -10), end: month}, {remaining: 5, created: now, end: week}},
+			want:  1,
+		},
+		{
+			name:  "сначала выданный раньше",
+			order: models.ConsumptionOldestFirst,
+			subs:  []sub{{remaining: 5, created: now, end: week}, {remaining: 5, created: now.AddDate(0, 0, -10), end: month}},
+			want:  1,
+		},
+		{
+			name:  "сначала выданный последним",
+			order: models.ConsumptionNewestFirst,
+			subs:  []sub{{remaining: 5, created: now, end: month}, {remaining: 5, created: now.AddDate(0, 0, -10), end: week}},
+			want:  0,
+		},
+		{
+			name:  "пропускает неподходящий",
+			order: models.ConsumptionExpiringFirst,
+			subs:  []sub{{remaining: 5, created: now, end: week, frozen: true}, {remaining: 5, created: now, end: month}},
+			want:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			studentID := f.students[0]
+			other := f.store.AddGroup(models.TrainingGroup{Name: "Дети", Code: "kids"})
+
+			var ids []int64
+			for _, s := range tt.subs {
+				subscription := f.addSubscription(t, studentID, s.remaining, s.created, s.end)
+				if s.otherOnly {
+					f.store.mu.Lock()
+					subscription.GroupIDs = []int64{int64(other.ID)}
+					f.store.subscriptions[subscription.ID] = subscription
+					f.store.mu.Unlock()
+				}
+				if s.frozen {
+					freeze := &models.SubscriptionFreeze{SubscriptionID: subscription.ID, StartDate: now.AddDate(0, 0, -1), EndDate: now.AddDate(0, 0, 3)}
+					if err := NewSubscriptionFreezeRepository(f.store).Create(freeze); err != nil {
+						t.Fatal(err)
+					}
+				}
+				ids = append(ids, subscription.ID)
+			}
+
+			order := tt.order
+			if order == "" {
+				order = models.ConsumptionExpiringFirst
+			}
+			got, err := NewSubscriptionRepository(f.store, order).GetActiveForTraining(studentID, f.training.ID)
+			if tt.want < 0 {
+				if !errors.Is(err, sql.ErrNoRows) {
+					t.Fatalf("GetActiveForTraining() = %+v, %v, want sql.ErrNoRows", got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.ID != ids[tt.want] {
+				t.Errorf("GetActiveForTraining() = абонемент %d, want %d", got.ID, ids[tt.want])
+			}
+		})
+	}
+}
+
+func TestGetActiveByStudentID(t *testing.T) {
+	now := time.Now()
+	month := now.AddDate(0, 1, 0)
+
+	type sub struct {
+		remaining int
+		created   time.Time
+		end       time.Time
+	}
+	tests := []struct {
+		name string
+		subs []sub
+		want int // индекс ожидаемого абонемента, -1 — активного нет
+	}{
+		{name: "нет абонементов", want: -1},
+		{name: "занятия закончились", subs: []sub{{remaining: 0, created: now, end: month}}, want: -1},
+		{name: "срок истёк", subs: []sub{{remaining: 5, created: now.AddDate(0, -2, 0), end: now.AddDate(0, 0, -1)}}, want: -1},
+		{name: "бессрочный", subs: []sub{{remaining: 5, created: now}}, want: 0},
+		{
+			name: "сначала заканчивающийся",
+			subs: []sub{{remaining: 5, created: now.AddDate(0, 0, -10), end: month}, {remaining: 5, created: now, end: now.AddDate(0, 0, 7)}},
+			want: 1,
+		},
+		{
+			name: "пропускает исчерпанный",
+			subs: []sub{{remaining: 5, created: now.AddDate(0, 0, -10), end: month}, {remaining: 0, created: now, end: month}},
+			want: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			studentID := f.students[0]
+
+			var ids []int64
+			for _, s := range tt.subs {
+				ids = append(ids, f.addSubscription(t, studentID, s.remaining, s.created, s.end).ID)
+			}
+
+			got, err := NewSubscriptionRepository(f.store, models.ConsumptionExpiringFirst).GetActiveByStudentID(studentID)
+			if tt.want < 0 {
+				if !errors.Is(err, sql.ErrNoRows) {
+					t.Fatalf("GetActiveByStudentID() = %+v, %v, want sql.ErrNoRows", got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.ID != ids[tt.want] {
+				t.Errorf("GetActiveByStudentID() = абонемент %d, want %d", got.ID, ids[tt.want])
+			}
+		})
+	}
+}
+
+func TestDecrementRemainingLessons(t *testing.T) {
+	f := newFixture(t)
+	repo := NewSubscriptionRepository(f.store, models.ConsumptionExpiringFirst)
+	subscription := f.addSubscription(t, f.students[0], 1, time.Now(), time.Now().AddDate(0, 1, 0))
+
+	charged, err := repo.DecrementRemainingLessons(f.students[0], f.training.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if charged != subscription.ID {
+		t.Errorf("DecrementRemainingLessons() = абонемент %d, want %d", charged, subscription.ID)
+	}
+	got, err := repo.GetByID(subscription.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.RemainingLessons != 0 {
+		t.Errorf("RemainingLessons = %d, want 0", got.RemainingLessons)
+	}
+	if _, err := repo.DecrementRemainingLessons(f.students[0], f.training.ID); err == nil {
+		t.Error("DecrementRemainingLessons() без остатка не вернул ошибку")
+	}
+}
@@ -0,0 +1,165 @@
+package memory
+
+import (
+	"errors"
+	"maps"
+	"reflect"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/repository"
+)
+
+// ErrTxConflict строку, изменённую транзакцией, за время транзакции изменил кто-то ещё
+var ErrTxConflict = errors.New("запись изменена параллельно, повторите операцию")
+
+type transactor struct {
+	store *Store
+	order models.ConsumptionOrder
+}
+
+// NewTransactor транзакции поверх Store. Транзакции выполняются строго по одной на копии Store;
+// при фиксации в Store переносятся только изменённые транзакцией строки, а при ошибке копия
+// просто отбрасывается, поэтому параллельные записи вне транзакций (очередь уведомлений,
+// сессии, отметки напоминаний) не теряются. Если такая запись задела строку, изменённую
+// транзакцией, фиксация отменяется с ErrTxConflict — как блокировка строки в Postgres,
+// изменение не перезаписывается молча.
+func NewTransactor(store *Store, order models.ConsumptionOrder) repository.Transactor {
+	return &transactor{store: store, order: order}
+}
+
+func (t *transactor) WithinTransaction(fn func(tx repository.TxRepositories) error) error {
+	t.store.txMu.Lock()
+	defer t.store.txMu.Unlock()
+
+	base := t.store.clone()
+	txStore := base.clone()
+
+	err := fn(repository.TxRepositories{
+		Attendance:    NewAttendanceRepository(txStore),
+		Schedule:      NewTrainingScheduleRepository(txStore),
+		Subscriptions: NewSubscriptionRepository(txStore, t.order),
+		Freezes:       NewSubscriptionFreezeRepository(txStore),
+		Ledger:        NewLessonLedgerRepository(txStore),
+		Payments:      NewPaymentRepository(txStore),
+		Waitlist:      NewWaitlistRepository(txStore),
+	})
+	if err != nil {
+		return err
+	}
+	return t.store.commit(base, txStore)
+}
+
+// clone копия всех таблиц Store; последовательности остаются общими
+func (s *Store) clone() *Store {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return &Store{
+		users:         maps.Clone(s.users),
+		students:      maps.Clone(s.students),
+		coaches:       maps.Clone(s.coaches),
+		subscriptions: maps.Clone(s.subscriptions),
+		plans:         maps.Clone(s.plans),
+		freezes:       maps.Clone(s.freezes),
+		ledger:        maps.Clone(s.ledger),
+		payments:      maps.Clone(s.payments),
+		groups:        maps.Clone(s.groups),
+		trainings:     maps.Clone(s.trainings),
+		templates:     maps.Clone(s.templates),
+		attendance:    maps.Clone(s.attendance),
+		botSessions:   maps.Clone(s.botSessions),
+		outbox:        maps.Clone(s.outbox),
+		reminders:     maps.Clone(s.reminders),
+		waitlist:      maps.Clone(s.waitlist),
+
+		subscriptionAlerts:  maps.Clone(s.subscriptionAlerts),
+		subscriptionMembers: maps.Clone(s.subscriptionMembers),
+		subscriptionDigests: maps.Clone(s.subscriptionDigests),
+		renewalRequests:     maps.Clone(s.renewalRequests),
+		attendanceLog:       maps.Clone(s.attendanceLog),
+
+		sequences: s.sequences,
+		seqMu:     s.seqMu,
+	}
+}
+
+// commit переносит в Store строки, которые транзакция tx изменила относительно base
+func (s *Store) commit(base, tx *Store) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tables := []table{
+		newTable(s.users, base.users, tx.users),
+		newTable(s.students, base.students, tx.students),
+		newTable(s.coaches, base.coaches, tx.coaches),
+		newTable(s.subscriptions, base.subscriptions, tx.subscriptions),
+		newTable(s.plans, base.plans, tx.plans),
+		newTable(s.freezes, base.freezes, tx.freezes),
+		newTable(s.ledger, base.ledger, tx.ledger),
+		newTable(s.payments, base.payments, tx.payments),
+		newTable(s.groups, base.groups, tx.groups),
+		newTable(s.trainings, base.trainings, tx.trainings),
+		newTable(s.templates, base.templates, tx.templates),
+		newTable(s.attendance, base.attendance, tx.attendance),
+		newTable(s.botSessions, base.botSessions, tx.botSessions),
+		newTable(s.outbox, base.outbox, tx.outbox),
+		newTable(s.reminders, base.reminders, tx.reminders),
+		newTable(s.waitlist, base.waitlist, tx.waitlist),
+		newTable(s.subscriptionAlerts, base.subscriptionAlerts, tx.subscriptionAlerts),
+		newTable(s.subscriptionMembers, base.subscriptionMembers, tx.subscriptionMembers),
+		newTable(s.subscriptionDigests, base.subscriptionDigests, tx.subscriptionDigests),
+		newTable(s.renewalRequests, base.renewalRequests, tx.renewalRequests),
+		newTable(s.attendanceLog, base.attendanceLog, tx.attendanceLog),
+	}
+
+	for _, t := range tables {
+		if t.conflicts() {
+			return ErrTxConflict
+		}
+	}
+	for _, t := range tables {
+		t.apply()
+	}
+	return nil
+}
+
+// table изменения одной таблицы, сделанные транзакцией
+type table struct {
+	conflicts func() bool // строку, изменённую транзакцией, успел изменить кто-то ещё
+	apply     func()
+}
+
+func newTable[K comparable, V any](live, base, tx map[K]V) table {
+	var changed []K
+	for key, value := range tx {
+		if old, ok := base[key]; !ok || !reflect.DeepEqual(old, value) {
+			changed = append(changed, key)
+		}
+	}
+	for key := range base {
+		if _, ok := tx[key]; !ok {
+			changed = append(changed, key)
+		}
+	}
+
+	return table{
+		conflicts: func() bool {
+			for _, key := range changed {
+				old, inBase := base[key]
+				current, inLive := live[key]
+				if inBase != inLive || !reflect.DeepEqual(old, current) {
+					return true
+				}
+			}
+			return false
+		},
+		apply: func() {
+			for _, key := range changed {
+				if value, ok := tx[key]; ok {
+					live[key] = value
+				} else {
+					delete(live, key)
+				}
+			}
+		},
+	}
+}
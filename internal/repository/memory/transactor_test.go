@@ -0,0 +1,129 @@
+package memory
+
+import (
+	"errors"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/repository"
+	"testing"
+	"time"
+)
+
+func TestWithinTransaction(t *testing.T) {
+	errFail := errors.New("ошибка в транзакции")
+
+	tests := []struct {
+		name string
+		// fn работает внутри транзакции; f.store доступен ей как «параллельный» писатель
+		fn            func(t *testing.T, f *fixture, tx repository.TxRepositories, subscriptionID int64) error
+		wantErr       error
+		wantRemaining int
+		wantLedger    int
+		wantOutbox    int
+	}{
+		{
+			name: "фиксация",
+			fn: func(t *testing.T, f *fixture, tx repository.TxRepositories, _ int64) error {
+				return chargeInTx(tx, f)
+			},
+			wantRemaining: 4,
+			wantLedger:    1,
+		},
+		{
+			name: "откат при ошибке",
+			fn: func(t *testing.T, f *fixture, tx repository.TxRepositories, _ int64) error {
+				if err := chargeInTx(tx, f); err != nil {
+					return err
+				}
+				return errFail
+			},
+			wantErr:       errFail,
+			wantRemaining: 5,
+		},
+		{
+			name: "откат не теряет параллельные записи",
+			fn: func(t *testing.T, f *fixture, tx repository.TxRepositories, _ int64) error {
+				if err := chargeInTx(tx, f); err != nil {
+					return err
+				}
+				enqueue(t, f)
+				return errFail
+			},
+			wantErr:       errFail,
+			wantRemaining: 5,
+			wantOutbox:    1,
+		},
+		{
+			name: "фиксация сохраняет параллельные записи",
+			fn: func(t *testing.T, f *fixture, tx repository.TxRepositories, _ int64) error {
+				enqueue(t, f)
+				return chargeInTx(tx, f)
+			},
+			wantRemaining: 4,
+			wantLedger:    1,
+			wantOutbox:    1,
+		},
+		{
+			name: "конфликт по изменённой строке",
+			fn: func(t *testing.T, f *fixture, tx repository.TxRepositories, subscriptionID int64) error {
+				if err := chargeInTx(tx, f); err != nil {
+					return err
+				}
+				// Пока транзакция не зафиксирована, абонемент меняют вне её
+				return NewSubscriptionRepository(f.store, models.ConsumptionExpiringFirst).
+					Update(&models.Subscription{ID: subscriptionID, RemainingLessons: 2})
+			},
+			wantErr:       ErrTxConflict,
+			wantRemaining: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			subscription := f.addSubscription(t, f.students[0], 5, time.Now(), time.Now().AddDate(0, 1, 0))
+
+			err := NewTransactor(f.store, models.ConsumptionExpiringFirst).WithinTransaction(func(tx repository.TxRepositories) error {
+				return tt.fn(t, f, tx, subscription.ID)
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("WithinTransaction() = %v, want %v", err, tt.wantErr)
+			}
+
+			got, err := NewSubscriptionRepository(f.store, models.ConsumptionExpiringFirst).GetByID(subscription.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.RemainingLessons != tt.wantRemaining {
+				t.Errorf("RemainingLessons = %d, want %d", got.RemainingLessons, tt.wantRemaining)
+			}
+			if n := len(f.store.ledger); n != tt.wantLedger {
+				t.Errorf("записей в журнале занятий = %d, want %d", n, tt.wantLedger)
+			}
+			if n := len(f.store.outbox); n != tt.wantOutbox {
+				t.Errorf("сообщений в очереди = %d, want %d", n, tt.wantOutbox)
+			}
+		})
+	}
+}
+
+// chargeInTx списывает занятие первого ученика за тренировку фикстуры и пишет его в журнал
+func chargeInTx(tx repository.TxRepositories, f *fixture) error {
+	subscriptionID, err := tx.Subscriptions.DecrementRemainingLessons(f.students[0], f.training.ID)
+	if err != nil {
+		return err
+	}
+	return tx.Ledger.Add(&models.LessonLedgerEntry{
+		SubscriptionID: subscriptionID,
+		Delta:          -1,
+		Reason:         models.LedgerReasonAttended,
+		TrainingID:     &f.training.ID,
+	})
+}
+
+// enqueue запись вне транзакции, как у фоновой очереди уведомлений
+func enqueue(t *testing.T, f *fixture) {
+	t.Helper()
+	if err := NewOutboxRepository(f.store).Enqueue(&models.OutboxMessage{ChatID: 1, Method: "sendMessage", Payload: []byte(`{"text":"напоминание"}`)}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,98 @@
+package memory
+
+import (
+	"database/sql"
+	"sort"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/repository"
+	"time"
+)
+
+type userRepository struct {
+	store *Store
+}
+
+func NewUserRepository(store *Store) repository.UserRepository {
+	return &userRepository{store: store}
+}
+
+// CreateOrUpdate повторяет INSERT ... ON CONFLICT (telegram_id): роль существующего пользователя не меняется
+func (r *userRepository) CreateOrUpdate(user *models.User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for id, existing := range r.store.users {
+		if existing.TelegramID == user.TelegramID {
+			existing.FirstName = user.FirstName
+			existing.LastName = user.LastName
+			existing.Username = user.Username
+			existing.UpdatedAt = time.Now()
+			r.store.users[id] = existing
+			user.ID = id
+			return nil
+		}
+	}
+
+	created := *user
+	created.ID = r.store.nextID("users")
+	created.UpdatedAt = time.Now()
+	r.store.users[created.ID] = created
+	user.ID = created.ID
+	return nil
+}
+
+func (r *userRepository) GetByTelegramID(telegramID int64) (*models.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, user := range r.store.users {
+		if user.TelegramID == telegramID {
+			return &user, nil
+		}
+	}
+	return &models.User{}, sql.ErrNoRows
+}
+
+func (r *userRepository) GetByID(id int64) (*models.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	user, ok := r.store.users[id]
+	if !ok {
+		return &models.User{}, sql.ErrNoRows
+	}
+	return &user, nil
+}
+
+func (r *userRepository) UpdateRole(telegramID int64, role string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for id, user := range r.store.users {
+		if user.TelegramID == telegramID {
+			user.Role = role
+			r.store.users[id] = user
+		}
+	}
+	return nil
+}
+
+func (r *userRepository) GetAllStudents() ([]*models.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var users []*models.User
+	for _, user := range r.store.users {
+		if user.Role == "student" {
+			users = append(users, &user)
+		}
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		if users[i].FirstName != users[j].FirstName {
+			return users[i].FirstName < users[j].FirstName
+		}
+		return users[i].LastName < users[j].LastName
+	})
+	return users, nil
+}
@@ -0,0 +1,214 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/repository"
+	"time"
+)
+
+type waitlistRepository struct {
+	store *Store
+}
+
+func NewWaitlistRepository(store *Store) repository.WaitlistRepository {
+	return &waitlistRepository{store: store}
+}
+
+func (r *waitlistRepository) Add(entry *models.WaitlistEntry) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.trainings[entry.TrainingID]; !ok {
+		return fmt.Errorf("тренировка %d не существует", entry.TrainingID)
+	}
+	if _, ok := r.store.students[int64(entry.StudentID)]; !ok {
+		return fmt.Errorf("ученик %d не существует", entry.StudentID)
+	}
+	for _, e := range r.store.waitlist {
+		if e.TrainingID == entry.TrainingID && e.StudentID == entry.StudentID && e.IsActive() {
+			return fmt.Errorf(`duplicate key value violates unique constraint "training_waitlist_active_uidx"`)
+		}
+	}
+
+	now := time.Now()
+	entry.ID = int(r.store.nextID("training_waitlist"))
+	entry.Status = models.WaitlistStatusWaiting
+	entry.OfferExpiresAt = nil
+	entry.CreatedAt = now
+	entry.UpdatedAt = now
+
+	stored := *entry
+	stored.TelegramID = 0
+	stored.Position = 0
+	r.store.waitlist[stored.ID] = stored
+	return nil
+}
+
+func (r *waitlistRepository) GetActive(trainingID, studentID int) (*models.WaitlistEntry, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, e := range r.store.waitlist {
+		if e.TrainingID == trainingID && e.StudentID == studentID && e.IsActive() {
+			entry, ok := r.withJoins(e)
+			if !ok {
+				return nil, nil
+			}
+			return &entry, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *waitlistRepository) GetActiveByStudent(studentID int) ([]models.WaitlistEntry, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var entries []models.WaitlistEntry
+	for _, e := range r.store.waitlist {
+		if e.StudentID != studentID || !e.IsActive() {
+			continue
+		}
+		if entry, ok := r.withJoins(e); ok {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+	return entries, nil
+}
+
+func (r *waitlistRepository) CountOffers(trainingID, excludeStudentID int) (int, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	now := time.Now()
+	count := 0
+	for _, e := range r.store.waitlist {
+		if e.TrainingID == trainingID && e.StudentID != excludeStudentID && isLiveOffer(e, now) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *waitlistRepository) OfferNext(trainingID int, ttl time.Duration) (*models.WaitlistEntry, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var next *models.WaitlistEntry
+	for _, e := range r.store.waitlist {
+		if e.TrainingID != trainingID || e.Status != models.WaitlistStatusWaiting {
+			continue
+		}
+		if next == nil || e.ID < next.ID {
+			next = &e
+		}
+	}
+	if next == nil {
+		return nil, nil
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	next.Status = models.WaitlistStatusOffered
+	next.OfferExpiresAt = &expiresAt
+	next.UpdatedAt = now
+	r.store.waitlist[next.ID] = *next
+
+	entry, ok := r.withJoins(*next)
+	if !ok {
+		return nil, nil
+	}
+	entry.Position = 0
+	return &entry, nil
+}
+
+func (r *waitlistRepository) Close(id int, status string) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	entry, ok := r.store.waitlist[id]
+	if !ok || !entry.IsActive() {
+		return false, nil
+	}
+	entry.Status = status
+	entry.UpdatedAt = time.Now()
+	r.store.waitlist[id] = entry
+	return true, nil
+}
+
+func (r *waitlistRepository) CloseAll(trainingID int, status string) ([]models.WaitlistEntry, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	now := time.Now()
+	var closed []models.WaitlistEntry
+	for id, e := range r.store.waitlist {
+		if e.TrainingID != trainingID || !e.IsActive() {
+			continue
+		}
+		e.Status = status
+		e.UpdatedAt = now
+		r.store.waitlist[id] = e
+
+		if entry, ok := r.withJoins(e); ok {
+			closed = append(closed, entry)
+		}
+	}
+	sort.Slice(closed, func(i, j int) bool { return closed[i].ID < closed[j].ID })
+	return closed, nil
+}
+
+func (r *waitlistRepository) ExpireOffers() ([]models.WaitlistEntry, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	now := time.Now()
+	var expired []models.WaitlistEntry
+	for id, e := range r.store.waitlist {
+		if e.Status != models.WaitlistStatusOffered || isLiveOffer(e, now) {
+			continue
+		}
+		e.Status = models.WaitlistStatusExpired
+		e.UpdatedAt = now
+		r.store.waitlist[id] = e
+
+		if entry, ok := r.withJoins(e); ok {
+			entry.Position = 0
+			expired = append(expired, entry)
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool { return expired[i].ID < expired[j].ID })
+	return expired, nil
+}
+
+// withJoins заполняет telegram_id (INNER JOIN students/users) и позицию в очереди; вызывать под s.mu
+func (r *waitlistRepository) withJoins(entry models.WaitlistEntry) (models.WaitlistEntry, bool) {
+	student, ok := r.store.students[int64(entry.StudentID)]
+	if !ok {
+		return entry, false
+	}
+	user, ok := r.store.users[student.UserID]
+	if !ok {
+		return entry, false
+	}
+	entry.TelegramID = user.TelegramID
+
+	entry.Position = 0
+	if entry.Status == models.WaitlistStatusWaiting {
+		for _, e := range r.store.waitlist {
+			if e.TrainingID == entry.TrainingID && e.Status == models.WaitlistStatusWaiting && e.ID <= entry.ID {
+				entry.Position++
+			}
+		}
+	}
+	return entry, true
+}
+
+// isLiveOffer аналог status = 'offered' AND offer_expires_at > CURRENT_TIMESTAMP
+func isLiveOffer(entry models.WaitlistEntry, now time.Time) bool {
+	return entry.Status == models.WaitlistStatusOffered &&
+		entry.OfferExpiresAt != nil && entry.OfferExpiresAt.After(now)
+}
@@ -0,0 +1,93 @@
+package outbox
+
+import (
+	"sort"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/repository"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type outboxRepository struct {
+	db *sqlx.DB
+}
+
+func NewOutboxRepository(db *sqlx.DB) repository.OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+func (r *outboxRepository) Enqueue(message *models.OutboxMessage) error {
+	query := `
+		INSERT INTO spectrum.notification_outbox (chat_id, method, payload)
+		VALUES ($1, $2, $3::jsonb)
+		RETURNING id, status, attempts, next_attempt_at, created_at
+	`
+	return r.db.QueryRow(query, message.ChatID, message.Method, string(message.Payload)).Scan(
+		&message.ID,
+		&message.Status,
+		&message.Attempts,
+		&message.NextAttemptAt,
+		&message.CreatedAt,
+	)
+}
+
+func (r *outboxRepository) ClaimDue(limit int, lease time.Duration) ([]models.OutboxMessage, error) {
+	// Сообщение в чат берём, только если перед ним нет других ожидающих:
+	// так порядок сообщений в чате сохраняется и при повторах, и при нескольких репликах
+	query := `
+		UPDATE spectrum.notification_outbox
+		SET attempts = attempts + 1,
+			next_attempt_at = CURRENT_TIMESTAMP + make_interval(secs => $2)
+		WHERE id IN (
+			SELECT c.id FROM spectrum.notification_outbox c
+			WHERE c.status = 'pending'
+			AND c.next_attempt_at <= CURRENT_TIMESTAMP
+			AND NOT EXISTS (
+				SELECT 1 FROM spectrum.notification_outbox p
+				WHERE p.chat_id = c.chat_id AND p.status = 'pending' AND p.id < c.id
+			)
+			ORDER BY c.id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, chat_id, method, payload, status, attempts, next_attempt_at, last_error, created_at, sent_at
+	`
+
+	var messages []models.OutboxMessage
+	if err := r.db.Select(&messages, query, limit, lease.Seconds()); err != nil {
+		return nil, err
+	}
+	sort.Slice(messages, func(i, j int) bool { return messages[i].ID < messages[j].ID })
+	return messages, nil
+}
+
+func (r *outboxRepository) MarkSent(id int64) error {
+	query := `
+		UPDATE spectrum.notification_outbox
+		SET status = 'sent', sent_at = CURRENT_TIMESTAMP, last_error = ''
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(query, id)
+	return err
+}
+
+func (r *outboxRepository) Reschedule(id int64, delay time.Duration, lastError string) error {
+	query := `
+		UPDATE spectrum.notification_outbox
+		SET next_attempt_at = CURRENT_TIMESTAMP + make_interval(secs => $2), last_error = $3
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(query, id, delay.Seconds(), lastError)
+	return err
+}
+
+func (r *outboxRepository) MarkFailed(id int64, lastError string) error {
+	query := `
+		UPDATE spectrum.notification_outbox
+		SET status = 'failed', last_error = $2
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(query, id, lastError)
+	return err
+}
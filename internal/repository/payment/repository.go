@@ -0,0 +1,92 @@
+package payment
+
+import (
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/repository"
+)
+
+type paymentRepository struct {
+	db repository.DBTX
+}
+
+func NewPaymentRepository(db repository.DBTX) repository.PaymentRepository {
+	return &paymentRepository{db: db}
+}
+
+func (r *paymentRepository) Create(payment *models.Payment) error {
+	query := `
+		INSERT INTO spectrum.payments
+		(student_id, plan_id, subscription_id, amount, currency, method, status, recorded_by, comment, paid_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, created_at
+	`
+	return r.db.QueryRow(
+		query,
+		payment.StudentID,
+		payment.PlanID,
+		payment.SubscriptionID,
+		payment.Amount,
+		payment.Currency,
+		payment.Method,
+		payment.Status,
+		payment.RecordedBy,
+		payment.Comment,
+		payment.PaidAt,
+	).Scan(&payment.ID, &payment.CreatedAt)
+}
+
+func (r *paymentRepository) GetByID(id int64) (*models.Payment, error) {
+	query := `SELECT * FROM spectrum.payments WHERE id = $1`
+
+	var payment models.Payment
+	if err := r.db.Get(&payment, query, id); err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+func (r *paymentRepository) MarkPaid(id int64, subscriptionID int64, telegramChargeID, providerChargeID string) (bool, error) {
+	query := `
+		UPDATE spectrum.payments
+		SET status = 'paid', subscription_id = $2, telegram_charge_id = $3,
+		    provider_charge_id = $4, paid_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'pending'
+	`
+	result, err := r.db.Exec(query, id, subscriptionID, telegramChargeID, providerChargeID)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+func (r *paymentRepository) Cancel(id int64) (bool, error) {
+	query := `UPDATE spectrum.payments SET status = 'cancelled' WHERE id = $1 AND status = 'pending'`
+	result, err := r.db.Exec(query, id)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+func (r *paymentRepository) GetByStudentID(studentID int64, limit int) ([]models.Payment, error) {
+	query := `
+		SELECT * FROM spectrum.payments
+		WHERE student_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT NULLIF($2, 0)
+	`
+
+	var payments []models.Payment
+	err := r.db.Select(&payments, query, studentID, max(limit, 0))
+	return payments, err
+}
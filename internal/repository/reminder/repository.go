@@ -0,0 +1,67 @@
+package reminder
+
+import (
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/repository"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type reminderRepository struct {
+	db *sqlx.DB
+}
+
+func NewReminderRepository(db *sqlx.DB) repository.ReminderRepository {
+	return &reminderRepository{db: db}
+}
+
+func (r *reminderRepository) GetUpcomingRegistrations(start, end time.Time) ([]models.UpcomingRegistration, error) {
+	query := `
+		SELECT
+			a.id AS attendance_id, a.training_id, a.student_id, u.telegram_id,
+			t.training_date, t.start_time, t.end_time,
+			COALESCE(t.description, '') AS description,
+			COALESCE(g.name, '') AS group_name,
+			COALESCE(cu.first_name || ' ' || cu.last_name, '') AS coach_name
+		FROM spectrum.attendance a
+		JOIN spectrum.training_schedule t ON a.training_id = t.id
+		JOIN spectrum.students s ON a.student_id = s.id
+		JOIN spectrum.users u ON s.user_id = u.id
+		LEFT JOIN spectrum.training_groups g ON t.group_id = g.id
+		LEFT JOIN spectrum.coaches c ON t.coach_id = c.id
+		LEFT JOIN spectrum.users cu ON c.user_id = cu.id
+		WHERE a.status = 'registered'
+		AND t.training_date BETWEEN $1 AND $2
+		AND t.cancelled_at IS NULL
+		ORDER BY t.training_date, t.start_time, a.id
+	`
+
+	var registrations []models.UpcomingRegistration
+	err := r.db.Select(&registrations, query, start.Format("2006-01-02"), end.Format("2006-01-02"))
+	return registrations, err
+}
+
+func (r *reminderRepository) MarkSent(attendanceID int, offsetMinutes int) (bool, error) {
+	query := `
+		INSERT INTO spectrum.training_reminders (attendance_id, offset_minutes)
+		VALUES ($1, $2)
+		ON CONFLICT (attendance_id, offset_minutes) DO NOTHING
+	`
+	result, err := r.db.Exec(query, attendanceID, offsetMinutes)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected == 1, nil
+}
+
+func (r *reminderRepository) Unmark(attendanceID int, offsetMinutes int) error {
+	query := `DELETE FROM spectrum.training_reminders WHERE attendance_id = $1 AND offset_minutes = $2`
+	_, err := r.db.Exec(query, attendanceID, offsetMinutes)
+	return err
+}
@@ -0,0 +1,111 @@
+package renewal_request
+
+import (
+	"database/sql"
+	"errors"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/repository"
+)
+
+type renewalRequestRepository struct {
+	db repository.DBTX
+}
+
+func NewRenewalRequestRepository(db repository.DBTX) repository.RenewalRequestRepository {
+	return &renewalRequestRepository{db: db}
+}
+
+// selectRequests запросы с именем и telegram_id ученика и названием тарифа
+const selectRequests = `
+	SELECT
+		r.id, r.student_id, r.plan_id, r.status, r.subscription_id, r.decided_by, r.created_at, r.decided_at,
+		u.first_name || ' ' || u.last_name AS student_name,
+		u.telegram_id,
+		p.name AS plan_name
+	FROM spectrum.renewal_requests r
+	JOIN spectrum.students s ON r.student_id = s.id
+	JOIN spectrum.users u ON s.user_id = u.id
+	JOIN spectrum.subscription_plans p ON r.plan_id = p.id
+`
+
+func (r *renewalRequestRepository) Create(request *models.RenewalRequest) error {
+	query := `
+		INSERT INTO spectrum.renewal_requests (student_id, plan_id)
+		VALUES ($1, $2)
+		RETURNING id, status, created_at
+	`
+	return r.db.QueryRow(query, request.StudentID, request.PlanID).Scan(
+		&request.ID,
+		&request.Status,
+		&request.CreatedAt,
+	)
+}
+
+func (r *renewalRequestRepository) GetByID(id int64) (*models.RenewalRequest, error) {
+	query := selectRequests + `WHERE r.id = $1`
+
+	var request models.RenewalRequest
+	if err := r.db.Get(&request, query, id); err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+func (r *renewalRequestRepository) GetPending() ([]models.RenewalRequest, error) {
+	query := selectRequests + `
+		WHERE r.status = 'pending'
+		ORDER BY r.created_at, r.id
+	`
+
+	var requests []models.RenewalRequest
+	err := r.db.Select(&requests, query)
+	return requests, err
+}
+
+func (r *renewalRequestRepository) GetPendingByStudent(studentID int64) (*models.RenewalRequest, error) {
+	query := selectRequests + `WHERE r.student_id = $1 AND r.status = 'pending'`
+
+	var request models.RenewalRequest
+	err := r.db.Get(&request, query, studentID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+func (r *renewalRequestRepository) Decide(id int64, status string, decidedBy *int64) (bool, error) {
+	query := `
+		UPDATE spectrum.renewal_requests
+		SET status = $2, decided_by = $3, decided_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'pending'
+	`
+	result, err := r.db.Exec(query, id, status, decidedBy)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+func (r *renewalRequestRepository) Reopen(id int64) error {
+	query := `
+		UPDATE spectrum.renewal_requests
+		SET status = 'pending', decided_by = NULL, decided_at = NULL
+		WHERE id = $1 AND status = 'approved' AND subscription_id IS NULL
+	`
+	_, err := r.db.Exec(query, id)
+	return err
+}
+
+func (r *renewalRequestRepository) SetSubscription(id int64, subscriptionID int64) error {
+	query := `UPDATE spectrum.renewal_requests SET subscription_id = $2 WHERE id = $1`
+	_, err := r.db.Exec(query, id, subscriptionID)
+	return err
+}
@@ -1,10 +1,38 @@
 package repository
 
 import (
+	"database/sql"
 	"spectrum-club-bot/internal/models"
 	"time"
 )
 
+// DBTX общие методы *sqlx.DB и *sqlx.Tx: репозиторий, построенный поверх DBTX,
+// одинаково работает и с пулом соединений, и внутри транзакции
+type DBTX interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+	Get(dest interface{}, query string, args ...interface{}) error
+	Select(dest interface{}, query string, args ...interface{}) error
+}
+
+// TxRepositories репозитории, привязанные к одной транзакции
+type TxRepositories struct {
+	Attendance    AttendanceRepository
+	Schedule      TrainingScheduleRepository
+	Subscriptions SubscriptionRepository
+	Freezes       SubscriptionFreezeRepository
+	Ledger        LessonLedgerRepository
+	Payments      PaymentRepository
+	Waitlist      WaitlistRepository
+}
+
+// Transactor выполняет fn в транзакции: если fn вернула ошибку, все изменения
+// через переданные репозитории откатываются, иначе фиксируются вместе
+type Transactor interface {
+	WithinTransaction(fn func(tx TxRepositories) error) error
+}
+
 type UserRepository interface {
 	CreateOrUpdate(user *models.User) error
 	GetByTelegramID(telegramID int64) (*models.User, error)
@@ -35,16 +63,78 @@ type SubscriptionRepository interface {
 	Create(subscription *models.Subscription) error
 	// GetByStudentID(studentID int64) ([]*models.Subscription, error)
 	GetByID(id int64) (*models.Subscription, error)
+	// GetByIDForUpdate то же, что GetByID, но блокирует строку абонемента до конца транзакции
+	GetByIDForUpdate(id int64) (*models.Subscription, error)
 	GetActiveByStudentID(studentID int64) (*models.Subscription, error)
+	// GetActiveForTraining активный абонемент ученика, ограничения которого допускают тренировку;
+	// sql.ErrNoRows, если такого нет
+	GetActiveForTraining(studentID int64, trainingID int) (*models.Subscription, error)
+	// GetActiveForTrainingForUpdate то же, что GetActiveForTraining, но блокирует строку
+	// абонемента до конца транзакции
+	GetActiveForTrainingForUpdate(studentID int64, trainingID int) (*models.Subscription, error)
 	GetHistoryByStudentID(studentID int64) ([]*models.Subscription, error)
 	Update(subscription *models.Subscription) error
-	DecrementRemainingLessons(studentID int64) error
+	// DecrementRemainingLessons списывает занятие за тренировку trainingID с активного абонемента
+	// ученика, который её допускает, и возвращает ID этого абонемента
+	DecrementRemainingLessons(studentID int64, trainingID int) (int64, error)
+	// AddRemainingLessons меняет остаток на delta; остаток не может стать отрицательным
+	AddRemainingLessons(id int64, delta int) error
+	// Extend продлевает абонемент: срок отсчитывается от end_date, а у истёкшего — от текущего момента,
+	// при days = 0 end_date не меняется; lessons добавляются и к total_lessons, и к remaining_lessons
+	Extend(id int64, days int, lessons int) error
+	// ExtendEndDate сдвигает end_date абонемента на days дней (отрицательное значение — назад)
+	ExtendEndDate(id int64, days int) error
 
 	///
 	GetAll() ([]*models.Subscription, error)
 	////
 	GetByStudentID(studentID int64) ([]*models.Subscription, error)
 	Delete(id int64) error
+
+	// Семейный абонемент: подключённые ученики пользуются остатком наравне с владельцем,
+	// поэтому выборки по ученику (активный абонемент, списание, история) учитывают и их
+	AddMember(subscriptionID, studentID int64, addedBy *int64) error
+	// RemoveMember отключает ученика от абонемента; false, если он не был подключён
+	RemoveMember(subscriptionID, studentID int64) (bool, error)
+	// GetMembers владелец (первым) и подключённые ученики с числом списанных за них занятий
+	GetMembers(subscriptionID int64) ([]models.SubscriptionMember, error)
+}
+
+// LessonLedgerRepository журнал занятий: записи только добавляются
+type LessonLedgerRepository interface {
+	Add(entry *models.LessonLedgerEntry) error
+	// GetBySubscriptionID записи абонемента, новые сначала; limit <= 0 — все
+	GetBySubscriptionID(subscriptionID int64, limit int) ([]models.LessonLedgerEntry, error)
+	GetByAttendanceID(attendanceID int) ([]models.LessonLedgerEntry, error)
+	// GetMismatches абонементы, у которых remaining_lessons не равен сумме по журналу
+	GetMismatches() ([]models.LessonBalanceMismatch, error)
+}
+type SubscriptionFreezeRepository interface {
+	Create(freeze *models.SubscriptionFreeze) error
+	// GetBySubscriptionID заморозки абонемента по дате начала
+	GetBySubscriptionID(subscriptionID int64) ([]models.SubscriptionFreeze, error)
+	// UpdateEndDate сокращает заморозку при досрочном снятии
+	UpdateEndDate(id int64, endDate time.Time) error
+	Delete(id int64) error
+}
+type SubscriptionPlanRepository interface {
+	Create(plan *models.SubscriptionPlan) error
+	Update(plan *models.SubscriptionPlan) error
+	GetByID(id int64) (*models.SubscriptionPlan, error)
+	// GetAll тарифы по названию; activeOnly — только те, что можно выдать
+	GetAll(activeOnly bool) ([]models.SubscriptionPlan, error)
+}
+
+type PaymentRepository interface {
+	Create(payment *models.Payment) error
+	GetByID(id int64) (*models.Payment, error)
+	// MarkPaid переводит платёж из pending в paid и привязывает выданный абонемент;
+	// false, если платёж уже не ожидает оплаты
+	MarkPaid(id int64, subscriptionID int64, telegramChargeID, providerChargeID string) (bool, error)
+	// Cancel отменяет неоплаченный счёт; false, если платёж уже не ожидает оплаты
+	Cancel(id int64) (bool, error)
+	// GetByStudentID платежи ученика, новые сначала; limit <= 0 — все
+	GetByStudentID(studentID int64, limit int) ([]models.Payment, error)
 }
 
 type TrainingGroupRepository interface {
@@ -65,11 +155,12 @@ type TrainingScheduleRepository interface {
 	GetAvailableTrainingsForStudent(studentID int, start, end time.Time) ([]models.TrainingSchedule, error)
 	UpdateTraining(training *models.TrainingSchedule) error
 	UpdateTrainingPartial(id int, updates map[string]interface{}) error
-	DeleteTraining(id int) error
+	CancelTraining(id int, reason string) (bool, error)
 
 	// Проверки
 	IsCoachAvailable(coachID int64, date time.Time, startTime, endTime time.Time) (bool, error)
 	GetTrainingParticipantsCount(trainingID int) (int, error)
+	// Exists учитывает и отменённые тренировки, чтобы шаблон недели не создавал их заново
 	Exists(groupID int, startTime time.Time) (bool, error)
 	ExistsForCoach(groupID int, coachID int64, startTime time.Time) (bool, error)
 }
@@ -86,21 +177,98 @@ type WeekScheduleRepository interface {
 }
 
 type AttendanceRepository interface {
-	// Записи на тренировки
+	// Записи на тренировки. Создание записи тоже попадает в историю статусов
 	CreateAttendance(attendance *models.Attendance) error
 	GetAttendanceByID(id int) (*models.Attendance, error)
 	GetAttendanceByTraining(trainingID int) ([]models.Attendance, error)
 	GetAttendanceByStudent(studentID int, start, end time.Time) ([]models.Attendance, error)
 	GetStudentAttendanceForTraining(studentID, trainingID int) (*models.Attendance, error)
-	UpdateAttendance(attendance *models.Attendance) error
-	DeleteAttendance(id int) error
 
-	// Статистика
+	// ChangeStatus переводит запись из from в to и пишет переход в историю; false, если статус
+	// записи уже не from. Допустимость перехода проверяет сервис. notes — пустая строка не меняет заметку
+	ChangeStatus(id int, from, to string, actorID *int64, notes string) (bool, error)
+	// GetStatusHistory переходы статуса записи, старые сначала
+	GetStatusHistory(id int) ([]models.AttendanceTransition, error)
+
+	// Статистика: present — пришли, absent — не пришли, total — записи, занимающие место
 	GetTrainingAttendanceStats(trainingID int) (present, absent, total int, err error)
 
-	//
-	CancelAttendance(trainingID, studentID int) error
+	// CancelByTraining переводит все действующие записи тренировки в cancelled
+	CancelByTraining(trainingID int, actorID *int64) error
+	// GetParticipants записи тренировки, занимающие место, в порядке записи
 	GetParticipants(trainingID int) ([]models.AttendanceWithStudent, error)
 	GetStudentSchedule(studentID int, start, end time.Time) ([]models.AttendanceWithTraining, error)
 	CreateAttendanceRecord(attendance models.Attendance) error
 }
+
+type BotSessionRepository interface {
+	Get(chatID int64) (*models.BotSession, error)
+	Save(session *models.BotSession) error
+	Delete(chatID int64) error
+}
+
+type OutboxRepository interface {
+	Enqueue(message *models.OutboxMessage) error
+	// ClaimDue забирает до limit сообщений, которым пора отправляться, по одному на чат
+	// (только самое раннее ожидающее), увеличивает им attempts и откладывает на lease,
+	// чтобы их не взяла соседняя реплика. Если отправитель упадёт, сообщение вернётся
+	// в выборку по истечении lease.
+	ClaimDue(limit int, lease time.Duration) ([]models.OutboxMessage, error)
+	MarkSent(id int64) error
+	Reschedule(id int64, delay time.Duration, lastError string) error
+	MarkFailed(id int64, lastError string) error
+}
+
+type ReminderRepository interface {
+	// GetUpcomingRegistrations записи со статусом registered на тренировки с датой в [start, end]
+	GetUpcomingRegistrations(start, end time.Time) ([]models.UpcomingRegistration, error)
+	// MarkSent отмечает напоминание отправленным; false, если оно уже было отмечено
+	MarkSent(attendanceID int, offsetMinutes int) (bool, error)
+	// Unmark снимает отметку, если сообщение так и не удалось поставить в очередь
+	Unmark(attendanceID int, offsetMinutes int) error
+}
+
+// SubscriptionAlertRepository отметки об отправленных предупреждениях по абонементам
+type SubscriptionAlertRepository interface {
+	// MarkSent отмечает предупреждение kind по абонементу; false, если оно уже было отмечено
+	MarkSent(subscriptionID int64, kind string) (bool, error)
+	// Unmark снимает отметку: предупреждение уйдёт снова, когда условие наступит ещё раз
+	Unmark(subscriptionID int64, kind string) error
+	// MarkDigestSent отмечает сводку для тренеров за неделю weekStart; false, если она уже отправлена
+	MarkDigestSent(weekStart time.Time) (bool, error)
+}
+
+type WaitlistRepository interface {
+	// Add ставит ученика в конец очереди на тренировку
+	Add(entry *models.WaitlistEntry) error
+	// GetActive запись ученика в очереди (waiting или offered) с позицией; nil, если её нет
+	GetActive(trainingID, studentID int) (*models.WaitlistEntry, error)
+	// GetActiveByStudent активные записи ученика во всех очередях
+	GetActiveByStudent(studentID int) ([]models.WaitlistEntry, error)
+	// CountOffers число действующих предложений на тренировку, не считая предложения ученику excludeStudentID
+	CountOffers(trainingID, excludeStudentID int) (int, error)
+	// OfferNext предлагает место первому в очереди на ttl; nil, если очередь пуста
+	OfferNext(trainingID int, ttl time.Duration) (*models.WaitlistEntry, error)
+	// Close переводит активную запись в итоговый статус; false, если она уже не активна
+	Close(id int, status string) (bool, error)
+	// ExpireOffers закрывает просроченные предложения и возвращает их
+	ExpireOffers() ([]models.WaitlistEntry, error)
+	// CloseAll переводит все активные записи тренировки в итоговый статус и возвращает их
+	CloseAll(trainingID int, status string) ([]models.WaitlistEntry, error)
+}
+
+// RenewalRequestRepository запросы учеников на продление абонемента.
+// GetByID возвращает sql.ErrNoRows, если запроса нет
+type RenewalRequestRepository interface {
+	Create(request *models.RenewalRequest) error
+	GetByID(id int64) (*models.RenewalRequest, error)
+	// GetPending запросы, ожидающие решения, старые сначала
+	GetPending() ([]models.RenewalRequest, error)
+	// GetPendingByStudent ожидающий решения запрос ученика; nil, если его нет
+	GetPendingByStudent(studentID int64) (*models.RenewalRequest, error)
+	// Decide переводит запрос из pending в итоговый статус; false, если решение уже принято
+	Decide(id int64, status string, decidedBy *int64) (bool, error)
+	// Reopen возвращает одобренный запрос без выданного абонемента в pending
+	Reopen(id int64) error
+	SetSubscription(id int64, subscriptionID int64) error
+}
@@ -7,15 +7,13 @@ import (
 	"spectrum-club-bot/internal/models"
 	"spectrum-club-bot/internal/repository"
 	"time"
-
-	"github.com/jmoiron/sqlx"
 )
 
 type trainingScheduleRepository struct {
-	db *sqlx.DB
+	db repository.DBTX
 }
 
-func NewTrainingScheduleRepository(db *sqlx.DB) repository.TrainingScheduleRepository {
+func NewTrainingScheduleRepository(db repository.DBTX) repository.TrainingScheduleRepository {
 	return &trainingScheduleRepository{db: db}
 }
 
@@ -44,7 +42,7 @@ func (r *trainingScheduleRepository) GetTrainingByID(id int) (*models.TrainingSc
 		SELECT 
 			ts.id, ts.group_id, ts.coach_id, ts.training_date, ts.start_time, 
 			ts.end_time, ts.description, ts.max_participants, ts.created_by,
-			ts.created_at, ts.updated_at,
+			ts.created_at, ts.updated_at, ts.cancelled_at, ts.cancel_reason,
 			tg.name as group_name,
 			u.first_name || ' ' || u.last_name as coach_name
 		FROM spectrum.training_schedule ts
@@ -59,6 +57,7 @@ func (r *trainingScheduleRepository) GetTrainingByID(id int) (*models.TrainingSc
 		&training.ID, &training.GroupID, &training.CoachID, &training.TrainingDate,
 		&training.StartTime, &training.EndTime, &training.Description, &training.MaxParticipants,
 		&training.CreatedBy, &training.CreatedAt, &training.UpdatedAt,
+		&training.CancelledAt, &training.CancelReason,
 		&training.GroupName, &training.CoachName,
 	)
 	if err != nil {
@@ -82,7 +81,7 @@ func (r *trainingScheduleRepository) GetTrainingsByDate(date time.Time) ([]model
 		LEFT JOIN spectrum.training_groups tg ON ts.group_id = tg.id
 		LEFT JOIN spectrum.coaches c ON ts.coach_id = c.id
 		LEFT JOIN spectrum.users u ON c.user_id = u.id
-		WHERE ts.training_date = $1
+		WHERE ts.training_date = $1 AND ts.cancelled_at IS NULL
 		ORDER BY ts.start_time ASC
 	`
 
@@ -122,7 +121,7 @@ func (r *trainingScheduleRepository) GetTrainingsByDateRange(start, end time.Tim
 		LEFT JOIN spectrum.training_groups tg ON ts.group_id = tg.id
 		LEFT JOIN spectrum.coaches c ON ts.coach_id = c.id
 		LEFT JOIN spectrum.users u ON c.user_id = u.id
-		WHERE ts.training_date BETWEEN $1 AND $2
+		WHERE ts.training_date BETWEEN $1 AND $2 AND ts.cancelled_at IS NULL
 		ORDER BY ts.training_date ASC, ts.start_time ASC
 	`
 
@@ -162,7 +161,7 @@ func (r *trainingScheduleRepository) GetTrainingsByGroup(groupID int, start, end
 		LEFT JOIN spectrum.training_groups tg ON ts.group_id = tg.id
 		LEFT JOIN spectrum.coaches c ON ts.coach_id = c.id
 		LEFT JOIN spectrum.users u ON c.user_id = u.id
-		WHERE ts.group_id = $1 AND ts.training_date BETWEEN $2 AND $3
+		WHERE ts.group_id = $1 AND ts.training_date BETWEEN $2 AND $3 AND ts.cancelled_at IS NULL
 		ORDER BY ts.training_date ASC, ts.start_time ASC
 	`
 
@@ -202,7 +201,7 @@ func (r *trainingScheduleRepository) GetTrainingsByCoach(coachID int64, start, e
 		LEFT JOIN spectrum.training_groups tg ON ts.group_id = tg.id
 		LEFT JOIN spectrum.coaches c ON ts.coach_id = c.id
 		LEFT JOIN spectrum.users u ON c.user_id = u.id
-		WHERE ts.coach_id = $1 AND ts.training_date BETWEEN $2 AND $3
+		WHERE ts.coach_id = $1 AND ts.training_date BETWEEN $2 AND $3 AND ts.cancelled_at IS NULL
 		ORDER BY ts.training_date ASC, ts.start_time ASC
 	`
 
@@ -231,7 +230,24 @@ func (r *trainingScheduleRepository) GetTrainingsByCoach(coachID int64, start, e
 }
 
 func (r *trainingScheduleRepository) GetAvailableTrainingsForStudent(studentID int, start, end time.Time) ([]models.TrainingSchedule, error) {
+	// Тренировки, на которые не распространяется ни один активный абонемент ученика, скрываются;
+	// ученику без активного абонемента расписание показывается целиком
 	query := `
+		WITH passes AS (
+			SELECT s.allowed_group_ids, s.allowed_weekdays, s.time_from, s.time_to
+			FROM spectrum.subscriptions s
+			WHERE (s.student_id = $3 OR EXISTS (
+				SELECT 1 FROM spectrum.subscription_members m
+				WHERE m.subscription_id = s.id AND m.student_id = $3
+			))
+			AND s.remaining_lessons > 0
+			AND (s.end_date IS NULL OR s.end_date > CURRENT_TIMESTAMP)
+			AND NOT EXISTS (
+				SELECT 1 FROM spectrum.subscription_freezes f
+				WHERE f.subscription_id = s.id
+				AND CURRENT_DATE BETWEEN f.start_date AND f.end_date
+			)
+		)
 		SELECT DISTINCT
 			ts.id, ts.group_id, ts.coach_id, ts.training_date, ts.start_time, 
 			ts.end_time, ts.description, ts.max_participants, ts.created_by,
@@ -244,13 +260,23 @@ func (r *trainingScheduleRepository) GetAvailableTrainingsForStudent(studentID i
 		LEFT JOIN spectrum.users u ON c.user_id = u.id
 		WHERE ts.training_date BETWEEN $1 AND $2
 		AND ts.training_date >= CURRENT_DATE
+		AND ts.cancelled_at IS NULL
 		AND (ts.max_participants IS NULL OR ts.max_participants > (
-			SELECT COUNT(*) FROM spectrum.attendance a WHERE a.training_id = ts.id
+			SELECT COUNT(*) FROM spectrum.attendance a
+			WHERE a.training_id = ts.id AND a.status NOT IN ('cancelled', 'late_cancelled')
 		))
 		AND NOT EXISTS (
 			SELECT 1 FROM spectrum.attendance a 
-			WHERE a.training_id = ts.id AND a.student_id = $3
+			WHERE a.training_id = ts.id AND a.student_id = $3 AND a.status <> 'cancelled'
 		)
+		AND (NOT EXISTS (SELECT 1 FROM passes) OR EXISTS (
+			SELECT 1 FROM passes p
+			WHERE (COALESCE(cardinality(p.allowed_group_ids), 0) = 0 OR ts.group_id = ANY(p.allowed_group_ids))
+			AND (COALESCE(cardinality(p.allowed_weekdays), 0) = 0
+				OR EXTRACT(DOW FROM ts.training_date)::int = ANY(p.allowed_weekdays))
+			AND (p.time_from IS NULL OR ts.start_time >= p.time_from)
+			AND (p.time_to IS NULL OR ts.end_time <= p.time_to)
+		))
 		ORDER BY ts.training_date ASC, ts.start_time ASC
 	`
 
@@ -299,10 +325,22 @@ func (r *trainingScheduleRepository) UpdateTraining(training *models.TrainingSch
 	).Scan(&training.UpdatedAt)
 }
 
-func (r *trainingScheduleRepository) DeleteTraining(id int) error {
-	query := `DELETE FROM spectrum.training_schedule WHERE id = $1`
-	_, err := r.db.Exec(query, id)
-	return err
+// CancelTraining помечает тренировку отменённой. false, если её нет или она уже отменена
+func (r *trainingScheduleRepository) CancelTraining(id int, reason string) (bool, error) {
+	query := `
+		UPDATE spectrum.training_schedule
+		SET cancelled_at = CURRENT_TIMESTAMP, cancel_reason = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND cancelled_at IS NULL
+	`
+	result, err := r.db.Exec(query, id, reason)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
 }
 
 func (r *trainingScheduleRepository) IsCoachAvailable(coachID int64, date time.Time, startTime, endTime time.Time) (bool, error) {
@@ -311,6 +349,7 @@ func (r *trainingScheduleRepository) IsCoachAvailable(coachID int64, date time.T
 			SELECT 1 FROM spectrum.training_schedule 
 			WHERE coach_id = $1 
 			AND training_date = $2
+			AND cancelled_at IS NULL
 			AND (
 				(start_time <= $3 AND end_time > $3) OR
 				(start_time < $4 AND end_time >= $4) OR
@@ -332,7 +371,7 @@ func (r *trainingScheduleRepository) IsCoachAvailable(coachID int64, date time.T
 }
 
 func (r *trainingScheduleRepository) GetTrainingParticipantsCount(trainingID int) (int, error) {
-	query := `SELECT COUNT(*) FROM spectrum.attendance WHERE training_id = $1`
+	query := `SELECT COUNT(*) FROM spectrum.attendance WHERE training_id = $1 AND status NOT IN ('cancelled', 'late_cancelled')`
 	var count int
 	err := r.db.QueryRow(query, trainingID).Scan(&count)
 	return count, err
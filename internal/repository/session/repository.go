@@ -0,0 +1,50 @@
+package session
+
+import (
+	"database/sql"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/repository"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type botSessionRepository struct {
+	db *sqlx.DB
+}
+
+func NewBotSessionRepository(db *sqlx.DB) repository.BotSessionRepository {
+	return &botSessionRepository{db: db}
+}
+
+// Get возвращает сохранённую сессию чата или nil, если её нет
+func (r *botSessionRepository) Get(chatID int64) (*models.BotSession, error) {
+	var session models.BotSession
+	query := `SELECT chat_id, state, data, updated_at FROM spectrum.bot_sessions WHERE chat_id = $1`
+	err := r.db.Get(&session, query, chatID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *botSessionRepository) Save(session *models.BotSession) error {
+	query := `
+		INSERT INTO spectrum.bot_sessions (chat_id, state, data, updated_at)
+		VALUES ($1, $2, $3::jsonb, CURRENT_TIMESTAMP)
+		ON CONFLICT (chat_id)
+		DO UPDATE SET
+			state = EXCLUDED.state,
+			data = EXCLUDED.data,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING updated_at
+	`
+	return r.db.QueryRow(query, session.ChatID, session.State, string(session.Data)).Scan(&session.UpdatedAt)
+}
+
+func (r *botSessionRepository) Delete(chatID int64) error {
+	_, err := r.db.Exec(`DELETE FROM spectrum.bot_sessions WHERE chat_id = $1`, chatID)
+	return err
+}
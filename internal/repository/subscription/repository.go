@@ -1,27 +1,29 @@
 package subscription
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"spectrum-club-bot/internal/models"
 	"spectrum-club-bot/internal/repository"
-
-	"github.com/jmoiron/sqlx"
 )
 
 type subscriptionRepository struct {
-	db *sqlx.DB
+	db    repository.DBTX
+	order models.ConsumptionOrder // с какого из активных абонементов списывать занятия
 }
 
-func NewSubscriptionRepository(db *sqlx.DB) repository.SubscriptionRepository {
-	return &subscriptionRepository{db: db}
+func NewSubscriptionRepository(db repository.DBTX, order models.ConsumptionOrder) repository.SubscriptionRepository {
+	return &subscriptionRepository{db: db, order: order}
 }
 
 //todo:implement
 func (r *subscriptionRepository) Create(subscription *models.Subscription) error {
 	query := `
         INSERT INTO spectrum.subscriptions 
-        (student_id, start_date, end_date, total_lessons, remaining_lessons, created_at)
-        VALUES ($1, $2, $3, $4, $5, $6)
+        (student_id, start_date, end_date, total_lessons, remaining_lessons, created_at, plan_id,
+         allowed_group_ids, allowed_weekdays, time_from, time_to)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
         RETURNING id
     `
 	return r.db.QueryRow(
@@ -32,6 +34,11 @@ func (r *subscriptionRepository) Create(subscription *models.Subscription) error
 		subscription.TotalLessons,
 		subscription.RemainingLessons,
 		subscription.CreatedAt,
+		subscription.PlanID,
+		subscription.GroupIDs,
+		subscription.Weekdays,
+		subscription.TimeFrom,
+		subscription.TimeTo,
 	).Scan(&subscription.ID)
 }
 
@@ -57,9 +64,10 @@ func (r *subscriptionRepository) Delete(id int64) error {
 
 func (r *subscriptionRepository) GetByStudentID(studentID int64) ([]*models.Subscription, error) {
 	query := `
-        SELECT id, student_id, start_date, end_date, total_lessons, remaining_lessons, created_at
-        FROM spectrum.subscriptions
-        WHERE student_id = $1
+        SELECT id, student_id, start_date, end_date, total_lessons, remaining_lessons, created_at, plan_id,
+               allowed_group_ids, allowed_weekdays, time_from, time_to
+        FROM spectrum.subscriptions s
+        WHERE ` + heldBy + `
         ORDER BY created_at DESC
     `
 
@@ -80,6 +88,11 @@ func (r *subscriptionRepository) GetByStudentID(studentID int64) ([]*models.Subs
 			&subscription.TotalLessons,
 			&subscription.RemainingLessons,
 			&subscription.CreatedAt,
+			&subscription.PlanID,
+			&subscription.GroupIDs,
+			&subscription.Weekdays,
+			&subscription.TimeFrom,
+			&subscription.TimeTo,
 		)
 		if err != nil {
 			return nil, err
@@ -120,6 +133,51 @@ func (r *subscriptionRepository) GetByID(id int64) (*models.Subscription, error)
 	return &subscription, err
 }
 
+func (r *subscriptionRepository) GetByIDForUpdate(id int64) (*models.Subscription, error) {
+	var subscription models.Subscription
+	query := `SELECT * FROM spectrum.subscriptions WHERE id = $1 FOR UPDATE`
+	err := r.db.Get(&subscription, query, id)
+	return &subscription, err
+}
+
+// heldBy условие "ученик $1 пользуется абонементом s": он владелец или подключён к семейному абонементу
+const heldBy = `(s.student_id = $1 OR EXISTS (
+			SELECT 1 FROM spectrum.subscription_members m
+			WHERE m.subscription_id = s.id AND m.student_id = $1
+		))`
+
+// consumptionOrder порядок выбора активного абонемента: сначала собственный абонемент ученика,
+// затем семейный, среди равных — по порядку списания (см. models.ConsumptionOrder.Before)
+func (r *subscriptionRepository) consumptionOrder() string {
+	switch r.order {
+	case models.ConsumptionNewestFirst:
+		return `(s.student_id = $1) DESC, s.created_at DESC`
+	case models.ConsumptionOldestFirst:
+		return `(s.student_id = $1) DESC, s.created_at ASC`
+	default:
+		return `(s.student_id = $1) DESC, s.end_date ASC NULLS LAST, s.created_at ASC`
+	}
+}
+
+// notFrozen условие "абонемент s не заморожен сегодня" для выборок активного абонемента
+const notFrozen = `NOT EXISTS (
+			SELECT 1 FROM spectrum.subscription_freezes f
+			WHERE f.subscription_id = s.id
+			AND CURRENT_DATE BETWEEN f.start_date AND f.end_date
+		)`
+
+// coversTraining условие "ограничения абонемента s допускают тренировку $2".
+// Повторяет models.SubscriptionRestrictions.CheckTraining
+const coversTraining = `EXISTS (
+			SELECT 1 FROM spectrum.training_schedule ts
+			WHERE ts.id = $2
+			AND (COALESCE(cardinality(s.allowed_group_ids), 0) = 0 OR ts.group_id = ANY(s.allowed_group_ids))
+			AND (COALESCE(cardinality(s.allowed_weekdays), 0) = 0
+				OR EXTRACT(DOW FROM ts.training_date)::int = ANY(s.allowed_weekdays))
+			AND (s.time_from IS NULL OR ts.start_time >= s.time_from)
+			AND (s.time_to IS NULL OR ts.end_time <= s.time_to)
+		)`
+
 //	func (r *subscriptionRepository) GetActiveByStudentID(studentID int64) (*models.Subscription, error) {
 //		var subscription models.Subscription
 //		query := `SELECT * FROM spectrum.subscriptions WHERE student_id = $1 AND is_active = true ORDER BY created_at DESC LIMIT 1`
@@ -129,20 +187,54 @@ func (r *subscriptionRepository) GetByID(id int64) (*models.Subscription, error)
 func (r *subscriptionRepository) GetActiveByStudentID(studentID int64) (*models.Subscription, error) {
 	var subscription models.Subscription
 	query := `
-		SELECT * FROM spectrum.subscriptions 
-		WHERE student_id = $1 
-		AND remaining_lessons > 0 
-		AND (end_date IS NULL OR end_date > CURRENT_TIMESTAMP)
-		ORDER BY created_at DESC 
+		SELECT * FROM spectrum.subscriptions s
+		WHERE ` + heldBy + `
+		AND s.remaining_lessons > 0
+		AND (s.end_date IS NULL OR s.end_date > CURRENT_TIMESTAMP)
+		AND ` + notFrozen + `
+		ORDER BY ` + r.consumptionOrder() + `
 		LIMIT 1`
 
 	err := r.db.Get(&subscription, query, studentID)
 	return &subscription, err
 }
 
+func (r *subscriptionRepository) GetActiveForTraining(studentID int64, trainingID int) (*models.Subscription, error) {
+	var subscription models.Subscription
+	query := `
+		SELECT * FROM spectrum.subscriptions s
+		WHERE ` + heldBy + `
+		AND s.remaining_lessons > 0
+		AND (s.end_date IS NULL OR s.end_date > CURRENT_TIMESTAMP)
+		AND ` + notFrozen + `
+		AND ` + coversTraining + `
+		ORDER BY ` + r.consumptionOrder() + `
+		LIMIT 1`
+
+	err := r.db.Get(&subscription, query, studentID, trainingID)
+	return &subscription, err
+}
+
+func (r *subscriptionRepository) GetActiveForTrainingForUpdate(studentID int64, trainingID int) (*models.Subscription, error) {
+	var subscription models.Subscription
+	query := `
+		SELECT * FROM spectrum.subscriptions s
+		WHERE ` + heldBy + `
+		AND s.remaining_lessons > 0
+		AND (s.end_date IS NULL OR s.end_date > CURRENT_TIMESTAMP)
+		AND ` + notFrozen + `
+		AND ` + coversTraining + `
+		ORDER BY ` + r.consumptionOrder() + `
+		LIMIT 1
+		FOR UPDATE`
+
+	err := r.db.Get(&subscription, query, studentID, trainingID)
+	return &subscription, err
+}
+
 func (r *subscriptionRepository) GetHistoryByStudentID(studentID int64) ([]*models.Subscription, error) {
 	var subscriptions []*models.Subscription
-	query := `SELECT * FROM spectrum.subscriptions WHERE student_id = $1 ORDER BY created_at DESC`
+	query := `SELECT * FROM spectrum.subscriptions s WHERE ` + heldBy + ` ORDER BY s.created_at DESC`
 	err := r.db.Select(&subscriptions, query, studentID)
 	return subscriptions, err
 }
@@ -162,23 +254,157 @@ func (r *subscriptionRepository) Update(subscription *models.Subscription) error
 	return err
 }
 
-// DecrementRemainingLessons уменьшает remaining_lessons на 1 для активного абонемента ученика
-func (r *subscriptionRepository) DecrementRemainingLessons(studentID int64) error {
-	// Сначала находим активный абонемент
-	subscription, err := r.GetActiveByStudentID(studentID)
+// DecrementRemainingLessons уменьшает remaining_lessons на 1 для активного абонемента ученика,
+// который допускает тренировку. Выбор абонемента и списание выполняются одним UPDATE
+// с блокировкой строки, поэтому параллельные отметки не теряют списания.
+func (r *subscriptionRepository) DecrementRemainingLessons(studentID int64, trainingID int) (int64, error) {
+	query := `
+		UPDATE spectrum.subscriptions
+		SET remaining_lessons = remaining_lessons - 1
+		WHERE id = (
+			SELECT s.id FROM spectrum.subscriptions s
+			WHERE ` + heldBy + `
+			AND s.remaining_lessons > 0
+			AND (s.end_date IS NULL OR s.end_date > CURRENT_TIMESTAMP)
+			AND ` + notFrozen + `
+			AND ` + coversTraining + `
+			ORDER BY ` + r.consumptionOrder() + `
+			LIMIT 1
+			FOR UPDATE
+		)
+		AND remaining_lessons > 0
+		RETURNING id`
+
+	var subscriptionID int64
+	err := r.db.QueryRow(query, studentID, trainingID).Scan(&subscriptionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("нет активного абонемента для тренировки %d у ученика с ID %d", trainingID, studentID)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("ошибка списания занятия для ученика с ID %d: %w", studentID, err)
+	}
+
+	return subscriptionID, nil
+}
+
+func (r *subscriptionRepository) Extend(id int64, days int, lessons int) error {
+	query := `
+		UPDATE spectrum.subscriptions
+		SET end_date = CASE WHEN $1 > 0
+				THEN GREATEST(end_date, CURRENT_TIMESTAMP) + make_interval(days => $1)
+				ELSE end_date END,
+			total_lessons = total_lessons + $2,
+			remaining_lessons = remaining_lessons + $2
+		WHERE id = $3`
+
+	result, err := r.db.Exec(query, days, lessons, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("абонемент с ID %d не найден", id)
+	}
+	return nil
+}
+
+func (r *subscriptionRepository) AddRemainingLessons(id int64, delta int) error {
+	query := `
+		UPDATE spectrum.subscriptions
+		SET remaining_lessons = remaining_lessons + $1
+		WHERE id = $2
+		AND remaining_lessons + $1 >= 0`
+
+	result, err := r.db.Exec(query, delta, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("абонемент с ID %d не найден или в нём недостаточно занятий", id)
+	}
+	return nil
+}
+
+func (r *subscriptionRepository) ExtendEndDate(id int64, days int) error {
+	query := `
+		UPDATE spectrum.subscriptions
+		SET end_date = end_date + make_interval(days => $1)
+		WHERE id = $2`
+
+	result, err := r.db.Exec(query, days, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("нет активного абонемента для ученика с ID %d: %v", studentID, err)
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("абонемент с ID %d не найден", id)
 	}
-	
-	if subscription == nil {
-		return fmt.Errorf("нет активного абонемента для ученика с ID %d", studentID)
+	return nil
+}
+
+func (r *subscriptionRepository) AddMember(subscriptionID, studentID int64, addedBy *int64) error {
+	query := `
+		INSERT INTO spectrum.subscription_members (subscription_id, student_id, added_by)
+		VALUES ($1, $2, $3)`
+	_, err := r.db.Exec(query, subscriptionID, studentID, addedBy)
+	return err
+}
+
+func (r *subscriptionRepository) RemoveMember(subscriptionID, studentID int64) (bool, error) {
+	query := `DELETE FROM spectrum.subscription_members WHERE subscription_id = $1 AND student_id = $2`
+	result, err := r.db.Exec(query, subscriptionID, studentID)
+	if err != nil {
+		return false, err
 	}
-	
-	// Обновляем remaining_lessons
-	subscription.RemainingLessons--
-	if subscription.RemainingLessons < 0 {
-		subscription.RemainingLessons = 0
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
 	}
-	
-	return r.Update(subscription)
+	return rowsAffected > 0, nil
+}
+
+// GetMembers списанные за ученика занятия считаются по журналу: записи о посещениях
+// и возвратах ссылаются на запись на тренировку, а через неё — на ученика
+func (r *subscriptionRepository) GetMembers(subscriptionID int64) ([]models.SubscriptionMember, error) {
+	query := `
+		WITH members AS (
+			SELECT s.id AS subscription_id, s.student_id, TRUE AS is_owner, s.created_at AS added_at
+			FROM spectrum.subscriptions s
+			WHERE s.id = $1
+			UNION ALL
+			SELECT m.subscription_id, m.student_id, FALSE, m.added_at
+			FROM spectrum.subscription_members m
+			WHERE m.subscription_id = $1
+		)
+		SELECT
+			mb.subscription_id, mb.student_id, mb.is_owner, mb.added_at,
+			TRIM(u.first_name || ' ' || COALESCE(u.last_name, '')) AS student_name,
+			COALESCE((
+				SELECT -SUM(l.delta)
+				FROM spectrum.lesson_ledger l
+				JOIN spectrum.attendance a ON a.id = l.attendance_id
+				WHERE l.subscription_id = mb.subscription_id AND a.student_id = mb.student_id
+			), 0) AS lessons_used
+		FROM members mb
+		JOIN spectrum.students st ON st.id = mb.student_id
+		JOIN spectrum.users u ON u.id = st.user_id
+		ORDER BY mb.is_owner DESC, mb.added_at`
+
+	var members []models.SubscriptionMember
+	err := r.db.Select(&members, query, subscriptionID)
+	return members, err
 }
@@ -0,0 +1,58 @@
+package subscription_alert
+
+import (
+	"spectrum-club-bot/internal/repository"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type subscriptionAlertRepository struct {
+	db *sqlx.DB
+}
+
+func NewSubscriptionAlertRepository(db *sqlx.DB) repository.SubscriptionAlertRepository {
+	return &subscriptionAlertRepository{db: db}
+}
+
+func (r *subscriptionAlertRepository) MarkSent(subscriptionID int64, kind string) (bool, error) {
+	query := `
+		INSERT INTO spectrum.subscription_alerts (subscription_id, kind)
+		VALUES ($1, $2)
+		ON CONFLICT (subscription_id, kind) DO NOTHING
+	`
+	result, err := r.db.Exec(query, subscriptionID, kind)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected == 1, nil
+}
+
+func (r *subscriptionAlertRepository) Unmark(subscriptionID int64, kind string) error {
+	query := `DELETE FROM spectrum.subscription_alerts WHERE subscription_id = $1 AND kind = $2`
+	_, err := r.db.Exec(query, subscriptionID, kind)
+	return err
+}
+
+func (r *subscriptionAlertRepository) MarkDigestSent(weekStart time.Time) (bool, error) {
+	query := `
+		INSERT INTO spectrum.subscription_digests (week_start)
+		VALUES ($1)
+		ON CONFLICT (week_start) DO NOTHING
+	`
+	result, err := r.db.Exec(query, weekStart.Format("2006-01-02"))
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected == 1, nil
+}
@@ -0,0 +1,78 @@
+package subscription_freeze
+
+import (
+	"fmt"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/repository"
+	"time"
+)
+
+type subscriptionFreezeRepository struct {
+	db repository.DBTX
+}
+
+func NewSubscriptionFreezeRepository(db repository.DBTX) repository.SubscriptionFreezeRepository {
+	return &subscriptionFreezeRepository{db: db}
+}
+
+func (r *subscriptionFreezeRepository) Create(freeze *models.SubscriptionFreeze) error {
+	query := `
+		INSERT INTO spectrum.subscription_freezes (subscription_id, reason, start_date, end_date, created_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+	return r.db.QueryRow(
+		query,
+		freeze.SubscriptionID,
+		freeze.Reason,
+		freeze.StartDate,
+		freeze.EndDate,
+		freeze.CreatedBy,
+	).Scan(&freeze.ID, &freeze.CreatedAt)
+}
+
+func (r *subscriptionFreezeRepository) GetBySubscriptionID(subscriptionID int64) ([]models.SubscriptionFreeze, error) {
+	query := `
+		SELECT * FROM spectrum.subscription_freezes
+		WHERE subscription_id = $1
+		ORDER BY start_date
+	`
+
+	var freezes []models.SubscriptionFreeze
+	err := r.db.Select(&freezes, query, subscriptionID)
+	return freezes, err
+}
+
+func (r *subscriptionFreezeRepository) UpdateEndDate(id int64, endDate time.Time) error {
+	query := `UPDATE spectrum.subscription_freezes SET end_date = $1 WHERE id = $2`
+	result, err := r.db.Exec(query, endDate, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("заморозка с ID %d не найдена", id)
+	}
+	return nil
+}
+
+func (r *subscriptionFreezeRepository) Delete(id int64) error {
+	query := `DELETE FROM spectrum.subscription_freezes WHERE id = $1`
+	result, err := r.db.Exec(query, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("заморозка с ID %d не найдена", id)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package subscription_plan
+
+import (
+	"fmt"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/repository"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type subscriptionPlanRepository struct {
+	db *sqlx.DB
+}
+
+func NewSubscriptionPlanRepository(db *sqlx.DB) repository.SubscriptionPlanRepository {
+	return &subscriptionPlanRepository{db: db}
+}
+
+func (r *subscriptionPlanRepository) Create(plan *models.SubscriptionPlan) error {
+	query := `
+		INSERT INTO spectrum.subscription_plans
+		(name, lessons, duration_days, price, is_active, allowed_group_ids, allowed_weekdays, time_from, time_to)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at, updated_at
+	`
+	return r.db.QueryRow(
+		query,
+		plan.Name, plan.Lessons, plan.DurationDays, plan.Price, plan.IsActive,
+		plan.GroupIDs, plan.Weekdays, plan.TimeFrom, plan.TimeTo,
+	).Scan(
+		&plan.ID,
+		&plan.CreatedAt,
+		&plan.UpdatedAt,
+	)
+}
+
+func (r *subscriptionPlanRepository) Update(plan *models.SubscriptionPlan) error {
+	query := `
+		UPDATE spectrum.subscription_plans
+		SET name = $1, lessons = $2, duration_days = $3, price = $4, is_active = $5,
+			allowed_group_ids = $6, allowed_weekdays = $7, time_from = $8, time_to = $9,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $10
+	`
+	result, err := r.db.Exec(
+		query,
+		plan.Name, plan.Lessons, plan.DurationDays, plan.Price, plan.IsActive,
+		plan.GroupIDs, plan.Weekdays, plan.TimeFrom, plan.TimeTo,
+		plan.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("тариф с ID %d не найден", plan.ID)
+	}
+	return nil
+}
+
+func (r *subscriptionPlanRepository) GetByID(id int64) (*models.SubscriptionPlan, error) {
+	var plan models.SubscriptionPlan
+	query := `SELECT * FROM spectrum.subscription_plans WHERE id = $1`
+	err := r.db.Get(&plan, query, id)
+	return &plan, err
+}
+
+func (r *subscriptionPlanRepository) GetAll(activeOnly bool) ([]models.SubscriptionPlan, error) {
+	query := `
+		SELECT * FROM spectrum.subscription_plans
+		WHERE is_active OR NOT $1
+		ORDER BY name
+	`
+
+	var plans []models.SubscriptionPlan
+	err := r.db.Select(&plans, query, activeOnly)
+	return plans, err
+}
@@ -0,0 +1,60 @@
+package transaction
+
+import (
+	"fmt"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/repository"
+	"spectrum-club-bot/internal/repository/attendance"
+	"spectrum-club-bot/internal/repository/lesson_ledger"
+	"spectrum-club-bot/internal/repository/payment"
+	"spectrum-club-bot/internal/repository/schedule"
+	"spectrum-club-bot/internal/repository/subscription"
+	"spectrum-club-bot/internal/repository/subscription_freeze"
+	"spectrum-club-bot/internal/repository/waitlist"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type transactor struct {
+	db    *sqlx.DB
+	order models.ConsumptionOrder
+}
+
+func NewTransactor(db *sqlx.DB, order models.ConsumptionOrder) repository.Transactor {
+	return &transactor{db: db, order: order}
+}
+
+func (t *transactor) WithinTransaction(fn func(tx repository.TxRepositories) error) (err error) {
+	tx, err := t.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	err = fn(repository.TxRepositories{
+		Attendance:    attendance.NewAttendanceRepository(tx),
+		Schedule:      schedule.NewTrainingScheduleRepository(tx),
+		Subscriptions: subscription.NewSubscriptionRepository(tx, t.order),
+		Freezes:       subscription_freeze.NewSubscriptionFreezeRepository(tx),
+		Ledger:        lesson_ledger.NewLessonLedgerRepository(tx),
+		Payments:      payment.NewPaymentRepository(tx),
+		Waitlist:      waitlist.NewWaitlistRepository(tx),
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+	return nil
+}
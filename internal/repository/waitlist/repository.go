@@ -0,0 +1,168 @@
+package waitlist
+
+import (
+	"database/sql"
+	"errors"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/repository"
+	"time"
+)
+
+type waitlistRepository struct {
+	db repository.DBTX
+}
+
+func NewWaitlistRepository(db repository.DBTX) repository.WaitlistRepository {
+	return &waitlistRepository{db: db}
+}
+
+// selectEntries записи очереди с telegram_id ученика и позицией среди ожидающих
+const selectEntries = `
+	SELECT
+		w.id, w.training_id, w.student_id, w.status, w.offer_expires_at, w.created_at, w.updated_at,
+		u.telegram_id,
+		CASE WHEN w.status = 'waiting' THEN (
+			SELECT COUNT(*) FROM spectrum.training_waitlist p
+			WHERE p.training_id = w.training_id AND p.status = 'waiting' AND p.id <= w.id
+		) ELSE 0 END AS position
+	FROM spectrum.training_waitlist w
+	JOIN spectrum.students s ON w.student_id = s.id
+	JOIN spectrum.users u ON s.user_id = u.id
+`
+
+// returningEntry список RETURNING для UPDATE ... FROM students/users
+const returningEntry = `
+	RETURNING w.id, w.training_id, w.student_id, w.status, w.offer_expires_at, w.created_at, w.updated_at,
+		u.telegram_id, 0 AS position
+`
+
+func (r *waitlistRepository) Add(entry *models.WaitlistEntry) error {
+	query := `
+		INSERT INTO spectrum.training_waitlist (training_id, student_id)
+		VALUES ($1, $2)
+		RETURNING id, status, created_at, updated_at
+	`
+	return r.db.QueryRow(query, entry.TrainingID, entry.StudentID).Scan(
+		&entry.ID,
+		&entry.Status,
+		&entry.CreatedAt,
+		&entry.UpdatedAt,
+	)
+}
+
+func (r *waitlistRepository) GetActive(trainingID, studentID int) (*models.WaitlistEntry, error) {
+	query := selectEntries + `
+		WHERE w.training_id = $1 AND w.student_id = $2 AND w.status IN ('waiting', 'offered')
+	`
+
+	var entry models.WaitlistEntry
+	err := r.db.Get(&entry, query, trainingID, studentID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *waitlistRepository) GetActiveByStudent(studentID int) ([]models.WaitlistEntry, error) {
+	query := selectEntries + `
+		WHERE w.student_id = $1 AND w.status IN ('waiting', 'offered')
+		ORDER BY w.id
+	`
+
+	var entries []models.WaitlistEntry
+	err := r.db.Select(&entries, query, studentID)
+	return entries, err
+}
+
+func (r *waitlistRepository) CountOffers(trainingID, excludeStudentID int) (int, error) {
+	query := `
+		SELECT COUNT(*) FROM spectrum.training_waitlist
+		WHERE training_id = $1 AND student_id <> $2
+		AND status = 'offered' AND offer_expires_at > CURRENT_TIMESTAMP
+	`
+
+	var count int
+	err := r.db.Get(&count, query, trainingID, excludeStudentID)
+	return count, err
+}
+
+func (r *waitlistRepository) OfferNext(trainingID int, ttl time.Duration) (*models.WaitlistEntry, error) {
+	// SKIP LOCKED: при одновременной отмене двух записей места уйдут двум разным ученикам
+	query := `
+		UPDATE spectrum.training_waitlist w
+		SET status = 'offered',
+			offer_expires_at = CURRENT_TIMESTAMP + make_interval(secs => $2),
+			updated_at = CURRENT_TIMESTAMP
+		FROM spectrum.students s
+		JOIN spectrum.users u ON s.user_id = u.id
+		WHERE s.id = w.student_id
+		AND w.id = (
+			SELECT id FROM spectrum.training_waitlist
+			WHERE training_id = $1 AND status = 'waiting'
+			ORDER BY id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+	` + returningEntry
+
+	var entry models.WaitlistEntry
+	err := r.db.Get(&entry, query, trainingID, ttl.Seconds())
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *waitlistRepository) Close(id int, status string) (bool, error) {
+	query := `
+		UPDATE spectrum.training_waitlist
+		SET status = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status IN ('waiting', 'offered')
+	`
+	result, err := r.db.Exec(query, id, status)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected == 1, nil
+}
+
+func (r *waitlistRepository) CloseAll(trainingID int, status string) ([]models.WaitlistEntry, error) {
+	query := `
+		UPDATE spectrum.training_waitlist w
+		SET status = $2, updated_at = CURRENT_TIMESTAMP
+		FROM spectrum.students s
+		JOIN spectrum.users u ON s.user_id = u.id
+		WHERE s.id = w.student_id
+		AND w.training_id = $1 AND w.status IN ('waiting', 'offered')
+	` + returningEntry
+
+	var entries []models.WaitlistEntry
+	err := r.db.Select(&entries, query, trainingID, status)
+	return entries, err
+}
+
+func (r *waitlistRepository) ExpireOffers() ([]models.WaitlistEntry, error) {
+	query := `
+		UPDATE spectrum.training_waitlist w
+		SET status = 'expired', updated_at = CURRENT_TIMESTAMP
+		FROM spectrum.students s
+		JOIN spectrum.users u ON s.user_id = u.id
+		WHERE s.id = w.student_id
+		AND w.status = 'offered' AND w.offer_expires_at <= CURRENT_TIMESTAMP
+	` + returningEntry
+
+	var entries []models.WaitlistEntry
+	err := r.db.Select(&entries, query)
+	return entries, err
+}
@@ -0,0 +1,103 @@
+package attendance_service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/repository"
+)
+
+// Отмена тренировки. Тренировка и записи на неё остаются в БД для истории;
+// отметка об отмене, возврат занятий, отмена записей и закрытие листа ожидания
+// выполняются в одной транзакции.
+func (s *attendanceService) CancelTraining(trainingID int, reason string, cancelledBy int64) error {
+	training, err := s.scheduleRepo.GetTrainingByID(trainingID)
+	if err != nil {
+		return fmt.Errorf("ошибка получения тренировки: %w", err)
+	}
+	if training == nil {
+		return errors.New("тренировка не найдена")
+	}
+	if training.IsCancelled() {
+		return errors.New("тренировка уже отменена")
+	}
+
+	var actorID *int64
+	if cancelledBy != 0 {
+		actorID = &cancelledBy
+	}
+	comment := "Отмена тренировки: " + trainingTitle(training)
+
+	var participants []models.AttendanceWithStudent
+	var waiting []models.WaitlistEntry
+	refunded := make(map[int]int) // studentID -> сколько занятий вернулось
+	err = s.transactor.WithinTransaction(func(tx repository.TxRepositories) error {
+		cancelled, err := tx.Schedule.CancelTraining(trainingID, reason)
+		if err != nil {
+			return fmt.Errorf("ошибка отмены тренировки: %w", err)
+		}
+		if !cancelled {
+			return errors.New("тренировка уже отменена")
+		}
+
+		participants, err = tx.Attendance.GetParticipants(trainingID)
+		if err != nil {
+			return fmt.Errorf("ошибка получения участников: %w", err)
+		}
+		for _, p := range participants {
+			lessons, err := refundLessons(tx, p.ID, trainingID, actorID, comment)
+			if err != nil {
+				return err
+			}
+			refunded[p.StudentID] += lessons
+		}
+
+		// Занятия, списанные за позднюю отмену, тоже возвращаются: тренировки не будет
+		records, err := tx.Attendance.GetAttendanceByTraining(trainingID)
+		if err != nil {
+			return fmt.Errorf("ошибка получения записей: %w", err)
+		}
+		for _, record := range records {
+			if record.Status != models.AttendanceStatusLateCancelled {
+				continue
+			}
+			if _, err := refundLessons(tx, record.ID, trainingID, actorID, comment); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Attendance.CancelByTraining(trainingID, actorID); err != nil {
+			return fmt.Errorf("ошибка отмены записей: %w", err)
+		}
+
+		waiting, err = tx.Waitlist.CloseAll(trainingID, models.WaitlistStatusCancelled)
+		if err != nil {
+			return fmt.Errorf("ошибка закрытия листа ожидания: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	training.CancelReason = reason
+	log.Printf("🚫 Тренировка %d отменена, участников: %d", trainingID, len(participants))
+
+	// Уведомления после фиксации: ошибки только логируются, отмена уже выполнена
+	for _, p := range participants {
+		if p.Student.User.TelegramID == 0 {
+			continue
+		}
+		if err := s.trainingNotifier.TrainingCancelled(p.Student.User.TelegramID, training, refunded[p.StudentID]); err != nil {
+			log.Printf("❌ Отмена тренировки: не удалось уведомить ученика %d: %v", p.StudentID, err)
+		}
+	}
+
+	for _, entry := range waiting {
+		if err := s.trainingNotifier.TrainingCancelled(entry.TelegramID, training, 0); err != nil {
+			log.Printf("❌ Отмена тренировки: не удалось уведомить ученика %d из листа ожидания: %v", entry.StudentID, err)
+		}
+	}
+	return nil
+}
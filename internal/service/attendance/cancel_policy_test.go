@@ -0,0 +1,131 @@
+package attendance_service
+
+import (
+	"errors"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/refund"
+	"spectrum-club-bot/internal/repository/memory"
+	"spectrum-club-bot/internal/service"
+	"spectrum-club-bot/internal/waitlist"
+	"testing"
+	"time"
+)
+
+// withPolicy заменяет сервис фикстуры сервисом с правилами отмены policy
+func (f *attendanceFixture) withPolicy(policy models.CancellationPolicy) *attendanceFixture {
+	order := models.ConsumptionExpiringFirst
+	var sent sentMessages
+	f.svc = NewAttendanceService(
+		memory.NewAttendanceRepository(f.store),
+		memory.NewTrainingScheduleRepository(f.store),
+		memory.NewSubscriptionRepository(f.store, order),
+		memory.NewWaitlistRepository(f.store),
+		memory.NewTransactor(f.store, order),
+		waitlist.NewOfferNotifier(&sent),
+		refund.NewNotifier(&sent),
+		time.Hour,
+		policy,
+	)
+	return f
+}
+
+func TestCancelSignUpPolicy(t *testing.T) {
+	tests := []struct {
+		name        string
+		untilStart  time.Duration
+		late        models.LateCancelPolicy
+		wantErr     error
+		wantStatus  string
+		wantBalance int
+		wantLedger  []string // причины записей журнала
+	}{
+		{
+			name:        "до срока бесплатно",
+			untilStart:  48 * time.Hour,
+			late:        models.LateCancelCharge,
+			wantStatus:  models.AttendanceStatusCancelled,
+			wantBalance: 8,
+		},
+		{
+			name:        "после срока со списанием",
+			untilStart:  2 * time.Hour,
+			late:        models.LateCancelCharge,
+			wantStatus:  models.AttendanceStatusLateCancelled,
+			wantBalance: 7,
+			wantLedger:  []string{models.LedgerReasonLateCancel},
+		},
+		{
+			name:        "после срока запрещено",
+			untilStart:  2 * time.Hour,
+			late:        models.LateCancelBlock,
+			wantErr:     service.ErrLateCancellation,
+			wantStatus:  models.AttendanceStatusRegistered,
+			wantBalance: 8,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newAttendanceFixture(t, time.Now().Add(tt.untilStart)).
+				withPolicy(models.CancellationPolicy{Deadline: 12 * time.Hour, Late: tt.late})
+
+			err := f.svc.CancelSignUp(f.studentID, f.training.ID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CancelSignUp() error = %v, want %v", err, tt.wantErr)
+			}
+
+			record, history := f.attendance(t)
+			if record.Status != tt.wantStatus {
+				t.Errorf("статус = %s, want %s", record.Status, tt.wantStatus)
+			}
+			if tt.wantErr != nil && len(history) != 1 {
+				t.Errorf("история статусов = %+v, want только создание записи", history)
+			}
+			if got := f.balance(t); got != tt.wantBalance {
+				t.Errorf("остаток = %d, want %d", got, tt.wantBalance)
+			}
+
+			var reasons []string
+			for _, entry := range f.ledger(t) {
+				reasons = append(reasons, entry.Reason)
+			}
+			if len(reasons) != len(tt.wantLedger) || len(reasons) > 0 && reasons[0] != tt.wantLedger[0] {
+				t.Errorf("журнал = %v, want %v", reasons, tt.wantLedger)
+			}
+		})
+	}
+}
+
+func TestSignUpAfterCancellation(t *testing.T) {
+	tests := []struct {
+		name       string
+		untilStart time.Duration
+		wantErr    bool
+		wantStatus string
+	}{
+		{name: "после отмены до срока", untilStart: 48 * time.Hour, wantStatus: models.AttendanceStatusRegistered},
+		{name: "после поздней отмены", untilStart: 2 * time.Hour, wantErr: true, wantStatus: models.AttendanceStatusLateCancelled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newAttendanceFixture(t, time.Now().Add(tt.untilStart)).
+				withPolicy(models.CancellationPolicy{Deadline: 12 * time.Hour, Late: models.LateCancelCharge})
+			if err := f.svc.CancelSignUp(f.studentID, f.training.ID); err != nil {
+				t.Fatal(err)
+			}
+			balance := f.balance(t)
+
+			err := f.svc.SignUpForTraining(f.studentID, f.training.ID)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SignUpForTraining() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if record, _ := f.attendance(t); record.Status != tt.wantStatus {
+				t.Errorf("статус = %s, want %s", record.Status, tt.wantStatus)
+			}
+			if got := f.balance(t); got != balance {
+				t.Errorf("остаток = %d, want %d: запись не списывает занятие", got, balance)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package attendance_service
+
+import (
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/notify"
+	"spectrum-club-bot/internal/refund"
+	"spectrum-club-bot/internal/repository/memory"
+	"spectrum-club-bot/internal/waitlist"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sentMessages notify.Notifier, запоминающий сообщения вместо отправки
+type sentMessages []notify.Message
+
+func (s *sentMessages) Send(message notify.Message) error {
+	*s = append(*s, message)
+	return nil
+}
+
+func TestCancelTrainingClosesWaitlist(t *testing.T) {
+	store := memory.NewStore()
+	order := models.ConsumptionExpiringFirst
+	group := store.AddGroup(models.TrainingGroup{Name: "Взрослые_вечер", Code: "adults"})
+
+	training := models.TrainingSchedule{
+		GroupID:      group.ID,
+		TrainingDate: time.Now().AddDate(0, 0, 2),
+		StartTime:    time.Date(0, 1, 1, 19, 0, 0, 0, time.UTC),
+		EndTime:      time.Date(0, 1, 1, 20, 30, 0, 0, time.UTC),
+	}
+	schedule := memory.NewTrainingScheduleRepository(store)
+	if err := schedule.CreateTraining(&training); err != nil {
+		t.Fatal(err)
+	}
+
+	var students []int
+	for i, name := range []string{"Анна", "Борис"} {
+		user := &models.User{TelegramID: int64(100 + i), FirstName: name, LastName: "Тестова", Role: "student"}
+		if err := memory.NewUserRepository(store).CreateOrUpdate(user); err != nil {
+			t.Fatal(err)
+		}
+		student := &models.Student{UserID: user.ID}
+		if err := memory.NewStudentRepository(store).Create(student); err != nil {
+			t.Fatal(err)
+		}
+		students = append(students, int(student.ID))
+	}
+
+	attendance := memory.NewAttendanceRepository(store)
+	if err := attendance.CreateAttendance(&models.Attendance{TrainingID: training.ID, StudentID: students[0]}); err != nil {
+		t.Fatal(err)
+	}
+	waitlistRepo := memory.NewWaitlistRepository(store)
+	entry := &models.WaitlistEntry{TrainingID: training.ID, StudentID: students[1]}
+	if err := waitlistRepo.Add(entry); err != nil {
+		t.Fatal(err)
+	}
+
+	var sent sentMessages
+	svc := NewAttendanceService(
+		attendance,
+		schedule,
+		memory.NewSubscriptionRepository(store, order),
+		waitlistRepo,
+		memory.NewTransactor(store, order),
+		waitlist.NewOfferNotifier(&sent),
+		refund.NewNotifier(&sent),
+		time.Hour,
+		models.CancellationPolicy{},
+	)
+
+	if err := svc.CancelTraining(training.ID, "Зал_закрыт", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	active, err := waitlistRepo.GetActive(training.ID, students[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if active != nil {
+		t.Errorf("запись в листе ожидания осталась активной: %+v", active)
+	}
+
+	if len(sent) != 2 {
+		t.Fatalf("отправлено сообщений = %d, want 2 (участнику и из листа ожидания)", len(sent))
+	}
+	for _, message := range sent {
+		if !strings.Contains(message.Text, `Взрослые\_вечер`) || !strings.Contains(message.Text, `Зал\_закрыт`) {
+			t.Errorf("группа и причина не экранированы: %q", message.Text)
+		}
+	}
+}
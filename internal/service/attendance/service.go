@@ -1,8 +1,10 @@
 package attendance_service
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
+	"log"
 	"spectrum-club-bot/internal/models"
 	"spectrum-club-bot/internal/repository"
 	"spectrum-club-bot/internal/service"
@@ -10,16 +12,39 @@ import (
 )
 
 type attendanceService struct {
-	attendanceRepo      repository.AttendanceRepository
-	scheduleRepo        repository.TrainingScheduleRepository
-	subscriptionService service.SubscriptionService
+	attendanceRepo   repository.AttendanceRepository
+	scheduleRepo     repository.TrainingScheduleRepository
+	subscriptionRepo repository.SubscriptionRepository
+	waitlistRepo     repository.WaitlistRepository
+	transactor       repository.Transactor
+
+	waitlistNotifier service.WaitlistNotifier
+	trainingNotifier service.TrainingNotifier
+	offerTTL         time.Duration // сколько держится место, предложенное из листа ожидания
+	cancellation     models.CancellationPolicy
 }
 
-func NewAttendanceService(attendanceRepo repository.AttendanceRepository, scheduleRepo repository.TrainingScheduleRepository, subscriptionService service.SubscriptionService) service.AttendanceService {
+func NewAttendanceService(
+	attendanceRepo repository.AttendanceRepository,
+	scheduleRepo repository.TrainingScheduleRepository,
+	subscriptionRepo repository.SubscriptionRepository,
+	waitlistRepo repository.WaitlistRepository,
+	transactor repository.Transactor,
+	waitlistNotifier service.WaitlistNotifier,
+	trainingNotifier service.TrainingNotifier,
+	offerTTL time.Duration,
+	cancellation models.CancellationPolicy,
+) service.AttendanceService {
 	return &attendanceService{
-		attendanceRepo:      attendanceRepo,
-		scheduleRepo:        scheduleRepo,
-		subscriptionService: subscriptionService,
+		attendanceRepo:   attendanceRepo,
+		scheduleRepo:     scheduleRepo,
+		subscriptionRepo: subscriptionRepo,
+		waitlistRepo:     waitlistRepo,
+		transactor:       transactor,
+		waitlistNotifier: waitlistNotifier,
+		trainingNotifier: trainingNotifier,
+		offerTTL:         offerTTL,
+		cancellation:     cancellation,
 	}
 }
 
@@ -31,7 +56,14 @@ func (s *attendanceService) SignUpForTraining(studentID, trainingID int) error {
 		return err
 	}
 	if existing != nil {
-		return errors.New("студент уже записан на эту тренировку")
+		switch existing.Status {
+		case models.AttendanceStatusCancelled:
+			// Отменённую заранее запись можно восстановить — ниже, после проверок
+		case models.AttendanceStatusLateCancelled:
+			return errors.New("запись на эту тренировку отменена слишком поздно, записаться снова нельзя")
+		default:
+			return errors.New("студент уже записан на эту тренировку")
+		}
 	}
 
 	// Проверяем доступность мест
@@ -39,98 +71,397 @@ func (s *attendanceService) SignUpForTraining(studentID, trainingID int) error {
 	if err != nil {
 		return err
 	}
+	if training == nil {
+		return errors.New("тренировка не найдена")
+	}
+	if training.IsCancelled() {
+		return errors.New("тренировка отменена")
+	}
+	if err := s.checkSubscriptionCovers(studentID, training); err != nil {
+		return err
+	}
+
+	// Места, предложенные другим ученикам из листа ожидания, тоже заняты;
+	// собственное предложение ученика место не занимает — он его и принимает
+	free, limited, err := s.freeSpots(training, studentID)
+	if err != nil {
+		return err
+	}
+	if limited && free <= 0 {
+		return service.ErrTrainingFull
+	}
 
-	if training.MaxParticipants != nil {
-		currentCount, err := s.scheduleRepo.GetTrainingParticipantsCount(trainingID)
+	if existing != nil {
+		changed, err := s.attendanceRepo.ChangeStatus(existing.ID, existing.Status, models.AttendanceStatusRegistered, nil, "")
 		if err != nil {
 			return err
 		}
-		if currentCount >= *training.MaxParticipants {
-			return errors.New("нет свободных мест на тренировку")
+		if !changed {
+			return errors.New("студент уже записан на эту тренировку")
+		}
+	} else {
+		attendance := &models.Attendance{
+			TrainingID: trainingID,
+			StudentID:  studentID,
+			RecordedAt: time.Now(),
+		}
+		if err := s.attendanceRepo.CreateAttendance(attendance); err != nil {
+			return err
 		}
 	}
 
-	attendance := &models.Attendance{
-		TrainingID: trainingID,
-		StudentID:  studentID,
-		Attended:   false,
-		RecordedAt: time.Now(),
+	// Записался — значит, больше не ждёт места
+	entry, err := s.waitlistRepo.GetActive(trainingID, studentID)
+	if err != nil {
+		return fmt.Errorf("ошибка получения листа ожидания: %w", err)
 	}
+	if entry != nil {
+		if _, err := s.waitlistRepo.Close(entry.ID, models.WaitlistStatusAccepted); err != nil {
+			return fmt.Errorf("ошибка обновления листа ожидания: %w", err)
+		}
+	}
+	return nil
+}
 
-	return s.attendanceRepo.CreateAttendance(attendance)
+// checkSubscriptionCovers отказывает в записи, если абонемент ученика не распространяется
+// на тренировку. Ученика без активного абонемента не останавливаем: это проверяют
+// вызывающие, а тренер может записать ученика и без абонемента
+func (s *attendanceService) checkSubscriptionCovers(studentID int, training *models.TrainingSchedule) error {
+	_, err := s.subscriptionRepo.GetActiveForTraining(int64(studentID), training.ID)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("ошибка получения абонемента: %w", err)
+	}
+
+	err = uncoveredError(s.subscriptionRepo, studentID, training)
+	if errors.Is(err, service.ErrSubscriptionNotCovered) {
+		return err
+	}
+	return nil
 }
 
-// Отмена записи
+// uncoveredError объясняет, почему у ученика нет абонемента для тренировки:
+// активного абонемента нет вовсе или его ограничения не допускают тренировку
+func uncoveredError(subscriptions repository.SubscriptionRepository, studentID int, training *models.TrainingSchedule) error {
+	active, err := subscriptions.GetActiveByStudentID(int64(studentID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("нет активного абонемента для ученика с ID %d", studentID)
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка получения абонемента: %w", err)
+	}
+
+	reason := errors.New("его ограничения не допускают тренировку")
+	if training != nil {
+		if err := active.CheckTraining(*training); err != nil {
+			reason = err
+		}
+	}
+	return fmt.Errorf("%w: %v", service.ErrSubscriptionNotCovered, reason)
+}
+
+// Отмена записи. Отмена позже срока из правил клуба считается поздней:
+// она либо запрещена, либо списывает занятие с абонемента
 func (s *attendanceService) CancelSignUp(studentID, trainingID int) error {
 	attendance, err := s.attendanceRepo.GetStudentAttendanceForTraining(studentID, trainingID)
 	if err != nil {
 		return err
 	}
-	if attendance == nil {
+	if attendance == nil || !attendance.HoldsSpot() {
 		return errors.New("студент не записан на эту тренировку")
 	}
 
-	return s.attendanceRepo.DeleteAttendance(attendance.ID)
-}
-
-// Для тренеров - отметка посещения
-func (s *attendanceService) MarkAttendance(trainingID, studentID, recordedBy int, attended bool, notes string) error {
-	attendance, err := s.attendanceRepo.GetStudentAttendanceForTraining(studentID, trainingID)
+	training, err := s.scheduleRepo.GetTrainingByID(trainingID)
 	if err != nil {
-		return fmt.Errorf("ошибка получения записи посещаемости: %w", err)
+		return err
 	}
-	if attendance == nil {
-		return errors.New("студент не записан на эту тренировку")
+	if training != nil && training.IsCancelled() {
+		return errors.New("тренировка отменена")
 	}
+	if attendance.Status != models.AttendanceStatusRegistered {
+		return fmt.Errorf("%w: посещение уже отмечено (%s)", service.ErrInvalidAttendanceTransition,
+			models.AttendanceStatusName(attendance.Status))
+	}
+
+	status := models.AttendanceStatusCancelled
+	comment := ""
+	if training != nil {
+		start := trainingStart(training)
+		if s.cancellation.IsLate(start, time.Now()) {
+			if s.cancellation.Late == models.LateCancelBlock {
+				return fmt.Errorf("%w: отменить запись можно было до %s", service.ErrLateCancellation,
+					s.cancellation.DeadlineFor(start).Format("02.01.2006 15:04"))
+			}
+			status = models.AttendanceStatusLateCancelled
+		}
+		comment = trainingTitle(training)
+	}
+
+	err = s.transactor.WithinTransaction(func(tx repository.TxRepositories) error {
+		charge := false
+		if status == models.AttendanceStatusLateCancelled {
+			// Ученика без подходящего абонемента отменяем без списания: списывать не с чего
+			_, err := tx.Subscriptions.GetActiveForTrainingForUpdate(int64(studentID), trainingID)
+			switch {
+			case err == nil:
+				charge = true
+			case !errors.Is(err, sql.ErrNoRows):
+				return fmt.Errorf("ошибка получения абонемента: %w", err)
+			}
+		}
+
+		changed, err := tx.Attendance.ChangeStatus(attendance.ID, attendance.Status, status, nil, "")
+		if err != nil {
+			return err
+		}
+		if !changed {
+			return errors.New("запись уже изменена, обновите данные")
+		}
 
-	// Проверяем, что посещаемость еще не была отмечена
-	if attended && (attendance.Status == "attended" || attendance.Attended) {
-		return fmt.Errorf("посещаемость уже была отмечена для этого ученика")
+		if charge {
+			_, err := chargeLesson(tx, studentID, trainingID, attendance.ID, models.LedgerReasonLateCancel, nil, comment)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if status == models.AttendanceStatusLateCancelled {
+		log.Printf("⏰ Поздняя отмена: ученик %d, тренировка %d", studentID, trainingID)
 	}
 
-	// Сохраняем старое значение для проверки необходимости списания абонемента
-	oldAttended := attendance.Attended
-	needsSubscriptionDeduction := attended && !oldAttended
+	// Освободившееся место сразу предлагаем следующему в листе ожидания
+	s.offerFreeSpots(trainingID)
+	return nil
+}
+
+func (s *attendanceService) CancellationPolicy() models.CancellationPolicy {
+	return s.cancellation
+}
 
-	// Обновляем поля посещаемости
-	attendance.Attended = attended
+// Для тренеров - отметка посещения: attended — пришёл, иначе отметка снимается
+func (s *attendanceService) MarkAttendance(trainingID, studentID, recordedBy int, attended bool, notes string) error {
+	status := models.AttendanceStatusRegistered
 	if attended {
-		attendance.Status = "attended"
+		status = models.AttendanceStatusAttended
 	}
-	attendance.Notes = notes
-	attendance.RecordedBy = &recordedBy
-	attendance.RecordedAt = time.Now()
+	return s.SetAttendanceStatus(trainingID, studentID, recordedBy, status, notes)
+}
 
-	// Логирование для отладки
-	fmt.Printf("[MarkAttendance] Обновление посещаемости: trainingID=%d, studentID=%d, attended=%v (было %v), attendance.ID=%d\n",
-		trainingID, studentID, attended, oldAttended, attendance.ID)
-	fmt.Printf("[MarkAttendance] attendance объект перед UpdateAttendance: ID=%d, Attended=%v, Notes=%s, RecordedBy=%v\n",
-		attendance.ID, attendance.Attended, attendance.Notes, attendance.RecordedBy)
+// SetAttendanceStatus переводит запись ученика в статус отметки.
+// Переход и списание занятия с абонемента выполняются в одной транзакции:
+// если списать не удалось, отметка тоже откатывается.
+// Уход из attended возвращает списанное занятие и сообщает об этом ученику.
+func (s *attendanceService) SetAttendanceStatus(trainingID, studentID, recordedBy int, status, notes string) error {
+	switch status {
+	case models.AttendanceStatusAttended, models.AttendanceStatusNoShow, models.AttendanceStatusRegistered:
+	default:
+		return fmt.Errorf("%w: тренер не может выставить статус «%s»", service.ErrInvalidAttendanceTransition,
+			models.AttendanceStatusName(status))
+	}
 
-	// ВАЖНО: Сначала обновляем attendance в БД
-	err = s.attendanceRepo.UpdateAttendance(attendance)
+	training, err := s.scheduleRepo.GetTrainingByID(trainingID)
 	if err != nil {
-		fmt.Printf("[MarkAttendance] ОШИБКА при обновлении в БД: %v\n", err)
-		return fmt.Errorf("ошибка обновления посещаемости в БД: %w", err)
+		return fmt.Errorf("ошибка получения тренировки: %w", err)
+	}
+	comment := ""
+	if training != nil {
+		if training.IsCancelled() {
+			return errors.New("тренировка отменена")
+		}
+		if status == models.AttendanceStatusNoShow && time.Now().Before(trainingStart(training)) {
+			return errors.New("тренировка ещё не началась")
+		}
+		comment = trainingTitle(training)
+	}
+	var actorID *int64
+	if recordedBy != 0 {
+		id := int64(recordedBy)
+		actorID = &id
 	}
 
-	fmt.Printf("[MarkAttendance] Посещаемость успешно обновлена для studentID=%d, attended=%v\n", studentID, attended)
+	attended := status == models.AttendanceStatusAttended
+	refunded := 0
+	var chargedSubscriptionID int64
+	err = s.transactor.WithinTransaction(func(tx repository.TxRepositories) error {
+		if attended {
+			// Блокируем абонемент до конца транзакции: параллельные отметки одного ученика
+			// выполняются по очереди и видят результат друг друга
+			_, err := tx.Subscriptions.GetActiveForTrainingForUpdate(int64(studentID), trainingID)
+			if errors.Is(err, sql.ErrNoRows) {
+				return uncoveredError(tx.Subscriptions, studentID, training)
+			}
+			if err != nil {
+				return fmt.Errorf("ошибка получения абонемента: %w", err)
+			}
+		}
 
-	// Только после успешного обновления списываем абонемент
-	if needsSubscriptionDeduction {
-		err := s.subscriptionService.DecrementRemainingLessons(int64(studentID))
+		attendance, err := tx.Attendance.GetStudentAttendanceForTraining(studentID, trainingID)
 		if err != nil {
-			// Логируем ошибку, но не откатываем обновление attendance
-			// Это не критично для отметки посещаемости
-			fmt.Printf("[MarkAttendance] Предупреждение: не удалось списать абонемент для studentID=%d: %v\n", studentID, err)
-		} else {
-			fmt.Printf("[MarkAttendance] Абонемент успешно списан для studentID=%d\n", studentID)
+			return fmt.Errorf("ошибка получения записи посещаемости: %w", err)
+		}
+		if attendance == nil || !attendance.HoldsSpot() {
+			return errors.New("студент не записан на эту тренировку")
+		}
+
+		// Проверяем, что посещаемость еще не была отмечена
+		if attended && attendance.Status == models.AttendanceStatusAttended {
+			return fmt.Errorf("посещаемость уже была отмечена для этого ученика")
 		}
+		if attendance.Status == status {
+			return nil
+		}
+		if !models.CanTransitionAttendance(attendance.Status, status) {
+			return fmt.Errorf("%w: «%s» → «%s»", service.ErrInvalidAttendanceTransition,
+				models.AttendanceStatusName(attendance.Status), models.AttendanceStatusName(status))
+		}
+
+		changed, err := tx.Attendance.ChangeStatus(attendance.ID, attendance.Status, status, actorID, notes)
+		if err != nil {
+			return fmt.Errorf("ошибка обновления посещаемости в БД: %w", err)
+		}
+		if !changed {
+			return errors.New("запись уже изменена, обновите данные")
+		}
+		log.Printf("📝 Запись %d (тренировка %d, ученик %d): %s → %s", attendance.ID, trainingID, studentID, attendance.Status, status)
+
+		if attended {
+			chargedSubscriptionID, err = chargeLesson(tx, studentID, trainingID, attendance.ID, models.LedgerReasonAttended, actorID, comment)
+			if err != nil {
+				return err
+			}
+		}
+
+		if attendance.Status == models.AttendanceStatusAttended {
+			refunded, err = refundLessons(tx, attendance.ID, trainingID, actorID, comment)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
+	if refunded > 0 && training != nil {
+		s.notifyAttendanceReverted(training, studentID, refunded)
+	}
+	if chargedSubscriptionID != 0 && training != nil {
+		s.notifyAttendanceMarked(training, studentID, chargedSubscriptionID)
+	}
 	return nil
 }
 
+// notifyAttendanceMarked сообщает ученику о списанном занятии и остатке на абонементе.
+// Ошибки только логируются: посещение уже отмечено
+func (s *attendanceService) notifyAttendanceMarked(training *models.TrainingSchedule, studentID int, subscriptionID int64) {
+	telegramID := s.participantTelegramID(training.ID, studentID)
+	if telegramID == 0 {
+		return
+	}
+	// Остаток показываем по абонементу, с которого списано занятие: у ученика их может быть несколько
+	subscription, err := s.subscriptionRepo.GetByID(subscriptionID)
+	if err != nil {
+		log.Printf("❌ Отметка посещения: не удалось получить абонемент %d: %v", subscriptionID, err)
+		return
+	}
+	if err := s.trainingNotifier.AttendanceMarked(telegramID, training, subscription.RemainingLessons); err != nil {
+		log.Printf("❌ Отметка посещения: не удалось уведомить ученика %d: %v", studentID, err)
+	}
+}
+
+// notifyAttendanceReverted сообщает ученику о вернувшемся занятии. Ошибки только логируются:
+// отметка уже снята и занятие возвращено
+func (s *attendanceService) notifyAttendanceReverted(training *models.TrainingSchedule, studentID, refunded int) {
+	telegramID := s.participantTelegramID(training.ID, studentID)
+	if telegramID == 0 {
+		return
+	}
+	if err := s.trainingNotifier.AttendanceReverted(telegramID, training, refunded); err != nil {
+		log.Printf("❌ Возврат занятия: не удалось уведомить ученика %d: %v", studentID, err)
+	}
+}
+
+// participantTelegramID Telegram ID записанного на тренировку ученика; 0, если писать некому
+func (s *attendanceService) participantTelegramID(trainingID, studentID int) int64 {
+	participants, err := s.attendanceRepo.GetParticipants(trainingID)
+	if err != nil {
+		log.Printf("❌ Не удалось получить участников тренировки %d: %v", trainingID, err)
+		return 0
+	}
+	for _, p := range participants {
+		if p.StudentID == studentID {
+			return p.Student.User.TelegramID
+		}
+	}
+	return 0
+}
+
+// chargeLesson списывает занятие за запись attendanceID с абонемента, покрывающего тренировку,
+// и возвращает ID этого абонемента
+func chargeLesson(tx repository.TxRepositories, studentID, trainingID, attendanceID int, reason string, actorID *int64, comment string) (int64, error) {
+	subscriptionID, err := tx.Subscriptions.DecrementRemainingLessons(int64(studentID), trainingID)
+	if err != nil {
+		return 0, fmt.Errorf("не удалось списать занятие с абонемента: %w", err)
+	}
+	err = tx.Ledger.Add(&models.LessonLedgerEntry{
+		SubscriptionID: subscriptionID,
+		Delta:          -1,
+		Reason:         reason,
+		AttendanceID:   &attendanceID,
+		TrainingID:     &trainingID,
+		ActorID:        actorID,
+		Comment:        comment,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("ошибка записи в журнал занятий: %w", err)
+	}
+	return subscriptionID, nil
+}
+
+// refundLessons возвращает на абонементы занятия, списанные за запись attendanceID,
+// и сообщает, сколько занятий вернулось
+func refundLessons(tx repository.TxRepositories, attendanceID, trainingID int, actorID *int64, comment string) (int, error) {
+	entries, err := tx.Ledger.GetByAttendanceID(attendanceID)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка чтения журнала занятий: %w", err)
+	}
+
+	charged := make(map[int64]int)
+	for _, entry := range entries {
+		charged[entry.SubscriptionID] -= entry.Delta
+	}
+
+	refunded := 0
+	for subscriptionID, lessons := range charged {
+		if lessons <= 0 {
+			continue
+		}
+		if err := tx.Subscriptions.AddRemainingLessons(subscriptionID, lessons); err != nil {
+			return 0, fmt.Errorf("не удалось вернуть занятие на абонемент: %w", err)
+		}
+		err := tx.Ledger.Add(&models.LessonLedgerEntry{
+			SubscriptionID: subscriptionID,
+			Delta:          lessons,
+			Reason:         models.LedgerReasonRefund,
+			AttendanceID:   &attendanceID,
+			TrainingID:     &trainingID,
+			ActorID:        actorID,
+			Comment:        comment,
+		})
+		if err != nil {
+			return 0, fmt.Errorf("ошибка записи в журнал занятий: %w", err)
+		}
+		refunded += lessons
+	}
+	return refunded, nil
+}
+
 // Просмотр записавшихся
 func (s *attendanceService) GetTrainingAttendees(trainingID int) ([]models.Attendance, error) {
 	return s.attendanceRepo.GetAttendanceByTraining(trainingID)
@@ -160,7 +491,7 @@ func (s *attendanceService) GetStudentSchedule(studentID int, start, end time.Ti
 func (s *attendanceService) CreateAttendance(attendance models.Attendance) error {
 	// Проверяем, не записан ли уже студент
 	existing, err := s.GetStudentAttendanceForTraining(attendance.StudentID, attendance.TrainingID)
-	if err == nil && existing != nil && existing.Status == "registered" {
+	if err == nil && existing != nil && existing.Status == models.AttendanceStatusRegistered {
 		return errors.New("student already registered for this training")
 	}
 
@@ -168,5 +499,5 @@ func (s *attendanceService) CreateAttendance(attendance models.Attendance) error
 }
 
 func (s *attendanceService) CancelAttendance(trainingID, studentID int) error {
-	return s.attendanceRepo.CancelAttendance(trainingID, studentID)
+	return s.CancelSignUp(studentID, trainingID)
 }
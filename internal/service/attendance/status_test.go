@@ -0,0 +1,223 @@
+package attendance_service
+
+import (
+	"errors"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/refund"
+	"spectrum-club-bot/internal/repository/memory"
+	"spectrum-club-bot/internal/service"
+	"spectrum-club-bot/internal/waitlist"
+	"testing"
+	"time"
+)
+
+const markedBy = 7 // users.id тренера, отмечающего посещение
+
+// attendanceFixture сервис посещаемости поверх in-memory хранилища: тренировка, начинающаяся
+// в start, и записанный на неё ученик (telegram_id 100) с абонементом на 8 занятий
+type attendanceFixture struct {
+	store          *memory.Store
+	training       models.TrainingSchedule
+	studentID      int
+	subscriptionID int64
+	svc            service.AttendanceService
+}
+
+func newAttendanceFixture(t *testing.T, start time.Time) *attendanceFixture {
+	t.Helper()
+	store := memory.NewStore()
+	order := models.ConsumptionExpiringFirst
+	group := store.AddGroup(models.TrainingGroup{Name: "Взрослые", Code: "adults"})
+
+	training := models.TrainingSchedule{
+		GroupID:      group.ID,
+		TrainingDate: start,
+		StartTime:    time.Date(0, 1, 1, start.Hour(), start.Minute(), 0, 0, time.UTC),
+		EndTime:      time.Date(0, 1, 1, start.Hour(), start.Minute(), 0, 0, time.UTC).Add(90 * time.Minute),
+	}
+	schedule := memory.NewTrainingScheduleRepository(store)
+	if err := schedule.CreateTraining(&training); err != nil {
+		t.Fatal(err)
+	}
+
+	user := &models.User{TelegramID: 100, FirstName: "Анна", LastName: "Тестова", Role: "student"}
+	if err := memory.NewUserRepository(store).CreateOrUpdate(user); err != nil {
+		t.Fatal(err)
+	}
+	student := &models.Student{UserID: user.ID}
+	if err := memory.NewStudentRepository(store).Create(student); err != nil {
+		t.Fatal(err)
+	}
+	subscriptions := memory.NewSubscriptionRepository(store, order)
+	subscription := &models.Subscription{
+		StudentID:        student.ID,
+		StartDate:        start.AddDate(0, 0, -7),
+		EndDate:          start.AddDate(0, 1, 0),
+		TotalLessons:     8,
+		RemainingLessons: 8,
+		CreatedAt:        start.AddDate(0, 0, -7),
+	}
+	if err := subscriptions.Create(subscription); err != nil {
+		t.Fatal(err)
+	}
+
+	attendance := memory.NewAttendanceRepository(store)
+	if err := attendance.CreateAttendance(&models.Attendance{TrainingID: training.ID, StudentID: int(student.ID)}); err != nil {
+		t.Fatal(err)
+	}
+
+	var sent sentMessages
+	svc := NewAttendanceService(
+		attendance,
+		schedule,
+		subscriptions,
+		memory.NewWaitlistRepository(store),
+		memory.NewTransactor(store, order),
+		waitlist.NewOfferNotifier(&sent),
+		refund.NewNotifier(&sent),
+		time.Hour,
+		models.CancellationPolicy{},
+	)
+	return &attendanceFixture{
+		store:          store,
+		training:       training,
+		studentID:      int(student.ID),
+		subscriptionID: subscription.ID,
+		svc:            svc,
+	}
+}
+
+// balance остаток занятий на абонементе ученика
+func (f *attendanceFixture) balance(t *testing.T) int {
+	t.Helper()
+	subscription, err := memory.NewSubscriptionRepository(f.store, models.ConsumptionExpiringFirst).GetByID(f.subscriptionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return subscription.RemainingLessons
+}
+
+// ledger движения занятий по абонементу ученика, новые сначала
+func (f *attendanceFixture) ledger(t *testing.T) []models.LessonLedgerEntry {
+	t.Helper()
+	entries, err := memory.NewLessonLedgerRepository(f.store).GetBySubscriptionID(f.subscriptionID, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return entries
+}
+
+// attendance запись ученика на тренировку и история её статусов
+func (f *attendanceFixture) attendance(t *testing.T) (*models.Attendance, []models.AttendanceTransition) {
+	t.Helper()
+	repo := memory.NewAttendanceRepository(f.store)
+	record, err := repo.GetStudentAttendanceForTraining(f.studentID, f.training.ID)
+	if err != nil || record == nil {
+		t.Fatalf("запись ученика: %+v, %v", record, err)
+	}
+	history, err := repo.GetStatusHistory(record.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return record, history
+}
+
+func TestSetAttendanceStatusMovesLessons(t *testing.T) {
+	f := newAttendanceFixture(t, time.Now().Add(-time.Hour))
+
+	steps := []struct {
+		status      string
+		wantBalance int
+		wantReason  string // причина последней записи журнала
+	}{
+		{status: models.AttendanceStatusAttended, wantBalance: 7, wantReason: models.LedgerReasonAttended},
+		{status: models.AttendanceStatusNoShow, wantBalance: 8, wantReason: models.LedgerReasonRefund},
+		{status: models.AttendanceStatusAttended, wantBalance: 7, wantReason: models.LedgerReasonAttended},
+		{status: models.AttendanceStatusRegistered, wantBalance: 8, wantReason: models.LedgerReasonRefund},
+	}
+	for i, step := range steps {
+		if err := f.svc.SetAttendanceStatus(f.training.ID, f.studentID, markedBy, step.status, ""); err != nil {
+			t.Fatalf("шаг %d, %s: %v", i, step.status, err)
+		}
+		if got := f.balance(t); got != step.wantBalance {
+			t.Errorf("шаг %d, %s: остаток = %d, want %d", i, step.status, got, step.wantBalance)
+		}
+
+		entries := f.ledger(t)
+		if len(entries) != i+1 {
+			t.Fatalf("шаг %d, %s: записей в журнале = %d, want %d", i, step.status, len(entries), i+1)
+		}
+		if last := entries[0]; last.Reason != step.wantReason || last.ActorID == nil || *last.ActorID != markedBy {
+			t.Errorf("шаг %d, %s: запись журнала = %+v", i, step.status, last)
+		}
+
+		record, history := f.attendance(t)
+		if record.Status != step.status {
+			t.Errorf("шаг %d: статус = %s, want %s", i, record.Status, step.status)
+		}
+		// Первая строка истории — создание записи
+		if len(history) != i+2 || history[i+1].ToStatus != step.status {
+			t.Errorf("шаг %d, %s: история статусов = %+v", i, step.status, history)
+		}
+	}
+}
+
+func TestSetAttendanceStatusRejectsInvalidTransitions(t *testing.T) {
+	tests := []struct {
+		name    string
+		current string // статус, который тренер выставил до попытки; пусто — запись как есть
+		status  string
+	}{
+		{name: "записан → отменено", status: models.AttendanceStatusCancelled},
+		{name: "записан → поздняя отмена", status: models.AttendanceStatusLateCancelled},
+		{name: "пришёл → поздняя отмена", current: models.AttendanceStatusAttended, status: models.AttendanceStatusLateCancelled},
+		{name: "не пришёл → отменено", current: models.AttendanceStatusNoShow, status: models.AttendanceStatusCancelled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newAttendanceFixture(t, time.Now().Add(-time.Hour))
+			if tt.current != "" {
+				if err := f.svc.SetAttendanceStatus(f.training.ID, f.studentID, markedBy, tt.current, ""); err != nil {
+					t.Fatal(err)
+				}
+			}
+			balance, entries := f.balance(t), len(f.ledger(t))
+			_, history := f.attendance(t)
+
+			err := f.svc.SetAttendanceStatus(f.training.ID, f.studentID, markedBy, tt.status, "")
+			if !errors.Is(err, service.ErrInvalidAttendanceTransition) {
+				t.Fatalf("SetAttendanceStatus() error = %v, want ErrInvalidAttendanceTransition", err)
+			}
+
+			if got := f.balance(t); got != balance {
+				t.Errorf("остаток = %d, want %d", got, balance)
+			}
+			if got := len(f.ledger(t)); got != entries {
+				t.Errorf("записей в журнале = %d, want %d", got, entries)
+			}
+			record, after := f.attendance(t)
+			if len(after) != len(history) {
+				t.Errorf("история статусов = %+v, want без изменений %+v", after, history)
+			}
+			want := tt.current
+			if want == "" {
+				want = models.AttendanceStatusRegistered
+			}
+			if record.Status != want {
+				t.Errorf("статус = %s, want %s", record.Status, want)
+			}
+		})
+	}
+}
+
+func TestSetAttendanceStatusNoShowBeforeStart(t *testing.T) {
+	f := newAttendanceFixture(t, time.Now().Add(2*time.Hour))
+
+	if err := f.svc.SetAttendanceStatus(f.training.ID, f.studentID, markedBy, models.AttendanceStatusNoShow, ""); err == nil {
+		t.Fatal("SetAttendanceStatus(no_show) до начала тренировки: error = nil")
+	}
+	if record, _ := f.attendance(t); record.Status != models.AttendanceStatusRegistered {
+		t.Errorf("статус = %s, want %s", record.Status, models.AttendanceStatusRegistered)
+	}
+}
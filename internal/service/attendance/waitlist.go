@@ -0,0 +1,210 @@
+package attendance_service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"spectrum-club-bot/internal/models"
+	"time"
+)
+
+// Встать в лист ожидания на заполненную тренировку
+func (s *attendanceService) JoinWaitlist(studentID, trainingID int) (*models.WaitlistEntry, error) {
+	training, err := s.scheduleRepo.GetTrainingByID(trainingID)
+	if err != nil {
+		return nil, err
+	}
+	if training == nil {
+		return nil, errors.New("тренировка не найдена")
+	}
+	if training.IsCancelled() {
+		return nil, errors.New("тренировка отменена")
+	}
+	if !trainingStart(training).After(time.Now()) {
+		return nil, errors.New("тренировка уже началась")
+	}
+	if err := s.checkSubscriptionCovers(studentID, training); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.attendanceRepo.GetStudentAttendanceForTraining(studentID, trainingID)
+	if err != nil {
+		return nil, err
+	}
+	// Отменённая заранее запись не мешает встать в очередь: при записи из очереди
+	// SignUpForTraining восстановит её
+	if existing != nil {
+		switch {
+		case existing.Status == models.AttendanceStatusLateCancelled:
+			return nil, errors.New("запись на эту тренировку отменена слишком поздно, записаться снова нельзя")
+		case existing.HoldsSpot():
+			return nil, errors.New("студент уже записан на эту тренировку")
+		}
+	}
+
+	// Повторное нажатие "встать в очередь" не двигает ученика в конец
+	entry, err := s.waitlistRepo.GetActive(trainingID, studentID)
+	if err != nil {
+		return nil, err
+	}
+	if entry != nil {
+		return entry, nil
+	}
+
+	free, limited, err := s.freeSpots(training, studentID)
+	if err != nil {
+		return nil, err
+	}
+	if !limited || free > 0 {
+		return nil, errors.New("на тренировке есть свободные места, можно записаться")
+	}
+
+	if err := s.waitlistRepo.Add(&models.WaitlistEntry{TrainingID: trainingID, StudentID: studentID}); err != nil {
+		return nil, fmt.Errorf("ошибка добавления в лист ожидания: %w", err)
+	}
+	return s.waitlistRepo.GetActive(trainingID, studentID)
+}
+
+// Выход из листа ожидания или отказ от предложенного места
+func (s *attendanceService) LeaveWaitlist(studentID, trainingID int) error {
+	entry, err := s.waitlistRepo.GetActive(trainingID, studentID)
+	if err != nil {
+		return err
+	}
+	if entry == nil {
+		return errors.New("студент не стоит в листе ожидания")
+	}
+
+	status := models.WaitlistStatusLeft
+	if entry.Status == models.WaitlistStatusOffered {
+		status = models.WaitlistStatusDeclined
+	}
+	if _, err := s.waitlistRepo.Close(entry.ID, status); err != nil {
+		return err
+	}
+
+	// Отказ от предложения освобождает место для следующего
+	if status == models.WaitlistStatusDeclined {
+		s.offerFreeSpots(trainingID)
+	}
+	return nil
+}
+
+func (s *attendanceService) GetWaitlistEntry(studentID, trainingID int) (*models.WaitlistEntry, error) {
+	return s.waitlistRepo.GetActive(trainingID, studentID)
+}
+
+func (s *attendanceService) GetStudentWaitlist(studentID int) ([]models.WaitlistEntry, error) {
+	return s.waitlistRepo.GetActiveByStudent(studentID)
+}
+
+// Закрытие просроченных предложений: место переходит следующему в очереди
+func (s *attendanceService) ExpireWaitlistOffers() error {
+	expired, err := s.waitlistRepo.ExpireOffers()
+	if err != nil {
+		return fmt.Errorf("ошибка закрытия просроченных предложений: %w", err)
+	}
+
+	trainingIDs := make(map[int]bool)
+	for _, entry := range expired {
+		training, err := s.scheduleRepo.GetTrainingByID(entry.TrainingID)
+		if err != nil || training == nil {
+			continue
+		}
+		trainingIDs[entry.TrainingID] = true
+
+		if err := s.waitlistNotifier.OfferExpired(entry, training); err != nil {
+			log.Printf("❌ Лист ожидания: не удалось сообщить об истёкшем предложении (запись %d): %v", entry.ID, err)
+		}
+	}
+
+	for trainingID := range trainingIDs {
+		s.offerFreeSpots(trainingID)
+	}
+	return nil
+}
+
+// offerFreeSpots предлагает свободные места тренировки ученикам из очереди.
+// Ошибки только логируются: отмена записи, которая к этому привела, уже выполнена.
+func (s *attendanceService) offerFreeSpots(trainingID int) {
+	training, err := s.scheduleRepo.GetTrainingByID(trainingID)
+	if err != nil || training == nil {
+		log.Printf("❌ Лист ожидания: не удалось получить тренировку %d: %v", trainingID, err)
+		return
+	}
+	if training.IsCancelled() {
+		return
+	}
+
+	// Предложение не переживает начало тренировки
+	untilStart := time.Until(trainingStart(training))
+	if untilStart <= 0 {
+		return
+	}
+	ttl := min(s.offerTTL, untilStart)
+
+	free, limited, err := s.freeSpots(training, 0)
+	if err != nil {
+		log.Printf("❌ Лист ожидания: ошибка подсчёта мест на тренировку %d: %v", trainingID, err)
+		return
+	}
+
+	for !limited || free > 0 {
+		entry, err := s.waitlistRepo.OfferNext(trainingID, ttl)
+		if err != nil {
+			log.Printf("❌ Лист ожидания: ошибка предложения места на тренировку %d: %v", trainingID, err)
+			return
+		}
+		if entry == nil {
+			return
+		}
+		free--
+
+		if err := s.waitlistNotifier.SpotOffered(*entry, training, time.Now().Add(ttl)); err != nil {
+			// Предложение остаётся в силе: если ученик его не увидит, оно истечёт и перейдёт дальше
+			log.Printf("❌ Лист ожидания: не удалось отправить предложение (запись %d): %v", entry.ID, err)
+		}
+	}
+}
+
+// Свободные места на тренировке для ученика studentID; 0 — ученик неизвестен
+func (s *attendanceService) FreeSpots(studentID, trainingID int) (int, bool, error) {
+	training, err := s.scheduleRepo.GetTrainingByID(trainingID)
+	if err != nil {
+		return 0, false, err
+	}
+	if training == nil {
+		return 0, false, errors.New("тренировка не найдена")
+	}
+	return s.freeSpots(training, studentID)
+}
+
+// freeSpots число мест, которые может занять ученик studentID: лимит минус записавшиеся
+// и минус действующие предложения другим ученикам. limited = false, если лимита нет.
+func (s *attendanceService) freeSpots(training *models.TrainingSchedule, studentID int) (free int, limited bool, err error) {
+	if training.MaxParticipants == nil {
+		return 0, false, nil
+	}
+
+	participants, err := s.scheduleRepo.GetTrainingParticipantsCount(training.ID)
+	if err != nil {
+		return 0, true, err
+	}
+	offers, err := s.waitlistRepo.CountOffers(training.ID, studentID)
+	if err != nil {
+		return 0, true, err
+	}
+	return *training.MaxParticipants - participants - offers, true, nil
+}
+
+// trainingTitle подпись тренировки для журнала занятий: группа, дата и время
+func trainingTitle(training *models.TrainingSchedule) string {
+	return fmt.Sprintf("%s, %s", training.GroupName, trainingStart(training).Format("02.01.2006 15:04"))
+}
+
+// trainingStart момент начала тренировки: DATE и TIME из БД хранят время клуба без зоны
+func trainingStart(training *models.TrainingSchedule) time.Time {
+	date := training.TrainingDate
+	clock := training.StartTime
+	return time.Date(date.Year(), date.Month(), date.Day(), clock.Hour(), clock.Minute(), 0, 0, time.Local)
+}
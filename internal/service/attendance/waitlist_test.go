@@ -0,0 +1,181 @@
+package attendance_service
+
+import (
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/refund"
+	"spectrum-club-bot/internal/repository/memory"
+	"spectrum-club-bot/internal/service"
+	"spectrum-club-bot/internal/waitlist"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitlistFixture сервис посещаемости поверх in-memory хранилища: тренировка через два дня
+// в группе с "_" в названии, на которую помещается maxParticipants учеников,
+// и ученики с telegram_id 100, 101, ...
+type waitlistFixture struct {
+	store    *memory.Store
+	training models.TrainingSchedule
+	students []int
+	svc      service.AttendanceService
+	sent     *sentMessages
+}
+
+func newWaitlistFixture(t *testing.T, students, maxParticipants int, offerTTL time.Duration) *waitlistFixture {
+	t.Helper()
+	store := memory.NewStore()
+	order := models.ConsumptionExpiringFirst
+	group := store.AddGroup(models.TrainingGroup{Name: "Взрослые_вечер", Code: "adults"})
+
+	training := models.TrainingSchedule{
+		GroupID:         group.ID,
+		TrainingDate:    time.Now().AddDate(0, 0, 2),
+		StartTime:       time.Date(0, 1, 1, 19, 0, 0, 0, time.UTC),
+		EndTime:         time.Date(0, 1, 1, 20, 30, 0, 0, time.UTC),
+		MaxParticipants: &maxParticipants,
+	}
+	schedule := memory.NewTrainingScheduleRepository(store)
+	if err := schedule.CreateTraining(&training); err != nil {
+		t.Fatal(err)
+	}
+
+	f := &waitlistFixture{store: store, training: training, sent: &sentMessages{}}
+	for i := 0; i < students; i++ {
+		user := &models.User{TelegramID: int64(100 + i), FirstName: "Ученик", LastName: "Тестовый", Role: "student"}
+		if err := memory.NewUserRepository(store).CreateOrUpdate(user); err != nil {
+			t.Fatal(err)
+		}
+		student := &models.Student{UserID: user.ID}
+		if err := memory.NewStudentRepository(store).Create(student); err != nil {
+			t.Fatal(err)
+		}
+		f.students = append(f.students, int(student.ID))
+	}
+
+	f.svc = NewAttendanceService(
+		memory.NewAttendanceRepository(store),
+		schedule,
+		memory.NewSubscriptionRepository(store, order),
+		memory.NewWaitlistRepository(store),
+		memory.NewTransactor(store, order),
+		waitlist.NewOfferNotifier(f.sent),
+		refund.NewNotifier(f.sent),
+		offerTTL,
+		models.CancellationPolicy{},
+	)
+	return f
+}
+
+// register записывает ученика на тренировку фикстуры в обход проверок абонемента
+func (f *waitlistFixture) register(t *testing.T, studentID int) {
+	t.Helper()
+	record := &models.Attendance{TrainingID: f.training.ID, StudentID: studentID}
+	if err := memory.NewAttendanceRepository(f.store).CreateAttendance(record); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// enqueue ставит ученика в лист ожидания тренировки фикстуры
+func (f *waitlistFixture) enqueue(t *testing.T, studentID int) {
+	t.Helper()
+	entry := &models.WaitlistEntry{TrainingID: f.training.ID, StudentID: studentID}
+	if err := memory.NewWaitlistRepository(f.store).Add(entry); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWaitlistOfferPromotionAndExpiry(t *testing.T) {
+	const ttl = 200 * time.Millisecond
+	f := newWaitlistFixture(t, 3, 1, ttl)
+	first, second, third := f.students[0], f.students[1], f.students[2]
+	f.register(t, first)
+	f.enqueue(t, second)
+	f.enqueue(t, third)
+
+	status := func(studentID int) string {
+		t.Helper()
+		entry, err := f.svc.GetWaitlistEntry(studentID, f.training.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entry == nil {
+			return ""
+		}
+		return entry.Status
+	}
+
+	// Отмена записи освобождает место — его предлагают первому в очереди
+	if err := f.svc.CancelSignUp(first, f.training.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := status(second); got != models.WaitlistStatusOffered {
+		t.Fatalf("статус первого в очереди = %q, want %q", got, models.WaitlistStatusOffered)
+	}
+	if got := status(third); got != models.WaitlistStatusWaiting {
+		t.Fatalf("статус второго в очереди = %q, want %q", got, models.WaitlistStatusWaiting)
+	}
+	if len(*f.sent) != 1 || (*f.sent)[0].ChatID != 101 {
+		t.Fatalf("сообщения после отмены = %+v, want предложение ученику 101", *f.sent)
+	}
+	if offer := (*f.sent)[0].Text; !strings.Contains(offer, `Взрослые\_вечер`) {
+		t.Errorf("название группы в предложении не экранировано: %q", offer)
+	}
+
+	// Пока предложение действует, место не переходит дальше
+	if err := f.svc.ExpireWaitlistOffers(); err != nil {
+		t.Fatal(err)
+	}
+	if got := status(third); got != models.WaitlistStatusWaiting {
+		t.Fatalf("до истечения предложения статус второго = %q, want %q", got, models.WaitlistStatusWaiting)
+	}
+
+	time.Sleep(2 * ttl)
+	if err := f.svc.ExpireWaitlistOffers(); err != nil {
+		t.Fatal(err)
+	}
+	if got := status(second); got != "" {
+		t.Errorf("просроченное предложение осталось активным: %q", got)
+	}
+	if got := status(third); got != models.WaitlistStatusOffered {
+		t.Fatalf("после истечения статус второго = %q, want %q", got, models.WaitlistStatusOffered)
+	}
+
+	var chats []int64
+	for _, message := range (*f.sent)[1:] {
+		chats = append(chats, message.ChatID)
+	}
+	if len(chats) != 2 || chats[0] != 101 || chats[1] != 102 {
+		t.Errorf("сообщения после истечения в чаты %v, want [101 102] (истекло, новое предложение)", chats)
+	}
+}
+
+func TestFreeSpotsCountsOffers(t *testing.T) {
+	f := newWaitlistFixture(t, 2, 1, time.Hour)
+	first, second := f.students[0], f.students[1]
+	f.register(t, first)
+	f.enqueue(t, second)
+	// Освободившееся место предложено второму: для остальных тренировка заполнена
+	if err := f.svc.CancelSignUp(first, f.training.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		studentID int
+		wantFree  int
+	}{
+		{name: "ученик с предложением", studentID: second, wantFree: 1},
+		{name: "другой ученик", studentID: first, wantFree: 0},
+		{name: "ученик неизвестен", studentID: 0, wantFree: 0},
+	}
+	for _, tt := range tests {
+		free, limited, err := f.svc.FreeSpots(tt.studentID, f.training.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !limited || free != tt.wantFree {
+			t.Errorf("%s: FreeSpots() = %d, %v, want %d, true", tt.name, free, limited, tt.wantFree)
+		}
+	}
+}
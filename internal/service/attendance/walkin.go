@@ -0,0 +1,129 @@
+package attendance_service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/repository"
+	"spectrum-club-bot/internal/service"
+	"strings"
+	"time"
+)
+
+// AddWalkIn отмечает ученика, который пришёл без записи. Лимит мест и лист ожидания
+// не проверяются: ученик уже на тренировке. Запись проходит обычный путь статусов
+// (создана → пришёл), так что в истории видно, кто и когда её добавил
+func (s *attendanceService) AddWalkIn(trainingID, studentID, recordedBy int, notes string) error {
+	training, err := s.scheduleRepo.GetTrainingByID(trainingID)
+	if err != nil {
+		return fmt.Errorf("ошибка получения тренировки: %w", err)
+	}
+	if training == nil {
+		return errors.New("тренировка не найдена")
+	}
+	if training.IsCancelled() {
+		return errors.New("тренировка отменена")
+	}
+	if time.Now().Before(trainingStart(training)) {
+		return errors.New("тренировка ещё не началась")
+	}
+
+	var actorID *int64
+	var recordedByID *int
+	if recordedBy != 0 {
+		id := int64(recordedBy)
+		actorID = &id
+		recordedByID = &recordedBy
+	}
+	note := models.AttendanceWalkInNote
+	if notes = strings.TrimSpace(notes); notes != "" {
+		note += ": " + notes
+	}
+	comment := trainingTitle(training) + " — " + strings.ToLower(models.AttendanceWalkInNote)
+
+	var chargedSubscriptionID int64
+	err = s.transactor.WithinTransaction(func(tx repository.TxRepositories) error {
+		_, err := tx.Subscriptions.GetActiveForTrainingForUpdate(int64(studentID), trainingID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return uncoveredError(tx.Subscriptions, studentID, training)
+		}
+		if err != nil {
+			return fmt.Errorf("ошибка получения абонемента: %w", err)
+		}
+
+		attendance, err := tx.Attendance.GetStudentAttendanceForTraining(studentID, trainingID)
+		if err != nil {
+			return fmt.Errorf("ошибка получения записи посещаемости: %w", err)
+		}
+
+		// Запись на тренировку у ученика одна: отменённую восстанавливаем, а не создаём заново
+		switch {
+		case attendance == nil:
+			attendance = &models.Attendance{
+				TrainingID: trainingID,
+				StudentID:  studentID,
+				Notes:      note,
+				RecordedBy: recordedByID,
+				RecordedAt: time.Now(),
+			}
+			if err := tx.Attendance.CreateAttendance(attendance); err != nil {
+				return fmt.Errorf("ошибка создания записи: %w", err)
+			}
+		case attendance.Status == models.AttendanceStatusAttended:
+			return errors.New("посещаемость уже была отмечена для этого ученика")
+		case attendance.Status == models.AttendanceStatusLateCancelled:
+			return errors.New("ученик поздно отменил запись на эту тренировку")
+		case attendance.Status == models.AttendanceStatusCancelled:
+			if err := changeAttendanceStatus(tx, attendance, models.AttendanceStatusRegistered, actorID, note); err != nil {
+				return err
+			}
+		case attendance.Status != models.AttendanceStatusRegistered:
+			// Ученик в списке участников (например, отмечен как не пришедший): отметка меняется там
+			return fmt.Errorf("%w: ученик уже в списке участников со статусом «%s»", service.ErrInvalidAttendanceTransition,
+				models.AttendanceStatusName(attendance.Status))
+		}
+
+		if err := changeAttendanceStatus(tx, attendance, models.AttendanceStatusAttended, actorID, note); err != nil {
+			return err
+		}
+		log.Printf("🚶 Ученик %d отмечен на тренировке %d без записи", studentID, trainingID)
+
+		chargedSubscriptionID, err = chargeLesson(tx, studentID, trainingID, attendance.ID, models.LedgerReasonAttended, actorID, comment)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// Пришёл — значит, больше не ждёт места
+	entry, err := s.waitlistRepo.GetActive(trainingID, studentID)
+	if err != nil {
+		log.Printf("❌ Не удалось получить лист ожидания тренировки %d: %v", trainingID, err)
+	} else if entry != nil {
+		if _, err := s.waitlistRepo.Close(entry.ID, models.WaitlistStatusAccepted); err != nil {
+			log.Printf("❌ Не удалось закрыть запись %d в листе ожидания: %v", entry.ID, err)
+		}
+	}
+
+	s.notifyAttendanceMarked(training, studentID, chargedSubscriptionID)
+	return nil
+}
+
+// changeAttendanceStatus переводит запись в статус to внутри транзакции, если такой переход допустим
+func changeAttendanceStatus(tx repository.TxRepositories, attendance *models.Attendance, to string, actorID *int64, notes string) error {
+	if !models.CanTransitionAttendance(attendance.Status, to) {
+		return fmt.Errorf("%w: «%s» → «%s»", service.ErrInvalidAttendanceTransition,
+			models.AttendanceStatusName(attendance.Status), models.AttendanceStatusName(to))
+	}
+	changed, err := tx.Attendance.ChangeStatus(attendance.ID, attendance.Status, to, actorID, notes)
+	if err != nil {
+		return fmt.Errorf("ошибка обновления посещаемости в БД: %w", err)
+	}
+	if !changed {
+		return errors.New("запись уже изменена, обновите данные")
+	}
+	attendance.Status = to
+	return nil
+}
@@ -0,0 +1,131 @@
+package attendance_service
+
+import (
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/repository/memory"
+	"strings"
+	"testing"
+	"time"
+)
+
+// walkIn переводит фикстуру на ученика, который пришёл без записи: тренировка заполнена
+// записавшимся ранее учеником, у нового ученика (telegram_id 101) абонемент на 8 занятий
+func (f *attendanceFixture) walkIn(t *testing.T) *attendanceFixture {
+	t.Helper()
+	full := 1
+	f.training.MaxParticipants = &full
+	if err := memory.NewTrainingScheduleRepository(f.store).UpdateTraining(&f.training); err != nil {
+		t.Fatal(err)
+	}
+
+	user := &models.User{TelegramID: 101, FirstName: "Борис", LastName: "Тестов", Role: "student"}
+	if err := memory.NewUserRepository(f.store).CreateOrUpdate(user); err != nil {
+		t.Fatal(err)
+	}
+	student := &models.Student{UserID: user.ID}
+	if err := memory.NewStudentRepository(f.store).Create(student); err != nil {
+		t.Fatal(err)
+	}
+	subscription := &models.Subscription{
+		StudentID:        student.ID,
+		StartDate:        time.Now().AddDate(0, 0, -7),
+		EndDate:          time.Now().AddDate(0, 1, 0),
+		TotalLessons:     8,
+		RemainingLessons: 8,
+	}
+	if err := memory.NewSubscriptionRepository(f.store, models.ConsumptionExpiringFirst).Create(subscription); err != nil {
+		t.Fatal(err)
+	}
+
+	f.studentID = int(student.ID)
+	f.subscriptionID = subscription.ID
+	return f
+}
+
+func TestAddWalkInBypassesCapacity(t *testing.T) {
+	f := newAttendanceFixture(t, time.Now().Add(-time.Hour)).walkIn(t)
+
+	if err := f.svc.AddWalkIn(f.training.ID, f.studentID, markedBy, "без телефона"); err != nil {
+		t.Fatalf("AddWalkIn() = %v", err)
+	}
+
+	record, history := f.attendance(t)
+	if record.Status != models.AttendanceStatusAttended {
+		t.Errorf("статус = %s, want %s", record.Status, models.AttendanceStatusAttended)
+	}
+	if !strings.HasPrefix(record.Notes, models.AttendanceWalkInNote) {
+		t.Errorf("пометка = %q, want %q", record.Notes, models.AttendanceWalkInNote)
+	}
+	if len(history) != 2 || history[0].FromStatus != nil || history[0].ToStatus != models.AttendanceStatusRegistered ||
+		history[1].ToStatus != models.AttendanceStatusAttended || history[1].ActorID == nil || *history[1].ActorID != markedBy {
+		t.Errorf("история статусов = %+v, want создана → пришёл от тренера %d", history, markedBy)
+	}
+
+	if got := f.balance(t); got != 7 {
+		t.Errorf("остаток = %d, want 7", got)
+	}
+	entries := f.ledger(t)
+	if len(entries) != 1 || entries[0].Reason != models.LedgerReasonAttended || entries[0].Delta != -1 ||
+		entries[0].AttendanceID == nil || *entries[0].AttendanceID != record.ID {
+		t.Errorf("журнал = %+v, want одно списание за эту запись", entries)
+	}
+
+	// Повторная отметка не списывает второе занятие
+	if err := f.svc.AddWalkIn(f.training.ID, f.studentID, markedBy, ""); err == nil {
+		t.Error("повторный AddWalkIn(): error = nil")
+	}
+	if got := f.balance(t); got != 7 {
+		t.Errorf("остаток после повтора = %d, want 7", got)
+	}
+}
+
+func TestAddWalkInExistingBooking(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      string // статус записи ученика до отметки
+		wantErr     bool
+		wantStatus  string
+		wantBalance int
+	}{
+		{name: "отменённая запись восстанавливается", status: models.AttendanceStatusCancelled, wantStatus: models.AttendanceStatusAttended, wantBalance: 7},
+		{name: "поздняя отмена", status: models.AttendanceStatusLateCancelled, wantErr: true, wantStatus: models.AttendanceStatusLateCancelled, wantBalance: 8},
+		{name: "отмечен не пришедшим", status: models.AttendanceStatusNoShow, wantErr: true, wantStatus: models.AttendanceStatusNoShow, wantBalance: 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newAttendanceFixture(t, time.Now().Add(-time.Hour))
+			record, _ := f.attendance(t)
+			changed, err := memory.NewAttendanceRepository(f.store).ChangeStatus(record.ID, record.Status, tt.status, nil, "")
+			if err != nil || !changed {
+				t.Fatalf("ChangeStatus() = %v, %v", changed, err)
+			}
+
+			err = f.svc.AddWalkIn(f.training.ID, f.studentID, markedBy, "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AddWalkIn() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if record, _ := f.attendance(t); record.Status != tt.wantStatus {
+				t.Errorf("статус = %s, want %s", record.Status, tt.wantStatus)
+			}
+			if got := f.balance(t); got != tt.wantBalance {
+				t.Errorf("остаток = %d, want %d", got, tt.wantBalance)
+			}
+			if tt.wantErr && len(f.ledger(t)) != 0 {
+				t.Errorf("журнал = %+v, want пусто", f.ledger(t))
+			}
+		})
+	}
+}
+
+func TestAddWalkInBeforeStart(t *testing.T) {
+	f := newAttendanceFixture(t, time.Now().Add(time.Hour)).walkIn(t)
+
+	if err := f.svc.AddWalkIn(f.training.ID, f.studentID, markedBy, ""); err == nil {
+		t.Fatal("AddWalkIn() до начала тренировки: error = nil")
+	}
+	if got := f.balance(t); got != 8 {
+		t.Errorf("остаток = %d, want 8", got)
+	}
+}
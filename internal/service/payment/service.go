@@ -0,0 +1,265 @@
+package payment_service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/repository"
+	"spectrum-club-bot/internal/service"
+	subscription_service "spectrum-club-bot/internal/service/subscription"
+	"strings"
+	"time"
+)
+
+type paymentService struct {
+	paymentRepo repository.PaymentRepository
+	planRepo    repository.SubscriptionPlanRepository
+	transactor  repository.Transactor
+	provider    service.PaymentProvider // nil — покупка в боте выключена
+	currency    string
+}
+
+func NewPaymentService(
+	paymentRepo repository.PaymentRepository,
+	planRepo repository.SubscriptionPlanRepository,
+	transactor repository.Transactor,
+	provider service.PaymentProvider,
+	currency string,
+) service.PaymentService {
+	return &paymentService{
+		paymentRepo: paymentRepo,
+		planRepo:    planRepo,
+		transactor:  transactor,
+		provider:    provider,
+		currency:    currency,
+	}
+}
+
+func (s *paymentService) RecordManualPayment(studentID, planID int64, method string, recordedBy int64, comment string) (*models.Subscription, *models.Payment, error) {
+	if method != models.PaymentMethodCash && method != models.PaymentMethodCard {
+		return nil, nil, fmt.Errorf("неизвестный способ оплаты: %s", method)
+	}
+
+	plan, err := s.getActivePlan(planID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	subscription := plan.NewSubscription(studentID, now)
+	payment := &models.Payment{
+		StudentID:  studentID,
+		PlanID:     &plan.ID,
+		Amount:     plan.Price,
+		Currency:   s.currency,
+		Method:     method,
+		Status:     models.PaymentStatusPaid,
+		RecordedBy: models.OptionalUserID(recordedBy),
+		Comment:    strings.TrimSpace(comment),
+		PaidAt:     &now,
+	}
+
+	err = s.transactor.WithinTransaction(func(tx repository.TxRepositories) error {
+		if err := subscription_service.CreateWithPurchase(tx, subscription, recordedBy, plan.Name); err != nil {
+			return err
+		}
+		payment.SubscriptionID = &subscription.ID
+		if err := tx.Payments.Create(payment); err != nil {
+			return fmt.Errorf("ошибка записи оплаты: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return subscription, payment, nil
+}
+
+func (s *paymentService) OnlinePaymentsEnabled() bool {
+	return s.provider != nil
+}
+
+// CreateInvoice создаёт платёж в статусе pending и отправляет счёт; если отправить
+// не удалось, платёж отменяется, чтобы не висеть в истории ученика
+func (s *paymentService) CreateInvoice(chatID, studentID, planID int64) (*models.Payment, error) {
+	if s.provider == nil {
+		return nil, errors.New("оплата в боте не настроена, обратитесь к тренеру")
+	}
+
+	plan, err := s.getActivePlan(planID)
+	if err != nil {
+		return nil, err
+	}
+	if plan.Price <= 0 {
+		return nil, fmt.Errorf("у тарифа «%s» не указана цена, обратитесь к тренеру", plan.Name)
+	}
+
+	payment := &models.Payment{
+		StudentID: studentID,
+		PlanID:    &plan.ID,
+		Amount:    plan.Price,
+		Currency:  s.currency,
+		Method:    models.PaymentMethodTelegram,
+		Status:    models.PaymentStatusPending,
+	}
+	if err := s.paymentRepo.Create(payment); err != nil {
+		return nil, fmt.Errorf("ошибка создания платежа: %w", err)
+	}
+
+	err = s.provider.SendInvoice(models.Invoice{
+		ChatID:      chatID,
+		Payload:     payment.InvoicePayload(),
+		Title:       "Абонемент «" + plan.Name + "»",
+		Description: "Абонемент на тренировки: " + plan.Summary(),
+		Currency:    payment.Currency,
+		Amount:      payment.Amount,
+	})
+	if err != nil {
+		if _, cancelErr := s.paymentRepo.Cancel(payment.ID); cancelErr != nil {
+			log.Printf("❌ Оплата: не удалось отменить платёж %d: %v", payment.ID, cancelErr)
+		}
+		return nil, fmt.Errorf("ошибка выставления счёта: %w", err)
+	}
+	return payment, nil
+}
+
+func (s *paymentService) AnswerPreCheckout(queryID, payload, currency string, totalAmount int) error {
+	if s.provider == nil {
+		return errors.New("оплата в боте не настроена")
+	}
+
+	errorMessage := ""
+	if err := s.checkInvoice(payload, currency, totalAmount); err != nil {
+		log.Printf("⚠️ Оплата: проверка счёта %q отклонена: %v", payload, err)
+		errorMessage = "Счёт больше не действует. Запросите новый в разделе «Мой абонемент»."
+	}
+	return s.provider.AnswerPreCheckout(queryID, errorMessage)
+}
+
+// CompleteInvoicePayment выдаёт абонемент и отмечает платёж оплаченным в одной транзакции:
+// если Telegram пришлёт уведомление повторно, MarkPaid вернёт false и транзакция откатится
+func (s *paymentService) CompleteInvoicePayment(payload, currency string, totalAmount int, telegramChargeID, providerChargeID string) (*models.Subscription, *models.Payment, error) {
+	payment, err := s.getInvoicePayment(payload)
+	if err != nil {
+		return nil, nil, err
+	}
+	if payment.Status == models.PaymentStatusPaid {
+		return nil, payment, service.ErrPaymentAlreadyProcessed
+	}
+	if err := matchAmount(payment, currency, totalAmount); err != nil {
+		return nil, nil, err
+	}
+	if payment.PlanID == nil {
+		return nil, nil, fmt.Errorf("у платежа %d не указан тариф", payment.ID)
+	}
+
+	// Тариф могли снять с продажи после выставления счёта — оплаченный счёт всё равно выполняется
+	plan, err := s.planRepo.GetByID(*payment.PlanID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ошибка получения тарифа %d: %w", *payment.PlanID, err)
+	}
+
+	subscription := plan.NewSubscription(payment.StudentID, time.Now())
+	err = s.transactor.WithinTransaction(func(tx repository.TxRepositories) error {
+		if err := subscription_service.CreateWithPurchase(tx, subscription, 0, plan.Name+", оплата в Telegram"); err != nil {
+			return err
+		}
+		paid, err := tx.Payments.MarkPaid(payment.ID, subscription.ID, telegramChargeID, providerChargeID)
+		if err != nil {
+			return fmt.Errorf("ошибка отметки оплаты: %w", err)
+		}
+		if !paid {
+			return service.ErrPaymentAlreadyProcessed
+		}
+		return nil
+	})
+	if errors.Is(err, service.ErrPaymentAlreadyProcessed) {
+		return nil, payment, err
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	log.Printf("💳 Оплата: счёт %d оплачен, выдан абонемент %d", payment.ID, subscription.ID)
+	payment.Status = models.PaymentStatusPaid
+	payment.SubscriptionID = &subscription.ID
+	payment.TelegramChargeID = telegramChargeID
+	payment.ProviderChargeID = providerChargeID
+	return subscription, payment, nil
+}
+
+func (s *paymentService) CompleteStubPayment(studentID int64, payload, currency string, totalAmount int, chargeID string) (*models.Subscription, *models.Payment, error) {
+	if s.provider == nil || !s.provider.HandlesCallbacks() {
+		return nil, nil, errors.New("оплата кнопкой в боте не настроена")
+	}
+	payment, err := s.getInvoicePayment(payload)
+	if err != nil {
+		return nil, nil, err
+	}
+	if payment.StudentID != studentID {
+		return nil, nil, fmt.Errorf("счёт %d выставлен другому ученику", payment.ID)
+	}
+	return s.CompleteInvoicePayment(payload, currency, totalAmount, chargeID, chargeID)
+}
+
+func (s *paymentService) GetStudentPayments(studentID int64, limit int) ([]models.Payment, error) {
+	return s.paymentRepo.GetByStudentID(studentID, limit)
+}
+
+// checkInvoice счёт можно оплатить: он ожидает оплаты, сумма совпадает, тариф существует
+func (s *paymentService) checkInvoice(payload, currency string, totalAmount int) error {
+	payment, err := s.getInvoicePayment(payload)
+	if err != nil {
+		return err
+	}
+	if payment.Status != models.PaymentStatusPending {
+		return fmt.Errorf("платёж %d в статусе %s", payment.ID, payment.Status)
+	}
+	if err := matchAmount(payment, currency, totalAmount); err != nil {
+		return err
+	}
+	if payment.PlanID == nil {
+		return fmt.Errorf("у платежа %d не указан тариф", payment.ID)
+	}
+	return nil
+}
+
+func (s *paymentService) getInvoicePayment(payload string) (*models.Payment, error) {
+	paymentID, err := models.ParseInvoicePayload(payload)
+	if err != nil {
+		return nil, err
+	}
+	payment, err := s.paymentRepo.GetByID(paymentID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("платёж с ID %d не найден", paymentID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+func (s *paymentService) getActivePlan(planID int64) (*models.SubscriptionPlan, error) {
+	plan, err := s.planRepo.GetByID(planID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("тариф с ID %d не найден", planID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !plan.IsActive {
+		return nil, fmt.Errorf("тариф «%s» больше не выдаётся", plan.Name)
+	}
+	return plan, nil
+}
+
+// matchAmount сумма из Telegram (в копейках) совпадает с суммой счёта
+func matchAmount(payment *models.Payment, currency string, totalAmount int) error {
+	if !strings.EqualFold(currency, payment.Currency) || totalAmount != payment.Amount*100 {
+		return fmt.Errorf("сумма %d %s не совпадает со счётом %d: %d %s",
+			totalAmount, currency, payment.ID, payment.Amount*100, payment.Currency)
+	}
+	return nil
+}
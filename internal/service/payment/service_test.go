@@ -0,0 +1,253 @@
+package payment_service
+
+import (
+	"errors"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/repository/memory"
+	"spectrum-club-bot/internal/service"
+	"testing"
+)
+
+const (
+	studentID   int64 = 1
+	chatID      int64 = -2
+	coachUserID int64 = 7
+)
+
+// fakeProvider service.PaymentProvider, запоминающий счета и ответы на pre_checkout_query
+type fakeProvider struct {
+	invoices   []models.Invoice
+	answers    []string // errorMessage каждого ответа; пустой — оплата разрешена
+	sendErr    error
+	byCallback bool
+}
+
+func (p *fakeProvider) SendInvoice(invoice models.Invoice) error {
+	if p.sendErr != nil {
+		return p.sendErr
+	}
+	p.invoices = append(p.invoices, invoice)
+	return nil
+}
+
+func (p *fakeProvider) AnswerPreCheckout(queryID, errorMessage string) error {
+	p.answers = append(p.answers, errorMessage)
+	return nil
+}
+
+func (p *fakeProvider) HandlesCallbacks() bool {
+	return p.byCallback
+}
+
+type testService struct {
+	store    *memory.Store
+	service  service.PaymentService
+	provider *fakeProvider
+	plan     models.SubscriptionPlan
+}
+
+// newTestService сервис оплаты поверх in-memory хранилища: ученики Анна (studentID) и Борис,
+// один тариф за 4000 ₽
+func newTestService(t *testing.T) *testService {
+	t.Helper()
+	store := memory.NewStore()
+	for i, name := range []string{"Анна", "Борис"} {
+		user := &models.User{TelegramID: chatID - int64(i), FirstName: name, LastName: "Тестов", Role: "student"}
+		if err := memory.NewUserRepository(store).CreateOrUpdate(user); err != nil {
+			t.Fatal(err)
+		}
+		if err := memory.NewStudentRepository(store).Create(&models.Student{UserID: user.ID}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	plans := memory.NewSubscriptionPlanRepository(store)
+	plan := models.SubscriptionPlan{Name: "16 занятий", Lessons: 16, DurationDays: 30, Price: 4000, IsActive: true}
+	if err := plans.Create(&plan); err != nil {
+		t.Fatal(err)
+	}
+
+	provider := &fakeProvider{}
+	svc := NewPaymentService(
+		memory.NewPaymentRepository(store),
+		plans,
+		memory.NewTransactor(store, models.ConsumptionExpiringFirst),
+		provider,
+		"RUB",
+	)
+	return &testService{store: store, service: svc, provider: provider, plan: plan}
+}
+
+// invoice выставляет счёт на тариф фикстуры
+func (f *testService) invoice(t *testing.T) *models.Payment {
+	t.Helper()
+	payment, err := f.service.CreateInvoice(chatID, studentID, f.plan.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return payment
+}
+
+func (f *testService) subscriptions(t *testing.T) []*models.Subscription {
+	t.Helper()
+	subscriptions, err := memory.NewSubscriptionRepository(f.store, models.ConsumptionExpiringFirst).GetByStudentID(studentID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return subscriptions
+}
+
+func TestCreateInvoiceCancelsPaymentWhenSendFails(t *testing.T) {
+	f := newTestService(t)
+	f.provider.sendErr = errors.New("Bad Request: chat not found")
+
+	if _, err := f.service.CreateInvoice(chatID, studentID, f.plan.ID); err == nil {
+		t.Fatal("CreateInvoice() error = nil, want ошибку отправки")
+	}
+
+	payments, err := f.service.GetStudentPayments(studentID, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(payments) != 1 || payments[0].Status != models.PaymentStatusCancelled {
+		t.Errorf("платежи = %+v, want один отменённый", payments)
+	}
+}
+
+func TestAnswerPreCheckout(t *testing.T) {
+	tests := []struct {
+		name     string
+		currency string
+		amount   int
+		paid     bool
+		wantOK   bool
+	}{
+		{name: "счёт в порядке", currency: "RUB", amount: 400000, wantOK: true},
+		{name: "валюта в другом регистре", currency: "rub", amount: 400000, wantOK: true},
+		{name: "другая сумма", currency: "RUB", amount: 4000, wantOK: false},
+		{name: "другая валюта", currency: "USD", amount: 400000, wantOK: false},
+		{name: "счёт уже оплачен", currency: "RUB", amount: 400000, paid: true, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newTestService(t)
+			payment := f.invoice(t)
+			if tt.paid {
+				if _, _, err := f.service.CompleteInvoicePayment(payment.InvoicePayload(), "RUB", 400000, "charge-1", "provider-1"); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := f.service.AnswerPreCheckout("query-1", payment.InvoicePayload(), tt.currency, tt.amount); err != nil {
+				t.Fatal(err)
+			}
+			if len(f.provider.answers) != 1 {
+				t.Fatalf("ответов провайдеру = %d, want 1", len(f.provider.answers))
+			}
+			if ok := f.provider.answers[0] == ""; ok != tt.wantOK {
+				t.Errorf("оплата разрешена = %v, want %v (ответ %q)", ok, tt.wantOK, f.provider.answers[0])
+			}
+		})
+	}
+}
+
+func TestCompleteInvoicePayment(t *testing.T) {
+	f := newTestService(t)
+	payment := f.invoice(t)
+	if len(f.provider.invoices) != 1 || f.provider.invoices[0].Payload != payment.InvoicePayload() || f.provider.invoices[0].Amount != f.plan.Price {
+		t.Fatalf("счета = %+v", f.provider.invoices)
+	}
+
+	subscription, paid, err := f.service.CompleteInvoicePayment(payment.InvoicePayload(), "RUB", 400000, "charge-1", "provider-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subscription.StudentID != studentID || subscription.RemainingLessons != f.plan.Lessons {
+		t.Errorf("абонемент = %+v", subscription)
+	}
+	if paid.Status != models.PaymentStatusPaid || paid.SubscriptionID == nil || *paid.SubscriptionID != subscription.ID {
+		t.Errorf("платёж = %+v", paid)
+	}
+
+	history, err := memory.NewLessonLedgerRepository(f.store).GetBySubscriptionID(subscription.ID, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 || history[0].Reason != models.LedgerReasonPurchase || history[0].Delta != f.plan.Lessons {
+		t.Errorf("журнал = %+v, want одну покупку на %d занятий", history, f.plan.Lessons)
+	}
+
+	// Telegram прислал successful_payment повторно
+	_, _, err = f.service.CompleteInvoicePayment(payment.InvoicePayload(), "RUB", 400000, "charge-1", "provider-1")
+	if !errors.Is(err, service.ErrPaymentAlreadyProcessed) {
+		t.Errorf("повторная оплата: error = %v, want ErrPaymentAlreadyProcessed", err)
+	}
+	if got := f.subscriptions(t); len(got) != 1 {
+		t.Errorf("абонементов = %d, want 1", len(got))
+	}
+}
+
+func TestCompleteStubPayment(t *testing.T) {
+	tests := []struct {
+		name       string
+		byCallback bool
+		studentID  int64
+		wantErr    bool
+	}{
+		{name: "заглушка, свой счёт", byCallback: true, studentID: studentID},
+		{name: "настоящий провайдер", byCallback: false, studentID: studentID, wantErr: true},
+		{name: "чужой счёт", byCallback: true, studentID: studentID + 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newTestService(t)
+			f.provider.byCallback = tt.byCallback
+			payment := f.invoice(t)
+
+			_, _, err := f.service.CompleteStubPayment(tt.studentID, payment.InvoicePayload(), "RUB", 400000, "stub-1")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CompleteStubPayment() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			want := 1
+			if tt.wantErr {
+				want = 0
+			}
+			if got := f.subscriptions(t); len(got) != want {
+				t.Errorf("абонементов = %d, want %d", len(got), want)
+			}
+		})
+	}
+}
+
+func TestRecordManualPayment(t *testing.T) {
+	tests := []struct {
+		method  string
+		wantErr bool
+	}{
+		{method: models.PaymentMethodCash},
+		{method: models.PaymentMethodCard},
+		{method: models.PaymentMethodTelegram, wantErr: true},
+		{method: "barter", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			f := newTestService(t)
+
+			_, payment, err := f.service.RecordManualPayment(studentID, f.plan.ID, tt.method, coachUserID, "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RecordManualPayment() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			want := 1
+			if tt.wantErr {
+				want = 0
+			} else if payment.RecordedBy == nil || *payment.RecordedBy != coachUserID || payment.Amount != f.plan.Price {
+				t.Errorf("платёж = %+v", payment)
+			}
+			if got := f.subscriptions(t); len(got) != want {
+				t.Errorf("абонементов = %d, want %d", len(got), want)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package renewal_service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/repository"
+	"spectrum-club-bot/internal/service"
+)
+
+type renewalService struct {
+	renewalRepo         repository.RenewalRequestRepository
+	subscriptionService service.SubscriptionService
+	notifier            service.RenewalNotifier
+}
+
+func NewRenewalService(
+	renewalRepo repository.RenewalRequestRepository,
+	subscriptionService service.SubscriptionService,
+	notifier service.RenewalNotifier,
+) service.RenewalService {
+	return &renewalService{
+		renewalRepo:         renewalRepo,
+		subscriptionService: subscriptionService,
+		notifier:            notifier,
+	}
+}
+
+func (s *renewalService) RequestRenewal(studentID, planID int64) (*models.RenewalRequest, error) {
+	plan, err := s.subscriptionService.GetPlanByID(planID)
+	if err != nil {
+		return nil, err
+	}
+	if !plan.IsActive {
+		return nil, fmt.Errorf("тариф «%s» больше не выдаётся", plan.Name)
+	}
+
+	pending, err := s.renewalRepo.GetPendingByStudent(studentID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка проверки запросов на продление: %w", err)
+	}
+	if pending != nil {
+		return nil, fmt.Errorf("запрос на абонемент «%s» уже ждёт решения тренера", pending.PlanName)
+	}
+
+	request := &models.RenewalRequest{StudentID: studentID, PlanID: planID}
+	if err := s.renewalRepo.Create(request); err != nil {
+		return nil, fmt.Errorf("ошибка создания запроса на продление: %w", err)
+	}
+	request, err = s.renewalRepo.GetByID(request.ID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения запроса на продление: %w", err)
+	}
+
+	log.Printf("📨 Продление: ученик %d запросил абонемент «%s» (запрос %d)", studentID, plan.Name, request.ID)
+	if err := s.notifier.RenewalRequested(*request, plan); err != nil {
+		log.Printf("❌ Продление: не удалось уведомить тренеров о запросе %d: %v", request.ID, err)
+	}
+	return request, nil
+}
+
+func (s *renewalService) CancelRenewal(studentID, requestID int64) error {
+	request, err := s.getRequest(requestID)
+	if err != nil {
+		return err
+	}
+	if request.StudentID != studentID {
+		return errors.New("это не ваш запрос")
+	}
+
+	cancelled, err := s.renewalRepo.Decide(requestID, models.RenewalStatusCancelled, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка отмены запроса: %w", err)
+	}
+	if !cancelled {
+		return errors.New("тренер уже рассмотрел этот запрос")
+	}
+	return nil
+}
+
+// ApproveRenewal сначала забирает запрос себе (Decide), чтобы два тренера не выдали
+// два абонемента; если абонемент выдать не удалось, запрос снова ждёт решения
+func (s *renewalService) ApproveRenewal(requestID, coachUserID int64) (*models.RenewalRequest, *models.Subscription, error) {
+	request, err := s.getRequest(requestID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	approved, err := s.renewalRepo.Decide(requestID, models.RenewalStatusApproved, models.OptionalUserID(coachUserID))
+	if err != nil {
+		return nil, nil, fmt.Errorf("ошибка одобрения запроса: %w", err)
+	}
+	if !approved {
+		return nil, nil, s.closedError(requestID)
+	}
+
+	subscription, err := s.subscriptionService.CreateFromPlan(request.StudentID, request.PlanID, coachUserID)
+	if err != nil {
+		if reopenErr := s.renewalRepo.Reopen(requestID); reopenErr != nil {
+			log.Printf("❌ Продление: не удалось вернуть запрос %d в ожидание: %v", requestID, reopenErr)
+		}
+		return nil, nil, err
+	}
+	if err := s.renewalRepo.SetSubscription(requestID, subscription.ID); err != nil {
+		log.Printf("❌ Продление: не удалось привязать абонемент %d к запросу %d: %v", subscription.ID, requestID, err)
+	}
+
+	log.Printf("📨 Продление: запрос %d одобрен, выдан абонемент %d", requestID, subscription.ID)
+	request.Status = models.RenewalStatusApproved
+	request.SubscriptionID = &subscription.ID
+	request.DecidedBy = models.OptionalUserID(coachUserID)
+	if err := s.notifier.RenewalApproved(*request, subscription); err != nil {
+		log.Printf("❌ Продление: не удалось уведомить ученика %d об одобрении: %v", request.StudentID, err)
+	}
+	return request, subscription, nil
+}
+
+func (s *renewalService) RejectRenewal(requestID, coachUserID int64) (*models.RenewalRequest, error) {
+	request, err := s.getRequest(requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	rejected, err := s.renewalRepo.Decide(requestID, models.RenewalStatusRejected, models.OptionalUserID(coachUserID))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка отклонения запроса: %w", err)
+	}
+	if !rejected {
+		return nil, s.closedError(requestID)
+	}
+
+	log.Printf("📨 Продление: запрос %d отклонён", requestID)
+	request.Status = models.RenewalStatusRejected
+	request.DecidedBy = models.OptionalUserID(coachUserID)
+	if err := s.notifier.RenewalRejected(*request); err != nil {
+		log.Printf("❌ Продление: не удалось уведомить ученика %d об отказе: %v", request.StudentID, err)
+	}
+	return request, nil
+}
+
+func (s *renewalService) GetPendingRequests() ([]models.RenewalRequest, error) {
+	return s.renewalRepo.GetPending()
+}
+
+func (s *renewalService) GetPendingRequest(studentID int64) (*models.RenewalRequest, error) {
+	return s.renewalRepo.GetPendingByStudent(studentID)
+}
+
+func (s *renewalService) getRequest(requestID int64) (*models.RenewalRequest, error) {
+	request, err := s.renewalRepo.GetByID(requestID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("запрос на продление %d не найден", requestID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// closedError ошибка для запроса, решение по которому уже принято
+func (s *renewalService) closedError(requestID int64) error {
+	request, err := s.renewalRepo.GetByID(requestID)
+	if err == nil && request.Status == models.RenewalStatusCancelled {
+		return errors.New("ученик отозвал запрос")
+	}
+	return errors.New("запрос уже рассмотрен")
+}
@@ -0,0 +1,165 @@
+package renewal_service
+
+import (
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/repository/memory"
+	"spectrum-club-bot/internal/service"
+	subscription_service "spectrum-club-bot/internal/service/subscription"
+	"testing"
+)
+
+const coachUserID int64 = 7
+
+// sentNotices service.RenewalNotifier, запоминающий уведомления вместо отправки
+type sentNotices struct {
+	requested, approved, rejected []models.RenewalRequest
+}
+
+func (n *sentNotices) RenewalRequested(request models.RenewalRequest, plan *models.SubscriptionPlan) error {
+	n.requested = append(n.requested, request)
+	return nil
+}
+
+func (n *sentNotices) RenewalApproved(request models.RenewalRequest, subscription *models.Subscription) error {
+	n.approved = append(n.approved, request)
+	return nil
+}
+
+func (n *sentNotices) RenewalRejected(request models.RenewalRequest) error {
+	n.rejected = append(n.rejected, request)
+	return nil
+}
+
+// testService сервис продлений поверх in-memory хранилища: ученик с запросом
+// абонемента по активному тарифу на 8 занятий
+type testService struct {
+	service       service.RenewalService
+	subscriptions service.SubscriptionService
+	notices       *sentNotices
+	studentID     int64
+	plan          *models.SubscriptionPlan
+	request       *models.RenewalRequest
+}
+
+func newTestService(t *testing.T) *testService {
+	t.Helper()
+	store := memory.NewStore()
+	order := models.ConsumptionExpiringFirst
+
+	user := &models.User{TelegramID: 100, FirstName: "Анна", LastName: "Тестова", Role: "student"}
+	if err := memory.NewUserRepository(store).CreateOrUpdate(user); err != nil {
+		t.Fatal(err)
+	}
+	student := &models.Student{UserID: user.ID}
+	if err := memory.NewStudentRepository(store).Create(student); err != nil {
+		t.Fatal(err)
+	}
+
+	subscriptions := subscription_service.NewSubscriptionService(
+		memory.NewSubscriptionRepository(store, order),
+		memory.NewSubscriptionPlanRepository(store),
+		memory.NewSubscriptionFreezeRepository(store),
+		memory.NewLessonLedgerRepository(store),
+		memory.NewTransactor(store, order),
+	)
+	plan := &models.SubscriptionPlan{Name: "8 занятий", Lessons: 8, DurationDays: 30, Price: 4000, IsActive: true}
+	if err := subscriptions.CreatePlan(plan); err != nil {
+		t.Fatal(err)
+	}
+
+	notices := &sentNotices{}
+	svc := NewRenewalService(memory.NewRenewalRequestRepository(store), subscriptions, notices)
+	request, err := svc.RequestRenewal(student.ID, plan.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testService{
+		service:       svc,
+		subscriptions: subscriptions,
+		notices:       notices,
+		studentID:     student.ID,
+		plan:          plan,
+		request:       request,
+	}
+}
+
+// issued абонементы, выданные ученику
+func (s *testService) issued(t *testing.T) []*models.Subscription {
+	t.Helper()
+	subscriptions, err := s.subscriptions.GetSubscriptionHistory(s.studentID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return subscriptions
+}
+
+func TestApproveRenewal(t *testing.T) {
+	s := newTestService(t)
+
+	request, subscription, err := s.service.ApproveRenewal(s.request.ID, coachUserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if request.Status != models.RenewalStatusApproved || request.SubscriptionID == nil || *request.SubscriptionID != subscription.ID {
+		t.Errorf("запрос = %+v, want одобрен с абонементом %d", request, subscription.ID)
+	}
+	if subscription.RemainingLessons != s.plan.Lessons {
+		t.Errorf("занятий = %d, want %d", subscription.RemainingLessons, s.plan.Lessons)
+	}
+	if len(s.notices.approved) != 1 {
+		t.Errorf("уведомлений об одобрении = %d, want 1", len(s.notices.approved))
+	}
+
+	// Второй тренер нажал «одобрить» по тому же запросу
+	if _, _, err := s.service.ApproveRenewal(s.request.ID, coachUserID+1); err == nil {
+		t.Error("повторное одобрение: error = nil")
+	}
+	if _, err := s.service.RejectRenewal(s.request.ID, coachUserID+1); err == nil {
+		t.Error("отклонение одобренного запроса: error = nil")
+	}
+	if got := len(s.issued(t)); got != 1 {
+		t.Errorf("выдано абонементов = %d, want 1", got)
+	}
+	if len(s.notices.approved) != 1 || len(s.notices.rejected) != 0 {
+		t.Errorf("уведомления = %+v, want одно об одобрении", s.notices)
+	}
+}
+
+func TestApproveRenewalReopensWhenPassNotIssued(t *testing.T) {
+	s := newTestService(t)
+
+	// Тариф сняли с продажи, пока запрос ждал решения: абонемент не выдаётся
+	s.plan.IsActive = false
+	if err := s.subscriptions.UpdatePlan(s.plan); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.service.ApproveRenewal(s.request.ID, coachUserID); err == nil {
+		t.Fatal("ApproveRenewal() по снятому тарифу: error = nil")
+	}
+
+	pending, err := s.service.GetPendingRequest(s.studentID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pending == nil || pending.ID != s.request.ID || pending.DecidedBy != nil {
+		t.Fatalf("запрос после ошибки = %+v, want снова ждёт решения", pending)
+	}
+	if got := len(s.issued(t)); got != 0 {
+		t.Errorf("выдано абонементов = %d, want 0", got)
+	}
+	if len(s.notices.approved) != 0 {
+		t.Errorf("ученик получил уведомление об одобрении: %+v", s.notices.approved)
+	}
+
+	// Тренер вернул тариф и одобрил запрос повторно
+	s.plan.IsActive = true
+	if err := s.subscriptions.UpdatePlan(s.plan); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.service.ApproveRenewal(s.request.ID, coachUserID); err != nil {
+		t.Fatalf("повторное одобрение после ошибки: %v", err)
+	}
+	if got := len(s.issued(t)); got != 1 {
+		t.Errorf("выдано абонементов = %d, want 1", got)
+	}
+}
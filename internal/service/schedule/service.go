@@ -42,10 +42,6 @@ func (s *trainingScheduleService) CreateTraining(training *models.TrainingSchedu
 	return s.scheduleRepo.CreateTraining(training)
 }
 
-func (s *trainingScheduleService) DeleteTraining(id int) error {
-	return s.scheduleRepo.DeleteTraining(id)
-}
-
 func (s *trainingScheduleService) GetCoachSchedule(coachID int64, start, end time.Time) ([]models.TrainingSchedule, error) {
 	return s.scheduleRepo.GetTrainingsByCoach(coachID, start, end)
 }
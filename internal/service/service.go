@@ -1,10 +1,27 @@
 package service
 
 import (
+	"errors"
 	"spectrum-club-bot/internal/models"
 	"time"
 )
 
+// ErrTrainingFull на тренировке нет свободных мест — можно встать в лист ожидания
+var ErrTrainingFull = errors.New("нет свободных мест на тренировку")
+
+// ErrPaymentAlreadyProcessed оплата по счёту уже учтена — повторное уведомление не выдаёт второй абонемент
+var ErrPaymentAlreadyProcessed = errors.New("оплата уже учтена")
+
+// ErrSubscriptionNotCovered у ученика есть абонемент, но его ограничения (группы, дни, время)
+// не допускают тренировку; причина добавляется к тексту ошибки
+var ErrSubscriptionNotCovered = errors.New("абонемент не подходит для этой тренировки")
+
+// ErrLateCancellation срок отмены записи прошёл, а правила клуба запрещают позднюю отмену
+var ErrLateCancellation = errors.New("срок отмены записи истёк")
+
+// ErrInvalidAttendanceTransition запись нельзя перевести из текущего статуса в запрошенный
+var ErrInvalidAttendanceTransition = errors.New("недопустимая смена статуса записи")
+
 type UserService interface {
 	RegisterOrUpdate(telegramID int64, firstName, lastName, username string, role string) (*models.User, error)
 	GetUserProfile(telegramID int64) (*models.User, *models.Student, *models.Subscription, *models.Coach, error)
@@ -35,15 +52,48 @@ type CoachService interface {
 type SubscriptionService interface {
 	CreateSubscription(studentID int64, remainingLessons int, totalLessons int, durationDays int) error
 	GetActiveSubscription(studentID int64) (*models.Subscription, error)
-	UseLesson(subscriptionID int64) error
-	ExtendSubscription(subscriptionID int64, additionalMonths int) error
+	// GetActiveSubscriptionForTraining активный абонемент, ограничения которого допускают тренировку
+	GetActiveSubscriptionForTraining(studentID int64, trainingID int) (*models.Subscription, error)
+	// GetChargedSubscription абонемент, с которого списано занятие за запись на тренировку attendanceID;
+	// sql.ErrNoRows, если за неё ничего не списано
+	GetChargedSubscription(attendanceID int) (*models.Subscription, error)
+	GetSubscriptionByID(subscriptionID int64) (*models.Subscription, error)
+	// UseLesson списывает занятие без записи на тренировку; actorID — users.id тренера, comment — причина
+	UseLesson(subscriptionID int64, actorID int64, comment string) error
+	// ExtendSubscription добавляет дни к сроку и/или занятия к абонементу; actorID — users.id тренера
+	ExtendSubscription(subscriptionID int64, extraDays int, extraLessons int, actorID int64) (*models.Subscription, error)
 	GetSubscriptionHistory(studentID int64) ([]*models.Subscription, error)
-	DecrementRemainingLessons(studentID int64) error
 
-	////i did
-	Create12Unlimited(studentID int64) error
-	Create16For30Days(studentID int64) error
-	Create1For30Days(studentID int64) error
+	// CreateFromPlan выдаёт ученику абонемент по активному тарифу; issuedBy — users.id тренера
+	CreateFromPlan(studentID int64, planID int64, issuedBy int64) (*models.Subscription, error)
+
+	// Журнал занятий: остаток абонемента меняется только вместе с записью в журнале
+	AdjustLessons(subscriptionID int64, delta int, actorID int64, comment string) error
+	// GetLessonHistory движения занятий по абонементу, новые сначала; limit <= 0 — все
+	GetLessonHistory(subscriptionID int64, limit int) ([]models.LessonLedgerEntry, error)
+	// ReconcileLessons абонементы, у которых остаток разошёлся с журналом
+	ReconcileLessons() ([]models.LessonBalanceMismatch, error)
+
+	// Тарифы абонементов
+	GetPlans(includeInactive bool) ([]models.SubscriptionPlan, error)
+	GetPlanByID(planID int64) (*models.SubscriptionPlan, error)
+	CreatePlan(plan *models.SubscriptionPlan) error
+	UpdatePlan(plan *models.SubscriptionPlan) error
+
+	// Заморозки абонементов: даты — календарные дни, обе включительно
+	FreezeSubscription(subscriptionID int64, startDate, endDate time.Time, reason string, createdBy int64) (*models.SubscriptionFreeze, error)
+	// UnfreezeSubscription досрочно снимает текущую или будущую заморозку и возвращает её
+	UnfreezeSubscription(subscriptionID int64) (*models.SubscriptionFreeze, error)
+	// GetCurrentFreeze действующая или ещё не начавшаяся заморозка; nil, если её нет
+	GetCurrentFreeze(subscriptionID int64) (*models.SubscriptionFreeze, error)
+	GetSubscriptionFreezes(subscriptionID int64) ([]models.SubscriptionFreeze, error)
+
+	// Семейный абонемент: подключённые ученики записываются на тренировки и списывают
+	// занятия с общего остатка; addedBy — users.id тренера
+	AddSubscriptionMember(subscriptionID, studentID int64, addedBy int64) error
+	RemoveSubscriptionMember(subscriptionID, studentID int64) error
+	// GetSubscriptionMembers владелец (первым) и подключённые ученики с числом использованных занятий
+	GetSubscriptionMembers(subscriptionID int64) ([]models.SubscriptionMember, error)
 
 	GetAll() ([]*models.Subscription, error)
 
@@ -51,6 +101,65 @@ type SubscriptionService interface {
 	GetSubscriptionsByStudentID(studentID int64) ([]*models.Subscription, error)
 }
 
+// PaymentService оплата абонементов: записи тренера о принятой оплате и покупка учеником в боте.
+// Суммы счетов Telegram — в копейках (минимальных единицах валюты)
+type PaymentService interface {
+	// RecordManualPayment выдаёт абонемент по тарифу и записывает оплату, принятую тренером
+	// наличными или картой; recordedBy — users.id тренера
+	RecordManualPayment(studentID, planID int64, method string, recordedBy int64, comment string) (*models.Subscription, *models.Payment, error)
+
+	// OnlinePaymentsEnabled настроен ли платёжный провайдер для покупки в боте
+	OnlinePaymentsEnabled() bool
+	// CreateInvoice выставляет ученику счёт на тариф в чат chatID
+	CreateInvoice(chatID, studentID, planID int64) (*models.Payment, error)
+	// AnswerPreCheckout проверяет счёт перед списанием денег и отвечает провайдеру
+	AnswerPreCheckout(queryID, payload, currency string, totalAmount int) error
+	// CompleteInvoicePayment выдаёт абонемент по оплаченному счёту.
+	// Для уже учтённой оплаты возвращает платёж и ErrPaymentAlreadyProcessed
+	CompleteInvoicePayment(payload, currency string, totalAmount int, telegramChargeID, providerChargeID string) (*models.Subscription, *models.Payment, error)
+	// CompleteStubPayment оплата тестового счёта кнопкой заглушки: принимается, только если
+	// настроен провайдер-заглушка и счёт выставлен ученику studentID, нажавшему кнопку
+	CompleteStubPayment(studentID int64, payload, currency string, totalAmount int, chargeID string) (*models.Subscription, *models.Payment, error)
+
+	// GetStudentPayments платежи ученика, новые сначала; limit <= 0 — все
+	GetStudentPayments(studentID int64, limit int) ([]models.Payment, error)
+}
+
+// PaymentProvider выставляет счета ученикам и отвечает на проверку перед оплатой
+type PaymentProvider interface {
+	SendInvoice(invoice models.Invoice) error
+	// AnswerPreCheckout разрешает оплату (errorMessage пустой) или отклоняет её с объяснением для ученика
+	AnswerPreCheckout(queryID, errorMessage string) error
+	// HandlesCallbacks оплата подтверждается кнопкой в боте (заглушка), а не successful_payment.
+	// Callback data присылает клиент, поэтому для настоящего провайдера такие кнопки не принимаются
+	HandlesCallbacks() bool
+}
+
+// RenewalService запросы учеников на продление абонемента: ученик выбирает тариф,
+// тренер одобряет запрос (абонемент выдаётся через SubscriptionService) или отклоняет его
+type RenewalService interface {
+	// RequestRenewal создаёт запрос и отправляет его тренерам; у ученика может быть
+	// только один запрос, ожидающий решения
+	RequestRenewal(studentID, planID int64) (*models.RenewalRequest, error)
+	// CancelRenewal ученик отзывает свой ожидающий решения запрос
+	CancelRenewal(studentID, requestID int64) error
+	// ApproveRenewal выдаёт абонемент по тарифу из запроса; coachUserID — users.id тренера
+	ApproveRenewal(requestID, coachUserID int64) (*models.RenewalRequest, *models.Subscription, error)
+	RejectRenewal(requestID, coachUserID int64) (*models.RenewalRequest, error)
+
+	// GetPendingRequests запросы, ожидающие решения, старые сначала
+	GetPendingRequests() ([]models.RenewalRequest, error)
+	// GetPendingRequest ожидающий решения запрос ученика; nil, если его нет
+	GetPendingRequest(studentID int64) (*models.RenewalRequest, error)
+}
+
+// RenewalNotifier сообщает тренерам о новых запросах на продление, а ученику — о решении
+type RenewalNotifier interface {
+	RenewalRequested(request models.RenewalRequest, plan *models.SubscriptionPlan) error
+	RenewalApproved(request models.RenewalRequest, subscription *models.Subscription) error
+	RenewalRejected(request models.RenewalRequest) error
+}
+
 type TrainingGroupService interface {
 	GetAllGroups() ([]models.TrainingGroup, error)
 	GetGroupByID(id int) (*models.TrainingGroup, error)
@@ -73,7 +182,6 @@ type TrainingScheduleService interface {
 	GetTrainingsByDateRange(start time.Time, end time.Time) ([]models.TrainingSchedule, error)
 	UpdateTrainingPartial(id int, updates map[string]interface{}) error
 	GetTrainingByID(id int) (*models.TrainingSchedule, error)
-	DeleteTraining(id int) error
 
 	WeekScheduleService
 }
@@ -95,10 +203,17 @@ type WeekScheduleService interface {
 type AttendanceService interface {
 	// Запись на тренировку
 	SignUpForTraining(studentID, trainingID int) error
-	// Отмена записи
+	// Отмена записи. Поздняя отмена по правилам клуба списывает занятие или запрещена (ErrLateCancellation)
 	CancelSignUp(studentID, trainingID int) error
+	// CancellationPolicy правила отмены записи, чтобы показать срок ученику до подтверждения
+	CancellationPolicy() models.CancellationPolicy
 	// Для тренеров - отметка посещения
 	MarkAttendance(trainingID, studentID, recordedBy int, attended bool, notes string) error
+	// SetAttendanceStatus отметка тренером по статусу: attended, no_show или registered (снять отметку)
+	SetAttendanceStatus(trainingID, studentID, recordedBy int, status, notes string) error
+	// AddWalkIn отмечает пришедшего без записи ученика: запись создаётся сразу пришедшей
+	// в обход лимита мест, занятие списывается, в записи остаётся пометка для аудита
+	AddWalkIn(trainingID, studentID, recordedBy int, notes string) error
 	// Просмотр записавшихся
 	GetTrainingAttendees(trainingID int) ([]models.Attendance, error)
 	// Статистика по тренировке
@@ -112,4 +227,37 @@ type AttendanceService interface {
 	GetStudentSchedule(studentID int, start, end time.Time) ([]models.AttendanceWithTraining, error)
 	CreateAttendance(attendance models.Attendance) error
 	CancelAttendance(trainingID, studentID int) error
+
+	// Лист ожидания. Место, освободившееся после CancelSignUp, предлагается первому
+	// в очереди и держится за ним до истечения предложения; принять его — это SignUpForTraining.
+	JoinWaitlist(studentID, trainingID int) (*models.WaitlistEntry, error)
+	// LeaveWaitlist выход из очереди или отказ от предложенного места
+	LeaveWaitlist(studentID, trainingID int) error
+	// GetWaitlistEntry запись ученика в очереди с позицией; nil, если он не в очереди
+	GetWaitlistEntry(studentID, trainingID int) (*models.WaitlistEntry, error)
+	GetStudentWaitlist(studentID int) ([]models.WaitlistEntry, error)
+	// ExpireWaitlistOffers закрывает просроченные предложения и передаёт места следующим
+	ExpireWaitlistOffers() error
+	// FreeSpots сколько мест на тренировке может занять ученик: лимит минус записавшиеся и минус
+	// предложения из листа ожидания другим ученикам; limited = false, если лимита нет
+	FreeSpots(studentID, trainingID int) (free int, limited bool, err error)
+
+	// CancelTraining отменяет тренировку, не удаляя её из расписания: списанные за неё
+	// занятия возвращаются на абонементы, записавшиеся и очередь получают уведомление
+	CancelTraining(trainingID int, reason string, cancelledBy int64) error
+}
+
+// WaitlistNotifier сообщает ученикам из листа ожидания об освободившихся местах
+type WaitlistNotifier interface {
+	SpotOffered(entry models.WaitlistEntry, training *models.TrainingSchedule, expiresAt time.Time) error
+	OfferExpired(entry models.WaitlistEntry, training *models.TrainingSchedule) error
+}
+
+// TrainingNotifier сообщает ученикам об отмене тренировки и о возврате занятий.
+// refunded — сколько занятий вернулось на абонемент ученика
+type TrainingNotifier interface {
+	TrainingCancelled(telegramID int64, training *models.TrainingSchedule, refunded int) error
+	AttendanceReverted(telegramID int64, training *models.TrainingSchedule, refunded int) error
+	// AttendanceMarked за посещение списано занятие; remaining — остаток на этом абонементе
+	AttendanceMarked(telegramID int64, training *models.TrainingSchedule, remaining int) error
 }
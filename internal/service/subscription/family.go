@@ -0,0 +1,50 @@
+package subscription_service
+
+import (
+	"errors"
+	"fmt"
+	"spectrum-club-bot/internal/models"
+)
+
+// Подключить ученика к семейному абонементу: владелец абонемента не меняется,
+// а занятия подключённого ученика списываются с общего остатка
+func (s *subscriptionService) AddSubscriptionMember(subscriptionID, studentID int64, addedBy int64) error {
+	subscription, err := s.getSubscription(subscriptionID)
+	if err != nil {
+		return err
+	}
+	if subscription.StudentID == studentID {
+		return errors.New("абонемент уже оформлен на этого ученика")
+	}
+
+	members, err := s.subscriptionRepo.GetMembers(subscriptionID)
+	if err != nil {
+		return fmt.Errorf("ошибка получения участников абонемента: %w", err)
+	}
+	for _, member := range members {
+		if member.StudentID == studentID {
+			return fmt.Errorf("%s уже пользуется этим абонементом", member.StudentName)
+		}
+	}
+
+	if err := s.subscriptionRepo.AddMember(subscriptionID, studentID, models.OptionalUserID(addedBy)); err != nil {
+		return fmt.Errorf("ошибка подключения ученика к абонементу: %w", err)
+	}
+	return nil
+}
+
+// Отключить ученика от семейного абонемента. Уже списанные за него занятия остаются в журнале
+func (s *subscriptionService) RemoveSubscriptionMember(subscriptionID, studentID int64) error {
+	removed, err := s.subscriptionRepo.RemoveMember(subscriptionID, studentID)
+	if err != nil {
+		return fmt.Errorf("ошибка отключения ученика от абонемента: %w", err)
+	}
+	if !removed {
+		return errors.New("ученик не подключён к этому абонементу")
+	}
+	return nil
+}
+
+func (s *subscriptionService) GetSubscriptionMembers(subscriptionID int64) ([]models.SubscriptionMember, error) {
+	return s.subscriptionRepo.GetMembers(subscriptionID)
+}
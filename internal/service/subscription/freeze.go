@@ -0,0 +1,143 @@
+package subscription_service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/repository"
+	"strings"
+	"time"
+)
+
+// errNotFrozen у абонемента нет действующей или запланированной заморозки
+var errNotFrozen = errors.New("абонемент не заморожен")
+
+// Заморозить абонемент на период [startDate, endDate]: срок абонемента сдвигается
+// на длительность заморозки, пока она действует — абонемент не считается активным.
+// Проверки идут в транзакции под блокировкой абонемента: две параллельные заморозки
+// выполняются по очереди, и вторая видит первую
+func (s *subscriptionService) FreezeSubscription(subscriptionID int64, startDate, endDate time.Time, reason string, createdBy int64) (*models.SubscriptionFreeze, error) {
+	startDate, endDate = dateOf(startDate), dateOf(endDate)
+	today := dateOf(time.Now())
+
+	switch {
+	case startDate.Before(today):
+		return nil, errors.New("заморозка не может начинаться в прошлом")
+	case endDate.Before(startDate):
+		return nil, errors.New("дата окончания заморозки раньше даты начала")
+	}
+
+	freeze := &models.SubscriptionFreeze{
+		SubscriptionID: subscriptionID,
+		Reason:         strings.TrimSpace(reason),
+		StartDate:      startDate,
+		EndDate:        endDate,
+		CreatedBy:      models.OptionalUserID(createdBy),
+	}
+
+	err := s.transactor.WithinTransaction(func(tx repository.TxRepositories) error {
+		subscription, err := tx.Subscriptions.GetByIDForUpdate(subscriptionID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("абонемент с ID %d не найден", subscriptionID)
+		}
+		if err != nil {
+			return err
+		}
+		if subscription.RemainingLessons <= 0 {
+			return errors.New("в абонементе не осталось занятий")
+		}
+		if !startDate.Before(dateOf(subscription.EndDate)) {
+			return errors.New("абонемент закончится раньше начала заморозки")
+		}
+
+		current, err := currentFreeze(tx.Freezes, subscriptionID)
+		if err != nil {
+			return err
+		}
+		if current != nil {
+			return fmt.Errorf("абонемент уже заморожен с %s по %s",
+				current.StartDate.Format("02.01.2006"), current.EndDate.Format("02.01.2006"))
+		}
+
+		if err := tx.Freezes.Create(freeze); err != nil {
+			return fmt.Errorf("ошибка сохранения заморозки: %w", err)
+		}
+		return tx.Subscriptions.ExtendEndDate(subscriptionID, freeze.Days())
+	})
+	if err != nil {
+		return nil, err
+	}
+	return freeze, nil
+}
+
+// Досрочно снять заморозку: неиспользованные дни заморозки вычитаются из срока абонемента.
+// Начавшаяся заморозка заканчивается вчерашним днём, не начавшаяся — удаляется целиком.
+func (s *subscriptionService) UnfreezeSubscription(subscriptionID int64) (*models.SubscriptionFreeze, error) {
+	yesterday := dateOf(time.Now()).AddDate(0, 0, -1)
+
+	var freeze *models.SubscriptionFreeze
+	err := s.transactor.WithinTransaction(func(tx repository.TxRepositories) error {
+		if _, err := tx.Subscriptions.GetByIDForUpdate(subscriptionID); err != nil {
+			return err
+		}
+		var err error
+		freeze, err = currentFreeze(tx.Freezes, subscriptionID)
+		if err != nil {
+			return err
+		}
+		if freeze == nil {
+			return errNotFrozen
+		}
+		plannedDays := freeze.Days()
+
+		if yesterday.Before(freeze.StartDate) {
+			if err := tx.Freezes.Delete(freeze.ID); err != nil {
+				return err
+			}
+			return tx.Subscriptions.ExtendEndDate(subscriptionID, -plannedDays)
+		}
+
+		if err := tx.Freezes.UpdateEndDate(freeze.ID, yesterday); err != nil {
+			return err
+		}
+		freeze.EndDate = yesterday
+		return tx.Subscriptions.ExtendEndDate(subscriptionID, -(plannedDays - freeze.Days()))
+	})
+	if errors.Is(err, errNotFrozen) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка снятия заморозки: %w", err)
+	}
+	return freeze, nil
+}
+
+func (s *subscriptionService) GetCurrentFreeze(subscriptionID int64) (*models.SubscriptionFreeze, error) {
+	return currentFreeze(s.freezeRepo, subscriptionID)
+}
+
+// currentFreeze действующая или запланированная заморозка абонемента; nil, если её нет
+func currentFreeze(freezes repository.SubscriptionFreezeRepository, subscriptionID int64) (*models.SubscriptionFreeze, error) {
+	all, err := freezes.GetBySubscriptionID(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	today := dateOf(time.Now())
+	for _, freeze := range all {
+		if !freeze.EndDate.Before(today) {
+			return &freeze, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *subscriptionService) GetSubscriptionFreezes(subscriptionID int64) ([]models.SubscriptionFreeze, error) {
+	return s.freezeRepo.GetBySubscriptionID(subscriptionID)
+}
+
+// dateOf календарный день t в виде значения колонки DATE (полночь UTC, как отдаёт lib/pq)
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,140 @@
+package subscription_service
+
+import (
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/repository/memory"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFreezeAndUnfreezeShiftEndDate(t *testing.T) {
+	end := time.Now().AddDate(0, 1, 0)
+	today := time.Now()
+
+	tests := []struct {
+		name      string
+		start     int // начало заморозки относительно сегодня, дней
+		length    int // длительность заморозки, дней
+		unfreeze  bool
+		wantShift int // на сколько дней сдвинулся срок абонемента
+	}{
+		{name: "неделя с сегодня", start: 0, length: 7, wantShift: 7},
+		{name: "один день через неделю", start: 7, length: 1, wantShift: 1},
+		{name: "снята до начала", start: 2, length: 7, unfreeze: true, wantShift: 0},
+		{name: "снята в первый день", start: 0, length: 7, unfreeze: true, wantShift: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _, subscriptionID := newTestService(t, models.Subscription{RemainingLessons: 3, EndDate: end})
+
+			freeze, err := svc.FreezeSubscription(subscriptionID,
+				today.AddDate(0, 0, tt.start), today.AddDate(0, 0, tt.start+tt.length-1), "Болезнь", coachUserID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if freeze.Days() != tt.length {
+				t.Errorf("Days() = %d, want %d", freeze.Days(), tt.length)
+			}
+			if tt.unfreeze {
+				if _, err := svc.UnfreezeSubscription(subscriptionID); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := svc.GetSubscriptionByID(subscriptionID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := end.AddDate(0, 0, tt.wantShift); !got.EndDate.Equal(want) {
+				t.Errorf("EndDate = %v, want %v", got.EndDate, want)
+			}
+		})
+	}
+}
+
+func TestUnfreezeStartedFreezeReturnsUnusedDays(t *testing.T) {
+	end := time.Now().AddDate(0, 1, 0)
+	svc, store, subscriptionID := newTestService(t, models.Subscription{RemainingLessons: 3, EndDate: end})
+
+	// Заморозка на неделю, начавшаяся три дня назад: срок уже сдвинут на 7 дней
+	today := dateOf(time.Now())
+	freeze := &models.SubscriptionFreeze{SubscriptionID: subscriptionID, StartDate: today.AddDate(0, 0, -3), EndDate: today.AddDate(0, 0, 3)}
+	if err := memory.NewSubscriptionFreezeRepository(store).Create(freeze); err != nil {
+		t.Fatal(err)
+	}
+	if err := memory.NewSubscriptionRepository(store, models.ConsumptionExpiringFirst).ExtendEndDate(subscriptionID, freeze.Days()); err != nil {
+		t.Fatal(err)
+	}
+
+	unfrozen, err := svc.UnfreezeSubscription(subscriptionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := today.AddDate(0, 0, -1); !unfrozen.EndDate.Equal(want) || unfrozen.Days() != 3 {
+		t.Errorf("заморозка после снятия = %s–%s, want до %s, 3 дня", unfrozen.StartDate, unfrozen.EndDate, want)
+	}
+
+	got, err := svc.GetSubscriptionByID(subscriptionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := end.AddDate(0, 0, 3); !got.EndDate.Equal(want) {
+		t.Errorf("EndDate = %v, want %v (продлён только на использованные 3 дня)", got.EndDate, want)
+	}
+	if current, err := svc.GetCurrentFreeze(subscriptionID); err != nil || current != nil {
+		t.Errorf("GetCurrentFreeze() = %+v, %v, want nil", current, err)
+	}
+	if _, err := svc.UnfreezeSubscription(subscriptionID); err == nil {
+		t.Error("повторное снятие заморозки: error = nil")
+	}
+}
+
+func TestConcurrentFreezesExtendOnce(t *testing.T) {
+	end := time.Now().AddDate(0, 1, 0)
+	svc, _, subscriptionID := newTestService(t, models.Subscription{RemainingLessons: 3, EndDate: end})
+
+	const requests = 32
+	today := time.Now()
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	errs := make(chan error, requests)
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			_, err := svc.FreezeSubscription(subscriptionID, today, today.AddDate(0, 0, 6), "Отпуск", coachUserID)
+			errs <- err
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		if err == nil {
+			succeeded++
+		}
+	}
+	if succeeded != 1 {
+		t.Errorf("успешных заморозок = %d, want 1", succeeded)
+	}
+
+	freezes, err := svc.GetSubscriptionFreezes(subscriptionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(freezes) != 1 {
+		t.Errorf("заморозок = %d, want 1", len(freezes))
+	}
+	got, err := svc.GetSubscriptionByID(subscriptionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := end.AddDate(0, 0, 7); !got.EndDate.Equal(want) {
+		t.Errorf("EndDate = %v, want %v", got.EndDate, want)
+	}
+}
@@ -0,0 +1,45 @@
+package subscription_service
+
+import (
+	"errors"
+	"fmt"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/repository"
+	"strings"
+)
+
+// Ручная корректировка остатка занятий тренером (ошибочное списание, подарочное занятие и т.п.)
+func (s *subscriptionService) AdjustLessons(subscriptionID int64, delta int, actorID int64, comment string) error {
+	comment = strings.TrimSpace(comment)
+	switch {
+	case delta == 0:
+		return errors.New("корректировка должна менять количество занятий")
+	case comment == "":
+		return errors.New("укажите причину корректировки")
+	}
+
+	return s.transactor.WithinTransaction(func(tx repository.TxRepositories) error {
+		if err := tx.Subscriptions.AddRemainingLessons(subscriptionID, delta); err != nil {
+			return err
+		}
+		err := tx.Ledger.Add(&models.LessonLedgerEntry{
+			SubscriptionID: subscriptionID,
+			Delta:          delta,
+			Reason:         models.LedgerReasonAdjustment,
+			ActorID:        models.OptionalUserID(actorID),
+			Comment:        comment,
+		})
+		if err != nil {
+			return fmt.Errorf("ошибка записи в журнал занятий: %w", err)
+		}
+		return nil
+	})
+}
+
+func (s *subscriptionService) GetLessonHistory(subscriptionID int64, limit int) ([]models.LessonLedgerEntry, error) {
+	return s.ledgerRepo.GetBySubscriptionID(subscriptionID, limit)
+}
+
+func (s *subscriptionService) ReconcileLessons() ([]models.LessonBalanceMismatch, error) {
+	return s.ledgerRepo.GetMismatches()
+}
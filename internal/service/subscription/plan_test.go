@@ -0,0 +1,141 @@
+package subscription_service
+
+import (
+	"spectrum-club-bot/internal/models"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func TestCreatePlanValidation(t *testing.T) {
+	evening := time.Date(0, 1, 1, 18, 0, 0, 0, time.UTC)
+	morning := time.Date(0, 1, 1, 9, 0, 0, 0, time.UTC)
+	valid := func(change func(plan *models.SubscriptionPlan)) models.SubscriptionPlan {
+		plan := models.SubscriptionPlan{Name: "8 занятий", Lessons: 8, DurationDays: 30, Price: 4000, IsActive: true}
+		change(&plan)
+		return plan
+	}
+
+	tests := []struct {
+		name     string
+		plan     models.SubscriptionPlan
+		wantErr  bool
+		wantName string
+	}{
+		{name: "тариф", plan: valid(func(p *models.SubscriptionPlan) {}), wantName: "8 занятий"},
+		{name: "пробелы в названии", plan: valid(func(p *models.SubscriptionPlan) { p.Name = "  Пробное  " }), wantName: "Пробное"},
+		{name: "бесплатный", plan: valid(func(p *models.SubscriptionPlan) { p.Price = 0 }), wantName: "8 занятий"},
+		{name: "выходные днём", plan: valid(func(p *models.SubscriptionPlan) {
+			p.Weekdays = pq.Int64Array{0, 6}
+			p.TimeFrom, p.TimeTo = &morning, &evening
+		}), wantName: "8 занятий"},
+		{name: "без названия", plan: valid(func(p *models.SubscriptionPlan) { p.Name = "   " }), wantErr: true},
+		{name: "без занятий", plan: valid(func(p *models.SubscriptionPlan) { p.Lessons = 0 }), wantErr: true},
+		{name: "без срока", plan: valid(func(p *models.SubscriptionPlan) { p.DurationDays = 0 }), wantErr: true},
+		{name: "отрицательная цена", plan: valid(func(p *models.SubscriptionPlan) { p.Price = -1 }), wantErr: true},
+		{name: "время наоборот", plan: valid(func(p *models.SubscriptionPlan) { p.TimeFrom, p.TimeTo = &evening, &morning }), wantErr: true},
+		{name: "нет такого дня недели", plan: valid(func(p *models.SubscriptionPlan) { p.Weekdays = pq.Int64Array{7} }), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _, _ := newTestService(t, models.Subscription{RemainingLessons: 3})
+
+			plan := tt.plan
+			err := svc.CreatePlan(&plan)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CreatePlan() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			plans, err := svc.GetPlans(true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantErr {
+				if len(plans) != 0 {
+					t.Errorf("тарифы = %+v, want ни одного", plans)
+				}
+				return
+			}
+			if len(plans) != 1 || plans[0].Name != tt.wantName {
+				t.Errorf("тарифы = %+v, want один «%s»", plans, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestPlanLifecycle(t *testing.T) {
+	svc, _, subscriptionID := newTestService(t, models.Subscription{RemainingLessons: 3})
+	existing, err := svc.GetSubscriptionByID(subscriptionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	studentID := existing.StudentID
+
+	plan := &models.SubscriptionPlan{Name: "16 занятий", Lessons: 16, DurationDays: 30, Price: 8000, IsActive: true,
+		SubscriptionRestrictions: models.SubscriptionRestrictions{Weekdays: pq.Int64Array{1, 3}}}
+	if err := svc.CreatePlan(plan); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.CreatePlan(&models.SubscriptionPlan{Name: "Пробное", Lessons: 1, DurationDays: 7, IsActive: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.CreatePlan(&models.SubscriptionPlan{Name: plan.Name, Lessons: 8, DurationDays: 30}); err == nil {
+		t.Error("второй тариф с тем же названием: error = nil")
+	}
+
+	// Выдача по тарифу переносит занятия, срок и ограничения
+	subscription, err := svc.CreateFromPlan(studentID, plan.ID, coachUserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subscription.TotalLessons != 16 || subscription.RemainingLessons != 16 ||
+		subscription.PlanID == nil || *subscription.PlanID != plan.ID || len(subscription.Weekdays) != 2 {
+		t.Errorf("абонемент по тарифу = %+v", subscription)
+	}
+	if want := subscription.StartDate.AddDate(0, 0, 30); !subscription.EndDate.Equal(want) {
+		t.Errorf("EndDate = %v, want %v", subscription.EndDate, want)
+	}
+
+	// Снятый с продажи тариф остаётся в списке тренера, но не выдаётся
+	plan.Price = 8500
+	plan.IsActive = false
+	if err := svc.UpdatePlan(plan); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := svc.GetPlanByID(plan.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Price != 8500 || stored.IsActive {
+		t.Errorf("тариф после изменения = %+v", stored)
+	}
+	if active, err := svc.GetPlans(false); err != nil || len(active) != 1 || active[0].Name != "Пробное" {
+		t.Errorf("GetPlans(false) = %+v, %v, want только «Пробное»", active, err)
+	}
+	if all, err := svc.GetPlans(true); err != nil || len(all) != 2 {
+		t.Errorf("GetPlans(true) = %+v, %v, want 2 тарифа", all, err)
+	}
+	if _, err := svc.CreateFromPlan(studentID, plan.ID, coachUserID); err == nil {
+		t.Error("выдача по снятому тарифу: error = nil")
+	}
+	if _, err := svc.CreateFromPlan(studentID, 999, coachUserID); err == nil {
+		t.Error("выдача по несуществующему тарифу: error = nil")
+	}
+
+	// Изменение тарифа не меняет уже выданный абонемент
+	issued, err := svc.GetSubscriptionByID(subscription.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if issued.TotalLessons != 16 {
+		t.Errorf("выданный абонемент после изменения тарифа = %+v", issued)
+	}
+
+	invalid := *stored
+	invalid.Lessons = 0
+	if err := svc.UpdatePlan(&invalid); err == nil {
+		t.Error("UpdatePlan() без занятий: error = nil")
+	}
+}
@@ -1,19 +1,43 @@
 package subscription_service
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
 	"spectrum-club-bot/internal/models"
 	"spectrum-club-bot/internal/repository"
 	"spectrum-club-bot/internal/service"
+	"strings"
 	"time"
 )
 
+// Ограничения одного продления: защищают от опечаток при вводе в боте
+const (
+	maxExtensionDays    = 730
+	maxExtensionLessons = 100
+)
+
 type subscriptionService struct {
 	subscriptionRepo repository.SubscriptionRepository
+	planRepo         repository.SubscriptionPlanRepository
+	freezeRepo       repository.SubscriptionFreezeRepository
+	ledgerRepo       repository.LessonLedgerRepository
+	transactor       repository.Transactor
 }
 
-func NewSubscriptionService(subscriptionRepo repository.SubscriptionRepository) service.SubscriptionService {
+func NewSubscriptionService(
+	subscriptionRepo repository.SubscriptionRepository,
+	planRepo repository.SubscriptionPlanRepository,
+	freezeRepo repository.SubscriptionFreezeRepository,
+	ledgerRepo repository.LessonLedgerRepository,
+	transactor repository.Transactor,
+) service.SubscriptionService {
 	return &subscriptionService{
 		subscriptionRepo: subscriptionRepo,
+		planRepo:         planRepo,
+		freezeRepo:       freezeRepo,
+		ledgerRepo:       ledgerRepo,
+		transactor:       transactor,
 	}
 }
 
@@ -26,7 +50,7 @@ func (s *subscriptionService) CreateSubscription(studentID int64, remainingLesso
 		RemainingLessons: remainingLessons,
 		CreatedAt:        time.Now(),
 	}
-	return s.subscriptionRepo.Create(subscription)
+	return s.createWithPurchase(subscription, 0, "")
 }
 func (s *subscriptionService) DeleteSubscription(subscriptionID int64) error {
 	return s.subscriptionRepo.Delete(subscriptionID)
@@ -44,92 +68,219 @@ func (s *subscriptionService) GetActiveSubscription(studentID int64) (*models.Su
 	return s.subscriptionRepo.GetActiveByStudentID(studentID)
 }
 
-func (s *subscriptionService) UseLesson(subscriptionID int64) error {
-	// subscription, err := s.subscriptionRepo.GetByStudentID(subscriptionID)
-	// if err != nil {
-	// 	return err
-	// }
+func (s *subscriptionService) GetActiveSubscriptionForTraining(studentID int64, trainingID int) (*models.Subscription, error) {
+	return s.subscriptionRepo.GetActiveForTraining(studentID, trainingID)
+}
 
-	// if subscription.UsedLessons < subscription.TotalLessons {
-	// 	subscription.UsedLessons++
+func (s *subscriptionService) GetChargedSubscription(attendanceID int) (*models.Subscription, error) {
+	entries, err := s.ledgerRepo.GetByAttendanceID(attendanceID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения журнала занятий: %w", err)
+	}
 
-	// 	// Если использованы все занятия, деактивируем абонемент
-	// 	if subscription.UsedLessons >= subscription.TotalLessons {
-	// 		subscription.IsActive = false
-	// 	}
+	// Списание и возвраты за одну запись: занятие остаётся списанным с того абонемента,
+	// по которому сумма отрицательна
+	charged := make(map[int64]int)
+	for _, entry := range entries {
+		charged[entry.SubscriptionID] += entry.Delta
+	}
+	for _, entry := range entries {
+		if charged[entry.SubscriptionID] < 0 {
+			return s.subscriptionRepo.GetByID(entry.SubscriptionID)
+		}
+	}
+	return nil, sql.ErrNoRows
+}
 
-	// 	return s.subscriptionRepo.Update(subscription)
-	// }
+func (s *subscriptionService) GetSubscriptionByID(subscriptionID int64) (*models.Subscription, error) {
+	return s.subscriptionRepo.GetByID(subscriptionID)
+}
+
+// Списать одно занятие с конкретного абонемента без привязки к тренировке
+// (индивидуальное занятие, тренировка вне расписания). Списание попадает в журнал
+// как корректировка с автором и причиной.
+func (s *subscriptionService) UseLesson(subscriptionID int64, actorID int64, comment string) error {
+	comment = strings.TrimSpace(comment)
+	if comment == "" {
+		return errors.New("укажите причину списания занятия")
+	}
+
+	subscription, err := s.getSubscription(subscriptionID)
+	if err != nil {
+		return err
+	}
+	if subscription.RemainingLessons <= 0 {
+		return errors.New("в абонементе не осталось занятий")
+	}
+	if !subscription.EndDate.IsZero() && !subscription.EndDate.After(time.Now()) {
+		return errors.New("срок действия абонемента истёк")
+	}
+	freeze, err := s.GetCurrentFreeze(subscriptionID)
+	if err != nil {
+		return err
+	}
+	if freeze != nil && freeze.Covers(time.Now()) {
+		return errors.New("абонемент заморожен")
+	}
 
+	err = s.transactor.WithinTransaction(func(tx repository.TxRepositories) error {
+		if err := tx.Subscriptions.AddRemainingLessons(subscriptionID, -1); err != nil {
+			return err
+		}
+		return tx.Ledger.Add(&models.LessonLedgerEntry{
+			SubscriptionID: subscriptionID,
+			Delta:          -1,
+			Reason:         models.LedgerReasonAdjustment,
+			ActorID:        models.OptionalUserID(actorID),
+			Comment:        comment,
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("ошибка списания занятия: %w", err)
+	}
 	return nil
 }
 
-func (s *subscriptionService) ExtendSubscription(subscriptionID int64, additionalMonths int) error {
-	// subscription, err := s.subscriptionRepo.GetByStudentID(subscriptionID)
-	// if err != nil {
-	// 	return err
-	// }
+// Продлить абонемент на extraDays дней и/или extraLessons занятий.
+// Истёкший абонемент продлевается от текущего момента; добавленные занятия попадают в журнал
+// корректировкой, а не покупкой: новый абонемент при продлении не выдаётся.
+func (s *subscriptionService) ExtendSubscription(subscriptionID int64, extraDays int, extraLessons int, actorID int64) (*models.Subscription, error) {
+	switch {
+	case extraDays < 0 || extraLessons < 0:
+		return nil, errors.New("продление не может уменьшать срок или количество занятий")
+	case extraDays == 0 && extraLessons == 0:
+		return nil, errors.New("укажите, на сколько дней или занятий продлить абонемент")
+	case extraDays > maxExtensionDays:
+		return nil, fmt.Errorf("продлить можно не больше чем на %d дней", maxExtensionDays)
+	case extraLessons > maxExtensionLessons:
+		return nil, fmt.Errorf("добавить можно не больше %d занятий", maxExtensionLessons)
+	}
 
-	// // Продлеваем абонемент
-	// subscription.EndDate = subscription.EndDate.AddDate(0, additionalMonths, 0)
-	// subscription.IsActive = true
+	if _, err := s.getSubscription(subscriptionID); err != nil {
+		return nil, err
+	}
 
-	// return s.subscriptionRepo.Update(subscription)
-	return nil
+	err := s.transactor.WithinTransaction(func(tx repository.TxRepositories) error {
+		if err := tx.Subscriptions.Extend(subscriptionID, extraDays, extraLessons); err != nil {
+			return err
+		}
+		if extraLessons == 0 {
+			return nil
+		}
+		return tx.Ledger.Add(&models.LessonLedgerEntry{
+			SubscriptionID: subscriptionID,
+			Delta:          extraLessons,
+			Reason:         models.LedgerReasonAdjustment,
+			ActorID:        models.OptionalUserID(actorID),
+			Comment:        "Продление абонемента",
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка продления абонемента: %w", err)
+	}
+	return s.subscriptionRepo.GetByID(subscriptionID)
 }
 
+// Все абонементы ученика, новые сначала
 func (s *subscriptionService) GetSubscriptionHistory(studentID int64) ([]*models.Subscription, error) {
-	// Этот метод потребует добавления нового метода в репозиторий
-	// Пока заглушка - вернем только активный абонемент
-	activeSub, err := s.subscriptionRepo.GetActiveByStudentID(studentID)
+	return s.subscriptionRepo.GetHistoryByStudentID(studentID)
+}
+
+func (s *subscriptionService) getSubscription(subscriptionID int64) (*models.Subscription, error) {
+	subscription, err := s.subscriptionRepo.GetByID(subscriptionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("абонемент с ID %d не найден", subscriptionID)
+	}
 	if err != nil {
 		return nil, err
 	}
+	return subscription, nil
+}
 
-	if activeSub != nil {
-		return []*models.Subscription{activeSub}, nil
+func (s *subscriptionService) CreateFromPlan(studentID int64, planID int64, issuedBy int64) (*models.Subscription, error) {
+	plan, err := s.planRepo.GetByID(planID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("тариф с ID %d не найден", planID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !plan.IsActive {
+		return nil, fmt.Errorf("тариф «%s» больше не выдаётся", plan.Name)
 	}
 
-	return []*models.Subscription{}, nil
+	subscription := plan.NewSubscription(studentID, time.Now())
+	if err := s.createWithPurchase(subscription, issuedBy, plan.Name); err != nil {
+		return nil, err
+	}
+	return subscription, nil
 }
 
-func (s *subscriptionService) Create12Unlimited(studentID int64) error {
-	subscription := &models.Subscription{
-		StudentID:        studentID,
-		StartDate:        time.Now(),
-		EndDate:          time.Now().AddDate(2, 0, 0), // 2 года
-		TotalLessons:     12,
-		RemainingLessons: 12,
-		CreatedAt:        time.Now(),
+// createWithPurchase создаёт абонемент вместе с записью о покупке в журнале занятий
+func (s *subscriptionService) createWithPurchase(subscription *models.Subscription, issuedBy int64, comment string) error {
+	return s.transactor.WithinTransaction(func(tx repository.TxRepositories) error {
+		return CreateWithPurchase(tx, subscription, issuedBy, comment)
+	})
+}
+
+// CreateWithPurchase создаёт абонемент и запись о покупке в транзакции tx: оплата вызывает его
+// сама, чтобы записать платёж в той же транзакции
+func CreateWithPurchase(tx repository.TxRepositories, subscription *models.Subscription, issuedBy int64, comment string) error {
+	if err := tx.Subscriptions.Create(subscription); err != nil {
+		return err
+	}
+	if subscription.RemainingLessons == 0 {
+		return nil
 	}
-	return s.subscriptionRepo.Create(subscription)
+	return tx.Ledger.Add(&models.LessonLedgerEntry{
+		SubscriptionID: subscription.ID,
+		Delta:          subscription.RemainingLessons,
+		Reason:         models.LedgerReasonPurchase,
+		ActorID:        models.OptionalUserID(issuedBy),
+		Comment:        comment,
+	})
 }
 
-func (s *subscriptionService) Create16For30Days(studentID int64) error {
-	subscription := &models.Subscription{
-		StudentID:        studentID,
-		StartDate:        time.Now(),
-		EndDate:          time.Now().AddDate(0, 0, 30), // 30 дней
-		TotalLessons:     16,
-		RemainingLessons: 16,
-		CreatedAt:        time.Now(),
+func (s *subscriptionService) GetPlans(includeInactive bool) ([]models.SubscriptionPlan, error) {
+	return s.planRepo.GetAll(!includeInactive)
+}
+
+func (s *subscriptionService) GetPlanByID(planID int64) (*models.SubscriptionPlan, error) {
+	return s.planRepo.GetByID(planID)
+}
+
+func (s *subscriptionService) CreatePlan(plan *models.SubscriptionPlan) error {
+	if err := validatePlan(plan); err != nil {
+		return err
 	}
-	return s.subscriptionRepo.Create(subscription)
+	return s.planRepo.Create(plan)
 }
 
-func (s *subscriptionService) Create1For30Days(studentID int64) error {
-	subscription := &models.Subscription{
-		StudentID:        studentID,
-		StartDate:        time.Now(),
-		EndDate:          time.Now().AddDate(0, 0, 30), // 30 дней
-		TotalLessons:     1,
-		RemainingLessons: 1,
-		CreatedAt:        time.Now(),
+func (s *subscriptionService) UpdatePlan(plan *models.SubscriptionPlan) error {
+	if err := validatePlan(plan); err != nil {
+		return err
 	}
-	return s.subscriptionRepo.Create(subscription)
+	return s.planRepo.Update(plan)
 }
 
-// DecrementRemainingLessons уменьшает remaining_lessons на 1 для активного абонемента ученика
-func (s *subscriptionService) DecrementRemainingLessons(studentID int64) error {
-	return s.subscriptionRepo.DecrementRemainingLessons(studentID)
+func validatePlan(plan *models.SubscriptionPlan) error {
+	plan.Name = strings.TrimSpace(plan.Name)
+	switch {
+	case plan.Name == "":
+		return errors.New("название тарифа не может быть пустым")
+	case plan.Lessons <= 0:
+		return errors.New("количество занятий должно быть больше нуля")
+	case plan.DurationDays <= 0:
+		return errors.New("срок действия должен быть больше нуля")
+	case plan.Price < 0:
+		return errors.New("цена не может быть отрицательной")
+	case plan.TimeFrom != nil && plan.TimeTo != nil && !plan.TimeFrom.Before(*plan.TimeTo):
+		return errors.New("время начала ограничения должно быть раньше времени окончания")
+	}
+	for _, day := range plan.Weekdays {
+		if day < 0 || day > 6 {
+			return fmt.Errorf("неверный день недели %d", day)
+		}
+	}
+	return nil
 }
@@ -0,0 +1,186 @@
+package subscription_service
+
+import (
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/repository/memory"
+	"spectrum-club-bot/internal/service"
+	"testing"
+	"time"
+)
+
+const coachUserID int64 = 7
+
+// newTestService сервис поверх in-memory хранилища с одним абонементом
+func newTestService(t *testing.T, subscription models.Subscription) (service.SubscriptionService, *memory.Store, int64) {
+	t.Helper()
+	store := memory.NewStore()
+	order := models.ConsumptionExpiringFirst
+	subscriptions := memory.NewSubscriptionRepository(store, order)
+
+	user := &models.User{TelegramID: -1, FirstName: "Анна", LastName: "Тестова", Role: "student"}
+	if err := memory.NewUserRepository(store).CreateOrUpdate(user); err != nil {
+		t.Fatal(err)
+	}
+	student := &models.Student{UserID: user.ID}
+	if err := memory.NewStudentRepository(store).Create(student); err != nil {
+		t.Fatal(err)
+	}
+
+	subscription.StudentID = student.ID
+	subscription.StartDate = time.Now().AddDate(0, 0, -1)
+	subscription.CreatedAt = subscription.StartDate
+	subscription.TotalLessons = 8
+	if err := subscriptions.Create(&subscription); err != nil {
+		t.Fatal(err)
+	}
+
+	svc := NewSubscriptionService(
+		subscriptions,
+		memory.NewSubscriptionPlanRepository(store),
+		memory.NewSubscriptionFreezeRepository(store),
+		memory.NewLessonLedgerRepository(store),
+		memory.NewTransactor(store, order),
+	)
+	return svc, store, subscription.ID
+}
+
+func TestUseLesson(t *testing.T) {
+	month := time.Now().AddDate(0, 1, 0)
+
+	tests := []struct {
+		name          string
+		subscription  models.Subscription
+		frozen        bool
+		comment       string
+		wantErr       bool
+		wantRemaining int
+	}{
+		{name: "списание", subscription: models.Subscription{RemainingLessons: 3, EndDate: month}, comment: "Индивидуальное занятие", wantRemaining: 2},
+		{name: "бессрочный", subscription: models.Subscription{RemainingLessons: 1}, comment: "Индивидуальное занятие", wantRemaining: 0},
+		{name: "без причины", subscription: models.Subscription{RemainingLessons: 3, EndDate: month}, comment: "  ", wantErr: true, wantRemaining: 3},
+		{name: "занятия закончились", subscription: models.Subscription{RemainingLessons: 0, EndDate: month}, comment: "Индивидуальное занятие", wantErr: true},
+		{name: "срок истёк", subscription: models.Subscription{RemainingLessons: 3, EndDate: time.Now().AddDate(0, 0, -1)}, comment: "Индивидуальное занятие", wantErr: true, wantRemaining: 3},
+		{name: "заморожен", subscription: models.Subscription{RemainingLessons: 3, EndDate: month}, frozen: true, comment: "Индивидуальное занятие", wantErr: true, wantRemaining: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, store, subscriptionID := newTestService(t, tt.subscription)
+			if tt.frozen {
+				freeze := &models.SubscriptionFreeze{SubscriptionID: subscriptionID, StartDate: time.Now().AddDate(0, 0, -1), EndDate: time.Now().AddDate(0, 0, 3)}
+				if err := memory.NewSubscriptionFreezeRepository(store).Create(freeze); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := svc.UseLesson(subscriptionID, coachUserID, tt.comment)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UseLesson() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			got, err := svc.GetSubscriptionByID(subscriptionID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.RemainingLessons != tt.wantRemaining {
+				t.Errorf("RemainingLessons = %d, want %d", got.RemainingLessons, tt.wantRemaining)
+			}
+
+			history, err := svc.GetLessonHistory(subscriptionID, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantErr {
+				if len(history) != 0 {
+					t.Errorf("журнал = %+v, want пустой", history)
+				}
+				return
+			}
+			if len(history) != 1 {
+				t.Fatalf("записей в журнале = %d, want 1", len(history))
+			}
+			entry := history[0]
+			if entry.Delta != -1 || entry.Reason != models.LedgerReasonAdjustment || entry.Comment != tt.comment {
+				t.Errorf("запись журнала = %+v", entry)
+			}
+			if entry.ActorID == nil || *entry.ActorID != coachUserID {
+				t.Errorf("ActorID = %v, want %d", entry.ActorID, coachUserID)
+			}
+		})
+	}
+}
+
+func TestExtendSubscription(t *testing.T) {
+	month := time.Now().AddDate(0, 1, 0)
+	expired := time.Now().AddDate(0, 0, -3)
+
+	tests := []struct {
+		name          string
+		endDate       time.Time
+		extraDays     int
+		extraLessons  int
+		wantErr       bool
+		wantRemaining int
+		wantLedger    int
+		wantEnd       time.Time // ожидаемый срок; пусто — отсчёт от текущего момента
+	}{
+		{name: "занятия", endDate: month, extraLessons: 4, wantRemaining: 7, wantLedger: 1, wantEnd: month},
+		{name: "дни", endDate: month, extraDays: 10, wantRemaining: 3, wantEnd: month.AddDate(0, 0, 10)},
+		{name: "дни и занятия", endDate: month, extraDays: 10, extraLessons: 2, wantRemaining: 5, wantLedger: 1, wantEnd: month.AddDate(0, 0, 10)},
+		{name: "истёкший, только занятия", endDate: expired, extraLessons: 4, wantRemaining: 7, wantLedger: 1, wantEnd: expired},
+		{name: "истёкший, дни от сегодня", endDate: expired, extraDays: 10, wantRemaining: 3},
+		{name: "ничего", endDate: month, wantErr: true, wantRemaining: 3, wantEnd: month},
+		{name: "отрицательные занятия", endDate: month, extraLessons: -1, wantErr: true, wantRemaining: 3, wantEnd: month},
+		{name: "отрицательные дни", endDate: month, extraDays: -1, wantErr: true, wantRemaining: 3, wantEnd: month},
+		{name: "слишком много занятий", endDate: month, extraLessons: maxExtensionLessons + 1, wantErr: true, wantRemaining: 3, wantEnd: month},
+		{name: "слишком много дней", endDate: month, extraDays: maxExtensionDays + 1, wantErr: true, wantRemaining: 3, wantEnd: month},
+		{name: "ровно по пределу", endDate: month, extraDays: maxExtensionDays, extraLessons: maxExtensionLessons, wantRemaining: 3 + maxExtensionLessons, wantLedger: 1, wantEnd: month.AddDate(0, 0, maxExtensionDays)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _, subscriptionID := newTestService(t, models.Subscription{RemainingLessons: 3, EndDate: tt.endDate})
+
+			before := time.Now()
+			got, err := svc.ExtendSubscription(subscriptionID, tt.extraDays, tt.extraLessons, coachUserID)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExtendSubscription() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got == nil {
+				got, err = svc.GetSubscriptionByID(subscriptionID)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got.RemainingLessons != tt.wantRemaining {
+				t.Errorf("RemainingLessons = %d, want %d", got.RemainingLessons, tt.wantRemaining)
+			}
+			if tt.wantEnd.IsZero() {
+				from, to := before.AddDate(0, 0, tt.extraDays), time.Now().AddDate(0, 0, tt.extraDays)
+				if got.EndDate.Before(from) || got.EndDate.After(to) {
+					t.Errorf("EndDate = %v, want %d дней от текущего момента", got.EndDate, tt.extraDays)
+				}
+			} else if !got.EndDate.Equal(tt.wantEnd) {
+				t.Errorf("EndDate = %v, want %v", got.EndDate, tt.wantEnd)
+			}
+
+			history, err := svc.GetLessonHistory(subscriptionID, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(history) != tt.wantLedger {
+				t.Fatalf("записей в журнале = %d, want %d", len(history), tt.wantLedger)
+			}
+			if tt.wantLedger == 0 {
+				return
+			}
+			entry := history[0]
+			if entry.Reason != models.LedgerReasonAdjustment || entry.Delta != tt.extraLessons {
+				t.Errorf("запись журнала = %s %+d, want %s %+d", entry.Reason, entry.Delta, models.LedgerReasonAdjustment, tt.extraLessons)
+			}
+			if entry.ActorID == nil || *entry.ActorID != coachUserID {
+				t.Errorf("ActorID = %v, want %d", entry.ActorID, coachUserID)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package telegram
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// DefaultAPIURL адрес официального Bot API
+const DefaultAPIURL = "https://api.telegram.org"
+
+// MethodURL адрес метода Bot API: <apiURL>/bot<token>/<method>
+func MethodURL(apiURL, token, method string) string {
+	return fmt.Sprintf("%s/bot%s/%s", normalize(apiURL), token, method)
+}
+
+// NewBotAPI создаёт клиент tgbotapi, который ходит на apiURL вместо api.telegram.org.
+// В библиотеке адрес зашит константой, поэтому подменяем его на уровне http.Client.
+func NewBotAPI(token, apiURL string) (*tgbotapi.BotAPI, error) {
+	client, err := NewHTTPClient(apiURL)
+	if err != nil {
+		return nil, err
+	}
+	return tgbotapi.NewBotAPIWithClient(token, client)
+}
+
+// NewHTTPClient http.Client, переписывающий запросы к api.telegram.org на apiURL
+func NewHTTPClient(apiURL string) (*http.Client, error) {
+	apiURL = normalize(apiURL)
+	if apiURL == DefaultAPIURL {
+		return &http.Client{}, nil
+	}
+
+	target, err := url.Parse(apiURL)
+	if err != nil {
+		return nil, fmt.Errorf("неверный адрес Telegram API %q: %w", apiURL, err)
+	}
+	if target.Scheme == "" || target.Host == "" {
+		return nil, fmt.Errorf("неверный адрес Telegram API %q: нужен полный URL со схемой", apiURL)
+	}
+
+	return &http.Client{
+		Transport: &rewriteTransport{target: target, base: http.DefaultTransport},
+	}, nil
+}
+
+type rewriteTransport struct {
+	target *url.URL
+	base   http.RoundTripper
+}
+
+func (t *rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host != "api.telegram.org" {
+		return t.base.RoundTrip(req)
+	}
+
+	// RoundTrip не должен менять исходный запрос
+	rewritten := req.Clone(req.Context())
+	rewritten.URL.Scheme = t.target.Scheme
+	rewritten.URL.Host = t.target.Host
+	rewritten.URL.Path = t.target.Path + req.URL.Path
+	rewritten.URL.RawPath = ""
+	rewritten.Host = t.target.Host
+
+	return t.base.RoundTrip(rewritten)
+}
+
+func normalize(apiURL string) string {
+	apiURL = strings.TrimRight(strings.TrimSpace(apiURL), "/")
+	if apiURL == "" {
+		return DefaultAPIURL
+	}
+	return apiURL
+}
@@ -0,0 +1,429 @@
+// Package telegramtest поддельный Telegram Bot API для офлайн-прогонов бота.
+//
+// Сервер записывает все вызовы методов (в первую очередь sendMessage) и отдаёт
+// через getUpdates заранее подложенные обновления, поэтому диалог с ботом можно
+// проиграть целиком без сети:
+//
+//	srv := telegramtest.NewServer("test-token")
+//	defer srv.Close()
+//	config.AppConfig.Bot.APIURL = srv.URL()
+//	srv.PushMessage(chatID, coachUser, "/add_subscription")
+//	replies, err := srv.WaitForMessages(1, time.Second)
+package telegramtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf16"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// maxPollWait верхняя граница ожидания в getUpdates, чтобы остановка бота не висела минуту
+const maxPollWait = 5 * time.Second
+
+// Request один вызов метода Bot API. Параметры JSON-запросов приводятся к url.Values:
+// строки и числа как есть, вложенные объекты (reply_markup и т.п.) — JSON-строкой.
+type Request struct {
+	Method string
+	Params url.Values
+}
+
+// SentMessage сообщение, отправленное ботом через sendMessage
+type SentMessage struct {
+	ChatID      int64
+	Text        string
+	ParseMode   string
+	ReplyMarkup string // JSON клавиатуры, если была
+}
+
+type Server struct {
+	Token string
+	Bot   tgbotapi.User // что отдаёт getMe
+
+	srv     *httptest.Server
+	closing chan struct{}
+
+	mu            sync.Mutex
+	requests      []Request
+	updates       []tgbotapi.Update
+	changed       chan struct{} // закрывается и пересоздаётся при каждом изменении
+	nextUpdateID  int
+	nextMessageID int
+	failures      map[string][]failure // метод -> ошибки, которые вернуть следующим вызовам
+}
+
+type failure struct {
+	code        int
+	description string
+	retryAfter  int
+}
+
+// NewServer запускает сервер; бот должен использовать тот же token
+func NewServer(token string) *Server {
+	s := &Server{
+		Token: token,
+		Bot: tgbotapi.User{
+			ID:        1,
+			FirstName: "Spectrum Test Bot",
+			UserName:  "spectrum_test_bot",
+			IsBot:     true,
+		},
+		closing:       make(chan struct{}),
+		failures:      make(map[string][]failure),
+		changed:       make(chan struct{}),
+		nextUpdateID:  1,
+		nextMessageID: 1,
+	}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// URL адрес для TELEGRAM_API_URL / config.Bot.APIURL
+func (s *Server) URL() string {
+	return s.srv.URL
+}
+
+func (s *Server) Close() {
+	close(s.closing)
+	s.srv.Close()
+}
+
+// PushUpdate ставит обновление в очередь getUpdates. Если UpdateID не задан, он назначается.
+func (s *Server) PushUpdate(update tgbotapi.Update) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if update.UpdateID == 0 {
+		update.UpdateID = s.nextUpdateID
+	}
+	if update.UpdateID >= s.nextUpdateID {
+		s.nextUpdateID = update.UpdateID + 1
+	}
+	s.updates = append(s.updates, update)
+	s.notifyLocked()
+	return update.UpdateID
+}
+
+// PushMessage кладёт текстовое сообщение от пользователя в личный чат chatID.
+// Текст, начинающийся с "/", помечается как команда.
+func (s *Server) PushMessage(chatID int64, from tgbotapi.User, text string) int {
+	message := &tgbotapi.Message{
+		MessageID: s.newMessageID(),
+		From:      &from,
+		Date:      int(time.Now().Unix()),
+		Chat:      &tgbotapi.Chat{ID: chatID, Type: "private", UserName: from.UserName, FirstName: from.FirstName, LastName: from.LastName},
+		Text:      text,
+	}
+	if strings.HasPrefix(text, "/") {
+		command, _, _ := strings.Cut(text, " ")
+		message.Entities = &[]tgbotapi.MessageEntity{{
+			Type:   "bot_command",
+			Offset: 0,
+			Length: len(utf16.Encode([]rune(command))),
+		}}
+	}
+
+	return s.PushUpdate(tgbotapi.Update{Message: message})
+}
+
+// PushCallback кладёт нажатие inline-кнопки с callback data
+func (s *Server) PushCallback(chatID int64, from tgbotapi.User, messageID int, data string) int {
+	return s.PushUpdate(tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+		ID:   strconv.Itoa(s.newMessageID()),
+		From: &from,
+		Message: &tgbotapi.Message{
+			MessageID: messageID,
+			Chat:      &tgbotapi.Chat{ID: chatID, Type: "private"},
+		},
+		Data: data,
+	}})
+}
+
+// FailNext следующий вызов method завершится ошибкой Bot API (вызов при этом не записывается).
+// retryAfter > 0 добавляет parameters.retry_after, как у 429 Too Many Requests.
+func (s *Server) FailNext(method string, code int, description string, retryAfter int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[method] = append(s.failures[method], failure{code: code, description: description, retryAfter: retryAfter})
+}
+
+// Requests вызовы метода method в порядке поступления; пустой method — все вызовы
+func (s *Server) Requests(method string) []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []Request
+	for _, req := range s.requests {
+		if method == "" || req.Method == method {
+			result = append(result, req)
+		}
+	}
+	return result
+}
+
+// SentMessages все сообщения, отправленные через sendMessage
+func (s *Server) SentMessages() []SentMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sentMessagesLocked()
+}
+
+// WaitForMessages ждёт, пока бот отправит хотя бы n сообщений
+func (s *Server) WaitForMessages(n int, timeout time.Duration) ([]SentMessage, error) {
+	deadline := time.After(timeout)
+	for {
+		s.mu.Lock()
+		messages := s.sentMessagesLocked()
+		changed := s.changed
+		s.mu.Unlock()
+
+		if len(messages) >= n {
+			return messages, nil
+		}
+
+		select {
+		case <-changed:
+		case <-deadline:
+			return messages, fmt.Errorf("за %s получено %d сообщений из %d", timeout, len(messages), n)
+		}
+	}
+}
+
+// WaitForRequests ждёт, пока бот вызовет method хотя бы n раз
+func (s *Server) WaitForRequests(method string, n int, timeout time.Duration) ([]Request, error) {
+	deadline := time.After(timeout)
+	for {
+		s.mu.Lock()
+		var requests []Request
+		for _, req := range s.requests {
+			if req.Method == method {
+				requests = append(requests, req)
+			}
+		}
+		changed := s.changed
+		s.mu.Unlock()
+
+		if len(requests) >= n {
+			return requests, nil
+		}
+
+		select {
+		case <-changed:
+		case <-deadline:
+			return requests, fmt.Errorf("за %s %s вызван %d раз из %d", timeout, method, len(requests), n)
+		}
+	}
+}
+
+// Reset забывает записанные вызовы (очередь обновлений не трогает)
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = nil
+}
+
+func (s *Server) sentMessagesLocked() []SentMessage {
+	var messages []SentMessage
+	for _, req := range s.requests {
+		if req.Method != "sendMessage" {
+			continue
+		}
+		chatID, _ := strconv.ParseInt(req.Params.Get("chat_id"), 10, 64)
+		messages = append(messages, SentMessage{
+			ChatID:      chatID,
+			Text:        req.Params.Get("text"),
+			ParseMode:   req.Params.Get("parse_mode"),
+			ReplyMarkup: req.Params.Get("reply_markup"),
+		})
+	}
+	return messages
+}
+
+func (s *Server) notifyLocked() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+func (s *Server) newMessageID() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := s.nextMessageID
+	s.nextMessageID++
+	return id
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	token, method, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/bot"), "/")
+	if !ok || !strings.HasPrefix(r.URL.Path, "/bot") {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	if token != s.Token {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	params, err := parseParams(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Bad Request: "+err.Error())
+		return
+	}
+
+	switch method {
+	case "getMe":
+		writeResult(w, s.Bot)
+	case "getUpdates":
+		writeResult(w, s.pollUpdates(r, params))
+	default:
+		s.mu.Lock()
+		if queued := s.failures[method]; len(queued) > 0 {
+			s.failures[method] = queued[1:]
+			s.mu.Unlock()
+			writeFailure(w, queued[0])
+			return
+		}
+		s.requests = append(s.requests, Request{Method: method, Params: params})
+		s.notifyLocked()
+		s.mu.Unlock()
+
+		switch method {
+		case "sendMessage", "sendPhoto", "sendDocument", "sendInvoice":
+			writeResult(w, s.message(params, s.newMessageID()))
+		case "editMessageText", "editMessageReplyMarkup", "editMessageCaption":
+			messageID, _ := strconv.Atoi(params.Get("message_id"))
+			writeResult(w, s.message(params, messageID))
+		default:
+			writeResult(w, true)
+		}
+	}
+}
+
+// pollUpdates отдаёт обновления с update_id >= offset, при пустой очереди ждёт как long polling
+func (s *Server) pollUpdates(r *http.Request, params url.Values) []tgbotapi.Update {
+	offset, _ := strconv.Atoi(params.Get("offset"))
+	timeout, _ := strconv.Atoi(params.Get("timeout"))
+	wait := time.Duration(timeout) * time.Second
+	if wait > maxPollWait {
+		wait = maxPollWait
+	}
+	deadline := time.After(wait)
+
+	for {
+		s.mu.Lock()
+		// Как в Telegram: offset подтверждает все обновления до него
+		pending := s.updates[:0]
+		for _, update := range s.updates {
+			if update.UpdateID >= offset {
+				pending = append(pending, update)
+			}
+		}
+		s.updates = pending
+		result := append([]tgbotapi.Update(nil), pending...)
+		changed := s.changed
+		s.mu.Unlock()
+
+		if len(result) > 0 || wait == 0 {
+			return result
+		}
+
+		select {
+		case <-changed:
+		case <-deadline:
+			return []tgbotapi.Update{}
+		case <-r.Context().Done():
+			return []tgbotapi.Update{}
+		case <-s.closing:
+			return []tgbotapi.Update{}
+		}
+	}
+}
+
+func (s *Server) message(params url.Values, messageID int) tgbotapi.Message {
+	chatID, _ := strconv.ParseInt(params.Get("chat_id"), 10, 64)
+	return tgbotapi.Message{
+		MessageID: messageID,
+		From:      &s.Bot,
+		Date:      int(time.Now().Unix()),
+		Chat:      &tgbotapi.Chat{ID: chatID, Type: "private"},
+		Text:      params.Get("text"),
+	}
+}
+
+func parseParams(r *http.Request) (url.Values, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	switch mediaType {
+	case "application/json":
+		var body map[string]interface{}
+		decoder := json.NewDecoder(r.Body)
+		decoder.UseNumber()
+		if err := decoder.Decode(&body); err != nil {
+			return nil, err
+		}
+
+		params := url.Values{}
+		for key, value := range body {
+			switch v := value.(type) {
+			case string:
+				params.Set(key, v)
+			case json.Number:
+				params.Set(key, v.String())
+			case bool:
+				params.Set(key, strconv.FormatBool(v))
+			case nil:
+			default:
+				encoded, err := json.Marshal(v)
+				if err != nil {
+					return nil, err
+				}
+				params.Set(key, string(encoded))
+			}
+		}
+		return params, nil
+	case "multipart/form-data":
+		if err := r.ParseMultipartForm(32 << 20); err != nil {
+			return nil, err
+		}
+		return r.MultipartForm.Value, nil
+	default:
+		if err := r.ParseForm(); err != nil {
+			return nil, err
+		}
+		return r.Form, nil
+	}
+}
+
+func writeResult(w http.ResponseWriter, result interface{}) {
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tgbotapi.APIResponse{Ok: true, Result: encoded})
+}
+
+func writeFailure(w http.ResponseWriter, f failure) {
+	resp := tgbotapi.APIResponse{ErrorCode: f.code, Description: f.description}
+	if f.retryAfter > 0 {
+		resp.Parameters = &tgbotapi.ResponseParameters{RetryAfter: f.retryAfter}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(f.code)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func writeError(w http.ResponseWriter, status int, description string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(tgbotapi.APIResponse{ErrorCode: status, Description: description})
+}
@@ -0,0 +1,132 @@
+package waitlist
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"spectrum-club-bot/internal/models"
+	"spectrum-club-bot/internal/notify"
+	"spectrum-club-bot/internal/service"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// Действия inline-кнопок листа ожидания
+const (
+	ActionJoin    = "join"    // встать в очередь на заполненную тренировку
+	ActionAccept  = "accept"  // занять предложенное место
+	ActionDecline = "decline" // отказаться от места или выйти из очереди
+)
+
+// callbackPrefix callback data кнопок листа ожидания: waitlist:<действие>:<id тренировки>
+const callbackPrefix = "waitlist:"
+
+// CallbackData callback data кнопки листа ожидания для тренировки
+func CallbackData(action string, trainingID int) string {
+	return callbackPrefix + action + ":" + strconv.Itoa(trainingID)
+}
+
+// ParseCallback разбирает callback data кнопки листа ожидания
+func ParseCallback(data string) (action string, trainingID int, ok bool) {
+	rest, found := strings.CutPrefix(data, callbackPrefix)
+	if !found {
+		return "", 0, false
+	}
+	action, idStr, found := strings.Cut(rest, ":")
+	if !found {
+		return "", 0, false
+	}
+	trainingID, err := strconv.Atoi(idStr)
+	if err != nil {
+		return "", 0, false
+	}
+	return action, trainingID, true
+}
+
+// offerNotifier отправляет ученикам предложения мест через очередь уведомлений
+type offerNotifier struct {
+	notifier notify.Notifier
+}
+
+func NewOfferNotifier(notifier notify.Notifier) service.WaitlistNotifier {
+	return &offerNotifier{notifier: notifier}
+}
+
+func (n *offerNotifier) SpotOffered(entry models.WaitlistEntry, training *models.TrainingSchedule, expiresAt time.Time) error {
+	var text strings.Builder
+	text.WriteString("🎉 *Освободилось место на тренировке!*\n\n")
+	writeTraining(&text, training)
+	text.WriteString(fmt.Sprintf("\nМесто закреплено за вами до *%s*. ", expiresAt.Format("02.01 15:04")))
+	text.WriteString("Если не подтвердите запись, оно перейдёт следующему в листе ожидания.")
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ Записаться", CallbackData(ActionAccept, training.ID)),
+			tgbotapi.NewInlineKeyboardButtonData("❌ Отказаться", CallbackData(ActionDecline, training.ID)),
+		),
+	)
+
+	return n.notifier.Send(notify.Message{
+		ChatID:      entry.TelegramID,
+		Text:        text.String(),
+		ParseMode:   "Markdown",
+		ReplyMarkup: keyboard,
+	})
+}
+
+func (n *offerNotifier) OfferExpired(entry models.WaitlistEntry, training *models.TrainingSchedule) error {
+	var text strings.Builder
+	text.WriteString("⌛ *Время на подтверждение места истекло*\n\n")
+	writeTraining(&text, training)
+	text.WriteString("\nМесто передано следующему в листе ожидания.")
+
+	return n.notifier.Send(notify.Message{
+		ChatID:    entry.TelegramID,
+		Text:      text.String(),
+		ParseMode: "Markdown",
+	})
+}
+
+func writeTraining(text *strings.Builder, training *models.TrainingSchedule) {
+	text.WriteString(fmt.Sprintf("📅 *Дата:* %s\n", training.TrainingDate.Format("02.01.2006")))
+	text.WriteString(fmt.Sprintf("🕐 *Время:* %s - %s\n",
+		training.StartTime.Format("15:04"), training.EndTime.Format("15:04")))
+	if training.GroupName != "" {
+		text.WriteString(fmt.Sprintf("👥 *Группа:* %s\n", notify.EscapeMarkdown(training.GroupName)))
+	}
+}
+
+// Expirer периодически закрывает просроченные предложения мест,
+// чтобы место переходило следующему, даже если никто больше не отменяет запись
+type Expirer struct {
+	attendanceService service.AttendanceService
+	interval          time.Duration
+}
+
+func NewExpirer(attendanceService service.AttendanceService, interval time.Duration) *Expirer {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	return &Expirer{attendanceService: attendanceService, interval: interval}
+}
+
+// Run проверяет предложения каждые interval до отмены ctx
+func (e *Expirer) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := e.attendanceService.ExpireWaitlistOffers(); err != nil {
+			log.Printf("❌ Лист ожидания: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
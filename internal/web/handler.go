@@ -1,13 +1,12 @@
 package web
 
 import (
-	"bytes"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
 	"net/url"
@@ -523,7 +522,7 @@ func (h *Handler) prepareScheduleViewJSON(trainings []models.TrainingSchedule, u
 			student, err := h.studentService.GetStudentByUserID(userIDInt)
 			if err == nil {
 				att, _ := h.attendanceService.GetStudentAttendanceForTraining(int(student.ID), training.ID)
-				isRegistered = att != nil && att.Status == "registered"
+				isRegistered = att != nil && att.Status == models.AttendanceStatusRegistered
 
 				// Создаем полную дату и время начала тренировки для правильного сравнения
 				trainingDateTime := time.Date(
@@ -710,6 +709,7 @@ func (h *Handler) TrainingDetailsAPI(w http.ResponseWriter, r *http.Request) {
 	isRegistered := false
 	canRegister := false
 	isFull := false
+	var waitlistEntry *models.WaitlistEntry
 
 	// Создаем полную дату и время начала тренировки для правильного сравнения
 	trainingDateTime := time.Date(
@@ -725,6 +725,8 @@ func (h *Handler) TrainingDetailsAPI(w http.ResponseWriter, r *http.Request) {
 
 	now := time.Now()
 	isPast := now.After(trainingDateTime)
+	// На отменённую тренировку нельзя ни записаться, ни встать в очередь, ни отметить посещение
+	isCancelled := training.IsCancelled()
 
 	// Проверяем, является ли тренер тренером этой тренировки
 	if isCoach && userIDStr != "" {
@@ -736,14 +738,17 @@ func (h *Handler) TrainingDetailsAPI(w http.ResponseWriter, r *http.Request) {
 
 	// Проверяем, может ли тренер отмечать посещаемость
 	// Условия: тренер, тренировка прошла, тренер является тренером этой тренировки
-	canMarkAttendance = isCoach && isPast && isTrainingCoach
+	canMarkAttendance = isCoach && isPast && isTrainingCoach && !isCancelled
 
 	// Проверяем регистрацию пользователя, если userID передан
+	var studentID int
 	if userIDStr != "" && !isCoach {
 		student, err := h.studentService.GetStudentByUserID(userID)
 		if err == nil {
+			studentID = int(student.ID)
 			att, _ := h.attendanceService.GetStudentAttendanceForTraining(int(student.ID), trainingID)
-			isRegistered = att != nil && att.Status == "registered"
+			isRegistered = att != nil && att.Status == models.AttendanceStatusRegistered
+			waitlistEntry, _ = h.attendanceService.GetWaitlistEntry(int(student.ID), trainingID)
 		}
 	}
 
@@ -751,24 +756,34 @@ func (h *Handler) TrainingDetailsAPI(w http.ResponseWriter, r *http.Request) {
 	// userID нужен только для самой записи, но для определения can_register достаточно проверить:
 	// 1. Пользователь - студент (не тренер)
 	// 2. Тренировка в будущем (дата и время начала)
-	// 3. Есть свободные места (если установлен лимит)
+	// 3. Есть свободные места (если установлен лимит): места, предложенные из листа ожидания
+	//    другим ученикам, заняты — так же считает SignUpForTraining
 	// 4. Пользователь не записан (если userID передан, иначе считаем что не записан)
 	// Примечание: проверка активного абонемента выполняется в RegisterForTraining,
 	// чтобы пользователь видел кнопку и получал сообщение об ошибке при попытке записи
-	if !isCoach && !isRegistered && trainingDateTime.After(now) {
-		if training.MaxParticipants != nil && *training.MaxParticipants > 0 {
-			maxParticipants := *training.MaxParticipants
-			if len(participants) < maxParticipants {
-				canRegister = true
-			} else {
-				isFull = true
-			}
+	if !isCoach && !isRegistered && !isCancelled && trainingDateTime.After(now) {
+		free, limited, err := h.attendanceService.FreeSpots(studentID, trainingID)
+		if err != nil {
+			log.Printf("[TrainingDetailsAPI] Ошибка подсчёта свободных мест: %v", err)
 		} else {
 			// Если лимита нет, всегда можно записаться (если тренировка в будущем и пользователь - студент)
-			canRegister = true
+			canRegister = !limited || free > 0
+			isFull = !canRegister
 		}
 	}
 
+	// Лист ожидания: позиция в очереди (null, если не в очереди) и предложенное место
+	var waitlistPosition interface{}
+	waitlistOffered := false
+	if waitlistEntry != nil {
+		waitlistPosition = waitlistEntry.Position
+		waitlistOffered = waitlistEntry.Status == models.WaitlistStatusOffered
+	}
+	canJoinWaitlist := isFull && waitlistEntry == nil && !isCancelled
+
+	// Срок бесплатной отмены показываем ученику до подтверждения отмены
+	cancelPolicy := h.attendanceService.CancellationPolicy()
+
 	// Формируем ответ
 	response := map[string]interface{}{
 		"training": map[string]interface{}{
@@ -780,6 +795,8 @@ func (h *Handler) TrainingDetailsAPI(w http.ResponseWriter, r *http.Request) {
 			"coach_name":       training.CoachName,
 			"description":      training.Description,
 			"max_participants": training.MaxParticipants,
+			"cancelled":        isCancelled,
+			"cancel_reason":    training.CancelReason,
 		},
 		"participants":        participants,
 		"participants_count":  len(participants),
@@ -790,7 +807,13 @@ func (h *Handler) TrainingDetailsAPI(w http.ResponseWriter, r *http.Request) {
 		"can_register":        canRegister,
 		"is_full":             isFull,
 		"is_past":             isPast,
+		"waitlist_position":   waitlistPosition,
+		"waitlist_offered":    waitlistOffered,
+		"can_join_waitlist":   canJoinWaitlist,
 		"current_time":        time.Now().Format(time.RFC3339),
+		"cancel_deadline":     cancelPolicy.DeadlineFor(trainingDateTime).Format(time.RFC3339),
+		"late_cancel_policy":  cancelPolicy.Late,
+		"cancel_terms":        cancelPolicy.Terms(trainingDateTime, now),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -906,26 +929,32 @@ func (h *Handler) RegisterForTraining(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Проверяем, есть ли места
-	participants, err := h.attendanceService.GetParticipants(trainingID)
-	if err == nil && training.MaxParticipants != nil {
-		if len(participants) >= *training.MaxParticipants {
-			http.Error(w, "No available spots", http.StatusBadRequest)
-			return
-		}
-	}
-
 	// Проверяем, не записан ли уже студент
 	existingAttendance, _ := h.attendanceService.GetStudentAttendanceForTraining(int(student.ID), trainingID)
 	if existingAttendance != nil {
-		if existingAttendance.Status == "registered" {
+		if existingAttendance.Status == models.AttendanceStatusRegistered {
 			http.Error(w, "Already registered for this training", http.StatusBadRequest)
 			return
 		}
 	}
 
-	// Записываем на тренировку
+	// Записываем на тренировку; если мест нет — ставим в лист ожидания
 	err = h.attendanceService.SignUpForTraining(int(student.ID), trainingID)
+	if errors.Is(err, service.ErrTrainingFull) {
+		entry, err := h.attendanceService.JoinWaitlist(int(student.ID), trainingID)
+		if err != nil {
+			http.Error(w, "No available spots: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("[RegisterForTraining] Мест нет, студент %d в листе ожидания (позиция %d)", student.ID, entry.Position)
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(fmt.Sprintf("Мест нет. Вы в листе ожидания, место в очереди: %d", entry.Position)))
+		return
+	}
+	if errors.Is(err, service.ErrSubscriptionNotCovered) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Failed to register: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -1002,16 +1031,29 @@ func (h *Handler) CancelRegistration(w http.ResponseWriter, r *http.Request) {
 
 	// Проверяем, записан ли студент
 	attendance, err := h.attendanceService.GetStudentAttendanceForTraining(int(student.ID), trainingID)
-	if err != nil || attendance == nil {
-		http.Error(w, "Not registered for this training", http.StatusBadRequest)
+	if err != nil || attendance == nil || !attendance.HoldsSpot() {
+		// Не записан (или запись уже отменена), но стоит в листе ожидания — отмена означает выход из очереди
+		entry, _ := h.attendanceService.GetWaitlistEntry(int(student.ID), trainingID)
+		if entry == nil {
+			http.Error(w, "Not registered for this training", http.StatusBadRequest)
+			return
+		}
+		if err := h.attendanceService.LeaveWaitlist(int(student.ID), trainingID); err != nil {
+			http.Error(w, "Failed to leave waitlist: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("Successfully left waitlist"))
 		return
 	}
 
 	// Проверяем, можно ли отменить (например, тренировка еще не прошла)
 	training, err := h.scheduleService.GetTrainingByID(trainingID)
 	if err == nil {
-		// Можно отменить только если тренировка в будущем
-		if training.TrainingDate.Before(time.Now()) {
+		// Можно отменить только если тренировка еще не началась
+		start := time.Date(training.TrainingDate.Year(), training.TrainingDate.Month(), training.TrainingDate.Day(),
+			training.StartTime.Hour(), training.StartTime.Minute(), 0, 0, time.Local)
+		if !start.After(time.Now()) {
 			http.Error(w, "Cannot cancel past training", http.StatusBadRequest)
 			return
 		}
@@ -1019,6 +1061,10 @@ func (h *Handler) CancelRegistration(w http.ResponseWriter, r *http.Request) {
 
 	// Отменяем запись
 	err = h.attendanceService.CancelSignUp(int(student.ID), trainingID)
+	if errors.Is(err, service.ErrLateCancellation) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Failed to cancel: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -1039,6 +1085,7 @@ func (h *Handler) MarkAttendanceAPI(w http.ResponseWriter, r *http.Request) {
 	var requestData struct {
 		TrainingID int   `json:"training_id"`
 		StudentIDs []int `json:"student_ids"`
+		NoShowIDs  []int `json:"no_show_ids"` // записались, но не пришли
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
@@ -1119,13 +1166,18 @@ func (h *Handler) MarkAttendanceAPI(w http.ResponseWriter, r *http.Request) {
 	for _, studentID := range requestData.StudentIDs {
 		attendedSet[studentID] = true
 	}
+	noShowSet := make(map[int]bool)
+	for _, studentID := range requestData.NoShowIDs {
+		noShowSet[studentID] = true
+	}
 
 	// Логирование для отладки
-	log.Printf("[MarkAttendanceAPI] Получено student_ids для отметки: %v", requestData.StudentIDs)
+	log.Printf("[MarkAttendanceAPI] Получено student_ids для отметки: %v, no_show_ids: %v", requestData.StudentIDs, requestData.NoShowIDs)
 	log.Printf("[MarkAttendanceAPI] Всего участников: %d", len(participants))
 
 	// Отмечаем посещаемость только для выбранных учеников
 	markedCount := 0
+	noShowCount := 0
 	failedStudents := []map[string]interface{}{}
 	errors := []string{}
 
@@ -1137,21 +1189,25 @@ func (h *Handler) MarkAttendanceAPI(w http.ResponseWriter, r *http.Request) {
 		log.Printf("[MarkAttendanceAPI] Участник ID=%d, studentID=%d, attended=%v (в attendedSet: %v)",
 			participant.ID, studentID, attended, attendedSet[studentID])
 
-		// Обновляем только выбранных учеников (attended = true)
+		// Обновляем только выбранных учеников: пришедших и не пришедших
+		status := models.AttendanceStatusAttended
 		if !attended {
-			log.Printf("[MarkAttendanceAPI] Ученик %d не выбран, пропускаем", studentID)
-			continue
+			if !noShowSet[studentID] {
+				log.Printf("[MarkAttendanceAPI] Ученик %d не выбран, пропускаем", studentID)
+				continue
+			}
+			status = models.AttendanceStatusNoShow
 		}
 
 		// recorded_by должен ссылаться на user_id, а не на coach.id
 		// Преобразуем userID в int (userID уже получен из getUserIDFromRequest)
 		recordedByUserID := int(userID)
 
-		err := h.attendanceService.MarkAttendance(
+		err := h.attendanceService.SetAttendanceStatus(
 			requestData.TrainingID,
 			studentID,
 			recordedByUserID,
-			true, // Всегда true, так как мы обрабатываем только выбранных
+			status,
 			"",
 		)
 		if err != nil {
@@ -1164,17 +1220,21 @@ func (h *Handler) MarkAttendanceAPI(w http.ResponseWriter, r *http.Request) {
 			errors = append(errors, fmt.Sprintf("Ученик %s: %v", participant.StudentName, err))
 			continue
 		}
+		if !attended {
+			noShowCount++
+			log.Printf("[MarkAttendanceAPI] Ученик %d отмечен как не пришедший", studentID)
+			continue
+		}
+		// Уведомление о списании занятия ученику отправляет сервис посещаемости
 		markedCount++
 		log.Printf("[MarkAttendanceAPI] Посещаемость успешно отмечена для ученика %d", studentID)
-
-		// Отправляем уведомление студенту о списании занятия
-		h.sendLessonDeductionNotification(studentID, requestData.TrainingID)
 	}
 
 	// Формируем ответ с информацией об ошибках
 	response := map[string]interface{}{
 		"success":         len(failedStudents) == 0,
 		"marked_count":    markedCount,
+		"no_show_count":   noShowCount,
 		"total_count":     len(participants),
 		"failed_count":    len(failedStudents),
 		"failed_students": failedStudents,
@@ -1235,7 +1295,7 @@ func (h *Handler) CheckRegistration(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"registered": attendance != nil && attendance.Status == "registered",
+		"registered": attendance != nil && attendance.Status == models.AttendanceStatusRegistered,
 		"attendance": attendance,
 	})
 }
@@ -1419,76 +1479,3 @@ func (h *Handler) getUserIDFromRequest(r *http.Request) (int64, error) {
 	log.Printf("[getUserIDFromRequest] Аутентификация не удалась: initData пустой и user_id не найден")
 	return 0, fmt.Errorf("user not authenticated")
 }
-
-// sendLessonDeductionNotification отправляет уведомление студенту о списании занятия
-func (h *Handler) sendLessonDeductionNotification(studentID int, trainingID int) {
-	// studentID - это ID из таблицы students
-	student, err := h.studentService.GetStudentByID(int64(studentID))
-	if err != nil {
-		log.Printf("[sendLessonDeductionNotification] Ошибка получения студента %d: %v", studentID, err)
-		return
-	}
-
-	// Получаем пользователя по userID студента
-	user, err := h.userService.GetByID(student.UserID)
-	if err != nil {
-		log.Printf("[sendLessonDeductionNotification] Ошибка получения пользователя для studentID %d: %v", studentID, err)
-		return
-	}
-
-	// Получаем информацию о тренировке
-	training, err := h.scheduleService.GetTrainingByID(trainingID)
-	if err != nil {
-		log.Printf("[sendLessonDeductionNotification] Ошибка получения тренировки %d: %v", trainingID, err)
-		return
-	}
-
-	// Получаем информацию об абонементе
-	subscription, err := h.subscriptionService.GetActiveSubscription(student.ID)
-	remainingLessons := 0
-	if err == nil && subscription != nil {
-		remainingLessons = subscription.RemainingLessons
-	}
-
-	// Формируем сообщение
-	msgText := fmt.Sprintf(
-		"✅ *Посещаемость отмечена!*\n\n"+
-			"📅 *Тренировка:* %s\n"+
-			"🕐 *Дата:* %s\n"+
-			"👥 *Группа:* %s\n\n"+
-			"🎫 *Осталось занятий:* %d",
-		training.StartTime.Format("15:04"),
-		training.TrainingDate.Format("02.01.2006"),
-		training.GroupName,
-		remainingLessons,
-	)
-
-	// Отправляем сообщение через Telegram Bot API
-	apiURL := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", h.botToken)
-	requestData := map[string]interface{}{
-		"chat_id":    user.TelegramID,
-		"text":       msgText,
-		"parse_mode": "Markdown",
-	}
-
-	requestJSON, err := json.Marshal(requestData)
-	if err != nil {
-		log.Printf("[sendLessonDeductionNotification] Ошибка маршалинга JSON: %v", err)
-		return
-	}
-
-	resp, err := http.Post(apiURL, "application/json", bytes.NewBuffer(requestJSON))
-	if err != nil {
-		log.Printf("[sendLessonDeductionNotification] Ошибка отправки уведомления: %v", err)
-		return
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		log.Printf("[sendLessonDeductionNotification] Ошибка Telegram API: %s", string(body))
-		return
-	}
-
-	log.Printf("[sendLessonDeductionNotification] Уведомление успешно отправлено студенту %d (telegramID: %d)", studentID, user.TelegramID)
-}